@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+)
+
+// chaingenesis prints the genesis config reproducing block 0 of the stored
+// chain. Chains started before the genesis block was fixed in config have a
+// block 0 of their own: put the printed section in the config before
+// starting the service on them. The difficulty policy of the config must be
+// the one block 0 was mined with.
+func main() {
+	cfg, err := blockchain.NewConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	err = blockchainlogic.SetDecimals(cfg.Decimals)
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	err = blockchainlogic.SetDifficultyPolicy(blockchainlogic.DifficultyPolicy{
+		Initial:          cfg.Difficulty.Initial,
+		Min:              cfg.Difficulty.Min,
+		RetargetInterval: cfg.RetargetInterval,
+		TargetBlockTime:  cfg.TargetBlockTime,
+	})
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+
+	_, db, err := postgres.New(cfg.PG.URL)
+	if err != nil {
+		log.Fatalf("Postgres error: %s", err)
+	}
+	defer db.Close()
+
+	store, err := blockchainlogic.NewPostgresChainStore(db)
+	if err != nil {
+		log.Fatalf("Chain store error: %s", err)
+	}
+	p, supply, err := blockchainlogic.StoredGenesis(store)
+	if err != nil {
+		log.Fatalf("Genesis error: %s", err)
+	}
+
+	fmt.Printf("genesis:\n  recipient: %q\n  timestamp: %s\n  message: %q\n  supply: %q\n",
+		p.Recipient, p.Timestamp.Format(time.RFC3339Nano), p.Message, supply.String())
+}
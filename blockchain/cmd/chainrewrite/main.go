@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+)
+
+// chainrewrite converts a stored chain to the current block and transaction
+// format. Blocks still stored as JSON are encoded in the binary format first.
+// It then recomputes transaction IDs, re-signs inputs with the keys of the
+// keystore and mines every main chain block again, retargeting difficulty with
+// the configured policy. Run it after a change of the format of blocks.
+func main() {
+	cfg, err := blockchain.NewConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	err = blockchainlogic.SetDecimals(cfg.Decimals)
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	err = blockchainlogic.SetDifficultyPolicy(blockchainlogic.DifficultyPolicy{
+		Initial:          cfg.Difficulty.Initial,
+		Min:              cfg.Difficulty.Min,
+		RetargetInterval: cfg.RetargetInterval,
+		TargetBlockTime:  cfg.TargetBlockTime,
+	})
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+
+	_, db, err := postgres.New(cfg.PG.URL)
+	if err != nil {
+		log.Fatalf("Postgres error: %s", err)
+	}
+	defer db.Close()
+
+	converted, err := blockchainlogic.ConvertBlockRows(db)
+	if err != nil {
+		log.Fatalf("Block conversion error: %s", err)
+	}
+	if converted > 0 {
+		fmt.Printf("converted %d blocks to the binary format\n", converted)
+	}
+
+	needed, err := blockchainlogic.ChainNeedsRewrite(db)
+	if err != nil {
+		log.Fatalf("Chain rewrite error: %s", err)
+	}
+	if !needed {
+		fmt.Println("chain is already in the current format")
+
+		return
+	}
+
+	masterKey, err := blockchainlogic.ParseMasterKey(cfg.MasterKey)
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	keystore, err := blockchainlogic.NewPostgresKeystore(db, masterKey)
+	if err != nil {
+		log.Fatalf("Keystore error: %s", err)
+	}
+	blocks, err := blockchainlogic.RewriteChain(db, keystore)
+	if err != nil {
+		log.Fatalf("Chain rewrite error: %s", err)
+	}
+
+	fmt.Printf("rewrote %d blocks, indexes are rebuilt on the next start\n", blocks)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+)
+
+// chainverify re-validates the stored chain from genesis to tip and exits
+// with a non-zero status when a block is invalid.
+func main() {
+	cfg, err := blockchain.NewConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	err = blockchainlogic.SetDecimals(cfg.Decimals)
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	err = blockchainlogic.SetDifficultyPolicy(blockchainlogic.DifficultyPolicy{
+		Initial:          cfg.Difficulty.Initial,
+		Min:              cfg.Difficulty.Min,
+		RetargetInterval: cfg.RetargetInterval,
+		TargetBlockTime:  cfg.TargetBlockTime,
+	})
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+
+	_, db, err := postgres.New(cfg.PG.URL)
+	if err != nil {
+		log.Fatalf("Postgres error: %s", err)
+	}
+
+	store, err := blockchainlogic.NewPostgresChainStore(db)
+	if err != nil {
+		db.Close()
+		log.Fatalf("Chain store error: %s", err)
+	}
+	report, err := blockchainlogic.VerifyChain(store)
+	db.Close()
+	if err != nil {
+		log.Fatalf("Chain verification error: %s", err)
+	}
+
+	fmt.Println(report)
+	if !report.Valid() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+)
+
+// walletimport copies the keys of a legacy wallet.dat file into the encrypted
+// Postgres keystore. Wallets already in the keystore are skipped, so it is
+// safe to run again. Delete the file once the import succeeded.
+func main() {
+	file := flag.String("file", "pkg/blockchain_logic/wallet.dat", "path of the wallet file to import")
+	flag.Parse()
+
+	cfg, err := blockchain.NewConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	masterKey, err := blockchainlogic.ParseMasterKey(cfg.MasterKey)
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+
+	wallets, err := blockchainlogic.LoadWalletFile(*file)
+	if err != nil {
+		log.Fatalf("Wallet file error: %s", err)
+	}
+
+	_, db, err := postgres.New(cfg.PG.URL)
+	if err != nil {
+		log.Fatalf("Postgres error: %s", err)
+	}
+	defer db.Close()
+
+	keystore, err := blockchainlogic.NewPostgresKeystore(db, masterKey)
+	if err != nil {
+		log.Fatalf("Keystore error: %s", err)
+	}
+
+	addresses, _ := wallets.Addresses()
+	var imported, skipped int
+	for _, address := range addresses {
+		wallet, _ := wallets.Get(address)
+		err = keystore.Put(wallet)
+		if errors.Is(err, blockchainlogic.ErrKeyExists) {
+			skipped++
+
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Import %s error: %s", address, err)
+		}
+		imported++
+	}
+
+	fmt.Printf("imported %d wallets, %d already in the keystore\n", imported, skipped)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/transport"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+)
+
+const usage = `usage:
+  walletkey export -address ADDRESS [-format pem|json] [-out FILE]
+  walletkey import -file FILE [-user USER_ID]
+
+The passphrase is read from WALLET_PASSPHRASE, or from the first line of
+standard input when it is not set.`
+
+// walletkey exports private keys of the keystore encrypted with a passphrase,
+// and imports them back. An imported key is bound to the user given with
+// -user, as its default wallet. Every attempt is recorded in the
+// wallet_key_events audit table.
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	cfg, err := blockchain.NewConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	masterKey, err := blockchainlogic.ParseMasterKey(cfg.MasterKey)
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+	_, db, err := postgres.New(cfg.PG.URL)
+	if err != nil {
+		log.Fatalf("Postgres error: %s", err)
+	}
+	defer db.Close()
+	keystore, err := blockchainlogic.NewPostgresKeystore(db, masterKey)
+	if err != nil {
+		log.Fatalf("Keystore error: %s", err)
+	}
+
+	event := blockchainlogic.KeyEvent{Source: blockchainlogic.KeySourceCLI}
+	switch os.Args[1] {
+	case "export":
+		event.Action = blockchainlogic.KeyActionExport
+		event.Err = export(keystore, os.Args[2:], &event)
+	case "import":
+		event.Action = blockchainlogic.KeyActionImport
+		event.Err = importKey(keystore, cfg, os.Args[2:], &event)
+	default:
+		log.Fatal(usage)
+	}
+
+	if err = blockchainlogic.RecordKeyEvent(db, event); err != nil {
+		log.Printf("Audit error: %s", err)
+	}
+	if event.Err != nil {
+		log.Fatalf("%s error: %s", event.Action, event.Err)
+	}
+}
+
+func export(keystore blockchainlogic.Keystore, args []string, event *blockchainlogic.KeyEvent) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	address := flags.String("address", "", "address of the key to export")
+	format := flags.String("format", blockchainlogic.KeyFormatPEM, "pem or json")
+	out := flags.String("out", "", "file to write the key to, standard output when empty")
+	_ = flags.Parse(args)
+	event.Address, event.Format = *address, *format
+
+	wallet, err := keystore.Get(*address)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	data, err := blockchainlogic.ExportKey(wallet, *format, passphrase)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+
+		return err
+	}
+
+	return os.WriteFile(*out, data, 0o600)
+}
+
+func importKey(keystore blockchainlogic.Keystore, cfg *blockchain.Config, args []string, event *blockchainlogic.KeyEvent) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "encrypted PEM key or JSON keyfile to import")
+	userID := flags.String("user", "", "user to bind the key to as its default wallet")
+	_ = flags.Parse(args)
+	event.UserID = *userID
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	event.Format, _ = blockchainlogic.DetectKeyFormat(data)
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	wallet, err := blockchainlogic.ImportKey(data, passphrase)
+	if err != nil {
+		return err
+	}
+	event.Address = string(wallet.GetAddress())
+
+	// Keys already in the keystore may belong to anyone, so they are never
+	// bound to the given user.
+	_, err = keystore.Get(event.Address)
+	if err == nil {
+		return blockchainlogic.ErrKeyExists
+	}
+	if !errors.Is(err, blockchainlogic.ErrKeyNotFound) {
+		return err
+	}
+	if *userID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		users := transport.NewUserGrpcTransport(cfg.Transport.UserGrpc)
+		if _, err = users.SetUserWallet(ctx, *userID, event.Address); err != nil {
+			return err
+		}
+	}
+	if err = keystore.Put(wallet); err != nil {
+		return err
+	}
+
+	fmt.Println(event.Address)
+
+	return nil
+}
+
+func readPassphrase() (string, error) {
+	if passphrase, ok := os.LookupEnv("WALLET_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
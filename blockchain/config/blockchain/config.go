@@ -47,7 +47,113 @@ type (
 		AccessTokenTTL int64  `mapstructure:"access_token_ttl" yaml:"access_token_ttl"`
 	}
 	Blockchain struct {
-		GenesisAddress string `mapstructure:"genesis_address" yaml:"genesis_address"`
+		// Network is main, or regtest for local chains with trivial
+		// difficulty and admin endpoints to mine, fund and reset them.
+		Network         string `mapstructure:"network" yaml:"network" env:"BLOCKCHAIN_NETWORK" env-default:"main"`
+		VerifyOnStartup bool   `mapstructure:"verify_on_startup" yaml:"verify_on_startup" env:"BLOCKCHAIN_VERIFY_ON_STARTUP"`
+		// Decimals is the number of decimal places of one coin. A Postgres
+		// chain is bound to them on its first start and refuses others.
+		Decimals   int `mapstructure:"decimals" yaml:"decimals" env-default:"8"`
+		Genesis    `yaml:"genesis"`
+		Treasury   `yaml:"treasury"`
+		TopUp      `yaml:"top_up"`
+		Mempool    `yaml:"mempool"`
+		Fees       `yaml:"fees"`
+		Difficulty `yaml:"difficulty"`
+		Keystore   `yaml:"keystore"`
+		Store      `yaml:"store"`
+		P2P        `yaml:"p2p"`
+		Stream     `yaml:"stream"`
+		Regtest    `yaml:"regtest"`
+	}
+	// Genesis fixes the genesis block. It must not change once the chain
+	// holds blocks: block 0 is checked against it on startup.
+	Genesis struct {
+		// Recipient receives the supply and is the treasury funding top ups.
+		// When empty, it is the address of the treasury key file, or a key
+		// generated on the first start; nodes of one network must then set
+		// the address it got. Regtest chains use their faucet instead.
+		Recipient string    `mapstructure:"recipient" yaml:"recipient" env:"BLOCKCHAIN_GENESIS_RECIPIENT"`
+		Timestamp time.Time `mapstructure:"timestamp" yaml:"timestamp" env:"BLOCKCHAIN_GENESIS_TIMESTAMP" env-default:"2024-01-01T00:00:00Z"`
+		Message   string    `mapstructure:"message" yaml:"message" env:"BLOCKCHAIN_GENESIS_MESSAGE" env-default:"The Times 03/Jan/2009 Chancellor on brink of second bailout for banks"`
+		// Supply is the number of coins paid to the recipient, as a decimal
+		// string.
+		Supply string `mapstructure:"supply" yaml:"supply" env:"BLOCKCHAIN_GENESIS_SUPPLY" env-default:"1000000"`
+	}
+	// Treasury loads the key of the genesis recipient into the keystore. With
+	// a recipient it is only needed once: later starts find the key in the
+	// keystore. The main network does not start without the key.
+	Treasury struct {
+		// KeyFile is an exported key of the recipient, an encrypted PEM or
+		// JSON keyfile.
+		KeyFile    string `mapstructure:"key_file" yaml:"key_file" env:"BLOCKCHAIN_TREASURY_KEY_FILE"`
+		Passphrase string `mapstructure:"passphrase" yaml:"passphrase" env:"BLOCKCHAIN_TREASURY_PASSPHRASE"`
+	}
+	// TopUp limits the top ups paid by the treasury, as decimal strings.
+	// Zero limits and threshold are off.
+	TopUp struct {
+		// DailyLimit caps the top ups of a user over the last 24 hours.
+		DailyLimit    string `mapstructure:"daily_limit" yaml:"daily_limit" env:"BLOCKCHAIN_TOP_UP_DAILY_LIMIT" env-default:"100"`
+		LifetimeLimit string `mapstructure:"lifetime_limit" yaml:"lifetime_limit" env:"BLOCKCHAIN_TOP_UP_LIFETIME_LIMIT" env-default:"1000"`
+		// ApprovalThreshold holds larger top ups for an admin to approve.
+		ApprovalThreshold string `mapstructure:"approval_threshold" yaml:"approval_threshold" env:"BLOCKCHAIN_TOP_UP_APPROVAL_THRESHOLD" env-default:"50"`
+		// RequireKYC refuses top ups to users who are not valid.
+		RequireKYC bool `mapstructure:"require_kyc" yaml:"require_kyc" env:"BLOCKCHAIN_TOP_UP_REQUIRE_KYC" env-default:"true"`
+	}
+	// Mempool -.
+	Mempool struct {
+		MaxBlockTransactions int           `mapstructure:"max_block_transactions" yaml:"max_block_transactions" env-default:"100"`
+		BlockInterval        time.Duration `mapstructure:"block_interval" yaml:"block_interval" env-default:"10s"`
+	}
+	// Fees -.
+	Fees struct {
+		// MinFee is the smallest fee accepted for a send, as a decimal string.
+		MinFee string `mapstructure:"min_fee" yaml:"min_fee" env:"BLOCKCHAIN_MIN_FEE" env-default:"0"`
+		// MinerAddress receives block subsidies and fees. It defaults to the
+		// address the chain is opened with.
+		MinerAddress string `mapstructure:"miner_address" yaml:"miner_address" env:"BLOCKCHAIN_MINER_ADDRESS"`
+	}
+	// Difficulty sets the proof of work difficulty, in leading zero bits of
+	// the block hash. RetargetInterval must not change once the chain holds
+	// blocks.
+	Difficulty struct {
+		Initial          int           `mapstructure:"initial" yaml:"initial" env:"BLOCKCHAIN_DIFFICULTY" env-default:"12"`
+		Min              int           `mapstructure:"min" yaml:"min" env-default:"1"`
+		RetargetInterval int           `mapstructure:"retarget_interval" yaml:"retarget_interval" env-default:"10"`
+		TargetBlockTime  time.Duration `mapstructure:"target_block_time" yaml:"target_block_time" env-default:"10s"`
+	}
+	// Keystore -.
+	Keystore struct {
+		// MasterKey encrypts the stored wallet keys, 32 hex encoded bytes.
+		MasterKey string `mapstructure:"master_key" yaml:"master_key" env:"BLOCKCHAIN_MASTER_KEY" env-required:"true"`
+	}
+	// Store selects where the chain is kept: postgres, memory or bolt, an
+	// embedded file at Path. A memory chain is lost on restart.
+	Store struct {
+		Backend string `mapstructure:"backend" yaml:"backend" env:"BLOCKCHAIN_STORE" env-default:"postgres"`
+		Path    string `mapstructure:"path" yaml:"path" env:"BLOCKCHAIN_STORE_PATH" env-default:"chain.db"`
+	}
+	// P2P connects the node to other blockchain service instances. Peers
+	// must share the genesis parameters.
+	P2P struct {
+		// Listen is the TCP address peers connect to, empty for none.
+		Listen string   `mapstructure:"listen" yaml:"listen" env:"P2P_LISTEN"`
+		Peers  []string `mapstructure:"peers" yaml:"peers" env:"P2P_PEERS" env-separator:","`
+	}
+	// Stream sets the events kept for clients of the event stream to resume
+	// and queued for each of them before it is disconnected.
+	Stream struct {
+		BufferSize int `mapstructure:"buffer_size" yaml:"buffer_size" env-default:"1024"`
+		QueueSize  int `mapstructure:"queue_size" yaml:"queue_size" env-default:"64"`
+		// Confirmations is the confirmation count up to which updates are
+		// sent for a transaction.
+		Confirmations int `mapstructure:"confirmations" yaml:"confirmations" env-default:"6"`
+	}
+	// Regtest configures the regtest network. Its genesis block pays the HD
+	// wallet of Mnemonic, which also mines and funds addresses, so nodes
+	// with the same mnemonic share the chain.
+	Regtest struct {
+		Mnemonic string `mapstructure:"mnemonic" yaml:"mnemonic" env:"BLOCKCHAIN_REGTEST_MNEMONIC" env-default:"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"`
 	}
 	Transport struct {
 		User     UserTransport     `yaml:"user"`
@@ -24,9 +24,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/blockchain/wallet": {
+        "/v1/blockchain/address/{address}/transactions": {
             "get": {
-                "description": "Retrieve a wallet from the blockchain for a specific user",
+                "description": "List confirmed transactions sending from or paying to the address, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,9 +34,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "Explorer"
                 ],
-                "summary": "Get a wallet by user ID",
+                "summary": "List transactions of an address",
                 "parameters": [
                     {
                         "type": "string",
@@ -44,13 +44,35 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of transactions, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of transactions to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Wallet address",
+                        "description": "Transactions",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Transaction"
+                            }
                         }
                     },
                     "400": {
@@ -74,9 +96,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/blockchain/wallet/balance": {
+        "/v1/blockchain/blocks": {
             "get": {
-                "description": "Retrieve the balance of a specific address on the blockchain",
+                "description": "List blocks in descending height order, starting at the tip or at the given height",
                 "consumes": [
                     "application/json"
                 ],
@@ -84,9 +106,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "Explorer"
                 ],
-                "summary": "Get the balance of an address",
+                "summary": "List blocks",
                 "parameters": [
                     {
                         "type": "string",
@@ -94,13 +116,28 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Height of the first block, defaults to the tip",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of blocks, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Balance",
+                        "description": "Blocks",
                         "schema": {
-                            "type": "number"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Block"
+                            }
                         }
                     },
                     "400": {
@@ -124,9 +161,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/blockchain/wallet/balance/address": {
+        "/v1/blockchain/blocks/height/{height}": {
             "get": {
-                "description": "Retrieve the balance of a specific address on the blockchain",
+                "description": "Retrieve the block at the given height, genesis being 0",
                 "consumes": [
                     "application/json"
                 ],
@@ -134,9 +171,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "Explorer"
                 ],
-                "summary": "Get the balance of an address",
+                "summary": "Get a block by height",
                 "parameters": [
                     {
                         "type": "string",
@@ -146,18 +183,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Wallet address",
-                        "name": "address",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "Block height",
+                        "name": "height",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Balance",
+                        "description": "Block",
                         "schema": {
-                            "type": "number"
+                            "$ref": "#/definitions/entity.Block"
                         }
                     },
                     "400": {
@@ -172,6 +209,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -181,9 +224,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/blockchain/wallet/create": {
-            "post": {
-                "description": "Create a new wallet on the blockchain",
+        "/v1/blockchain/blocks/{hash}": {
+            "get": {
+                "description": "Retrieve a block with its decoded transactions",
                 "consumes": [
                     "application/json"
                 ],
@@ -191,9 +234,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "Explorer"
                 ],
-                "summary": "Create a new wallet",
+                "summary": "Get a block by hash",
                 "parameters": [
                     {
                         "type": "string",
@@ -201,23 +244,30 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Block hash",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "New Wallet",
+                        "description": "Block",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.Block"
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "string"
                         }
@@ -231,9 +281,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/blockchain/wallet/qr": {
-            "get": {
-                "description": "Return a wallet QR code by user ID",
+        "/v1/blockchain/regtest/fund": {
+            "post": {
+                "description": "Send coins from the regtest faucet to an address and mine a block confirming them. Admins only, regtest network only",
                 "consumes": [
                     "application/json"
                 ],
@@ -241,9 +291,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "Regtest"
                 ],
-                "summary": "Get a wallet QR code by user ID",
+                "summary": "Fund an address on a regtest chain",
                 "parameters": [
                     {
                         "type": "string",
@@ -251,13 +301,22 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Fund Request",
+                        "name": "fundRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.FundRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Wallet QR code",
+                        "description": "Confirmed transaction",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/dto.TransactionResponse"
                         }
                     },
                     "400": {
@@ -272,6 +331,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -281,9 +346,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/blockchain/wallet/transactions": {
-            "put": {
-                "description": "TopUp top up of an account",
+        "/v1/blockchain/regtest/generate": {
+            "post": {
+                "description": "Mine the given number of blocks at once, the first one confirming the pending transactions. Admins only, regtest network only",
                 "consumes": [
                     "application/json"
                 ],
@@ -291,9 +356,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "Regtest"
                 ],
-                "summary": "TopUp top up of an account",
+                "summary": "Mine blocks on a regtest chain",
                 "parameters": [
                     {
                         "type": "string",
@@ -303,20 +368,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Top up Request",
-                        "name": "topUpRequest",
+                        "description": "Generate Blocks Request",
+                        "name": "generateBlocksRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.TopupRequest"
+                            "$ref": "#/definitions/dto.GenerateBlocksRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "Hashes of the mined blocks",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/dto.GenerateBlocksResponse"
                         }
                     },
                     "400": {
@@ -331,6 +396,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -338,19 +409,65 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/v1/blockchain/regtest/reset": {
             "post": {
-                "description": "Send cryptocurrency from one address to another on the blockchain",
-                "consumes": [
+                "description": "Drop every block and pending transaction and start over from the genesis block. Admins only, regtest network only",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Regtest"
+                ],
+                "summary": "Reset a regtest chain",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Genesis block hash",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResetChainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/stream": {
+            "get": {
+                "description": "Push the headers of new blocks and of blocks a reorganisation disconnected from the main chain, the pending and mined transactions of the addresses of the user wallets and their confirmation counts, up to the configured depth. Requests with an Upgrade header get a WebSocket sending events as JSON, others get Server-Sent Events. A client resumes after the last event it got with the Last-Event-ID header or the last_event_id parameter; a resync event tells it the events in between are lost. Clients falling behind are disconnected and have to resume",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Blockchain"
                 ],
-                "summary": "Send cryptocurrency to another address",
+                "summary": "Stream blocks and wallet activity",
                 "parameters": [
                     {
                         "type": "string",
@@ -360,20 +477,91 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Send Request",
-                        "name": "sendRequest",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Events",
                         "schema": {
-                            "$ref": "#/definitions/dto.SendRequest"
+                            "$ref": "#/definitions/stream.Event"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/topups": {
+            "get": {
+                "description": "List the top ups with a status, oldest first. Pending top ups wait for an admin decision. Admins only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopUps"
+                ],
+                "summary": "List top ups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, paying, approved, failed, rejected or denied, pending by default",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of top ups, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of top ups to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "Top ups",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.TopUp"
+                            }
                         }
                     },
                     "400": {
@@ -388,6 +576,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -397,19 +591,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/blockchain/wallet/usd/balance": {
-            "get": {
-                "description": "Retrieve the balance in USD of a specific address on the blockchain",
-                "consumes": [
-                    "application/json"
-                ],
+        "/v1/blockchain/topups/{id}/approve": {
+            "post": {
+                "description": "Pay a top up held for approval from the treasury. The decision is recorded under the admin. Admins only",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Blockchain"
+                    "TopUps"
                 ],
-                "summary": "Get the balance in USD of an address",
+                "summary": "Approve a pending top up",
                 "parameters": [
                     {
                         "type": "string",
@@ -417,13 +608,20 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Top up ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Balance in USD",
+                        "description": "Approved top up",
                         "schema": {
-                            "type": "number"
+                            "$ref": "#/definitions/entity.TopUp"
                         }
                     },
                     "400": {
@@ -438,6 +636,24 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Top up not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Top up is not pending",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -446,32 +662,2085 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.SendRequest": {
-            "type": "object",
-            "required": [
-                "amount",
-                "to"
-            ],
-            "properties": {
-                "amount": {
-                    "type": "number"
-                },
-                "to": {
-                    "type": "string"
-                }
-            }
         },
-        "dto.TopupRequest": {
-            "type": "object",
-            "required": [
-                "amount"
-            ],
-            "properties": {
-                "amount": {
-                    "type": "number"
+        "/v1/blockchain/topups/{id}/reject": {
+            "post": {
+                "description": "Refuse a top up held for approval. The decision and its reason are recorded under the admin. Admins only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopUps"
+                ],
+                "summary": "Reject a pending top up",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Top up ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reject Top Up Request",
+                        "name": "rejectTopUpRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RejectTopUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rejected top up",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TopUp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Top up not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Top up is not pending",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/transactions/build": {
+            "post": {
+                "description": "Build a transaction spending from a wallet of the user without signing it. Every input has to be signed over its sighash with the key of its address, which also goes into the input, before the transaction is submitted to /v1/blockchain/transactions/raw",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Build an unsigned transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Build Transaction Request",
+                        "name": "buildTransactionRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BuildTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unsigned transaction",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TxTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/transactions/raw": {
+            "post": {
+                "description": "Verify a transaction signed outside the node and queue it for mining",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Submit a signed transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Raw Transaction Request",
+                        "name": "rawTransactionRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RawTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pending transaction",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid transaction",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Transaction conflicts with a pending one",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/transactions/{txid}": {
+            "get": {
+                "description": "Retrieve a pending or confirmed transaction with decoded inputs and outputs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Explorer"
+                ],
+                "summary": "Get a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/transactions/{txid}/proof": {
+            "get": {
+                "description": "Retrieve the Merkle branch of a confirmed transaction and the header of its block, to check inclusion without the whole block",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Explorer"
+                ],
+                "summary": "Get the Merkle proof of a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merkle proof",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TransactionProof"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/treasury": {
+            "get": {
+                "description": "Get the balance of the treasury, the genesis recipient funding top ups, and its outgoing transactions, newest first. Pages are chained with next_cursor. Admins only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Treasury"
+                ],
+                "summary": "Get the treasury account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of outflows, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Treasury",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Treasury"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet": {
+            "get": {
+                "description": "Retrieve a wallet from the blockchain for a specific user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get a wallet by user ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet label or address, defaults to the default wallet",
+                        "name": "wallet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallet address",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/address": {
+            "post": {
+                "description": "Derive the next receive address of a user HD wallet. Funds sent to it count towards the wallet balance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Derive a new receive address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet label or address, defaults to the default wallet",
+                        "name": "wallet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Receive address",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Wallet is not an HD wallet",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/balance": {
+            "get": {
+                "description": "Retrieve the balance of a wallet of the user on the blockchain",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get the balance of a wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet label or address, defaults to the default wallet",
+                        "name": "wallet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance as a decimal string",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/balance/address": {
+            "get": {
+                "description": "Retrieve the balance of a specific address on the blockchain",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get the balance of an address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet address",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance as a decimal string",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/balance/total": {
+            "get": {
+                "description": "Retrieve the sum of the balances of all wallets of the user, and the balance of each",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get the balance of all wallets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Total balance",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TotalBalance"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/create": {
+            "post": {
+                "description": "Create a new HD wallet on the blockchain. The mnemonic is returned only once and is the only way to restore the wallet. The first wallet of a user becomes the default one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Create a new wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Wallet Request",
+                        "name": "createWalletRequest",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWalletRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New Wallet",
+                        "schema": {
+                            "$ref": "#/definitions/entity.NewWallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Wallet label is already used",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/export": {
+            "post": {
+                "description": "Export the private key of a custodial wallet encrypted with a passphrase, as an encrypted PKCS #8 PEM key or a JSON keyfile. Every export is audited",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Export the private key of a wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Export Key Request",
+                        "name": "exportKeyRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ExportKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Encrypted key",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ExportedKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or non-custodial wallet",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/external": {
+            "post": {
+                "description": "Add a wallet whose key stays with the user. Its transactions are built with /v1/blockchain/transactions/build and submitted signed with /v1/blockchain/transactions/raw",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Add a non-custodial wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "External Wallet Request",
+                        "name": "externalWalletRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ExternalWalletRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallet",
+                        "schema": {
+                            "$ref": "#/definitions/entity.UserWallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Address belongs to a custodial wallet, or wallet label or address is already used",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/history": {
+            "get": {
+                "description": "List confirmed transactions of the user wallet, newest first. Pages are chained with next_cursor. With format=csv the whole filtered history is returned as a CSV statement",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get the transaction history of the user wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "in or out",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallet history",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WalletHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/import": {
+            "post": {
+                "description": "Import an encrypted PKCS #8 PEM key or a JSON keyfile into the keystore and make it the default wallet of the user. Keys held for other wallets are refused. Every import is audited",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Import a private key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Import Key Request",
+                        "name": "importKeyRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallet address",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid key or wrong passphrase",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Key is held for another wallet, or wallet label or address is already used",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/list": {
+            "get": {
+                "description": "List the wallets of the user with their labels and balances",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "List the wallets of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.UserWallet"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/qr": {
+            "get": {
+                "description": "Return a wallet QR code by user ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get a wallet QR code by user ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet label or address, defaults to the default wallet",
+                        "name": "wallet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallet QR code",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/restore": {
+            "post": {
+                "description": "Restore the HD wallet of a mnemonic and add it to the wallets of the user. Addresses already used on chain are derived again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Restore a wallet from its mnemonic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Restore Wallet Request",
+                        "name": "restoreWalletRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RestoreWalletRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wallet address",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Wallet label or address is already used",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/transactions": {
+            "put": {
+                "description": "Top up the account from the treasury within the daily and lifetime limits of the user. Verified users only. Amounts above the approval threshold are held for an admin to approve",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "TopUp top up of an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Top up Request",
+                        "name": "topUpRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TopupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pending transaction",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TransactionResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Top up held for approval",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TopUp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "User not verified or limit reached",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Send cryptocurrency from one address to another on the blockchain",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Send cryptocurrency to another address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Send Request",
+                        "name": "sendRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pending transaction",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, fee too low, non-custodial wallet or not enough funds",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Outputs spent by a pending transaction or transfer already queued",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/blockchain/wallet/usd/balance": {
+            "get": {
+                "description": "Retrieve the balance in USD of a specific address on the blockchain",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get the balance in USD of an address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wallet label or address, defaults to the default wallet",
+                        "name": "wallet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance in USD",
+                        "schema": {
+                            "type": "number"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.BuildTransactionRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "12.5"
+                },
+                "fee": {
+                    "description": "Fee defaults to the minimum fee.",
+                    "type": "string",
+                    "example": "0.001"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "wallet": {
+                    "description": "Wallet is the label or address of the wallet to spend from, defaults\nto the default wallet.",
+                    "type": "string",
+                    "example": "cold"
+                }
+            }
+        },
+        "dto.CreateWalletRequest": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default makes the new wallet the default one.",
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "savings"
+                }
+            }
+        },
+        "dto.ExportKeyRequest": {
+            "type": "object",
+            "required": [
+                "passphrase"
+            ],
+            "properties": {
+                "address": {
+                    "description": "Address selects a receive or change address of an HD wallet, defaults\nto the wallet address.",
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "pem",
+                        "json"
+                    ],
+                    "example": "pem"
+                },
+                "passphrase": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "wallet": {
+                    "description": "Wallet is the label or address of the wallet to export, defaults to the\ndefault wallet.",
+                    "type": "string",
+                    "example": "savings"
+                }
+            }
+        },
+        "dto.ExternalWalletRequest": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "default": {
+                    "description": "Default makes the wallet the default one.",
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "cold"
+                }
+            }
+        },
+        "dto.FundRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "amount"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "string",
+                    "example": "12.5"
+                }
+            }
+        },
+        "dto.GenerateBlocksRequest": {
+            "type": "object",
+            "required": [
+                "blocks"
+            ],
+            "properties": {
+                "blocks": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1,
+                    "example": 10
+                }
+            }
+        },
+        "dto.GenerateBlocksResponse": {
+            "type": "object",
+            "properties": {
+                "hashes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.ImportKeyRequest": {
+            "type": "object",
+            "required": [
+                "key",
+                "passphrase"
+            ],
+            "properties": {
+                "key": {
+                    "description": "Key is an encrypted PEM (PKCS #8) key or a JSON keyfile.",
+                    "type": "string"
+                },
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RawTransactionRequest": {
+            "type": "object",
+            "required": [
+                "tx"
+            ],
+            "properties": {
+                "tx": {
+                    "description": "Tx is the hex encoded signed transaction.",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RejectTopUpRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 256
+                }
+            }
+        },
+        "dto.ResetChainResponse": {
+            "type": "object",
+            "properties": {
+                "genesis": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RestoreWalletRequest": {
+            "type": "object",
+            "required": [
+                "mnemonic"
+            ],
+            "properties": {
+                "label": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "savings"
+                },
+                "mnemonic": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SendRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "12.5"
+                },
+                "fee": {
+                    "description": "Fee defaults to the minimum fee.",
+                    "type": "string",
+                    "example": "0.001"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "wallet": {
+                    "description": "Wallet is the label or address of the wallet to send from, defaults to\nthe default wallet.",
+                    "type": "string",
+                    "example": "savings"
+                }
+            }
+        },
+        "dto.TopupRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "12.5"
+                }
+            }
+        },
+        "dto.TransactionResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                },
+                "txid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Block": {
+            "type": "object",
+            "properties": {
+                "confirmations": {
+                    "type": "integer"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "merkle_root": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "integer"
+                },
+                "previous_hash": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Transaction"
+                    }
+                }
+            }
+        },
+        "entity.BlockHeader": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "type": "integer"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "merkle_root": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "integer"
+                },
+                "previous_hash": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ExportedKey": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.HistoryEntry": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "block_hash": {
+                    "type": "string"
+                },
+                "block_height": {
+                    "type": "integer"
+                },
+                "change": {
+                    "type": "string"
+                },
+                "confirmations": {
+                    "type": "integer"
+                },
+                "counterparty": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "txid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Input": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "pub_key": {
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "txid": {
+                    "type": "string"
+                },
+                "unlock_script": {
+                    "description": "UnlockScript is the hex encoded unlocking script of inputs spending\nscript outputs.",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                },
+                "vout": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.NewWallet": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "mnemonic": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Output": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "pub_key_hash": {
+                    "type": "string"
+                },
+                "script": {
+                    "description": "Script is the hex encoded locking script of outputs not paying a\nsingle address.",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TemplateInput": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "sighash": {
+                    "type": "string"
+                },
+                "txid": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                },
+                "vout": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TopUp": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "decided_at": {
+                    "type": "string"
+                },
+                "decided_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "txid": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TotalBalance": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "string"
+                },
+                "wallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.UserWallet"
+                    }
+                }
+            }
+        },
+        "entity.Transaction": {
+            "type": "object",
+            "properties": {
+                "block_hash": {
+                    "type": "string"
+                },
+                "block_height": {
+                    "type": "integer"
+                },
+                "coinbase": {
+                    "type": "boolean"
+                },
+                "confirmations": {
+                    "type": "integer"
+                },
+                "fee": {
+                    "type": "string"
+                },
+                "inputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Input"
+                    }
+                },
+                "lock_time": {
+                    "type": "integer"
+                },
+                "outputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Output"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "txid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TransactionProof": {
+            "type": "object",
+            "properties": {
+                "branch": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "confirmations": {
+                    "type": "integer"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "header": {
+                    "$ref": "#/definitions/entity.BlockHeader"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "txid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Treasury": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "balance": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "outflows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.HistoryEntry"
+                    }
+                },
+                "supply": {
+                    "description": "Supply is the amount the genesis block paid the treasury.",
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TxTemplate": {
+            "type": "object",
+            "properties": {
+                "fee": {
+                    "type": "string"
+                },
+                "inputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.TemplateInput"
+                    }
+                },
+                "outputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Output"
+                    }
+                },
+                "tx": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.UserWallet": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "balance": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "custodial": {
+                    "type": "boolean"
+                },
+                "default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.WalletHistory": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.HistoryEntry"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "stream.Event": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         }
@@ -1,7 +1,9 @@
 package applicator
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -17,13 +19,16 @@ import (
 	"github.com/damndelion/blockchain_justCode/config/blockchain"
 	_ "github.com/damndelion/blockchain_justCode/config/blockchain"
 	v1 "github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/metrics"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/transport"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase/repo"
 	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
 	"github.com/damndelion/blockchain_justCode/pkg/httpserver"
 	"github.com/damndelion/blockchain_justCode/pkg/logger"
+	"github.com/damndelion/blockchain_justCode/pkg/p2p"
 	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+	"github.com/damndelion/blockchain_justCode/pkg/stream"
 	"github.com/gin-gonic/gin"
 )
 
@@ -55,22 +60,142 @@ func Run(cfg *blockchain.Config) {
 	}(closer)
 	opentracing.SetGlobalTracer(tracer)
 
-	address := blockchainlogic.CreateWallet()
+	err = blockchainlogic.SetDecimals(cfg.Decimals)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.SetDecimals: %w", err))
+	}
+	supply, err := blockchainlogic.ParseAmount(cfg.Genesis.Supply)
+	if err == nil {
+		err = blockchainlogic.SetGenesisSupply(supply)
+	}
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.SetGenesisSupply: %w", err))
+	}
+	regtest, err := isRegtest(cfg)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - isRegtest: %w", err))
+	}
+	policy := blockchainlogic.DifficultyPolicy{
+		Initial:          cfg.Difficulty.Initial,
+		Min:              cfg.Difficulty.Min,
+		RetargetInterval: cfg.RetargetInterval,
+		TargetBlockTime:  cfg.TargetBlockTime,
+	}
+	if regtest {
+		policy = blockchainlogic.RegtestDifficultyPolicy
+	}
+	err = blockchainlogic.SetDifficultyPolicy(policy)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.SetDifficultyPolicy: %w", err))
+	}
+	store, err := openChainStore(cfg, db)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - openChainStore: %w", err))
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer func() {
+			err = c.Close()
+			if err != nil {
+				l.Error(fmt.Errorf("blockchain - chain store: %w", err))
+			}
+		}()
+	}
 
+	if cfg.VerifyOnStartup {
+		report, err := blockchainlogic.VerifyChain(store)
+		if err != nil {
+			l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.VerifyChain: %w", err))
+		}
+		if !report.Valid() {
+			l.Fatal(fmt.Errorf("blockchain - Run - chain is corrupted:\n%s", report))
+		}
+		l.Info("blockchain - Run - chain verified: %d blocks", report.Blocks)
+	}
+
+	masterKey, err := blockchainlogic.ParseMasterKey(cfg.MasterKey)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.ParseMasterKey: %w", err))
+	}
+	keystore, err := blockchainlogic.NewPostgresKeystore(db, masterKey)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.NewPostgresKeystore: %w", err))
+	}
 	userGrpcTransport := transport.NewUserGrpcTransport(cfg.Transport.UserGrpc)
 
+	var chain *blockchainlogic.Blockchain
+	if regtest {
+		chain, err = blockchainlogic.OpenRegtestBlockchain(store, keystore, cfg.Regtest.Mnemonic)
+		if err != nil {
+			l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.OpenRegtestBlockchain: %w", err))
+		}
+		// The faucet funds top ups.
+		cfg.Genesis.Recipient = chain.Faucet()
+	} else {
+		cfg.Genesis.Recipient, err = loadTreasuryKey(cfg, db, keystore)
+		if err != nil {
+			l.Fatal(fmt.Errorf("blockchain - Run - loadTreasuryKey: %w", err))
+		}
+		chain, err = blockchainlogic.OpenBlockchain(store, keystore, blockchainlogic.GenesisParams{
+			Recipient: cfg.Genesis.Recipient,
+			Timestamp: cfg.Genesis.Timestamp,
+			Message:   cfg.Genesis.Message,
+		})
+		if err != nil {
+			l.Fatal(fmt.Errorf("blockchain - Run - blockchainlogic.OpenBlockchain: %w", err))
+		}
+	}
+	if cfg.MinerAddress != "" {
+		err = chain.SetMinerAddress(cfg.MinerAddress)
+		if err != nil {
+			l.Fatal(fmt.Errorf("blockchain - Run - chain.SetMinerAddress: %w", err))
+		}
+	}
+	minFee, err := blockchainlogic.ParseAmount(cfg.MinFee)
+	if err != nil || minFee < 0 {
+		l.Fatal(fmt.Errorf("blockchain - Run - min fee %q is not valid", cfg.MinFee))
+	}
+	chain.SetMinFee(minFee)
+	chain.SetMiningObserver(metrics.Mining{})
+
+	// Peer to peer networking
+	if cfg.P2P.Listen != "" || len(cfg.P2P.Peers) > 0 {
+		node := p2p.NewNode(chain, p2p.Config{Listen: cfg.P2P.Listen, Peers: cfg.P2P.Peers}, l)
+		err = node.Start()
+		if err != nil {
+			l.Fatal(fmt.Errorf("blockchain - Run - node.Start: %w", err))
+		}
+		defer node.Close()
+	}
+
+	// Events of the chain, before the miner adds blocks.
+	hub := stream.NewHub(chain, stream.Config{
+		BufferSize:    cfg.Stream.BufferSize,
+		QueueSize:     cfg.Stream.QueueSize,
+		Confirmations: cfg.Stream.Confirmations,
+	})
+
+	// Miner packaging mempool transactions into blocks. Regtest chains
+	// only get blocks on demand.
+	if !regtest {
+		minerCtx, stopMiner := context.WithCancel(context.Background())
+		defer stopMiner()
+		go chain.RunMiner(minerCtx, cfg.MaxBlockTransactions, cfg.BlockInterval)
+	}
+
 	// Use case
-	chainUseCase := usecase.NewBlockchain(repo.NewBlockchainRepo(db, address, userGrpcTransport), cfg, userGrpcTransport)
-	blockchainlogic.ListAddresses()
-	// address to create genesis block
-	chain := blockchainlogic.CreateBlockchain(db, address)
+	chainUseCase := usecase.NewBlockchain(repo.NewBlockchainRepo(db, chain, userGrpcTransport), cfg, userGrpcTransport)
+	topUpPolicy, err := usecase.NewTopUpPolicy(cfg.TopUp)
+	if err != nil {
+		l.Fatal(fmt.Errorf("blockchain - Run - usecase.NewTopUpPolicy: %w", err))
+	}
+	chainUseCase.SetTopUpPolicy(topUpPolicy)
 
 	redisClient, err := cache.NewRedisClient(cfg.Redis.Host)
 	blockchainCache := cache.NewBlockchainCache(redisClient, 10*time.Minute)
 
 	// HTTP Server
 	handler := gin.New()
-	v1.NewBlockchainRouter(handler, l, chainUseCase, *chain, cfg, blockchainCache)
+	v1.NewBlockchainRouter(handler, l, chainUseCase, chain, hub, cfg, blockchainCache)
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
 
 	// Waiting signal
@@ -90,3 +215,123 @@ func Run(cfg *blockchain.Config) {
 		}
 	}
 }
+
+// loadTreasuryKey returns the treasury address and makes sure the keystore
+// holds its key. It is the genesis recipient of the config, whose key is
+// imported from the treasury key file when missing. Without a recipient it
+// is the address of the key file, or of a key generated on the first start
+// and recorded in the chain parameters.
+func loadTreasuryKey(cfg *blockchain.Config, db *sql.DB, keys blockchainlogic.Keystore) (string, error) {
+	recipient := cfg.Genesis.Recipient
+	if recipient != "" {
+		_, err := keys.Get(recipient)
+		if !errors.Is(err, blockchainlogic.ErrKeyNotFound) {
+			return recipient, err
+		}
+		if cfg.Treasury.KeyFile == "" {
+			return "", fmt.Errorf("the keystore has no key of the genesis recipient %s: set treasury.key_file "+
+				"(BLOCKCHAIN_TREASURY_KEY_FILE) to an exported key of it, or import the key with "+
+				"make wallet-key ARGS=\"import -file FILE\"", recipient)
+		}
+	}
+
+	if cfg.Treasury.KeyFile != "" {
+		w, err := readTreasuryKey(cfg)
+		if err != nil {
+			return "", err
+		}
+		address := string(w.GetAddress())
+		if recipient != "" && address != recipient {
+			return "", fmt.Errorf("treasury key file holds the key of %s, not of the genesis recipient %s", address, recipient)
+		}
+		err = keys.Put(w)
+		if err != nil && !errors.Is(err, blockchainlogic.ErrKeyExists) {
+			return "", err
+		}
+
+		return address, nil
+	}
+
+	return generatedTreasury(db, keys)
+}
+
+func readTreasuryKey(cfg *blockchain.Config) (*blockchainlogic.Wallet, error) {
+	data, err := os.ReadFile(cfg.Treasury.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return blockchainlogic.ImportKey(data, cfg.Treasury.Passphrase)
+}
+
+// generatedTreasury returns the treasury address recorded in the chain
+// parameters, generating its key on the first start. Instances starting
+// together keep the key of the first one to record it.
+func generatedTreasury(db *sql.DB, keys blockchainlogic.Keystore) (string, error) {
+	address, err := blockchainlogic.ChainParam(db, blockchainlogic.ParamTreasury)
+	if err == nil {
+		_, err = keys.Get(address)
+
+		return address, err
+	}
+	if !errors.Is(err, blockchainlogic.ErrNotFound) {
+		return "", err
+	}
+
+	created, err := blockchainlogic.CreateWallet(keys)
+	if err != nil {
+		return "", err
+	}
+	address, err = blockchainlogic.BindChainParam(db, blockchainlogic.ParamTreasury, created)
+	if err != nil {
+		return "", err
+	}
+	if address != created {
+		err = keys.Delete(created)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return address, nil
+}
+
+// isRegtest reports whether the config selects the regtest network.
+func isRegtest(cfg *blockchain.Config) (bool, error) {
+	switch cfg.Network {
+	case "main":
+		return false, nil
+	case "regtest":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown network %q", cfg.Network)
+	}
+}
+
+// openChainStore opens the chain store selected by the config. Blocks kept in
+// Postgres in an older format must be rewritten first, and their amounts must
+// use the configured decimals.
+func openChainStore(cfg *blockchain.Config, db *sql.DB) (blockchainlogic.ChainStore, error) {
+	switch cfg.Store.Backend {
+	case "postgres":
+		needsRewrite, err := blockchainlogic.ChainNeedsRewrite(db)
+		if err != nil {
+			return nil, err
+		}
+		if needsRewrite {
+			return nil, errors.New("stored blocks use an older format, run make chain-rewrite")
+		}
+		err = blockchainlogic.BindDecimals(db)
+		if err != nil {
+			return nil, err
+		}
+
+		return blockchainlogic.NewPostgresChainStore(db)
+	case "memory":
+		return blockchainlogic.NewMemoryChainStore(), nil
+	case "bolt":
+		return blockchainlogic.OpenBoltChainStore(cfg.Store.Path)
+	default:
+		return nil, fmt.Errorf("unknown chain store %q", cfg.Store.Backend)
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// AdminVerify lets through requests with a valid token of the admin role.
+func AdminVerify(SecretKey string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenHeader := ctx.Request.Header.Get("Authorization")
+		if tokenHeader == "" {
+			ctx.AbortWithStatus(http.StatusForbidden)
+
+			return
+		}
+
+		token, err := jwt.Parse(tokenHeader, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+
+			return []byte(SecretKey), nil
+		})
+		if err != nil || !token.Valid {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+
+			return
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+
+			return
+		}
+		exp, ok := claims["exp"].(float64)
+		if !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+
+			return
+		}
+
+		role, _ := claims["role"].(string)
+		if role != "admin" {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+
+			return
+		}
+		ctx.Set("user_id", fmt.Sprintf("%v", claims["user_id"]))
+
+		ctx.Next()
+	}
+}
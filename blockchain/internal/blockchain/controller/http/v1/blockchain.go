@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/damndelion/blockchain_justCode/config/blockchain"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
 	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
 	"github.com/damndelion/blockchain_justCode/pkg/logger"
@@ -28,20 +31,28 @@ type chainRoutes struct {
 	chainCache cache.Blockchain
 }
 
-func newBlockchainRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, _ blockchainlogic.Blockchain, cfg *blockchain.Config, chainCache cache.Blockchain) {
+func newBlockchainRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, _ *blockchainlogic.Blockchain, cfg *blockchain.Config, chainCache cache.Blockchain) {
 	r := &chainRoutes{c, l, cfg, chainCache}
 
 	blockchainHandler := handler.Group("/blockchain/wallet")
 	{
 		blockchainHandler.Use(middleware.JwtVerify(cfg.SecretKey))
 		blockchainHandler.GET("/", r.GetWallet)
+		blockchainHandler.GET("/list", r.GetWallets)
 		blockchainHandler.GET("/balance", r.GetBalance)
+		blockchainHandler.GET("/balance/total", r.GetTotalBalance)
 		blockchainHandler.GET("/balance/address", r.GetBalanceByAddress) // util
 		blockchainHandler.GET("/usd/balance", r.GetBalanceUSD)
 		blockchainHandler.POST("/create", r.CreateWallet)
+		blockchainHandler.POST("/restore", r.RestoreWallet)
+		blockchainHandler.POST("/address", r.NewReceiveAddress)
+		blockchainHandler.POST("/external", r.AddExternalWallet)
+		blockchainHandler.POST("/export", r.ExportKey)
+		blockchainHandler.POST("/import", r.ImportKey)
 		blockchainHandler.POST("/transactions", r.Send)
 		blockchainHandler.PUT("/transactions", r.TopUp)
 		blockchainHandler.GET("/qr", r.GetWalletQRCode)
+		blockchainHandler.GET("/history", r.GetWalletHistory)
 	}
 }
 
@@ -52,9 +63,11 @@ func newBlockchainRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l log
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "JWT Token"
+// @Param wallet query string false "Wallet label or address, defaults to the default wallet"
 // @Success 200 {string} string "Wallet address"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet [get].
 func (bc *chainRoutes) GetWallet(ctx *gin.Context) {
@@ -62,7 +75,12 @@ func (bc *chainRoutes) GetWallet(ctx *gin.Context) {
 	defer span.Finish()
 	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
 	userID, _ := ctx.Get("user_id")
-	wallet, err := bc.c.Wallet(spanCtx, userID.(string))
+	wallet, err := bc.c.Wallet(spanCtx, userID.(string), ctx.Query("wallet"))
+	if errors.Is(err, entity.ErrWalletNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
 	if err != nil {
 		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getWallet: %w", err))
 		errorResponse(ctx, http.StatusInternalServerError, "Wallet does not exist")
@@ -73,16 +91,45 @@ func (bc *chainRoutes) GetWallet(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, wallet)
 }
 
+// GetWallets godoc
+// @Summary List the wallets of a user
+// @Description List the wallets of the user with their labels and balances
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Success 200 {array} entity.UserWallet "Wallets"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/list [get].
+func (bc *chainRoutes) GetWallets(ctx *gin.Context) {
+	span := opentracing.StartSpan("get wallets handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+	wallets, err := bc.c.Wallets(spanCtx, userID.(string))
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getWallets: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wallets)
+}
+
 // GetBalance godoc
-// @Summary Get the balance of an address
-// @Description Retrieve the balance of a specific address on the blockchain
+// @Summary Get the balance of a wallet
+// @Description Retrieve the balance of a wallet of the user on the blockchain
 // @Tags Blockchain
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "JWT Token"
-// @Success 200 {number} float64 "Balance"
+// @Param wallet query string false "Wallet label or address, defaults to the default wallet"
+// @Success 200 {string} string "Balance as a decimal string"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet/balance [get].
 func (bc *chainRoutes) GetBalance(ctx *gin.Context) {
@@ -90,7 +137,12 @@ func (bc *chainRoutes) GetBalance(ctx *gin.Context) {
 	defer span.Finish()
 	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
 	userID, _ := ctx.Get("user_id")
-	balance, err := bc.c.GetBalance(spanCtx, userID.(string))
+	balance, err := bc.c.GetBalance(spanCtx, userID.(string), ctx.Query("wallet"))
+	if errors.Is(err, entity.ErrWalletNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
 	if err != nil {
 		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getBalance: %w", err))
 		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
@@ -98,6 +150,33 @@ func (bc *chainRoutes) GetBalance(ctx *gin.Context) {
 		return
 	}
 
+	ctx.JSON(http.StatusOK, balance.String())
+}
+
+// GetTotalBalance godoc
+// @Summary Get the balance of all wallets
+// @Description Retrieve the sum of the balances of all wallets of the user, and the balance of each
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Success 200 {object} entity.TotalBalance "Total balance"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/balance/total [get].
+func (bc *chainRoutes) GetTotalBalance(ctx *gin.Context) {
+	span := opentracing.StartSpan("get total balance handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+	balance, err := bc.c.GetTotalBalance(spanCtx, userID.(string))
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getTotalBalance: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
 	ctx.JSON(http.StatusOK, balance)
 }
 
@@ -109,7 +188,7 @@ func (bc *chainRoutes) GetBalance(ctx *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "JWT Token"
 // @Param address query string true "Wallet address"
-// @Success 200 {number} float64 "Balance"
+// @Success 200 {string} string "Balance as a decimal string"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal Server Error"
@@ -127,7 +206,7 @@ func (bc *chainRoutes) GetBalanceByAddress(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, balance)
+	ctx.JSON(http.StatusOK, balance.String())
 }
 
 // GetBalanceUSD godoc
@@ -137,9 +216,11 @@ func (bc *chainRoutes) GetBalanceByAddress(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "JWT Token"
+// @Param wallet query string false "Wallet label or address, defaults to the default wallet"
 // @Success 200 {number} float64 "Balance in USD"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet/usd/balance [get].
 func (bc *chainRoutes) GetBalanceUSD(ctx *gin.Context) {
@@ -148,7 +229,12 @@ func (bc *chainRoutes) GetBalanceUSD(ctx *gin.Context) {
 	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
 	userID, _ := ctx.Get("user_id")
 
-	balance, err := bc.c.GetBalanceUSD(spanCtx, userID.(string))
+	balance, err := bc.c.GetBalanceUSD(spanCtx, userID.(string), ctx.Query("wallet"))
+	if errors.Is(err, entity.ErrWalletNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
 	if err != nil {
 		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getBalanceUSD: %w", err))
 		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
@@ -161,14 +247,16 @@ func (bc *chainRoutes) GetBalanceUSD(ctx *gin.Context) {
 
 // CreateWallet godoc
 // @Summary Create a new wallet
-// @Description Create a new wallet on the blockchain
+// @Description Create a new HD wallet on the blockchain. The mnemonic is returned only once and is the only way to restore the wallet. The first wallet of a user becomes the default one
 // @Tags Blockchain
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "JWT Token"
-// @Success 200 {string} string "New Wallet"
+// @Param createWalletRequest body dto.CreateWalletRequest false "Create Wallet Request"
+// @Success 200 {object} entity.NewWallet "New Wallet"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Wallet label is already used"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet/create [post].
 func (bc *chainRoutes) CreateWallet(ctx *gin.Context) {
@@ -177,16 +265,268 @@ func (bc *chainRoutes) CreateWallet(ctx *gin.Context) {
 	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
 	userID, _ := ctx.Get("user_id")
 
-	wallet, err := bc.c.CreateWallet(spanCtx, userID.(string))
+	var req dto.CreateWalletRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	wallet, err := bc.c.CreateWallet(spanCtx, userID.(string), req.Label, req.Default)
+	if errors.Is(err, entity.ErrWalletLabelTaken) {
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+
+		return
+	}
 	if err != nil {
 		bc.l.Error(fmt.Errorf("http - v1 - blockchain - createWallet: %w", err))
 		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
 
 		return
 	}
+	if wallet.Default {
+		// The QR code of the default wallet is cached by user.
+		if err = bc.chainCache.Set(ctx, userID.(string), wallet.Address); err != nil {
+			bc.l.Error(fmt.Errorf("http - v1 - blockchain - createWallet: %w", err))
+		}
+	}
+	ctx.JSON(http.StatusOK, wallet)
+}
+
+// RestoreWallet godoc
+// @Summary Restore a wallet from its mnemonic
+// @Description Restore the HD wallet of a mnemonic and add it to the wallets of the user. Addresses already used on chain are derived again
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param restoreWalletRequest body dto.RestoreWalletRequest true "Restore Wallet Request"
+// @Success 200 {string} string "Wallet address"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Wallet label or address is already used"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/restore [post].
+func (bc *chainRoutes) RestoreWallet(ctx *gin.Context) {
+	span := opentracing.StartSpan("restore wallet handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	var req dto.RestoreWalletRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	address, err := bc.c.RestoreWallet(spanCtx, userID.(string), req.Mnemonic, req.Label)
+	if errors.Is(err, blockchainlogic.ErrInvalidMnemonic) {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if errors.Is(err, entity.ErrWalletLabelTaken) || errors.Is(err, entity.ErrWalletAddressTaken) {
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - restoreWallet: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, address)
+}
+
+// NewReceiveAddress godoc
+// @Summary Derive a new receive address
+// @Description Derive the next receive address of a user HD wallet. Funds sent to it count towards the wallet balance
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param wallet query string false "Wallet label or address, defaults to the default wallet"
+// @Success 200 {string} string "Receive address"
+// @Failure 400 {string} string "Wallet is not an HD wallet"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/address [post].
+func (bc *chainRoutes) NewReceiveAddress(ctx *gin.Context) {
+	span := opentracing.StartSpan("new receive address handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	address, err := bc.c.NewReceiveAddress(spanCtx, userID.(string), ctx.Query("wallet"))
+	if errors.Is(err, blockchainlogic.ErrNotHD) {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if errors.Is(err, entity.ErrWalletNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - newReceiveAddress: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, address)
+}
+
+// AddExternalWallet godoc
+// @Summary Add a non-custodial wallet
+// @Description Add a wallet whose key stays with the user. Its transactions are built with /v1/blockchain/transactions/build and submitted signed with /v1/blockchain/transactions/raw
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param externalWalletRequest body dto.ExternalWalletRequest true "External Wallet Request"
+// @Success 200 {object} entity.UserWallet "Wallet"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Address belongs to a custodial wallet, or wallet label or address is already used"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/external [post].
+func (bc *chainRoutes) AddExternalWallet(ctx *gin.Context) {
+	span := opentracing.StartSpan("add external wallet handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	var req dto.ExternalWalletRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	wallet, err := bc.c.AddExternalWallet(spanCtx, userID.(string), req.Address, req.Label, req.Default)
+	if errors.Is(err, entity.ErrCustodialAddress) || errors.Is(err, entity.ErrWalletLabelTaken) ||
+		errors.Is(err, entity.ErrWalletAddressTaken) {
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - addExternalWallet: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if wallet.Default {
+		if err = bc.chainCache.Set(ctx, userID.(string), wallet.Address); err != nil {
+			bc.l.Error(fmt.Errorf("http - v1 - blockchain - addExternalWallet: %w", err))
+		}
+	}
 	ctx.JSON(http.StatusOK, wallet)
 }
 
+// ExportKey godoc
+// @Summary Export the private key of a wallet
+// @Description Export the private key of a custodial wallet encrypted with a passphrase, as an encrypted PKCS #8 PEM key or a JSON keyfile. Every export is audited
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param exportKeyRequest body dto.ExportKeyRequest true "Export Key Request"
+// @Success 200 {object} entity.ExportedKey "Encrypted key"
+// @Failure 400 {string} string "Invalid input or non-custodial wallet"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/export [post].
+func (bc *chainRoutes) ExportKey(ctx *gin.Context) {
+	span := opentracing.StartSpan("export key handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	var req dto.ExportKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	key, err := bc.c.ExportKey(spanCtx, userID.(string), req.Wallet, req.Address, req.Format, req.Passphrase)
+	if errors.Is(err, entity.ErrNotCustodial) || errors.Is(err, blockchainlogic.ErrShortPassphrase) {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if errors.Is(err, entity.ErrWalletNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - exportKey: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, key)
+}
+
+// ImportKey godoc
+// @Summary Import a private key
+// @Description Import an encrypted PKCS #8 PEM key or a JSON keyfile into the keystore and make it the default wallet of the user. Keys held for other wallets are refused. Every import is audited
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param importKeyRequest body dto.ImportKeyRequest true "Import Key Request"
+// @Success 200 {string} string "Wallet address"
+// @Failure 400 {string} string "Invalid key or wrong passphrase"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Key is held for another wallet, or wallet label or address is already used"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/import [post].
+func (bc *chainRoutes) ImportKey(ctx *gin.Context) {
+	span := opentracing.StartSpan("import key handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	var req dto.ImportKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	address, err := bc.c.ImportKey(spanCtx, userID.(string), []byte(req.Key), req.Passphrase)
+	if errors.Is(err, blockchainlogic.ErrWrongPassphrase) || errors.Is(err, blockchainlogic.ErrKeyFormat) {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if errors.Is(err, entity.ErrKeyConflict) || errors.Is(err, entity.ErrWalletLabelTaken) ||
+		errors.Is(err, entity.ErrWalletAddressTaken) {
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - importKey: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	// The imported key is the default wallet now.
+	if err = bc.chainCache.Set(ctx, userID.(string), address); err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - importKey: %w", err))
+	}
+	ctx.JSON(http.StatusOK, address)
+}
+
 // Send godoc
 // @Summary Send cryptocurrency to another address
 // @Description Send cryptocurrency from one address to another on the blockchain
@@ -195,9 +535,11 @@ func (bc *chainRoutes) CreateWallet(ctx *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "JWT Token"
 // @Param sendRequest body dto.SendRequest true "Send Request"
-// @Success 200 {string} string "Success"
-// @Failure 400 {string} string "Invalid input"
+// @Success 200 {object} dto.TransactionResponse "Pending transaction"
+// @Failure 400 {string} string "Invalid input, fee too low, non-custodial wallet or not enough funds"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
+// @Failure 409 {string} string "Outputs spent by a pending transaction or transfer already queued"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet/transactions [post].
 func (bc *chainRoutes) Send(ctx *gin.Context) {
@@ -213,9 +555,40 @@ func (bc *chainRoutes) Send(ctx *gin.Context) {
 
 		return
 	}
+	amount, err := parseAmount(sendData.Amount)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	var fee blockchainlogic.Amount
+	if sendData.Fee != "" {
+		fee, err = parseAmount(sendData.Fee)
+		if err != nil {
+			errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("fee: %v ", err))
+
+			return
+		}
+	}
 	userID, _ := ctx.Get("user_id")
 
-	err = bc.c.Send(spanCtx, userID.(string), sendData.To, sendData.Amount)
+	txID, err := bc.c.Send(spanCtx, userID.(string), sendData.Wallet, sendData.To, amount, fee)
+	if errors.Is(err, entity.ErrWalletNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if errors.Is(err, entity.ErrNotCustodial) || errors.Is(err, blockchainlogic.ErrInsufficientFunds) ||
+		errors.Is(err, blockchainlogic.ErrInvalidAmount) || errors.Is(err, blockchainlogic.ErrFeeTooLow) {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if errors.Is(err, blockchainlogic.ErrDoubleSpend) || errors.Is(err, blockchainlogic.ErrAlreadyProcessed) {
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+
+		return
+	}
 	if err != nil {
 		bc.l.Error(fmt.Errorf("http - v1 - blockchain - send: %w", err))
 		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
@@ -223,20 +596,22 @@ func (bc *chainRoutes) Send(ctx *gin.Context) {
 		return
 	}
 	metrics.TransactionRequestsTotalCollector.WithLabelValues(fmt.Sprintf("%v", ctx.Request.URL), strconv.Itoa(0), ctx.Request.Method).Inc()
-	ctx.JSON(http.StatusOK, "Success ")
+	ctx.JSON(http.StatusOK, dto.TransactionResponse{TxID: txID, Status: string(blockchainlogic.TxStatusPending)})
 }
 
 // TopUp godoc
 // @Summary TopUp top up of an account
-// @Description TopUp top up of an account
+// @Description Top up the account from the treasury within the daily and lifetime limits of the user. Verified users only. Amounts above the approval threshold are held for an admin to approve
 // @Tags Blockchain
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "JWT Token"
 // @Param topUpRequest body dto.TopupRequest true "Top up Request"
-// @Success 200 {string} string "Success"
+// @Success 200 {object} dto.TransactionResponse "Pending transaction"
+// @Success 202 {object} entity.TopUp "Top up held for approval"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "User not verified or limit reached"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet/transactions [put].
 func (bc *chainRoutes) TopUp(ctx *gin.Context) {
@@ -251,17 +626,33 @@ func (bc *chainRoutes) TopUp(ctx *gin.Context) {
 
 		return
 	}
+	amount, err := parseAmount(topupData.Amount)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
 	userID, _ := ctx.Get("user_id")
 
-	err = bc.c.TopUp(spanCtx, userID.(string), topupData.Amount)
-	if err != nil {
+	topUp, err := bc.c.TopUp(spanCtx, userID.(string), amount)
+	switch {
+	case errors.Is(err, entity.ErrKYCRequired), errors.Is(err, entity.ErrTopUpLimit):
+		errorResponse(ctx, http.StatusForbidden, fmt.Sprintf("%v ", err))
+
+		return
+	case err != nil:
 		bc.l.Error(fmt.Errorf("http - v1 - blockchain - send: %w", err))
 		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
 
 		return
 	}
+	if topUp.Status == entity.TopUpPending {
+		ctx.JSON(http.StatusAccepted, topUp)
+
+		return
+	}
 	metrics.TransactionRequestsTotalCollector.WithLabelValues(fmt.Sprintf("%v", ctx.Request.URL), strconv.Itoa(0), ctx.Request.Method).Inc()
-	ctx.JSON(http.StatusOK, "Success ")
+	ctx.JSON(http.StatusOK, dto.TransactionResponse{TxID: topUp.TxID, Status: string(blockchainlogic.TxStatusPending)})
 }
 
 // GetWalletQRCode godoc
@@ -271,9 +662,11 @@ func (bc *chainRoutes) TopUp(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "JWT Token"
+// @Param wallet query string false "Wallet label or address, defaults to the default wallet"
 // @Success 200 {string} string "Wallet QR code"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /v1/blockchain/wallet/qr [get].
 func (bc *chainRoutes) GetWalletQRCode(ctx *gin.Context) {
@@ -281,14 +674,24 @@ func (bc *chainRoutes) GetWalletQRCode(ctx *gin.Context) {
 	defer span.Finish()
 	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
 	userID, _ := ctx.Get("user_id")
-	wallet, err := bc.chainCache.Get(ctx, userID.(string))
+	selector := ctx.Query("wallet")
+	cacheKey := userID.(string)
+	if selector != "" {
+		cacheKey += ":" + selector
+	}
+	wallet, err := bc.chainCache.Get(ctx, cacheKey)
 	if err != nil {
 		return
 	}
 
 	if wallet == "" {
 		time.Sleep(1 * time.Second)
-		wallet, err = bc.c.Wallet(spanCtx, userID.(string))
+		wallet, err = bc.c.Wallet(spanCtx, userID.(string), selector)
+		if errors.Is(err, entity.ErrWalletNotFound) {
+			errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+			return
+		}
 		if err != nil {
 			bc.l.Error(fmt.Errorf("http - v1 - blockchain - GetWalletQRCode: %w", err))
 			errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
@@ -296,7 +699,7 @@ func (bc *chainRoutes) GetWalletQRCode(ctx *gin.Context) {
 			return
 		}
 
-		err = bc.chainCache.Set(ctx, userID.(string), wallet)
+		err = bc.chainCache.Set(ctx, cacheKey, wallet)
 		if err != nil {
 			bc.l.Error(fmt.Errorf("http - v1 - user - getUsersById: %w", err))
 			errorResponse(ctx, http.StatusInternalServerError, "getUsersById cache error")
@@ -315,3 +718,16 @@ func (bc *chainRoutes) GetWalletQRCode(ctx *gin.Context) {
 
 	ctx.Data(http.StatusOK, "image/png", qrCode)
 }
+
+// parseAmount parses a positive decimal amount such as "12.5".
+func parseAmount(s string) (blockchainlogic.Amount, error) {
+	amount, err := blockchainlogic.ParseAmount(s)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q: %w", s, err)
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("amount %q: must be positive", s)
+	}
+
+	return amount, nil
+}
@@ -1,22 +1,127 @@
 package dto
 
+import "time"
+
 type SendRequest struct {
-	To     string  `json:"to" binding:"required"`
-	Amount float64 `json:"amount" binding:"required"`
+	// Wallet is the label or address of the wallet to send from, defaults to
+	// the default wallet.
+	Wallet string `json:"wallet,omitempty" example:"savings"`
+	To     string `json:"to" binding:"required"`
+	Amount string `json:"amount" binding:"required" example:"12.5"`
+	// Fee defaults to the minimum fee.
+	Fee string `json:"fee,omitempty" example:"0.001"`
+}
+
+type BuildTransactionRequest struct {
+	// Wallet is the label or address of the wallet to spend from, defaults
+	// to the default wallet.
+	Wallet string `json:"wallet,omitempty" example:"cold"`
+	To     string `json:"to" binding:"required"`
+	Amount string `json:"amount" binding:"required" example:"12.5"`
+	// Fee defaults to the minimum fee.
+	Fee string `json:"fee,omitempty" example:"0.001"`
+}
+
+type RawTransactionRequest struct {
+	// Tx is the hex encoded signed transaction.
+	Tx string `json:"tx" binding:"required"`
 }
 
 type TopUpRequest struct {
-	Amount float64 `json:"amount" binding:"required"`
+	Amount string `json:"amount" binding:"required" example:"12.5"`
 }
 type TopupRequest struct {
-	Amount float64 `json:"amount" binding:"required"`
+	Amount string `json:"amount" binding:"required" example:"12.5"`
 }
 type AddressRequest struct {
 	Address string `json:"address" binding:"required"`
 }
 
+type CreateWalletRequest struct {
+	Label string `json:"label,omitempty" binding:"max=64" example:"savings"`
+	// Default makes the new wallet the default one.
+	Default bool `json:"default,omitempty"`
+}
+
+type ExternalWalletRequest struct {
+	Address string `json:"address" binding:"required"`
+	Label   string `json:"label,omitempty" binding:"max=64" example:"cold"`
+	// Default makes the wallet the default one.
+	Default bool `json:"default,omitempty"`
+}
+
+type RestoreWalletRequest struct {
+	Mnemonic string `json:"mnemonic" binding:"required"`
+	Label    string `json:"label,omitempty" binding:"max=64" example:"savings"`
+}
+
+type ExportKeyRequest struct {
+	// Wallet is the label or address of the wallet to export, defaults to the
+	// default wallet.
+	Wallet string `json:"wallet,omitempty" example:"savings"`
+	// Address selects a receive or change address of an HD wallet, defaults
+	// to the wallet address.
+	Address    string `json:"address,omitempty"`
+	Format     string `json:"format,omitempty" binding:"omitempty,oneof=pem json" example:"pem"`
+	Passphrase string `json:"passphrase" binding:"required,min=8"`
+}
+
+type ImportKeyRequest struct {
+	// Key is an encrypted PEM (PKCS #8) key or a JSON keyfile.
+	Key        string `json:"key" binding:"required"`
+	Passphrase string `json:"passphrase" binding:"required"`
+}
+
+type TransactionResponse struct {
+	TxID   string `json:"txid"`
+	Status string `json:"status"`
+}
+
 type CoinGeckoResponse struct {
 	Bitcoin struct {
 		USD float64 `json:"usd"`
 	} `json:"bitcoin"`
 }
+
+type HistoryRequest struct {
+	Cursor    string    `form:"cursor"`
+	From      time.Time `form:"from" time_format:"2006-01-02"`
+	To        time.Time `form:"to" time_format:"2006-01-02"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=in out"`
+	Limit     int       `form:"limit" binding:"omitempty,min=1,max=100"`
+	Format    string    `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+type GenerateBlocksRequest struct {
+	Blocks int `json:"blocks" binding:"required,min=1,max=1000" example:"10"`
+}
+
+type GenerateBlocksResponse struct {
+	Hashes []string `json:"hashes"`
+}
+
+type FundRequest struct {
+	Address string `json:"address" binding:"required"`
+	Amount  string `json:"amount" binding:"required" example:"12.5"`
+}
+
+type ResetChainResponse struct {
+	Genesis string `json:"genesis"`
+}
+
+type TreasuryRequest struct {
+	Cursor string    `form:"cursor"`
+	From   time.Time `form:"from" time_format:"2006-01-02"`
+	To     time.Time `form:"to" time_format:"2006-01-02"`
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type TopUpsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending paying approved failed rejected denied"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type RejectTopUpRequest struct {
+	Reason string `json:"reason" binding:"required,max=256"`
+}
@@ -0,0 +1,307 @@
+package v1
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type explorerRoutes struct {
+	c usecase.ChainUseCase
+	l logger.Interface
+}
+
+func newExplorerRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, cfg *blockchain.Config) {
+	r := &explorerRoutes{c, l}
+
+	explorerHandler := handler.Group("/blockchain")
+	{
+		explorerHandler.Use(middleware.JwtVerify(cfg.SecretKey))
+		explorerHandler.GET("/blocks", r.GetBlocks)
+		explorerHandler.GET("/blocks/:hash", r.GetBlockByHash)
+		explorerHandler.GET("/blocks/height/:height", r.GetBlockByHeight)
+		explorerHandler.GET("/transactions/:txid", r.GetTransaction)
+		explorerHandler.GET("/transactions/:txid/proof", r.GetTransactionProof)
+		explorerHandler.GET("/address/:address/transactions", r.GetAddressTransactions)
+	}
+}
+
+// queryInt reads a non-negative integer query parameter, falling back to def
+// when it is absent.
+func queryInt(ctx *gin.Context, key string, def int) (int, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+
+	return n, nil
+}
+
+func pageLimit(ctx *gin.Context) (int, error) {
+	limit, err := queryInt(ctx, "limit", defaultPageLimit)
+	if err != nil {
+		return 0, err
+	}
+	if limit == 0 || limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return limit, nil
+}
+
+func (er *explorerRoutes) lookupError(ctx *gin.Context, op string, err error) {
+	if errors.Is(err, blockchainlogic.ErrNotFound) {
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	er.l.Error(fmt.Errorf("http - v1 - explorer - %s: %w", op, err))
+	errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+}
+
+// GetBlocks godoc
+// @Summary List blocks
+// @Description List blocks in descending height order, starting at the tip or at the given height
+// @Tags Explorer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param from query int false "Height of the first block, defaults to the tip"
+// @Param limit query int false "Number of blocks, at most 100"
+// @Success 200 {array} entity.Block "Blocks"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/blocks [get].
+func (er *explorerRoutes) GetBlocks(ctx *gin.Context) {
+	span := opentracing.StartSpan("get blocks handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	from, err := queryInt(ctx, "from", -1)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, err.Error())
+
+		return
+	}
+	limit, err := pageLimit(ctx)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	blocks, err := er.c.Blocks(spanCtx, from, limit)
+	if err != nil {
+		er.lookupError(ctx, "getBlocks", err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, blocks)
+}
+
+// GetBlockByHash godoc
+// @Summary Get a block by hash
+// @Description Retrieve a block with its decoded transactions
+// @Tags Explorer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param hash path string true "Block hash"
+// @Success 200 {object} entity.Block "Block"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/blocks/{hash} [get].
+func (er *explorerRoutes) GetBlockByHash(ctx *gin.Context) {
+	span := opentracing.StartSpan("get block by hash handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	block, err := er.c.BlockByHash(spanCtx, ctx.Param("hash"))
+	if err != nil {
+		er.lookupError(ctx, "getBlockByHash", err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, block)
+}
+
+// GetBlockByHeight godoc
+// @Summary Get a block by height
+// @Description Retrieve the block at the given height, genesis being 0
+// @Tags Explorer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param height path int true "Block height"
+// @Success 200 {object} entity.Block "Block"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/blocks/height/{height} [get].
+func (er *explorerRoutes) GetBlockByHeight(ctx *gin.Context) {
+	span := opentracing.StartSpan("get block by height handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	height, err := strconv.Atoi(ctx.Param("height"))
+	if err != nil || height < 0 {
+		errorResponse(ctx, http.StatusBadRequest, "height must be a non-negative integer")
+
+		return
+	}
+
+	block, err := er.c.BlockByHeight(spanCtx, height)
+	if err != nil {
+		er.lookupError(ctx, "getBlockByHeight", err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, block)
+}
+
+// GetTransaction godoc
+// @Summary Get a transaction
+// @Description Retrieve a pending or confirmed transaction with decoded inputs and outputs
+// @Tags Explorer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param txid path string true "Transaction ID"
+// @Success 200 {object} entity.Transaction "Transaction"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/transactions/{txid} [get].
+func (er *explorerRoutes) GetTransaction(ctx *gin.Context) {
+	span := opentracing.StartSpan("get transaction handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	txID := ctx.Param("txid")
+	if _, err := hex.DecodeString(txID); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, "txid must be hex encoded")
+
+		return
+	}
+
+	tx, err := er.c.Transaction(spanCtx, txID)
+	if err != nil {
+		er.lookupError(ctx, "getTransaction", err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tx)
+}
+
+// GetTransactionProof godoc
+// @Summary Get the Merkle proof of a transaction
+// @Description Retrieve the Merkle branch of a confirmed transaction and the header of its block, to check inclusion without the whole block
+// @Tags Explorer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param txid path string true "Transaction ID"
+// @Success 200 {object} entity.TransactionProof "Merkle proof"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/transactions/{txid}/proof [get].
+func (er *explorerRoutes) GetTransactionProof(ctx *gin.Context) {
+	span := opentracing.StartSpan("get transaction proof handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	txID := ctx.Param("txid")
+	if _, err := hex.DecodeString(txID); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, "txid must be hex encoded")
+
+		return
+	}
+
+	proof, err := er.c.TransactionProof(spanCtx, txID)
+	if err != nil {
+		er.lookupError(ctx, "getTransactionProof", err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, proof)
+}
+
+// GetAddressTransactions godoc
+// @Summary List transactions of an address
+// @Description List confirmed transactions sending from or paying to the address, newest first
+// @Tags Explorer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param address path string true "Wallet address"
+// @Param limit query int false "Number of transactions, at most 100"
+// @Param offset query int false "Number of transactions to skip"
+// @Success 200 {array} entity.Transaction "Transactions"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/address/{address}/transactions [get].
+func (er *explorerRoutes) GetAddressTransactions(ctx *gin.Context) {
+	span := opentracing.StartSpan("get address transactions handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	address := ctx.Param("address")
+	if !blockchainlogic.ValidateAddress(address) {
+		errorResponse(ctx, http.StatusBadRequest, "address is not valid")
+
+		return
+	}
+	limit, err := pageLimit(ctx)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, err.Error())
+
+		return
+	}
+	offset, err := queryInt(ctx, "offset", 0)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	txs, err := er.c.AddressTransactions(spanCtx, address, limit, offset)
+	if err != nil {
+		er.lookupError(ctx, "getAddressTransactions", err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, txs)
+}
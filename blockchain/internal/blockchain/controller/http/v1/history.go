@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+)
+
+// GetWalletHistory godoc
+// @Summary Get the transaction history of the user wallet
+// @Description List confirmed transactions of the user wallet, newest first. Pages are chained with next_cursor. With format=csv the whole filtered history is returned as a CSV statement
+// @Tags Blockchain
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param Authorization header string true "JWT Token"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Param direction query string false "in or out"
+// @Param limit query int false "Number of entries, at most 100"
+// @Param format query string false "json or csv"
+// @Success 200 {object} entity.WalletHistory "Wallet history"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/wallet/history [get].
+func (bc *chainRoutes) GetWalletHistory(ctx *gin.Context) {
+	span := opentracing.StartSpan("get wallet history handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	var req dto.HistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	filter := entity.HistoryFilter{
+		From:      req.From,
+		Direction: req.Direction,
+		Limit:     req.Limit,
+	}
+	if !req.To.IsZero() {
+		// The last day is inclusive.
+		filter.To = req.To.Add(24 * time.Hour)
+	}
+	if req.Cursor != "" {
+		cursor, err := strconv.ParseInt(req.Cursor, 10, 64)
+		if err != nil || cursor <= 0 {
+			errorResponse(ctx, http.StatusBadRequest, "cursor is not valid")
+
+			return
+		}
+		filter.Cursor = cursor
+	}
+
+	if req.Format == "csv" {
+		bc.writeHistoryCSV(ctx, spanCtx, userID.(string), filter)
+
+		return
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+	history, err := bc.c.WalletHistory(spanCtx, userID.(string), filter)
+	if err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getWalletHistory: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// writeHistoryCSV fetches every page matching filter and writes them as a
+// CSV attachment.
+func (bc *chainRoutes) writeHistoryCSV(ctx *gin.Context, spanCtx context.Context, userID string, filter entity.HistoryFilter) {
+	filter.Limit = maxPageLimit
+
+	var entries []entity.HistoryEntry
+	var address string
+	for {
+		history, err := bc.c.WalletHistory(spanCtx, userID, filter)
+		if err != nil {
+			bc.l.Error(fmt.Errorf("http - v1 - blockchain - getWalletHistory: %w", err))
+			errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+			return
+		}
+		address = history.Address
+		entries = append(entries, history.Entries...)
+		if history.NextCursor == "" {
+			break
+		}
+		filter.Cursor, _ = strconv.ParseInt(history.NextCursor, 10, 64)
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=statement-%s.csv", address))
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Status(http.StatusOK)
+
+	w := csv.NewWriter(ctx.Writer)
+	w.Write([]string{"timestamp", "txid", "direction", "counterparty", "amount", "change", "block_hash", "confirmations"}) //nolint:errcheck // checked by w.Error
+	for _, e := range entries {
+		w.Write([]string{ //nolint:errcheck // checked by w.Error
+			e.Timestamp.UTC().Format(time.RFC3339),
+			e.TxID,
+			e.Direction,
+			e.Counterparty,
+			e.Amount,
+			e.Change,
+			e.BlockHash,
+			strconv.Itoa(e.Confirmations),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		bc.l.Error(fmt.Errorf("http - v1 - blockchain - getWalletHistory: %w", err))
+	}
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+type regtestRoutes struct {
+	c usecase.ChainUseCase
+	l logger.Interface
+}
+
+// newRegtestRoutes registers the admin endpoints driving a regtest chain.
+// Other networks do not serve them.
+func newRegtestRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, cfg *blockchain.Config) {
+	if cfg.Network != "regtest" {
+		return
+	}
+	r := &regtestRoutes{c, l}
+
+	regtestHandler := handler.Group("/blockchain/regtest")
+	{
+		regtestHandler.Use(middleware.AdminVerify(cfg.SecretKey))
+		regtestHandler.POST("/generate", r.GenerateBlocks)
+		regtestHandler.POST("/fund", r.Fund)
+		regtestHandler.POST("/reset", r.ResetChain)
+	}
+}
+
+// GenerateBlocks godoc
+// @Summary Mine blocks on a regtest chain
+// @Description Mine the given number of blocks at once, the first one confirming the pending transactions. Admins only, regtest network only
+// @Tags Regtest
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param generateBlocksRequest body dto.GenerateBlocksRequest true "Generate Blocks Request"
+// @Success 200 {object} dto.GenerateBlocksResponse "Hashes of the mined blocks"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/regtest/generate [post].
+func (rr *regtestRoutes) GenerateBlocks(ctx *gin.Context) {
+	span := opentracing.StartSpan("generate blocks handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	var req dto.GenerateBlocksRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	hashes, err := rr.c.GenerateBlocks(spanCtx, req.Blocks)
+	if err != nil {
+		rr.l.Error(fmt.Errorf("http - v1 - regtest - generateBlocks: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.GenerateBlocksResponse{Hashes: hashes})
+}
+
+// Fund godoc
+// @Summary Fund an address on a regtest chain
+// @Description Send coins from the regtest faucet to an address and mine a block confirming them. Admins only, regtest network only
+// @Tags Regtest
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param fundRequest body dto.FundRequest true "Fund Request"
+// @Success 200 {object} dto.TransactionResponse "Confirmed transaction"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/regtest/fund [post].
+func (rr *regtestRoutes) Fund(ctx *gin.Context) {
+	span := opentracing.StartSpan("fund handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	var req dto.FundRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	txID, err := rr.c.Fund(spanCtx, req.Address, amount)
+	switch {
+	case errors.Is(err, blockchainlogic.ErrInvalidTransaction), errors.Is(err, blockchainlogic.ErrInvalidAmount):
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	case err != nil:
+		rr.l.Error(fmt.Errorf("http - v1 - regtest - fund: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.TransactionResponse{TxID: txID, Status: string(blockchainlogic.TxStatusConfirmed)})
+}
+
+// ResetChain godoc
+// @Summary Reset a regtest chain
+// @Description Drop every block and pending transaction and start over from the genesis block. Admins only, regtest network only
+// @Tags Regtest
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Success 200 {object} dto.ResetChainResponse "Genesis block hash"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/regtest/reset [post].
+func (rr *regtestRoutes) ResetChain(ctx *gin.Context) {
+	span := opentracing.StartSpan("reset chain handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	genesis, err := rr.c.ResetChain(spanCtx)
+	if err != nil {
+		rr.l.Error(fmt.Errorf("http - v1 - regtest - resetChain: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.ResetChainResponse{Genesis: genesis})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
 	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
 	"github.com/damndelion/blockchain_justCode/pkg/logger"
+	"github.com/damndelion/blockchain_justCode/pkg/stream"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -20,7 +21,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewBlockchainRouter(handler *gin.Engine, l logger.Interface, c usecase.ChainUseCase, bc blockchainlogic.Blockchain, cfg *blockchain.Config, cache cache.Blockchain) {
+func NewBlockchainRouter(handler *gin.Engine, l logger.Interface, c usecase.ChainUseCase, bc *blockchainlogic.Blockchain, hub *stream.Hub, cfg *blockchain.Config, cache cache.Blockchain) {
 	// Options
 	handler.Use(gin.Logger())
 	handler.Use(gin.Recovery())
@@ -42,5 +43,11 @@ func NewBlockchainRouter(handler *gin.Engine, l logger.Interface, c usecase.Chai
 	h := handler.Group("/v1")
 	{
 		newBlockchainRoutes(h, c, l, bc, cfg, cache)
+		newExplorerRoutes(h, c, l, cfg)
+		newTransactionRoutes(h, c, l, cfg)
+		newTreasuryRoutes(h, c, l, cfg)
+		newTopUpRoutes(h, c, l, cfg)
+		newRegtestRoutes(h, c, l, cfg)
+		newStreamRoutes(h, c, l, hub, cfg)
 	}
 }
@@ -0,0 +1,221 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+	"github.com/damndelion/blockchain_justCode/pkg/stream"
+)
+
+const (
+	// streamWriteWait is the time allowed to write an event to a client.
+	streamWriteWait = 10 * time.Second
+	// streamPingPeriod is the interval of the keep-alives sent to clients.
+	streamPingPeriod = 30 * time.Second
+	// streamPongWait is the time allowed for a WebSocket client to answer a
+	// ping.
+	streamPongWait = 2 * streamPingPeriod
+)
+
+// The stream is authenticated by the Authorization header rather than by
+// cookies, so cross-origin WebSocket clients are let through.
+var streamUpgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+type streamRoutes struct {
+	c   usecase.ChainUseCase
+	l   logger.Interface
+	hub *stream.Hub
+}
+
+func newStreamRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, hub *stream.Hub, cfg *blockchain.Config) {
+	r := &streamRoutes{c, l, hub}
+
+	streamHandler := handler.Group("/blockchain/stream")
+	{
+		streamHandler.Use(middleware.JwtVerify(cfg.SecretKey))
+		streamHandler.GET("", r.Stream)
+	}
+}
+
+// Stream godoc
+// @Summary Stream blocks and wallet activity
+// @Description Push the headers of new blocks and of blocks a reorganisation disconnected from the main chain, the pending and mined transactions of the addresses of the user wallets and their confirmation counts, up to the configured depth. Requests with an Upgrade header get a WebSocket sending events as JSON, others get Server-Sent Events. A client resumes after the last event it got with the Last-Event-ID header or the last_event_id parameter; a resync event tells it the events in between are lost. Clients falling behind are disconnected and have to resume
+// @Tags Blockchain
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query string false "ID of the last event received"
+// @Success 200 {object} stream.Event "Events"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/stream [get].
+func (sr *streamRoutes) Stream(ctx *gin.Context) {
+	span := opentracing.StartSpan("stream handler")
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+	addresses, err := sr.c.UserAddresses(spanCtx, userID.(string))
+	span.Finish()
+	if err != nil {
+		sr.l.Error(fmt.Errorf("http - v1 - stream - stream: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+	sub, replay := sr.hub.Subscribe(addresses, lastEventID)
+	defer sub.Close()
+
+	if websocket.IsWebSocketUpgrade(ctx.Request) {
+		sr.serveWebSocket(ctx, sub, replay)
+
+		return
+	}
+	sr.serveSSE(ctx, sub, replay)
+}
+
+// eventWriter sends events to a client.
+type eventWriter interface {
+	event(e stream.Event) error
+	ping() error
+}
+
+// pump sends the replayed events and then those of sub to w until the
+// client is gone or sub is dropped.
+func pump(w eventWriter, sub *stream.Subscription, replay []stream.Event, gone <-chan struct{}) {
+	for _, e := range replay {
+		if w.event(e) != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(streamPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-sub.Events():
+			if w.event(e) != nil {
+				return
+			}
+		case <-ticker.C:
+			if w.ping() != nil {
+				return
+			}
+		case <-sub.Done():
+			return
+		case <-gone:
+			return
+		}
+	}
+}
+
+type sseWriter struct {
+	w  gin.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (s *sseWriter) write(format string, args ...any) error {
+	// The deadline also lifts the write timeout of the server.
+	err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, format, args...)
+	if err != nil {
+		return err
+	}
+	s.w.Flush()
+
+	return nil
+}
+
+func (s *sseWriter) event(e stream.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	return s.write("id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+func (s *sseWriter) ping() error {
+	return s.write(": ping\n\n")
+}
+
+func (sr *streamRoutes) serveSSE(ctx *gin.Context, sub *stream.Subscription, replay []stream.Event) {
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Writer.WriteHeader(http.StatusOK)
+
+	w := &sseWriter{w: ctx.Writer, rc: http.NewResponseController(ctx.Writer)}
+	if w.ping() != nil {
+		return
+	}
+	pump(w, sub, replay, ctx.Request.Context().Done())
+}
+
+type wsWriter struct {
+	conn *websocket.Conn
+}
+
+func (w *wsWriter) event(e stream.Event) error {
+	err := w.conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if err != nil {
+		return err
+	}
+
+	return w.conn.WriteJSON(e)
+}
+
+func (w *wsWriter) ping() error {
+	return w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait))
+}
+
+func (sr *streamRoutes) serveWebSocket(ctx *gin.Context, sub *stream.Subscription, replay []stream.Event) {
+	// Upgrade answers the failed handshakes itself.
+	conn, err := streamUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Clients only answer pings, anything else they send is dropped. The
+	// read deadline replaces the read timeout of the server.
+	gone := make(chan struct{})
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	pump(&wsWriter{conn}, sub, replay, gone)
+	if err = sub.Err(); err != nil {
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error())
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(streamWriteWait))
+	}
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+type topUpRoutes struct {
+	c usecase.ChainUseCase
+	l logger.Interface
+}
+
+func newTopUpRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, cfg *blockchain.Config) {
+	r := &topUpRoutes{c, l}
+
+	topUpHandler := handler.Group("/blockchain/topups")
+	{
+		topUpHandler.Use(middleware.AdminVerify(cfg.SecretKey))
+		topUpHandler.GET("", r.GetTopUps)
+		topUpHandler.POST("/:id/approve", r.ApproveTopUp)
+		topUpHandler.POST("/:id/reject", r.RejectTopUp)
+	}
+}
+
+// topUpError answers a failed decision on a top up.
+func (tr *topUpRoutes) topUpError(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrTopUpNotFound):
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+	case errors.Is(err, entity.ErrTopUpNotPending):
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+	default:
+		tr.l.Error(fmt.Errorf("http - v1 - topups - %s: %w", op, err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+	}
+}
+
+// topUpID parses the top up ID of the path.
+func topUpID(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		errorResponse(ctx, http.StatusBadRequest, "top up id is not valid")
+
+		return 0, false
+	}
+
+	return id, true
+}
+
+// GetTopUps godoc
+// @Summary List top ups
+// @Description List the top ups with a status, oldest first. Pending top ups wait for an admin decision. Admins only
+// @Tags TopUps
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param status query string false "pending, paying, approved, failed, rejected or denied, pending by default"
+// @Param limit query int false "Number of top ups, at most 100"
+// @Param offset query int false "Number of top ups to skip"
+// @Success 200 {array} entity.TopUp "Top ups"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/topups [get].
+func (tr *topUpRoutes) GetTopUps(ctx *gin.Context) {
+	span := opentracing.StartSpan("get top ups handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	var req dto.TopUpsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if req.Status == "" {
+		req.Status = entity.TopUpPending
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultPageLimit
+	}
+
+	topUps, err := tr.c.TopUps(spanCtx, req.Status, req.Limit, req.Offset)
+	if err != nil {
+		tr.topUpError(ctx, "getTopUps", err)
+
+		return
+	}
+	ctx.JSON(http.StatusOK, topUps)
+}
+
+// ApproveTopUp godoc
+// @Summary Approve a pending top up
+// @Description Pay a top up held for approval from the treasury. The decision is recorded under the admin. Admins only
+// @Tags TopUps
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param id path int true "Top up ID"
+// @Success 200 {object} entity.TopUp "Approved top up"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Top up not found"
+// @Failure 409 {string} string "Top up is not pending"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/topups/{id}/approve [post].
+func (tr *topUpRoutes) ApproveTopUp(ctx *gin.Context) {
+	span := opentracing.StartSpan("approve top up handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	adminID, _ := ctx.Get("user_id")
+
+	id, ok := topUpID(ctx)
+	if !ok {
+		return
+	}
+
+	topUp, err := tr.c.ApproveTopUp(spanCtx, id, adminID.(string))
+	if err != nil {
+		tr.topUpError(ctx, "approveTopUp", err)
+
+		return
+	}
+	ctx.JSON(http.StatusOK, topUp)
+}
+
+// RejectTopUp godoc
+// @Summary Reject a pending top up
+// @Description Refuse a top up held for approval. The decision and its reason are recorded under the admin. Admins only
+// @Tags TopUps
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param id path int true "Top up ID"
+// @Param rejectTopUpRequest body dto.RejectTopUpRequest true "Reject Top Up Request"
+// @Success 200 {object} entity.TopUp "Rejected top up"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Top up not found"
+// @Failure 409 {string} string "Top up is not pending"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/topups/{id}/reject [post].
+func (tr *topUpRoutes) RejectTopUp(ctx *gin.Context) {
+	span := opentracing.StartSpan("reject top up handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	adminID, _ := ctx.Get("user_id")
+
+	id, ok := topUpID(ctx)
+	if !ok {
+		return
+	}
+	var req dto.RejectTopUpRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	topUp, err := tr.c.RejectTopUp(spanCtx, id, adminID.(string), req.Reason)
+	if err != nil {
+		tr.topUpError(ctx, "rejectTopUp", err)
+
+		return
+	}
+	ctx.JSON(http.StatusOK, topUp)
+}
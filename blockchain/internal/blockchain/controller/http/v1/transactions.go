@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/metrics"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+type transactionRoutes struct {
+	c usecase.ChainUseCase
+	l logger.Interface
+}
+
+func newTransactionRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, cfg *blockchain.Config) {
+	r := &transactionRoutes{c, l}
+
+	transactionHandler := handler.Group("/blockchain/transactions")
+	{
+		transactionHandler.Use(middleware.JwtVerify(cfg.SecretKey))
+		transactionHandler.POST("/build", r.BuildTransaction)
+		transactionHandler.POST("/raw", r.SubmitRawTransaction)
+	}
+}
+
+// transactionError answers a failed build or submission, telling apart the
+// errors caused by the request.
+func (tr *transactionRoutes) transactionError(ctx *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrWalletNotFound):
+		errorResponse(ctx, http.StatusNotFound, fmt.Sprintf("%v ", err))
+	case errors.Is(err, blockchainlogic.ErrDoubleSpend), errors.Is(err, blockchainlogic.ErrTxExists):
+		errorResponse(ctx, http.StatusConflict, fmt.Sprintf("%v ", err))
+	case errors.Is(err, blockchainlogic.ErrInvalidTransaction), errors.Is(err, blockchainlogic.ErrFeeTooLow),
+		errors.Is(err, blockchainlogic.ErrInvalidAmount), errors.Is(err, blockchainlogic.ErrInsufficientFunds):
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+	default:
+		tr.l.Error(fmt.Errorf("http - v1 - transactions - %s: %w", op, err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+	}
+}
+
+// BuildTransaction godoc
+// @Summary Build an unsigned transaction
+// @Description Build a transaction spending from a wallet of the user without signing it. Every input has to be signed over its sighash with the key of its address, which also goes into the input, before the transaction is submitted to /v1/blockchain/transactions/raw
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param buildTransactionRequest body dto.BuildTransactionRequest true "Build Transaction Request"
+// @Success 200 {object} entity.TxTemplate "Unsigned transaction"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Wallet not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/transactions/build [post].
+func (tr *transactionRoutes) BuildTransaction(ctx *gin.Context) {
+	span := opentracing.StartSpan("build transaction handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+	userID, _ := ctx.Get("user_id")
+
+	var req dto.BuildTransactionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	var fee blockchainlogic.Amount
+	if req.Fee != "" {
+		fee, err = parseAmount(req.Fee)
+		if err != nil {
+			errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("fee: %v ", err))
+
+			return
+		}
+	}
+
+	template, err := tr.c.BuildTransaction(spanCtx, userID.(string), req.Wallet, req.To, amount, fee)
+	if err != nil {
+		tr.transactionError(ctx, "buildTransaction", err)
+
+		return
+	}
+	ctx.JSON(http.StatusOK, template)
+}
+
+// SubmitRawTransaction godoc
+// @Summary Submit a signed transaction
+// @Description Verify a transaction signed outside the node and queue it for mining
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param rawTransactionRequest body dto.RawTransactionRequest true "Raw Transaction Request"
+// @Success 200 {object} dto.TransactionResponse "Pending transaction"
+// @Failure 400 {string} string "Invalid transaction"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Transaction conflicts with a pending one"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/transactions/raw [post].
+func (tr *transactionRoutes) SubmitRawTransaction(ctx *gin.Context) {
+	span := opentracing.StartSpan("submit raw transaction handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	var req dto.RawTransactionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	if len(req.Tx) > 2*blockchainlogic.MaxRawTxSize {
+		errorResponse(ctx, http.StatusBadRequest, "transaction is too large")
+
+		return
+	}
+	raw, err := hex.DecodeString(req.Tx)
+	if err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("tx: %v ", err))
+
+		return
+	}
+
+	txID, err := tr.c.SubmitRawTransaction(spanCtx, raw)
+	if err != nil {
+		tr.transactionError(ctx, "submitRawTransaction", err)
+
+		return
+	}
+	metrics.TransactionRequestsTotalCollector.WithLabelValues(fmt.Sprintf("%v", ctx.Request.URL), strconv.Itoa(0), ctx.Request.Method).Inc()
+	ctx.JSON(http.StatusOK, dto.TransactionResponse{TxID: txID, Status: string(blockchainlogic.TxStatusPending)})
+}
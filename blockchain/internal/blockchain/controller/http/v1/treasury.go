@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/middleware"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/usecase"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+type treasuryRoutes struct {
+	c usecase.ChainUseCase
+	l logger.Interface
+}
+
+func newTreasuryRoutes(handler *gin.RouterGroup, c usecase.ChainUseCase, l logger.Interface, cfg *blockchain.Config) {
+	r := &treasuryRoutes{c, l}
+
+	treasuryHandler := handler.Group("/blockchain/treasury")
+	{
+		treasuryHandler.Use(middleware.AdminVerify(cfg.SecretKey))
+		treasuryHandler.GET("", r.GetTreasury)
+	}
+}
+
+// GetTreasury godoc
+// @Summary Get the treasury account
+// @Description Get the balance of the treasury, the genesis recipient funding top ups, and its outgoing transactions, newest first. Pages are chained with next_cursor. Admins only
+// @Tags Treasury
+// @Produce json
+// @Param Authorization header string true "JWT Token"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Param limit query int false "Number of outflows, at most 100"
+// @Success 200 {object} entity.Treasury "Treasury"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /v1/blockchain/treasury [get].
+func (tr *treasuryRoutes) GetTreasury(ctx *gin.Context) {
+	span := opentracing.StartSpan("get treasury handler")
+	defer span.Finish()
+	spanCtx := opentracing.ContextWithSpan(ctx.Request.Context(), span)
+
+	var req dto.TreasuryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%v ", err))
+
+		return
+	}
+
+	filter := entity.HistoryFilter{From: req.From, Limit: req.Limit}
+	if !req.To.IsZero() {
+		// The last day is inclusive.
+		filter.To = req.To.Add(24 * time.Hour)
+	}
+	if req.Cursor != "" {
+		cursor, err := strconv.ParseInt(req.Cursor, 10, 64)
+		if err != nil || cursor <= 0 {
+			errorResponse(ctx, http.StatusBadRequest, "cursor is not valid")
+
+			return
+		}
+		filter.Cursor = cursor
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+
+	treasury, err := tr.c.Treasury(spanCtx, filter)
+	if err != nil {
+		tr.l.Error(fmt.Errorf("http - v1 - treasury - getTreasury: %w", err))
+		errorResponse(ctx, http.StatusInternalServerError, fmt.Sprintf("%v ", err))
+
+		return
+	}
+	ctx.JSON(http.StatusOK, treasury)
+}
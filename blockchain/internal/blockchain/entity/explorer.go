@@ -0,0 +1,74 @@
+package entity
+
+import "time"
+
+type Block struct {
+	Hash          string        `json:"hash"`
+	Height        int           `json:"height"`
+	PrevHash      string        `json:"previous_hash"`
+	MerkleRoot    string        `json:"merkle_root"`
+	Timestamp     time.Time     `json:"timestamp"`
+	Nonce         int           `json:"nonce"`
+	Difficulty    int           `json:"difficulty"`
+	Confirmations int           `json:"confirmations"`
+	Transactions  []Transaction `json:"transactions"`
+}
+
+type BlockHeader struct {
+	Hash       string    `json:"hash"`
+	PrevHash   string    `json:"previous_hash"`
+	MerkleRoot string    `json:"merkle_root"`
+	Timestamp  time.Time `json:"timestamp"`
+	Nonce      int       `json:"nonce"`
+	Difficulty int       `json:"difficulty"`
+}
+
+// TransactionProof is the Merkle branch of a confirmed transaction. Hashing
+// the transaction ID up the branch yields the Merkle root of the header; the
+// side of every sibling follows from Index and Count.
+type TransactionProof struct {
+	TxID          string      `json:"txid"`
+	Height        int         `json:"height"`
+	Confirmations int         `json:"confirmations"`
+	Index         int         `json:"index"`
+	Count         int         `json:"count"`
+	Branch        []string    `json:"branch"`
+	Header        BlockHeader `json:"header"`
+}
+
+type Transaction struct {
+	TxID          string     `json:"txid"`
+	Status        string     `json:"status"`
+	BlockHash     string     `json:"block_hash,omitempty"`
+	BlockHeight   *int       `json:"block_height,omitempty"`
+	Timestamp     *time.Time `json:"timestamp,omitempty"`
+	Confirmations int        `json:"confirmations"`
+	Coinbase      bool       `json:"coinbase"`
+	Fee           string     `json:"fee"`
+	LockTime      int64      `json:"lock_time,omitempty"`
+	Inputs        []Input    `json:"inputs"`
+	Outputs       []Output   `json:"outputs"`
+}
+
+type Input struct {
+	TxID      string `json:"txid,omitempty"`
+	Vout      int    `json:"vout"`
+	Address   string `json:"address,omitempty"`
+	Value     string `json:"value"`
+	PubKey    string `json:"pub_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// UnlockScript is the hex encoded unlocking script of inputs spending
+	// script outputs.
+	UnlockScript string `json:"unlock_script,omitempty"`
+	Sequence     int64  `json:"sequence,omitempty"`
+}
+
+type Output struct {
+	Index      int    `json:"index"`
+	Value      string `json:"value"`
+	Address    string `json:"address"`
+	PubKeyHash string `json:"pub_key_hash"`
+	// Script is the hex encoded locking script of outputs not paying a
+	// single address.
+	Script string `json:"script,omitempty"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+type HistoryFilter struct {
+	From      time.Time
+	To        time.Time
+	Direction string
+	Cursor    int64
+	Limit     int
+}
+
+type HistoryEntry struct {
+	TxID          string    `json:"txid"`
+	Direction     string    `json:"direction"`
+	Counterparty  string    `json:"counterparty"`
+	Amount        string    `json:"amount"`
+	Change        string    `json:"change"`
+	BlockHash     string    `json:"block_hash"`
+	BlockHeight   int       `json:"block_height"`
+	Timestamp     time.Time `json:"timestamp"`
+	Confirmations int       `json:"confirmations"`
+}
+
+type WalletHistory struct {
+	Address    string         `json:"address"`
+	Entries    []HistoryEntry `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,20 @@
+package entity
+
+// TxTemplate is an unsigned transaction. Tx is the hex encoded transaction in
+// the canonical binary format; every input must be signed over its sighash,
+// get the public key of its address, and the result be submitted as a raw
+// transaction.
+type TxTemplate struct {
+	Tx      string          `json:"tx"`
+	Fee     string          `json:"fee"`
+	Inputs  []TemplateInput `json:"inputs"`
+	Outputs []Output        `json:"outputs"`
+}
+
+type TemplateInput struct {
+	TxID    string `json:"txid"`
+	Vout    int    `json:"vout"`
+	Address string `json:"address"`
+	Value   string `json:"value"`
+	SigHash string `json:"sighash"`
+}
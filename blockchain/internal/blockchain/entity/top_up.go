@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTopUpNotFound = errors.New("top up not found")
+	// ErrTopUpNotPending is returned when deciding on a top up that is no
+	// longer waiting for approval.
+	ErrTopUpNotPending = errors.New("top up is not pending")
+	// ErrKYCRequired is returned to users who have not passed KYC.
+	ErrKYCRequired = errors.New("top ups require a verified user")
+	// ErrTopUpLimit is returned for top ups over a limit of the user.
+	ErrTopUpLimit = errors.New("top up limit reached")
+)
+
+// Top up statuses. Approved top ups are paid, either at once by the policy
+// or by an admin after being held as pending. A top up is claimed as paying
+// before the treasury pays it, so it is paid once, and ends up approved with
+// the transaction ID or failed when the payment fails.
+const (
+	TopUpPending  = "pending"
+	TopUpPaying   = "paying"
+	TopUpApproved = "approved"
+	TopUpFailed   = "failed"
+	TopUpRejected = "rejected"
+	TopUpDenied   = "denied"
+)
+
+// Top up decisions. Held top ups wait for an admin.
+const (
+	DecisionApproved = "approved"
+	DecisionHeld     = "held"
+	DecisionRejected = "rejected"
+	DecisionDenied   = "denied"
+)
+
+// DecisionActorPolicy is the actor of the decisions taken by the top up
+// policy; admins decide under their user ID.
+const DecisionActorPolicy = "policy"
+
+type TopUp struct {
+	ID        int64      `json:"id"`
+	UserID    string     `json:"user_id"`
+	Amount    string     `json:"amount"`
+	Status    string     `json:"status"`
+	TxID      string     `json:"txid,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	DecidedBy string     `json:"decided_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+// TopUpDecision records a decision on a top up.
+type TopUpDecision struct {
+	Decision string
+	Actor    string
+	Reason   string
+}
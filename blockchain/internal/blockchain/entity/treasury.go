@@ -0,0 +1,11 @@
+package entity
+
+// Treasury is the account paying top ups, the genesis recipient.
+type Treasury struct {
+	Address string `json:"address"`
+	// Supply is the amount the genesis block paid the treasury.
+	Supply     string         `json:"supply"`
+	Balance    string         `json:"balance"`
+	Outflows   []HistoryEntry `json:"outflows"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
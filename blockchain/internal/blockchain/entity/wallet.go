@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWalletNotFound is returned when a wallet selector matches none of
+	// the wallets of a user.
+	ErrWalletNotFound = errors.New("wallet not found")
+	// ErrNotCustodial is returned when the node is asked to sign for a wallet
+	// whose keys it does not hold.
+	ErrNotCustodial = errors.New("wallet is not custodial, build the transaction and sign it instead")
+	// ErrCustodialAddress is returned when an address whose key is held by
+	// the node is registered as a non-custodial wallet.
+	ErrCustodialAddress = errors.New("address belongs to a custodial wallet")
+	// ErrKeyConflict is returned when an imported key is already held for a
+	// wallet of another user, or is registered as a non-custodial wallet.
+	ErrKeyConflict = errors.New("key is already held for another wallet")
+	// ErrWalletLabelTaken is returned when the user already has a wallet
+	// with the label.
+	ErrWalletLabelTaken = errors.New("wallet label is already used")
+	// ErrWalletAddressTaken is returned when the address is already a wallet
+	// of a user.
+	ErrWalletAddressTaken = errors.New("wallet address is already used")
+)
+
+// NewWallet is returned once when a wallet is created. The mnemonic is not
+// stored and can not be shown again.
+type NewWallet struct {
+	Address  string `json:"address"`
+	Label    string `json:"label"`
+	Default  bool   `json:"default"`
+	Mnemonic string `json:"mnemonic"`
+}
+
+// UserWallet is one of the wallets of a user. The keys of custodial wallets
+// are held by the node, the others sign their transactions themselves.
+type UserWallet struct {
+	Address   string    `json:"address"`
+	Label     string    `json:"label"`
+	Default   bool      `json:"default"`
+	Custodial bool      `json:"custodial"`
+	CreatedAt time.Time `json:"created_at"`
+	Balance   string    `json:"balance"`
+}
+
+// TotalBalance is the balance of all wallets of a user.
+type TotalBalance struct {
+	Total   string        `json:"total"`
+	Wallets []*UserWallet `json:"wallets"`
+}
+
+// ExportedKey is a private key encrypted with the passphrase of its owner.
+type ExportedKey struct {
+	Address string `json:"address"`
+	Format  string `json:"format"`
+	Key     string `json:"key"`
+}
@@ -41,3 +41,23 @@ func newTransactionCounterVec(name, help string, labelValues ...string) *prometh
 		labelValues,
 	)
 }
+
+func newGauge(name, help string) prometheus.Gauge {
+	return prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "blockchain_service",
+			Name:      name,
+			Help:      help,
+		},
+	)
+}
+
+func newCounter(name, help string) prometheus.Counter {
+	return prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "blockchain_service",
+			Name:      name,
+			Help:      help,
+		},
+	)
+}
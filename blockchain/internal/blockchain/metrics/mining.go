@@ -0,0 +1,29 @@
+package metrics
+
+var (
+	MinerHashrate = newGauge(
+		"miner_hashrate",
+		"Hashes per second of the last proof of work",
+	)
+
+	BlocksMinedTotal = newCounter(
+		"blocks_mined_total",
+		"Counter of blocks mined by this node",
+	)
+)
+
+//nolint:gochecknoinits  // correct function
+func init() {
+	mustRegister(MinerHashrate, BlocksMinedTotal)
+}
+
+// Mining reports the statistics of the chain miner to Prometheus.
+type Mining struct{}
+
+func (Mining) ObserveHashrate(hashesPerSecond float64) {
+	MinerHashrate.Set(hashesPerSecond)
+}
+
+func (Mining) BlockMined() {
+	BlocksMinedTotal.Inc()
+}
@@ -4,33 +4,574 @@ package mocks
 
 import (
 	context "context"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+
+	entity "github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+
+	mock "github.com/stretchr/testify/mock"
+
 	sync "sync"
 
-	mock "github.com/stretchr/testify/mock"
-)
+	time "time"
+)
+
+// ChainRepo is an autogenerated mock type for the ChainRepo type
+type ChainRepo struct {
+	mock.Mock
+}
+
+// AddExternalWallet provides a mock function with given fields: ctx, userID, address, label, isDefault
+func (_m *ChainRepo) AddExternalWallet(ctx context.Context, userID string, address string, label string, isDefault bool) (*entity.UserWallet, error) {
+	ret := _m.Called(ctx, userID, address, label, isDefault)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddExternalWallet")
+	}
+
+	var r0 *entity.UserWallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, bool) (*entity.UserWallet, error)); ok {
+		return rf(ctx, userID, address, label, isDefault)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, bool) *entity.UserWallet); ok {
+		r0 = rf(ctx, userID, address, label, isDefault)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.UserWallet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, bool) error); ok {
+		r1 = rf(ctx, userID, address, label, isDefault)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BuildTransaction provides a mock function with given fields: ctx, userID, wallet, to, amount, fee
+func (_m *ChainRepo) BuildTransaction(ctx context.Context, userID string, wallet string, to string, amount blockchainlogic.Amount, fee blockchainlogic.Amount) (*entity.TxTemplate, error) {
+	ret := _m.Called(ctx, userID, wallet, to, amount, fee)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BuildTransaction")
+	}
+
+	var r0 *entity.TxTemplate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, blockchainlogic.Amount, blockchainlogic.Amount) (*entity.TxTemplate, error)); ok {
+		return rf(ctx, userID, wallet, to, amount, fee)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, blockchainlogic.Amount, blockchainlogic.Amount) *entity.TxTemplate); ok {
+		r0 = rf(ctx, userID, wallet, to, amount, fee)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TxTemplate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, blockchainlogic.Amount, blockchainlogic.Amount) error); ok {
+		r1 = rf(ctx, userID, wallet, to, amount, fee)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateTopUp provides a mock function with given fields: ctx, userID, amount, status, txID, decision
+func (_m *ChainRepo) CreateTopUp(ctx context.Context, userID string, amount blockchainlogic.Amount, status string, txID string, decision entity.TopUpDecision) (*entity.TopUp, error) {
+	ret := _m.Called(ctx, userID, amount, status, txID, decision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTopUp")
+	}
+
+	var r0 *entity.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, blockchainlogic.Amount, string, string, entity.TopUpDecision) (*entity.TopUp, error)); ok {
+		return rf(ctx, userID, amount, status, txID, decision)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, blockchainlogic.Amount, string, string, entity.TopUpDecision) *entity.TopUp); ok {
+		r0 = rf(ctx, userID, amount, status, txID, decision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, blockchainlogic.Amount, string, string, entity.TopUpDecision) error); ok {
+		r1 = rf(ctx, userID, amount, status, txID, decision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateTopUpWithinLimits provides a mock function with given fields: ctx, userID, amount, since, decide
+func (_m *ChainRepo) CreateTopUpWithinLimits(ctx context.Context, userID string, amount blockchainlogic.Amount, since time.Time, decide func(blockchainlogic.Amount, blockchainlogic.Amount) (string, entity.TopUpDecision)) (*entity.TopUp, error) {
+	ret := _m.Called(ctx, userID, amount, since, decide)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTopUpWithinLimits")
+	}
+
+	var r0 *entity.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, blockchainlogic.Amount, time.Time, func(blockchainlogic.Amount, blockchainlogic.Amount) (string, entity.TopUpDecision)) (*entity.TopUp, error)); ok {
+		return rf(ctx, userID, amount, since, decide)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, blockchainlogic.Amount, time.Time, func(blockchainlogic.Amount, blockchainlogic.Amount) (string, entity.TopUpDecision)) *entity.TopUp); ok {
+		r0 = rf(ctx, userID, amount, since, decide)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, blockchainlogic.Amount, time.Time, func(blockchainlogic.Amount, blockchainlogic.Amount) (string, entity.TopUpDecision)) error); ok {
+		r1 = rf(ctx, userID, amount, since, decide)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWallet provides a mock function with given fields: ctx, userID, label, isDefault
+func (_m *ChainRepo) CreateWallet(ctx context.Context, userID string, label string, isDefault bool) (*entity.NewWallet, error) {
+	ret := _m.Called(ctx, userID, label, isDefault)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWallet")
+	}
+
+	var r0 *entity.NewWallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) (*entity.NewWallet, error)); ok {
+		return rf(ctx, userID, label, isDefault)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) *entity.NewWallet); ok {
+		r0 = rf(ctx, userID, label, isDefault)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.NewWallet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool) error); ok {
+		r1 = rf(ctx, userID, label, isDefault)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecideTopUp provides a mock function with given fields: ctx, id, status, txID, decision
+func (_m *ChainRepo) DecideTopUp(ctx context.Context, id int64, status string, txID string, decision entity.TopUpDecision) (*entity.TopUp, error) {
+	ret := _m.Called(ctx, id, status, txID, decision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DecideTopUp")
+	}
+
+	var r0 *entity.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, entity.TopUpDecision) (*entity.TopUp, error)); ok {
+		return rf(ctx, id, status, txID, decision)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, entity.TopUpDecision) *entity.TopUp); ok {
+		r0 = rf(ctx, id, status, txID, decision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, entity.TopUpDecision) error); ok {
+		r1 = rf(ctx, id, status, txID, decision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportKey provides a mock function with given fields: ctx, userID, wallet, address, format, passphrase
+func (_m *ChainRepo) ExportKey(ctx context.Context, userID string, wallet string, address string, format string, passphrase string) (*entity.ExportedKey, error) {
+	ret := _m.Called(ctx, userID, wallet, address, format, passphrase)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportKey")
+	}
+
+	var r0 *entity.ExportedKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) (*entity.ExportedKey, error)); ok {
+		return rf(ctx, userID, wallet, address, format, passphrase)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) *entity.ExportedKey); ok {
+		r0 = rf(ctx, userID, wallet, address, format, passphrase)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ExportedKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, userID, wallet, address, format, passphrase)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Fund provides a mock function with given fields: ctx, address, amount
+func (_m *ChainRepo) Fund(ctx context.Context, address string, amount blockchainlogic.Amount) (string, error) {
+	ret := _m.Called(ctx, address, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Fund")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, blockchainlogic.Amount) (string, error)); ok {
+		return rf(ctx, address, amount)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, blockchainlogic.Amount) string); ok {
+		r0 = rf(ctx, address, amount)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, blockchainlogic.Amount) error); ok {
+		r1 = rf(ctx, address, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateBlocks provides a mock function with given fields: ctx, n
+func (_m *ChainRepo) GenerateBlocks(ctx context.Context, n int) ([]string, error) {
+	ret := _m.Called(ctx, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateBlocks")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]string, error)); ok {
+		return rf(ctx, n)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []string); ok {
+		r0 = rf(ctx, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAddressTransactions provides a mock function with given fields: ctx, address, limit, offset
+func (_m *ChainRepo) GetAddressTransactions(ctx context.Context, address string, limit int, offset int) ([]*entity.Transaction, error) {
+	ret := _m.Called(ctx, address, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddressTransactions")
+	}
+
+	var r0 []*entity.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*entity.Transaction, error)); ok {
+		return rf(ctx, address, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*entity.Transaction); ok {
+		r0 = rf(ctx, address, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, address, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBalance provides a mock function with given fields: ctx, userID, wallet
+func (_m *ChainRepo) GetBalance(ctx context.Context, userID string, wallet string) (blockchainlogic.Amount, error) {
+	ret := _m.Called(ctx, userID, wallet)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBalance")
+	}
+
+	var r0 blockchainlogic.Amount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (blockchainlogic.Amount, error)); ok {
+		return rf(ctx, userID, wallet)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) blockchainlogic.Amount); ok {
+		r0 = rf(ctx, userID, wallet)
+	} else {
+		r0 = ret.Get(0).(blockchainlogic.Amount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, wallet)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBalanceByAddress provides a mock function with given fields: _a0, address
+func (_m *ChainRepo) GetBalanceByAddress(_a0 context.Context, address string) (blockchainlogic.Amount, error) {
+	ret := _m.Called(_a0, address)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBalanceByAddress")
+	}
+
+	var r0 blockchainlogic.Amount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (blockchainlogic.Amount, error)); ok {
+		return rf(_a0, address)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) blockchainlogic.Amount); ok {
+		r0 = rf(_a0, address)
+	} else {
+		r0 = ret.Get(0).(blockchainlogic.Amount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, address)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBalanceUSD provides a mock function with given fields: ctx, userID, wallet
+func (_m *ChainRepo) GetBalanceUSD(ctx context.Context, userID string, wallet string) (float64, error) {
+	ret := _m.Called(ctx, userID, wallet)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBalanceUSD")
+	}
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (float64, error)); ok {
+		return rf(ctx, userID, wallet)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) float64); ok {
+		r0 = rf(ctx, userID, wallet)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, wallet)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlockByHash provides a mock function with given fields: ctx, hash
+func (_m *ChainRepo) GetBlockByHash(ctx context.Context, hash string) (*entity.Block, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBlockByHash")
+	}
+
+	var r0 *entity.Block
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.Block, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.Block); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Block)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlockByHeight provides a mock function with given fields: ctx, height
+func (_m *ChainRepo) GetBlockByHeight(ctx context.Context, height int) (*entity.Block, error) {
+	ret := _m.Called(ctx, height)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBlockByHeight")
+	}
+
+	var r0 *entity.Block
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.Block, error)); ok {
+		return rf(ctx, height)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.Block); ok {
+		r0 = rf(ctx, height)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Block)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, height)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlocks provides a mock function with given fields: ctx, from, limit
+func (_m *ChainRepo) GetBlocks(ctx context.Context, from int, limit int) ([]*entity.Block, error) {
+	ret := _m.Called(ctx, from, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBlocks")
+	}
+
+	var r0 []*entity.Block
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*entity.Block, error)); ok {
+		return rf(ctx, from, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*entity.Block); ok {
+		r0 = rf(ctx, from, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.Block)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, from, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopUp provides a mock function with given fields: ctx, id
+func (_m *ChainRepo) GetTopUp(ctx context.Context, id int64) (*entity.TopUp, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTopUp")
+	}
+
+	var r0 *entity.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.TopUp, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.TopUp); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopUps provides a mock function with given fields: ctx, status, limit, offset
+func (_m *ChainRepo) GetTopUps(ctx context.Context, status string, limit int, offset int) ([]*entity.TopUp, error) {
+	ret := _m.Called(ctx, status, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTopUps")
+	}
+
+	var r0 []*entity.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*entity.TopUp, error)); ok {
+		return rf(ctx, status, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*entity.TopUp); ok {
+		r0 = rf(ctx, status, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.TopUp)
+		}
+	}
 
-// ChainRepo is an autogenerated mock type for the ChainRepo type
-type ChainRepo struct {
-	mock.Mock
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, status, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// CreateWallet provides a mock function with given fields: ctx, userID
-func (_m *ChainRepo) CreateWallet(ctx context.Context, userID string) (string, error) {
+// GetTotalBalance provides a mock function with given fields: ctx, userID
+func (_m *ChainRepo) GetTotalBalance(ctx context.Context, userID string) (*entity.TotalBalance, error) {
 	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateWallet")
+		panic("no return value specified for GetTotalBalance")
 	}
 
-	var r0 string
+	var r0 *entity.TotalBalance
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.TotalBalance, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.TotalBalance); ok {
 		r0 = rf(ctx, userID)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TotalBalance)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -42,27 +583,29 @@ func (_m *ChainRepo) CreateWallet(ctx context.Context, userID string) (string, e
 	return r0, r1
 }
 
-// GetBalance provides a mock function with given fields: ctx, userID
-func (_m *ChainRepo) GetBalance(ctx context.Context, userID string) (float64, error) {
-	ret := _m.Called(ctx, userID)
+// GetTransaction provides a mock function with given fields: ctx, txID
+func (_m *ChainRepo) GetTransaction(ctx context.Context, txID string) (*entity.Transaction, error) {
+	ret := _m.Called(ctx, txID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetBalance")
+		panic("no return value specified for GetTransaction")
 	}
 
-	var r0 float64
+	var r0 *entity.Transaction
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (float64, error)); ok {
-		return rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.Transaction, error)); ok {
+		return rf(ctx, txID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) float64); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.Transaction); ok {
+		r0 = rf(ctx, txID)
 	} else {
-		r0 = ret.Get(0).(float64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Transaction)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, userID)
+		r1 = rf(ctx, txID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -70,27 +613,59 @@ func (_m *ChainRepo) GetBalance(ctx context.Context, userID string) (float64, er
 	return r0, r1
 }
 
-// GetBalanceByAddress provides a mock function with given fields: _a0, address
-func (_m *ChainRepo) GetBalanceByAddress(_a0 context.Context, address string) (float64, error) {
-	ret := _m.Called(_a0, address)
+// GetTransactionProof provides a mock function with given fields: ctx, txID
+func (_m *ChainRepo) GetTransactionProof(ctx context.Context, txID string) (*entity.TransactionProof, error) {
+	ret := _m.Called(ctx, txID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetBalanceByAddress")
+		panic("no return value specified for GetTransactionProof")
 	}
 
-	var r0 float64
+	var r0 *entity.TransactionProof
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (float64, error)); ok {
-		return rf(_a0, address)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.TransactionProof, error)); ok {
+		return rf(ctx, txID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) float64); ok {
-		r0 = rf(_a0, address)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.TransactionProof); ok {
+		r0 = rf(ctx, txID)
 	} else {
-		r0 = ret.Get(0).(float64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TransactionProof)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(_a0, address)
+		r1 = rf(ctx, txID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTreasury provides a mock function with given fields: ctx, address, filter
+func (_m *ChainRepo) GetTreasury(ctx context.Context, address string, filter entity.HistoryFilter) (*entity.Treasury, error) {
+	ret := _m.Called(ctx, address, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTreasury")
+	}
+
+	var r0 *entity.Treasury
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, entity.HistoryFilter) (*entity.Treasury, error)); ok {
+		return rf(ctx, address, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, entity.HistoryFilter) *entity.Treasury); ok {
+		r0 = rf(ctx, address, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Treasury)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, entity.HistoryFilter) error); ok {
+		r1 = rf(ctx, address, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -98,23 +673,25 @@ func (_m *ChainRepo) GetBalanceByAddress(_a0 context.Context, address string) (f
 	return r0, r1
 }
 
-// GetBalanceUSD provides a mock function with given fields: ctx, userID
-func (_m *ChainRepo) GetBalanceUSD(ctx context.Context, userID string) (float64, error) {
+// GetUserAddresses provides a mock function with given fields: ctx, userID
+func (_m *ChainRepo) GetUserAddresses(ctx context.Context, userID string) ([]string, error) {
 	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetBalanceUSD")
+		panic("no return value specified for GetUserAddresses")
 	}
 
-	var r0 float64
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (float64, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) float64); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
 		r0 = rf(ctx, userID)
 	} else {
-		r0 = ret.Get(0).(float64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -154,40 +731,346 @@ func (_m *ChainRepo) GetWallet(ctx context.Context, userID string) (string, erro
 	return r0, r1
 }
 
-// Send provides a mock function with given fields: ctx, from, to, amount, wg
-func (_m *ChainRepo) Send(ctx context.Context, from string, to string, amount float64, wg *sync.WaitGroup) error {
-	ret := _m.Called(ctx, from, to, amount, wg)
+// GetWalletAddress provides a mock function with given fields: ctx, userID, wallet
+func (_m *ChainRepo) GetWalletAddress(ctx context.Context, userID string, wallet string) (string, error) {
+	ret := _m.Called(ctx, userID, wallet)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWalletAddress")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, userID, wallet)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, userID, wallet)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, wallet)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletHistory provides a mock function with given fields: ctx, userID, filter
+func (_m *ChainRepo) GetWalletHistory(ctx context.Context, userID string, filter entity.HistoryFilter) (*entity.WalletHistory, error) {
+	ret := _m.Called(ctx, userID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWalletHistory")
+	}
+
+	var r0 *entity.WalletHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, entity.HistoryFilter) (*entity.WalletHistory, error)); ok {
+		return rf(ctx, userID, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, entity.HistoryFilter) *entity.WalletHistory); ok {
+		r0 = rf(ctx, userID, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.WalletHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, entity.HistoryFilter) error); ok {
+		r1 = rf(ctx, userID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallets provides a mock function with given fields: ctx, userID
+func (_m *ChainRepo) GetWallets(ctx context.Context, userID string) ([]*entity.UserWallet, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWallets")
+	}
+
+	var r0 []*entity.UserWallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*entity.UserWallet, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*entity.UserWallet); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.UserWallet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportKey provides a mock function with given fields: ctx, userID, key, passphrase
+func (_m *ChainRepo) ImportKey(ctx context.Context, userID string, key []byte, passphrase string) (string, error) {
+	ret := _m.Called(ctx, userID, key, passphrase)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportKey")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, string) (string, error)); ok {
+		return rf(ctx, userID, key, passphrase)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, string) string); ok {
+		r0 = rf(ctx, userID, key, passphrase)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, string) error); ok {
+		r1 = rf(ctx, userID, key, passphrase)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsUserValid provides a mock function with given fields: ctx, userID
+func (_m *ChainRepo) IsUserValid(ctx context.Context, userID string) (bool, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsUserValid")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewReceiveAddress provides a mock function with given fields: ctx, userID, wallet
+func (_m *ChainRepo) NewReceiveAddress(ctx context.Context, userID string, wallet string) (string, error) {
+	ret := _m.Called(ctx, userID, wallet)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NewReceiveAddress")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, userID, wallet)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, userID, wallet)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, wallet)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetChain provides a mock function with given fields: ctx
+func (_m *ChainRepo) ResetChain(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetChain")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreWallet provides a mock function with given fields: ctx, userID, mnemonic, label
+func (_m *ChainRepo) RestoreWallet(ctx context.Context, userID string, mnemonic string, label string) (string, error) {
+	ret := _m.Called(ctx, userID, mnemonic, label)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreWallet")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (string, error)); ok {
+		return rf(ctx, userID, mnemonic, label)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = rf(ctx, userID, mnemonic, label)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, userID, mnemonic, label)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Send provides a mock function with given fields: ctx, from, wallet, to, amount, fee, wg
+func (_m *ChainRepo) Send(ctx context.Context, from string, wallet string, to string, amount blockchainlogic.Amount, fee blockchainlogic.Amount, wg *sync.WaitGroup) (string, error) {
+	ret := _m.Called(ctx, from, wallet, to, amount, fee, wg)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Send")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, float64, *sync.WaitGroup) error); ok {
-		r0 = rf(ctx, from, to, amount, wg)
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, blockchainlogic.Amount, blockchainlogic.Amount, *sync.WaitGroup) (string, error)); ok {
+		return rf(ctx, from, wallet, to, amount, fee, wg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, blockchainlogic.Amount, blockchainlogic.Amount, *sync.WaitGroup) string); ok {
+		r0 = rf(ctx, from, wallet, to, amount, fee, wg)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, blockchainlogic.Amount, blockchainlogic.Amount, *sync.WaitGroup) error); ok {
+		r1 = rf(ctx, from, wallet, to, amount, fee, wg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SettleTopUp provides a mock function with given fields: ctx, id, status, txID, reason
+func (_m *ChainRepo) SettleTopUp(ctx context.Context, id int64, status string, txID string, reason string) (*entity.TopUp, error) {
+	ret := _m.Called(ctx, id, status, txID, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SettleTopUp")
+	}
+
+	var r0 *entity.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, string) (*entity.TopUp, error)); ok {
+		return rf(ctx, id, status, txID, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, string) *entity.TopUp); ok {
+		r0 = rf(ctx, id, status, txID, reason)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.TopUp)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, string) error); ok {
+		r1 = rf(ctx, id, status, txID, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubmitRawTransaction provides a mock function with given fields: ctx, raw
+func (_m *ChainRepo) SubmitRawTransaction(ctx context.Context, raw []byte) (string, error) {
+	ret := _m.Called(ctx, raw)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubmitRawTransaction")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (string, error)); ok {
+		return rf(ctx, raw)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) string); ok {
+		r0 = rf(ctx, raw)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, raw)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // TopUp provides a mock function with given fields: ctx, from, to, amount, wg
-func (_m *ChainRepo) TopUp(ctx context.Context, from string, to string, amount float64, wg *sync.WaitGroup) error {
+func (_m *ChainRepo) TopUp(ctx context.Context, from string, to string, amount blockchainlogic.Amount, wg *sync.WaitGroup) (string, error) {
 	ret := _m.Called(ctx, from, to, amount, wg)
 
 	if len(ret) == 0 {
 		panic("no return value specified for TopUp")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, float64, *sync.WaitGroup) error); ok {
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, blockchainlogic.Amount, *sync.WaitGroup) (string, error)); ok {
+		return rf(ctx, from, to, amount, wg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, blockchainlogic.Amount, *sync.WaitGroup) string); ok {
 		r0 = rf(ctx, from, to, amount, wg)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, blockchainlogic.Amount, *sync.WaitGroup) error); ok {
+		r1 = rf(ctx, from, to, amount, wg)
+	} else {
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
 // NewChainRepo creates a new instance of ChainRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
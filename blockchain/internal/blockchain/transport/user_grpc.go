@@ -6,9 +6,12 @@ import (
 	"fmt"
 
 	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
 	pb "github.com/damndelion/blockchain_justCode/pkg/protobuf/userService/gw"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type UserGrpcTransport struct {
@@ -81,9 +84,51 @@ func (t *UserGrpcTransport) SetUserWallet(ctx context.Context, userID, address s
 		UserId:  userID,
 		Address: address,
 	})
+	if status.Code(err) == codes.AlreadyExists {
+		return resp, walletTaken(err)
+	}
 	if err != nil {
 		return resp, errors.New(fmt.Sprintf("cannot SetUserWallet: %v", err))
 	}
 
 	return resp, nil
 }
+
+func (t *UserGrpcTransport) ListUserWallets(ctx context.Context, userID string) ([]*pb.UserWallet, error) {
+	resp, err := t.client.ListUserWallets(ctx, &pb.ListUserWalletsRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot ListUserWallets: %v", err))
+	}
+
+	return resp.GetWallets(), nil
+}
+
+func (t *UserGrpcTransport) AddUserWallet(ctx context.Context, userID, address, label string, isDefault, custodial bool) (*pb.UserWallet, error) {
+	resp, err := t.client.AddUserWallet(ctx, &pb.AddUserWalletRequest{
+		UserId:    userID,
+		Address:   address,
+		Label:     label,
+		IsDefault: isDefault,
+		Custodial: custodial,
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil, walletTaken(err)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot AddUserWallet: %v", err))
+	}
+
+	return resp, nil
+}
+
+// walletTaken returns the error the user service reported with AlreadyExists:
+// ErrWalletAddressTaken, or ErrWalletLabelTaken.
+func walletTaken(err error) error {
+	if status.Convert(err).Message() == entity.ErrWalletAddressTaken.Error() {
+		return entity.ErrWalletAddressTaken
+	}
+
+	return entity.ErrWalletLabelTaken
+}
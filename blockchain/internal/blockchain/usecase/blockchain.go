@@ -7,93 +7,162 @@ import (
 	"github.com/opentracing/opentracing-go"
 
 	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/transport"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
 )
 
 type Blockchain struct {
 	repo              ChainRepo
 	cfg               *blockchain.Config
 	userGrpcTransport *transport.UserGrpcTransport
+	topUpPolicy       TopUpPolicy
+	// topUps serializes the top up decisions of this instance, so concurrent
+	// requests can not overrun a limit together. Payments are guarded by the
+	// status of the top up rows.
+	topUps sync.Mutex
 }
 
 func NewBlockchain(repo ChainRepo, cfg *blockchain.Config, userGrpcTransport *transport.UserGrpcTransport) *Blockchain {
-	return &Blockchain{repo, cfg, userGrpcTransport}
+	return &Blockchain{repo: repo, cfg: cfg, userGrpcTransport: userGrpcTransport}
 }
 
-func (b *Blockchain) Wallet(ctx context.Context, userID string) (string, error) {
+func (b *Blockchain) Wallet(ctx context.Context, userID, wallet string) (string, error) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "wallet use case")
 	defer span.Finish()
 
-	return b.repo.GetWallet(spanCtx, userID)
+	return b.repo.GetWalletAddress(spanCtx, userID, wallet)
 }
 
-func (b *Blockchain) GetBalance(ctx context.Context, userID string) (float64, error) {
+func (b *Blockchain) Wallets(ctx context.Context, userID string) ([]*entity.UserWallet, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "wallets use case")
+	defer span.Finish()
+
+	return b.repo.GetWallets(spanCtx, userID)
+}
+
+func (b *Blockchain) UserAddresses(ctx context.Context, userID string) ([]string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "user addresses use case")
+	defer span.Finish()
+
+	return b.repo.GetUserAddresses(spanCtx, userID)
+}
+
+func (b *Blockchain) GetBalance(ctx context.Context, userID, wallet string) (blockchainlogic.Amount, error) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get balance use case")
 	defer span.Finish()
 
-	return b.repo.GetBalance(spanCtx, userID)
+	return b.repo.GetBalance(spanCtx, userID, wallet)
+}
+
+func (b *Blockchain) GetTotalBalance(ctx context.Context, userID string) (*entity.TotalBalance, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get total balance use case")
+	defer span.Finish()
+
+	return b.repo.GetTotalBalance(spanCtx, userID)
 }
 
-func (b *Blockchain) GetBalanceByAddress(ctx context.Context, address string) (float64, error) {
+func (b *Blockchain) GetBalanceByAddress(ctx context.Context, address string) (blockchainlogic.Amount, error) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get balance by address use case")
 	defer span.Finish()
 
 	return b.repo.GetBalanceByAddress(spanCtx, address)
 }
 
-func (b *Blockchain) GetBalanceUSD(ctx context.Context, userID string) (float64, error) {
+func (b *Blockchain) GetBalanceUSD(ctx context.Context, userID, wallet string) (float64, error) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get balance is usd use case")
 	defer span.Finish()
 
-	return b.repo.GetBalanceUSD(spanCtx, userID)
+	return b.repo.GetBalanceUSD(spanCtx, userID, wallet)
 }
 
-func (b *Blockchain) CreateWallet(ctx context.Context, userID string) (string, error) {
+func (b *Blockchain) CreateWallet(ctx context.Context, userID, label string, isDefault bool) (*entity.NewWallet, error) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "create wallet use case")
 	defer span.Finish()
-	wallet, err := b.repo.CreateWallet(spanCtx, userID)
+	wallet, err := b.repo.CreateWallet(spanCtx, userID, label, isDefault)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	return wallet, nil
 }
 
-func (b *Blockchain) Send(ctx context.Context, from, to string, amount float64) error {
+func (b *Blockchain) RestoreWallet(ctx context.Context, userID, mnemonic, label string) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "restore wallet use case")
+	defer span.Finish()
+
+	return b.repo.RestoreWallet(spanCtx, userID, mnemonic, label)
+}
+
+func (b *Blockchain) NewReceiveAddress(ctx context.Context, userID, wallet string) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "new receive address use case")
+	defer span.Finish()
+
+	return b.repo.NewReceiveAddress(spanCtx, userID, wallet)
+}
+
+func (b *Blockchain) AddExternalWallet(ctx context.Context, userID, address, label string, isDefault bool) (*entity.UserWallet, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "add external wallet use case")
+	defer span.Finish()
+
+	return b.repo.AddExternalWallet(spanCtx, userID, address, label, isDefault)
+}
+
+func (b *Blockchain) ExportKey(ctx context.Context, userID, wallet, address, format, passphrase string) (*entity.ExportedKey, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "export key use case")
+	defer span.Finish()
+
+	return b.repo.ExportKey(spanCtx, userID, wallet, address, format, passphrase)
+}
+
+func (b *Blockchain) ImportKey(ctx context.Context, userID string, key []byte, passphrase string) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "import key use case")
+	defer span.Finish()
+
+	return b.repo.ImportKey(spanCtx, userID, key, passphrase)
+}
+
+func (b *Blockchain) BuildTransaction(ctx context.Context, userID, wallet, to string, amount, fee blockchainlogic.Amount) (*entity.TxTemplate, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "build transaction use case")
+	defer span.Finish()
+
+	return b.repo.BuildTransaction(spanCtx, userID, wallet, to, amount, fee)
+}
+
+func (b *Blockchain) SubmitRawTransaction(ctx context.Context, raw []byte) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "submit raw transaction use case")
+	defer span.Finish()
+
+	return b.repo.SubmitRawTransaction(spanCtx, raw)
+}
+
+func (b *Blockchain) Send(ctx context.Context, from, wallet, to string, amount, fee blockchainlogic.Amount) (string, error) {
 	span, spanCtx := opentracing.StartSpanFromContext(ctx, "send use case")
 	defer span.Finish()
 	var wg sync.WaitGroup
-	var err error
 	wg.Add(1)
 
-	err = b.repo.Send(spanCtx, from, to, amount, &wg)
+	txID, err := b.repo.Send(spanCtx, from, wallet, to, amount, fee, &wg)
 
 	wg.Wait()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return txID, nil
 }
 
-func (b *Blockchain) TopUp(ctx context.Context, to string, amount float64) error {
-	span, spanCtx := opentracing.StartSpanFromContext(ctx, "top up use case")
+// Treasury returns the balance and outflows of the treasury funding top ups.
+func (b *Blockchain) Treasury(ctx context.Context, filter entity.HistoryFilter) (*entity.Treasury, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "treasury use case")
+	defer span.Finish()
+
+	return b.repo.GetTreasury(spanCtx, b.cfg.Genesis.Recipient, filter)
+}
+
+func (b *Blockchain) WalletHistory(ctx context.Context, userID string, filter entity.HistoryFilter) (*entity.WalletHistory, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "wallet history use case")
 	defer span.Finish()
-	var wg sync.WaitGroup
-	var err error
-	wg.Add(1)
-	go func() error {
-		err = b.repo.TopUp(spanCtx, b.cfg.GenesisAddress, to, amount, &wg)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}()
-	wg.Wait()
-	if err != nil {
-		return err
-	}
 
-	return nil
+	return b.repo.GetWalletHistory(spanCtx, userID, filter)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/damndelion/blockchain_justCode/config/blockchain"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/mocks"
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/transport"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
 )
 
 func TestBlockchain_Send(t *testing.T) {
@@ -20,8 +21,10 @@ func TestBlockchain_Send(t *testing.T) {
 	type args struct {
 		ctx    context.Context
 		from   string
+		wallet string
 		to     string
-		amount float64
+		amount blockchainlogic.Amount
+		fee    blockchainlogic.Amount
 		wg     *sync.WaitGroup
 	}
 	tests := []struct {
@@ -40,8 +43,10 @@ func TestBlockchain_Send(t *testing.T) {
 			args: args{
 				ctx:    context.Background(),
 				from:   "1EzU4cx9yfdBC3X38MV3xYiNf3XVBmDBpW",
+				wallet: "savings",
 				to:     "1HM5Mom2VKzchdJToC1R4ji6K7XKt1Xf5B",
-				amount: 1.00,
+				amount: blockchainlogic.Coin(),
+				fee:    blockchainlogic.Coin() / 1000,
 				wg:     &sync.WaitGroup{},
 			},
 			wantErr: false,
@@ -58,7 +63,7 @@ func TestBlockchain_Send(t *testing.T) {
 				ctx:    context.Background(),
 				from:   "1EzU4cx9yfdBC3X38MV3xYiNf3XVBmDBpW",
 				to:     "1HM5Mom2VKzchdJToC1R4ji6K7XKt1Xf5B",
-				amount: 100.00,
+				amount: 100 * blockchainlogic.Coin(),
 				wg:     &sync.WaitGroup{},
 			},
 			wantErr: true,
@@ -67,16 +72,16 @@ func TestBlockchain_Send(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if !tt.wantErr {
-				tt.fields.repoMock.On("Send", tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg).Return(nil)
+				tt.fields.repoMock.On("Send", tt.args.ctx, tt.args.from, tt.args.wallet, tt.args.to, tt.args.amount, tt.args.fee, tt.args.wg).Return("9a1c", nil)
 			} else {
-				tt.fields.repoMock.On("Send", tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg).Return(errors.New("send error"))
+				tt.fields.repoMock.On("Send", tt.args.ctx, tt.args.from, tt.args.wallet, tt.args.to, tt.args.amount, tt.args.fee, tt.args.wg).Return("", errors.New("send error"))
 			}
 			b := &Blockchain{
 				repo:              tt.fields.repoMock,
 				cfg:               tt.fields.cfg,
 				userGrpcTransport: tt.fields.userGrpcTransport,
 			}
-			if err := b.repo.Send(tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg); (err != nil) != tt.wantErr {
+			if _, err := b.repo.Send(tt.args.ctx, tt.args.from, tt.args.wallet, tt.args.to, tt.args.amount, tt.args.fee, tt.args.wg); (err != nil) != tt.wantErr {
 				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -93,7 +98,7 @@ func TestBlockchain_TopUp(t *testing.T) {
 		ctx    context.Context
 		from   string
 		to     string
-		amount float64
+		amount blockchainlogic.Amount
 		wg     *sync.WaitGroup
 	}
 	tests := []struct {
@@ -113,7 +118,7 @@ func TestBlockchain_TopUp(t *testing.T) {
 				ctx:    context.Background(),
 				from:   "1Pq4qTbgTH4KhmFiPQ91YXVyyK5oo6aX1G",
 				to:     "1EzU4cx9yfdBC3X38MV3xYiNf3XVBmDBpW",
-				amount: 1.00,
+				amount: blockchainlogic.Coin(),
 				wg:     &sync.WaitGroup{},
 			},
 			wantErr: false,
@@ -130,7 +135,7 @@ func TestBlockchain_TopUp(t *testing.T) {
 				ctx:    context.Background(),
 				from:   "1Pq4qTbgTH4KhmFiPQ91YXVyyK5oo6aX1G",
 				to:     "1EzU4cx9yfdBC3X38MV3xYiNf3XVBmDBpW",
-				amount: -1.00,
+				amount: -blockchainlogic.Coin(),
 				wg:     &sync.WaitGroup{},
 			},
 			wantErr: true,
@@ -140,16 +145,16 @@ func TestBlockchain_TopUp(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var err error
 			if !tt.wantErr {
-				tt.fields.repoMock.On("TopUp", tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg).Return(err)
+				tt.fields.repoMock.On("TopUp", tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg).Return("9a1c", err)
 			} else {
-				tt.fields.repoMock.On("TopUp", tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg).Return(errors.New("error"))
+				tt.fields.repoMock.On("TopUp", tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg).Return("", errors.New("error"))
 			}
 			b := &Blockchain{
 				repo:              tt.fields.repoMock,
 				cfg:               tt.fields.cfg,
 				userGrpcTransport: tt.fields.userGrpcTransport,
 			}
-			if err = b.repo.TopUp(tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg); (err != nil) != tt.wantErr {
+			if _, err = b.repo.TopUp(tt.args.ctx, tt.args.from, tt.args.to, tt.args.amount, tt.args.wg); (err != nil) != tt.wantErr {
 				t.Errorf("TopUp() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
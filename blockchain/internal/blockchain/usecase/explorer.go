@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+)
+
+func (b *Blockchain) Blocks(ctx context.Context, from, limit int) ([]*entity.Block, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "blocks use case")
+	defer span.Finish()
+
+	return b.repo.GetBlocks(spanCtx, from, limit)
+}
+
+func (b *Blockchain) BlockByHash(ctx context.Context, hash string) (*entity.Block, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "block by hash use case")
+	defer span.Finish()
+
+	return b.repo.GetBlockByHash(spanCtx, hash)
+}
+
+func (b *Blockchain) BlockByHeight(ctx context.Context, height int) (*entity.Block, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "block by height use case")
+	defer span.Finish()
+
+	return b.repo.GetBlockByHeight(spanCtx, height)
+}
+
+func (b *Blockchain) Transaction(ctx context.Context, txID string) (*entity.Transaction, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "transaction use case")
+	defer span.Finish()
+
+	return b.repo.GetTransaction(spanCtx, txID)
+}
+
+func (b *Blockchain) TransactionProof(ctx context.Context, txID string) (*entity.TransactionProof, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "transaction proof use case")
+	defer span.Finish()
+
+	return b.repo.GetTransactionProof(spanCtx, txID)
+}
+
+func (b *Blockchain) AddressTransactions(ctx context.Context, address string, limit, offset int) ([]*entity.Transaction, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "address transactions use case")
+	defer span.Finish()
+
+	return b.repo.GetAddressTransactions(spanCtx, address, limit, offset)
+}
@@ -4,28 +4,86 @@ package usecase
 import (
 	"context"
 	"sync"
+	"time"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
 )
 
 //go:generate mockgen -source=interfaces.go -destination=./mocks_test.go -package=usecase_test
 
 type (
 	ChainUseCase interface {
-		Wallet(ctx context.Context, userID string) (string, error)
-		GetBalance(ctx context.Context, address string) (float64, error)
-		GetBalanceUSD(ctx context.Context, address string) (float64, error)
-		CreateWallet(ctx context.Context, userID string) (string, error)
-		Send(ctx context.Context, from, to string, amount float64) error
-		TopUp(ctx context.Context, to string, amount float64) error
-		GetBalanceByAddress(ctx context.Context, address string) (float64, error)
+		Wallet(ctx context.Context, userID, wallet string) (string, error)
+		Wallets(ctx context.Context, userID string) ([]*entity.UserWallet, error)
+		UserAddresses(ctx context.Context, userID string) ([]string, error)
+		GetBalance(ctx context.Context, userID, wallet string) (blockchainlogic.Amount, error)
+		GetBalanceUSD(ctx context.Context, userID, wallet string) (float64, error)
+		GetTotalBalance(ctx context.Context, userID string) (*entity.TotalBalance, error)
+		CreateWallet(ctx context.Context, userID, label string, isDefault bool) (*entity.NewWallet, error)
+		RestoreWallet(ctx context.Context, userID, mnemonic, label string) (string, error)
+		NewReceiveAddress(ctx context.Context, userID, wallet string) (string, error)
+		AddExternalWallet(ctx context.Context, userID, address, label string, isDefault bool) (*entity.UserWallet, error)
+		ExportKey(ctx context.Context, userID, wallet, address, format, passphrase string) (*entity.ExportedKey, error)
+		ImportKey(ctx context.Context, userID string, key []byte, passphrase string) (string, error)
+		Send(ctx context.Context, from, wallet, to string, amount, fee blockchainlogic.Amount) (string, error)
+		BuildTransaction(ctx context.Context, userID, wallet, to string, amount, fee blockchainlogic.Amount) (*entity.TxTemplate, error)
+		SubmitRawTransaction(ctx context.Context, raw []byte) (string, error)
+		TopUp(ctx context.Context, to string, amount blockchainlogic.Amount) (*entity.TopUp, error)
+		TopUps(ctx context.Context, status string, limit, offset int) ([]*entity.TopUp, error)
+		ApproveTopUp(ctx context.Context, id int64, adminID string) (*entity.TopUp, error)
+		RejectTopUp(ctx context.Context, id int64, adminID, reason string) (*entity.TopUp, error)
+		GetBalanceByAddress(ctx context.Context, address string) (blockchainlogic.Amount, error)
+		WalletHistory(ctx context.Context, userID string, filter entity.HistoryFilter) (*entity.WalletHistory, error)
+		Blocks(ctx context.Context, from, limit int) ([]*entity.Block, error)
+		BlockByHash(ctx context.Context, hash string) (*entity.Block, error)
+		BlockByHeight(ctx context.Context, height int) (*entity.Block, error)
+		Transaction(ctx context.Context, txID string) (*entity.Transaction, error)
+		TransactionProof(ctx context.Context, txID string) (*entity.TransactionProof, error)
+		AddressTransactions(ctx context.Context, address string, limit, offset int) ([]*entity.Transaction, error)
+		GenerateBlocks(ctx context.Context, n int) ([]string, error)
+		Fund(ctx context.Context, address string, amount blockchainlogic.Amount) (string, error)
+		ResetChain(ctx context.Context) (string, error)
+		Treasury(ctx context.Context, filter entity.HistoryFilter) (*entity.Treasury, error)
 	}
 
 	ChainRepo interface {
 		GetWallet(ctx context.Context, userID string) (string, error)
-		GetBalance(ctx context.Context, userID string) (float64, error)
-		GetBalanceUSD(ctx context.Context, userID string) (float64, error)
-		CreateWallet(ctx context.Context, userID string) (string, error)
-		Send(ctx context.Context, from, to string, amount float64, wg *sync.WaitGroup) error
-		TopUp(ctx context.Context, from, to string, amount float64, wg *sync.WaitGroup) error
-		GetBalanceByAddress(_ context.Context, address string) (float64, error)
+		GetWalletAddress(ctx context.Context, userID, wallet string) (string, error)
+		GetWallets(ctx context.Context, userID string) ([]*entity.UserWallet, error)
+		GetUserAddresses(ctx context.Context, userID string) ([]string, error)
+		GetBalance(ctx context.Context, userID, wallet string) (blockchainlogic.Amount, error)
+		GetBalanceUSD(ctx context.Context, userID, wallet string) (float64, error)
+		GetTotalBalance(ctx context.Context, userID string) (*entity.TotalBalance, error)
+		CreateWallet(ctx context.Context, userID, label string, isDefault bool) (*entity.NewWallet, error)
+		RestoreWallet(ctx context.Context, userID, mnemonic, label string) (string, error)
+		NewReceiveAddress(ctx context.Context, userID, wallet string) (string, error)
+		AddExternalWallet(ctx context.Context, userID, address, label string, isDefault bool) (*entity.UserWallet, error)
+		ExportKey(ctx context.Context, userID, wallet, address, format, passphrase string) (*entity.ExportedKey, error)
+		ImportKey(ctx context.Context, userID string, key []byte, passphrase string) (string, error)
+		Send(ctx context.Context, from, wallet, to string, amount, fee blockchainlogic.Amount, wg *sync.WaitGroup) (string, error)
+		BuildTransaction(ctx context.Context, userID, wallet, to string, amount, fee blockchainlogic.Amount) (*entity.TxTemplate, error)
+		SubmitRawTransaction(ctx context.Context, raw []byte) (string, error)
+		TopUp(ctx context.Context, from, to string, amount blockchainlogic.Amount, wg *sync.WaitGroup) (string, error)
+		GetBalanceByAddress(_ context.Context, address string) (blockchainlogic.Amount, error)
+		GetWalletHistory(ctx context.Context, userID string, filter entity.HistoryFilter) (*entity.WalletHistory, error)
+		GetBlocks(ctx context.Context, from, limit int) ([]*entity.Block, error)
+		GetBlockByHash(ctx context.Context, hash string) (*entity.Block, error)
+		GetBlockByHeight(ctx context.Context, height int) (*entity.Block, error)
+		GetTransaction(ctx context.Context, txID string) (*entity.Transaction, error)
+		GetTransactionProof(ctx context.Context, txID string) (*entity.TransactionProof, error)
+		GetAddressTransactions(ctx context.Context, address string, limit, offset int) ([]*entity.Transaction, error)
+		GenerateBlocks(ctx context.Context, n int) ([]string, error)
+		Fund(ctx context.Context, address string, amount blockchainlogic.Amount) (string, error)
+		ResetChain(ctx context.Context) (string, error)
+		GetTreasury(ctx context.Context, address string, filter entity.HistoryFilter) (*entity.Treasury, error)
+		IsUserValid(ctx context.Context, userID string) (bool, error)
+		CreateTopUpWithinLimits(ctx context.Context, userID string, amount blockchainlogic.Amount, since time.Time,
+			decide func(recent, lifetime blockchainlogic.Amount) (string, entity.TopUpDecision)) (*entity.TopUp, error)
+		CreateTopUp(ctx context.Context, userID string, amount blockchainlogic.Amount, status, txID string, decision entity.TopUpDecision) (*entity.TopUp, error)
+		DecideTopUp(ctx context.Context, id int64, status, txID string, decision entity.TopUpDecision) (*entity.TopUp, error)
+		SettleTopUp(ctx context.Context, id int64, status, txID, reason string) (*entity.TopUp, error)
+		GetTopUp(ctx context.Context, id int64) (*entity.TopUp, error)
+		GetTopUps(ctx context.Context, status string, limit, offset int) ([]*entity.TopUp, error)
 	}
 )
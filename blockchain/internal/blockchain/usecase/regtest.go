@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+func (b *Blockchain) GenerateBlocks(ctx context.Context, n int) ([]string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "generate blocks use case")
+	defer span.Finish()
+
+	return b.repo.GenerateBlocks(spanCtx, n)
+}
+
+func (b *Blockchain) Fund(ctx context.Context, address string, amount blockchainlogic.Amount) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "fund use case")
+	defer span.Finish()
+
+	return b.repo.Fund(spanCtx, address, amount)
+}
+
+func (b *Blockchain) ResetChain(ctx context.Context) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "reset chain use case")
+	defer span.Finish()
+
+	return b.repo.ResetChain(spanCtx)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/controller/http/v1/dto"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
 	"github.com/opentracing/opentracing-go"
 
 	"github.com/damndelion/blockchain_justCode/internal/blockchain/transport"
@@ -24,8 +25,7 @@ type BlockchainRepo struct {
 	userGrpcTransport *transport.UserGrpcTransport
 }
 
-func NewBlockchainRepo(db *sql.DB, address string, userGrpcTransport *transport.UserGrpcTransport) *BlockchainRepo {
-	chain := blockchainlogic.CreateBlockchain(db, address)
+func NewBlockchainRepo(db *sql.DB, chain *blockchainlogic.Blockchain, userGrpcTransport *transport.UserGrpcTransport) *BlockchainRepo {
 	fetchBTCPrice()
 
 	return &BlockchainRepo{db, chain, userGrpcTransport}
@@ -45,95 +45,157 @@ func (br *BlockchainRepo) GetWallet(ctx context.Context, userID string) (wallet
 	return address.Wallet, nil
 }
 
-func (br *BlockchainRepo) GetBalance(ctx context.Context, userID string) (balance float64, err error) {
+func (br *BlockchainRepo) GetBalance(ctx context.Context, userID, wallet string) (balance blockchainlogic.Amount, err error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "get balance repo")
 	defer span.Finish()
-	address, err := br.GetWallet(ctx, userID)
+	address, err := br.GetWalletAddress(ctx, userID, wallet)
 	if err != nil {
 		return 0, err
 	}
 
-	res := br.chain.GetBalance(address)
-
-	return res, nil
+	return br.chain.GetWalletBalance(address)
 }
 
-func (br *BlockchainRepo) GetBalanceByAddress(ctx context.Context, address string) (balance float64, err error) {
+func (br *BlockchainRepo) GetBalanceByAddress(ctx context.Context, address string) (balance blockchainlogic.Amount, err error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "get balance by address repo")
 	defer span.Finish()
-	res := br.chain.GetBalance(address)
-
-	return res, nil
+	return br.chain.GetBalance(address)
 }
 
 var btcPrice float64
 
-func (br *BlockchainRepo) GetBalanceUSD(ctx context.Context, userID string) (balance float64, err error) {
+func (br *BlockchainRepo) GetBalanceUSD(ctx context.Context, userID, wallet string) (balance float64, err error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "get balance is usd repo")
 	defer span.Finish()
-	address, err := br.GetWallet(ctx, userID)
+	address, err := br.GetWalletAddress(ctx, userID, wallet)
+	if err != nil {
+		return 0, err
+	}
 
-	bitcoinBalance := br.chain.GetBalance(address)
-	totalBalanceUSD := bitcoinBalance * btcPrice
+	bitcoinBalance, err := br.chain.GetWalletBalance(address)
+	if err != nil {
+		return 0, err
+	}
+	totalBalanceUSD := bitcoinBalance.Float() * btcPrice
 
 	return totalBalanceUSD, nil
 }
 
-func (br *BlockchainRepo) CreateWallet(ctx context.Context, userID string) (string, error) {
+func (br *BlockchainRepo) CreateWallet(ctx context.Context, userID, label string, isDefault bool) (*entity.NewWallet, error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "create wallet repo")
 	defer span.Finish()
-	wallet, err := br.GetWallet(ctx, userID)
-	if wallet != "" {
-		return "", errors.New(fmt.Sprintf("user wallet already exists"))
-	}
 	user, err := br.userGrpcTransport.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 	if !user.Valid {
-		return "", errors.New(fmt.Sprintf("user is not valid"))
+		return nil, errors.New(fmt.Sprintf("user is not valid"))
+	}
+
+	address, mnemonic, err := br.chain.CreateHDWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := br.userGrpcTransport.AddUserWallet(ctx, userID, address, label, isDefault, true)
+	if err != nil {
+		// Nobody could use the key.
+		if deleteErr := br.chain.Keystore().Delete(address); deleteErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("remove key of %s: %w", address, deleteErr))
+		}
+
+		return nil, err
 	}
 
-	address := blockchainlogic.CreateWallet()
+	return &entity.NewWallet{Address: address, Label: wallet.Label, Default: wallet.IsDefault, Mnemonic: mnemonic}, nil
+}
 
-	_, err = br.userGrpcTransport.SetUserWallet(ctx, userID, address)
+func (br *BlockchainRepo) RestoreWallet(ctx context.Context, userID, mnemonic, label string) (string, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "restore wallet repo")
+	defer span.Finish()
+	address, err := blockchainlogic.HDWalletAddress(mnemonic)
 	if err != nil {
 		return "", err
 	}
+	_, err = br.GetWalletAddress(ctx, userID, address)
+	known := err == nil
+	if err != nil && !errors.Is(err, entity.ErrWalletNotFound) {
+		return "", err
+	}
+	if !known {
+		user, err := br.userGrpcTransport.GetUserByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		if !user.Valid {
+			return "", errors.New("user is not valid")
+		}
+	}
+
+	_, err = br.chain.RestoreWallet(mnemonic)
+	stored := err == nil
+	if err != nil && !errors.Is(err, blockchainlogic.ErrKeyExists) {
+		return "", err
+	}
+
+	if !known {
+		_, err = br.userGrpcTransport.AddUserWallet(ctx, userID, address, label, false, true)
+		if err != nil {
+			if !stored {
+				return "", err
+			}
+			if deleteErr := br.chain.Keystore().Delete(address); deleteErr != nil {
+				return "", errors.Join(err, fmt.Errorf("remove key of %s: %w", address, deleteErr))
+			}
+
+			return "", err
+		}
+	}
 
 	return address, nil
 }
 
-func (br *BlockchainRepo) Send(ctx context.Context, from, to string, amount float64, wg *sync.WaitGroup) error {
+func (br *BlockchainRepo) NewReceiveAddress(ctx context.Context, userID, wallet string) (string, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "new receive address repo")
+	defer span.Finish()
+	address, err := br.GetWalletAddress(ctx, userID, wallet)
+	if err != nil {
+		return "", err
+	}
+
+	return br.chain.NextAddress(address, blockchainlogic.ChainReceive)
+}
+
+func (br *BlockchainRepo) Send(ctx context.Context, from, wallet, to string, amount, fee blockchainlogic.Amount, wg *sync.WaitGroup) (string, error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "send repo")
 	defer span.Finish()
 	defer wg.Done()
-	user, err := br.userGrpcTransport.GetUserByID(ctx, from)
+	selected, err := br.userWallet(ctx, from, wallet)
 	if err != nil {
-		return err
+		return "", err
 	}
-	err = br.chain.Send(user.Wallet, to, amount)
-	if err != nil {
-		return err
+	if !selected.Custodial {
+		return "", entity.ErrNotCustodial
 	}
 
-	return nil
+	return br.chain.Send(selected.Address, to, amount, fee)
 }
 
-func (br *BlockchainRepo) TopUp(ctx context.Context, from, to string, amount float64, wg *sync.WaitGroup) error {
+// TopUp pays amount from the treasury address from to the default wallet of
+// the user to.
+func (br *BlockchainRepo) TopUp(ctx context.Context, from, to string, amount blockchainlogic.Amount, wg *sync.WaitGroup) (string, error) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "top up repo")
 	defer span.Finish()
 	defer wg.Done()
 	if amount < 0 {
-		return fmt.Errorf("top up amount can not be negative")
+		return "", fmt.Errorf("top up amount can not be negative")
 	}
-	user, err := br.userGrpcTransport.GetUserByID(ctx, to)
+	wallet, err := br.userWallet(ctx, to, "")
 	if err != nil {
-		return err
-	}
-	err = br.chain.Send(from, user.Wallet, amount)
-	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return br.chain.Send(from, wallet.Address, amount, 0)
 }
 
 func fetchBTCPrice() {
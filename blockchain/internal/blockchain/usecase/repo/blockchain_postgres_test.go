@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/transport"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	pb "github.com/damndelion/blockchain_justCode/pkg/protobuf/userService/gw"
+)
+
+// userService is a user service whose users have the legacy wallet and the
+// user wallets given, and whose AddUserWallet fails with addErr.
+type userService struct {
+	pb.UnimplementedUserServiceServer
+	legacyWallet string
+	wallets      []*pb.UserWallet
+	addErr       error
+}
+
+func (s *userService) GetUserByID(_ context.Context, request *pb.GetUserByIDRequest) (*pb.User, error) {
+	return &pb.User{Name: request.Id, Wallet: s.legacyWallet, Valid: true}, nil
+}
+
+func (s *userService) ListUserWallets(context.Context, *pb.ListUserWalletsRequest) (*pb.ListUserWalletsResponse, error) {
+	return &pb.ListUserWalletsResponse{Wallets: s.wallets}, nil
+}
+
+func (s *userService) AddUserWallet(_ context.Context, request *pb.AddUserWalletRequest) (*pb.UserWallet, error) {
+	if s.addErr != nil {
+		return nil, s.addErr
+	}
+
+	return &pb.UserWallet{Address: request.Address, Label: request.Label, IsDefault: request.IsDefault, Custodial: request.Custodial}, nil
+}
+
+// testRepo returns a repo on an in-memory chain talking to users over gRPC.
+func testRepo(t *testing.T, users pb.UserServiceServer) (*BlockchainRepo, blockchainlogic.Keystore) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterUserServiceServer(server, users)
+	go server.Serve(lis) //nolint:errcheck // ends with Stop
+	t.Cleanup(server.Stop)
+
+	keys := blockchainlogic.NewWallets()
+	chain := blockchainlogic.NewBlockchain(blockchainlogic.NewMemoryChainStore(), keys, string(blockchainlogic.NewWallet().GetAddress()))
+	userTransport := transport.NewUserGrpcTransport(blockchain.UserGrpcTransport{Host: lis.Addr().String()})
+
+	return &BlockchainRepo{chain: chain, userGrpcTransport: userTransport}, keys
+}
+
+func TestBlockchainRepo_CreateWallet(t *testing.T) {
+	tests := []struct {
+		name    string
+		addErr  error
+		wantErr error
+	}{
+		{name: "Bound"},
+		{name: "Label taken", addErr: status.Error(codes.AlreadyExists, "wallet label is already used"), wantErr: entity.ErrWalletLabelTaken},
+		{name: "Address taken", addErr: status.Error(codes.AlreadyExists, "wallet address is already used"), wantErr: entity.ErrWalletAddressTaken},
+		{name: "User service failure", addErr: status.Error(codes.Unavailable, "down")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, keys := testRepo(t, &userService{addErr: tt.addErr})
+
+			wallet, err := repo.CreateWallet(context.Background(), "1", "savings", false)
+			if tt.addErr == nil {
+				if err != nil {
+					t.Fatalf("CreateWallet() error = %v", err)
+				}
+				if _, err = keys.Get(wallet.Address); err != nil {
+					t.Errorf("Get() of the new wallet error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("CreateWallet() error = %v, want %v", err, tt.addErr)
+			}
+			// The key of the unbound wallet is not kept.
+			if addresses, _ := keys.Addresses(); len(addresses) != 0 {
+				t.Errorf("keystore holds %v after a failed binding", addresses)
+			}
+		})
+	}
+}
+
+func TestBlockchainRepo_TopUp(t *testing.T) {
+	legacy := string(blockchainlogic.NewWallet().GetAddress())
+	savings := string(blockchainlogic.NewWallet().GetAddress())
+	main := string(blockchainlogic.NewWallet().GetAddress())
+	repo, keys := testRepo(t, &userService{legacyWallet: legacy, wallets: []*pb.UserWallet{
+		{Address: savings, Label: "savings"},
+		{Address: main, Label: "main", IsDefault: true},
+	}})
+	treasury, err := blockchainlogic.CreateWallet(keys)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	repo.chain = blockchainlogic.CreateBlockchain(blockchainlogic.NewMemoryChainStore(), keys, treasury)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	txID, err := repo.TopUp(context.Background(), treasury, "1", 5, &wg)
+	if err != nil {
+		t.Fatalf("TopUp() error = %v", err)
+	}
+	tx, ok := repo.chain.PendingTransaction(txID)
+	if !ok {
+		t.Fatalf("PendingTransaction() of the top up is missing")
+	}
+	if err = repo.chain.MineBlock(context.Background(), []*blockchainlogic.Transaction{tx}); err != nil {
+		t.Fatalf("MineBlock() error = %v", err)
+	}
+
+	// The default user wallet is paid, not the legacy users.wallet.
+	for address, want := range map[string]blockchainlogic.Amount{main: 5, savings: 0, legacy: 0} {
+		if balance, err := repo.chain.GetBalance(address); err != nil || balance != want {
+			t.Errorf("GetBalance() of %s = %v, %v, want %v", address, balance, err, want)
+		}
+	}
+}
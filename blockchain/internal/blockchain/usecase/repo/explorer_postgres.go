@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+func (br *BlockchainRepo) GetBlocks(ctx context.Context, from, limit int) ([]*entity.Block, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get blocks repo")
+	defer span.Finish()
+	infos, err := br.chain.Blocks(from, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*entity.Block, 0, len(infos))
+	for _, info := range infos {
+		block, err := br.toBlock(info)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+func (br *BlockchainRepo) GetBlockByHash(ctx context.Context, hash string) (*entity.Block, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get block by hash repo")
+	defer span.Finish()
+	info, err := br.chain.BlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return br.toBlock(info)
+}
+
+func (br *BlockchainRepo) GetBlockByHeight(ctx context.Context, height int) (*entity.Block, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get block by height repo")
+	defer span.Finish()
+	info, err := br.chain.BlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return br.toBlock(info)
+}
+
+func (br *BlockchainRepo) GetTransaction(ctx context.Context, txID string) (*entity.Transaction, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get transaction repo")
+	defer span.Finish()
+	info, err := br.chain.TransactionInfo(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	return br.toTransaction(info)
+}
+
+func (br *BlockchainRepo) GetTransactionProof(ctx context.Context, txID string) (*entity.TransactionProof, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get transaction proof repo")
+	defer span.Finish()
+	txProof, err := br.chain.TransactionProof(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	header := txProof.Block.Block.Header()
+	proof := &entity.TransactionProof{
+		TxID:          txID,
+		Height:        txProof.Block.Height,
+		Confirmations: txProof.Block.Confirmations,
+		Index:         txProof.Proof.Index,
+		Count:         txProof.Proof.Count,
+		Branch:        make([]string, 0, len(txProof.Proof.Branch)),
+		Header: entity.BlockHeader{
+			Hash:       header.Hash,
+			PrevHash:   header.PrevHash,
+			MerkleRoot: hex.EncodeToString(header.MerkleRoot),
+			Timestamp:  header.Timestamp,
+			Nonce:      header.Nonce,
+			Difficulty: header.Difficulty,
+		},
+	}
+	for _, hash := range txProof.Proof.Branch {
+		proof.Branch = append(proof.Branch, hex.EncodeToString(hash))
+	}
+
+	return proof, nil
+}
+
+func (br *BlockchainRepo) GetAddressTransactions(ctx context.Context, address string, limit, offset int) ([]*entity.Transaction, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get address transactions repo")
+	defer span.Finish()
+	infos, err := br.chain.AddressTransactions(address, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]*entity.Transaction, 0, len(infos))
+	for _, info := range infos {
+		tx, err := br.toTransaction(info)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
+
+func (br *BlockchainRepo) toBlock(info *blockchainlogic.BlockInfo) (*entity.Block, error) {
+	block := &entity.Block{
+		Hash:          info.Block.Hash,
+		Height:        info.Height,
+		PrevHash:      info.Block.PrevHash,
+		MerkleRoot:    hex.EncodeToString(info.Block.MerkleRoot),
+		Timestamp:     info.Block.Timestamp,
+		Nonce:         info.Block.Nonce,
+		Difficulty:    info.Block.Difficulty,
+		Confirmations: info.Confirmations,
+	}
+
+	for _, tx := range info.Block.Transactions {
+		transaction, err := br.toTransaction(&blockchainlogic.TxInfo{
+			Tx:            tx,
+			Status:        blockchainlogic.TxStatusConfirmed,
+			BlockHash:     info.Block.Hash,
+			Height:        info.Height,
+			Timestamp:     info.Block.Timestamp,
+			Confirmations: info.Confirmations,
+		})
+		if err != nil {
+			return nil, err
+		}
+		block.Transactions = append(block.Transactions, *transaction)
+	}
+
+	return block, nil
+}
+
+// toTransaction decodes inputs and outputs, resolving the address and value
+// of every spent output.
+func (br *BlockchainRepo) toTransaction(info *blockchainlogic.TxInfo) (*entity.Transaction, error) {
+	tx := info.Tx
+	transaction := &entity.Transaction{
+		TxID:          hex.EncodeToString(tx.ID),
+		Status:        string(info.Status),
+		BlockHash:     info.BlockHash,
+		Confirmations: info.Confirmations,
+		Coinbase:      tx.IsCoinbase(),
+		LockTime:      tx.LockTime,
+		Inputs:        []entity.Input{},
+		Outputs:       []entity.Output{},
+	}
+	if info.Status == blockchainlogic.TxStatusConfirmed {
+		height, timestamp := info.Height, info.Timestamp
+		transaction.BlockHeight = &height
+		transaction.Timestamp = &timestamp
+	}
+
+	var inputs, outputs, fee blockchainlogic.Amount
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Vin {
+			prevTx, err := br.chain.FindTransaction(in.Txid)
+			if err != nil {
+				return nil, err
+			}
+			prevOut := prevTx.Vout[in.Vout]
+			inputs += prevOut.Value
+			transaction.Inputs = append(transaction.Inputs, entity.Input{
+				TxID:         hex.EncodeToString(in.Txid),
+				Vout:         in.Vout,
+				Address:      prevOut.Address(),
+				Value:        prevOut.Value.String(),
+				PubKey:       hex.EncodeToString(in.PubKey),
+				Signature:    hex.EncodeToString(in.Signature),
+				UnlockScript: hex.EncodeToString(in.UnlockScript),
+				Sequence:     in.Sequence,
+			})
+		}
+	}
+
+	for i, out := range tx.Vout {
+		outputs += out.Value
+		transaction.Outputs = append(transaction.Outputs, entity.Output{
+			Index:      i,
+			Value:      out.Value.String(),
+			Address:    out.Address(),
+			PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+			Script:     hex.EncodeToString(out.Script),
+		})
+	}
+	if !tx.IsCoinbase() {
+		fee = inputs - outputs
+	}
+	transaction.Fee = fee.String()
+
+	return transaction, nil
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+func (br *BlockchainRepo) GetWalletHistory(ctx context.Context, userID string, filter entity.HistoryFilter) (*entity.WalletHistory, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get wallet history repo")
+	defer span.Finish()
+	address, err := br.GetWallet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return br.walletHistory(address, filter)
+}
+
+// walletHistory returns the history of every address of the wallet owning
+// address.
+func (br *BlockchainRepo) walletHistory(address string, filter entity.HistoryFilter) (*entity.WalletHistory, error) {
+	addresses, err := br.chain.WalletAddresses(address)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, next, err := br.chain.History(addresses, blockchainlogic.HistoryFilter{
+		From:      filter.From,
+		To:        filter.To,
+		Direction: blockchainlogic.Direction(filter.Direction),
+		Cursor:    filter.Cursor,
+		Limit:     filter.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := &entity.WalletHistory{
+		Address: address,
+		Entries: make([]entity.HistoryEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		history.Entries = append(history.Entries, entity.HistoryEntry{
+			TxID:          e.TxID,
+			Direction:     string(e.Direction),
+			Counterparty:  e.Counterparty,
+			Amount:        e.Amount.String(),
+			Change:        e.Change.String(),
+			BlockHash:     e.BlockHash,
+			BlockHeight:   e.Height,
+			Timestamp:     e.Timestamp,
+			Confirmations: e.Confirmations,
+		})
+	}
+	if next != 0 {
+		history.NextCursor = strconv.FormatInt(next, 10)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	pb "github.com/damndelion/blockchain_justCode/pkg/protobuf/userService/gw"
+)
+
+// ExportKey returns the private key of a custodial wallet of the user,
+// encrypted with passphrase. address selects one of the addresses of an HD
+// wallet, the wallet address when empty. Every attempt is audited.
+func (br *BlockchainRepo) ExportKey(ctx context.Context, userID, wallet, address, format, passphrase string) (key *entity.ExportedKey, err error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "export key repo")
+	defer span.Finish()
+	if format == "" {
+		format = blockchainlogic.KeyFormatPEM
+	}
+	defer func() {
+		br.recordKeyEvent(blockchainlogic.KeyEvent{
+			UserID: userID, Address: address, Action: blockchainlogic.KeyActionExport,
+			Format: format, Source: blockchainlogic.KeySourceAPI, Err: err,
+		})
+	}()
+
+	selected, err := br.userWallet(spanCtx, userID, wallet)
+	if err != nil {
+		return nil, err
+	}
+	if !selected.Custodial {
+		return nil, entity.ErrNotCustodial
+	}
+	keys := br.chain.Keystore()
+	if address == "" {
+		address = selected.Address
+	}
+	group, err := keys.Group(selected.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(group, address) {
+		return nil, entity.ErrWalletNotFound
+	}
+
+	w, err := keys.Get(address)
+	if err != nil {
+		return nil, err
+	}
+	data, err := blockchainlogic.ExportKey(w, format, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ExportedKey{Address: address, Format: format, Key: string(data)}, nil
+}
+
+// ImportKey decrypts an exported key, stores it in the keystore and makes it
+// the default wallet of the user. Importing a key the user already holds
+// only makes it the default again; a key held for anyone else is refused.
+// Every attempt is audited.
+func (br *BlockchainRepo) ImportKey(ctx context.Context, userID string, data []byte, passphrase string) (address string, err error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "import key repo")
+	defer span.Finish()
+	format, _ := blockchainlogic.DetectKeyFormat(data)
+	defer func() {
+		br.recordKeyEvent(blockchainlogic.KeyEvent{
+			UserID: userID, Address: address, Action: blockchainlogic.KeyActionImport,
+			Format: format, Source: blockchainlogic.KeySourceAPI, Err: err,
+		})
+	}()
+
+	w, err := blockchainlogic.ImportKey(data, passphrase)
+	if err != nil {
+		return "", err
+	}
+	address = string(w.GetAddress())
+
+	user, err := br.userGrpcTransport.GetUserByID(spanCtx, userID)
+	if err != nil {
+		return "", err
+	}
+	if !user.Valid {
+		return "", errors.New("user is not valid")
+	}
+	wallets, err := br.userGrpcTransport.ListUserWallets(spanCtx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, uw := range wallets {
+		if uw.Address == address && !uw.Custodial {
+			return "", fmt.Errorf("%w: it is registered as a non-custodial wallet", entity.ErrKeyConflict)
+		}
+	}
+
+	keys := br.chain.Keystore()
+	_, err = keys.Get(address)
+	switch {
+	case err == nil:
+		var owned bool
+		owned, err = br.ownsAddress(address, wallets)
+		if err != nil {
+			return "", err
+		}
+		if !owned {
+			return "", entity.ErrKeyConflict
+		}
+	case errors.Is(err, blockchainlogic.ErrKeyNotFound):
+		// Store first, so the user never gets a default wallet without its
+		// key. The user service refuses addresses of other users, whose key
+		// must not stay stored.
+		err = keys.Put(w)
+		if errors.Is(err, blockchainlogic.ErrKeyExists) {
+			return "", entity.ErrKeyConflict
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err = br.userGrpcTransport.SetUserWallet(spanCtx, userID, address); err != nil {
+			if deleteErr := keys.Delete(address); deleteErr != nil {
+				return "", errors.Join(err, fmt.Errorf("remove key of %s: %w", address, deleteErr))
+			}
+
+			return "", err
+		}
+
+		return address, nil
+	default:
+		return "", err
+	}
+
+	if _, err = br.userGrpcTransport.SetUserWallet(spanCtx, userID, address); err != nil {
+		return "", err
+	}
+
+	return address, nil
+}
+
+// ownsAddress reports whether address is one of the custodial wallets, or an
+// address of the HD wallets, of the user.
+func (br *BlockchainRepo) ownsAddress(address string, wallets []*pb.UserWallet) (bool, error) {
+	for _, w := range wallets {
+		if !w.Custodial {
+			continue
+		}
+		if w.Address == address {
+			return true, nil
+		}
+		group, err := br.chain.Keystore().Group(w.Address)
+		if errors.Is(err, blockchainlogic.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if slices.Contains(group, address) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (br *BlockchainRepo) recordKeyEvent(e blockchainlogic.KeyEvent) {
+	if err := blockchainlogic.RecordKeyEvent(br.DB, e); err != nil {
+		log.Printf("record key %s event of %s: %v", e.Action, e.Address, err)
+	}
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+func (br *BlockchainRepo) BuildTransaction(ctx context.Context, userID, wallet, to string, amount, fee blockchainlogic.Amount) (*entity.TxTemplate, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "build transaction repo")
+	defer span.Finish()
+	address, err := br.GetWalletAddress(spanCtx, userID, wallet)
+	if err != nil {
+		return nil, err
+	}
+	template, err := br.chain.BuildTransaction(address, to, amount, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := template.Tx
+	result := &entity.TxTemplate{
+		Tx:      hex.EncodeToString(tx.Serialize()),
+		Fee:     template.Fee.String(),
+		Inputs:  make([]entity.TemplateInput, 0, len(tx.Vin)),
+		Outputs: make([]entity.Output, 0, len(tx.Vout)),
+	}
+	for i, in := range tx.Vin {
+		result.Inputs = append(result.Inputs, entity.TemplateInput{
+			TxID:    hex.EncodeToString(in.Txid),
+			Vout:    in.Vout,
+			Address: template.Inputs[i].Address,
+			Value:   template.Inputs[i].Value.String(),
+			SigHash: hex.EncodeToString(template.Inputs[i].SigHash),
+		})
+	}
+	for i, out := range tx.Vout {
+		result.Outputs = append(result.Outputs, entity.Output{
+			Index:      i,
+			Value:      out.Value.String(),
+			Address:    out.Address(),
+			PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+			Script:     hex.EncodeToString(out.Script),
+		})
+	}
+
+	return result, nil
+}
+
+func (br *BlockchainRepo) SubmitRawTransaction(ctx context.Context, raw []byte) (string, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "submit raw transaction repo")
+	defer span.Finish()
+	tx, err := blockchainlogic.DeserializeTransaction(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", blockchainlogic.ErrInvalidTransaction, err)
+	}
+
+	return br.chain.SubmitTransaction(tx)
+}
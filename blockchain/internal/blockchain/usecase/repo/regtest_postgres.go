@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+func (br *BlockchainRepo) GenerateBlocks(ctx context.Context, n int) ([]string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "generate blocks repo")
+	defer span.Finish()
+
+	return br.chain.GenerateBlocks(spanCtx, n)
+}
+
+func (br *BlockchainRepo) Fund(ctx context.Context, address string, amount blockchainlogic.Amount) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "fund repo")
+	defer span.Finish()
+
+	return br.chain.Fund(spanCtx, address, amount)
+}
+
+func (br *BlockchainRepo) ResetChain(ctx context.Context) (string, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "reset chain repo")
+	defer span.Finish()
+
+	genesis, err := br.chain.ResetRegtest()
+	if err != nil {
+		return "", err
+	}
+
+	return genesis.Hash, nil
+}
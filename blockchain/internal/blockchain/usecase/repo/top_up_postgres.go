@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+const topUpColumns = "id, user_id, amount, status, tx_id, reason, decided_by, created_at, decided_at"
+
+// IsUserValid reports whether the user passed KYC.
+func (br *BlockchainRepo) IsUserValid(ctx context.Context, userID string) (bool, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "is user valid repo")
+	defer span.Finish()
+	user, err := br.userGrpcTransport.GetUserByID(spanCtx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.Valid, nil
+}
+
+// CreateTopUpWithinLimits records a top up of the user with the status and
+// decision that decide takes given the amounts of the pending, paying and
+// approved top ups of the user since the given time and overall. The totals
+// are read and the top up inserted in one database transaction holding an
+// advisory lock on the user, so concurrent top ups, on any instance, are
+// decided one after the other.
+func (br *BlockchainRepo) CreateTopUpWithinLimits(ctx context.Context, userID string, amount blockchainlogic.Amount, since time.Time,
+	decide func(recent, lifetime blockchainlogic.Amount) (string, entity.TopUpDecision),
+) (*entity.TopUp, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "create top up within limits repo")
+	defer span.Finish()
+	dbTx, err := br.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+
+	_, err = dbTx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('top_ups'), hashtext($1))", userID)
+	if err != nil {
+		return nil, err
+	}
+	var recent, lifetime blockchainlogic.Amount
+	err = dbTx.QueryRowContext(ctx, `SELECT
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(amount), 0)
+		FROM top_ups WHERE user_id = $1 AND status IN ($3, $4, $5)`,
+		userID, since.UTC(), entity.TopUpPending, entity.TopUpPaying, entity.TopUpApproved).Scan(&recent, &lifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	status, decision := decide(recent, lifetime)
+	topUp, err := insertTopUp(ctx, dbTx, userID, amount, status, "", decision)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp, dbTx.Commit()
+}
+
+// CreateTopUp records a top up with the decision the policy took on it.
+func (br *BlockchainRepo) CreateTopUp(ctx context.Context, userID string, amount blockchainlogic.Amount, status, txID string, decision entity.TopUpDecision) (*entity.TopUp, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "create top up repo")
+	defer span.Finish()
+	dbTx, err := br.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+
+	topUp, err := insertTopUp(ctx, dbTx, userID, amount, status, txID, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp, dbTx.Commit()
+}
+
+// insertTopUp records a top up and its decision in dbTx.
+func insertTopUp(ctx context.Context, dbTx *sql.Tx, userID string, amount blockchainlogic.Amount, status, txID string, decision entity.TopUpDecision) (*entity.TopUp, error) {
+	topUp, err := scanTopUp(dbTx.QueryRowContext(ctx, `INSERT INTO top_ups (user_id, amount, status, tx_id, reason, decided_by, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING `+topUpColumns,
+		userID, amount, status, txID, decision.Reason, decision.Actor))
+	if err != nil {
+		return nil, err
+	}
+	err = recordTopUpDecision(ctx, dbTx, topUp.ID, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp, nil
+}
+
+// DecideTopUp moves a pending top up to status and records the decision. It
+// returns ErrTopUpNotPending for top ups already decided on.
+func (br *BlockchainRepo) DecideTopUp(ctx context.Context, id int64, status, txID string, decision entity.TopUpDecision) (*entity.TopUp, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "decide top up repo")
+	defer span.Finish()
+	dbTx, err := br.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+
+	topUp, err := scanTopUp(dbTx.QueryRowContext(ctx, `UPDATE top_ups
+		SET status = $2, tx_id = $3, reason = $4, decided_by = $5, decided_at = NOW()
+		WHERE id = $1 AND status = $6 RETURNING `+topUpColumns,
+		id, status, txID, decision.Reason, decision.Actor, entity.TopUpPending))
+	if errors.Is(err, entity.ErrTopUpNotFound) {
+		_, err = br.GetTopUp(ctx, id)
+		if err == nil {
+			err = entity.ErrTopUpNotPending
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = recordTopUpDecision(ctx, dbTx, id, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp, dbTx.Commit()
+}
+
+// SettleTopUp moves a paying top up to status, approved with the ID of the
+// transaction paying it or failed with the reason. It returns
+// ErrTopUpNotPending for top ups that are not being paid.
+func (br *BlockchainRepo) SettleTopUp(ctx context.Context, id int64, status, txID, reason string) (*entity.TopUp, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "settle top up repo")
+	defer span.Finish()
+
+	topUp, err := scanTopUp(br.QueryRowContext(ctx, `UPDATE top_ups SET status = $2, tx_id = $3, reason = $4
+		WHERE id = $1 AND status = $5 RETURNING `+topUpColumns,
+		id, status, txID, reason, entity.TopUpPaying))
+	if errors.Is(err, entity.ErrTopUpNotFound) {
+		_, err = br.GetTopUp(ctx, id)
+		if err == nil {
+			err = entity.ErrTopUpNotPending
+		}
+	}
+
+	return topUp, err
+}
+
+func (br *BlockchainRepo) GetTopUp(ctx context.Context, id int64) (*entity.TopUp, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get top up repo")
+	defer span.Finish()
+
+	return scanTopUp(br.QueryRowContext(ctx, "SELECT "+topUpColumns+" FROM top_ups WHERE id = $1", id))
+}
+
+// GetTopUps returns the top ups with the given status, oldest first.
+func (br *BlockchainRepo) GetTopUps(ctx context.Context, status string, limit, offset int) ([]*entity.TopUp, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get top ups repo")
+	defer span.Finish()
+	rows, err := br.QueryContext(ctx, "SELECT "+topUpColumns+" FROM top_ups WHERE status = $1 ORDER BY id LIMIT $2 OFFSET $3", status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topUps := make([]*entity.TopUp, 0, limit)
+	for rows.Next() {
+		topUp, err := scanTopUp(rows)
+		if err != nil {
+			return nil, err
+		}
+		topUps = append(topUps, topUp)
+	}
+
+	return topUps, rows.Err()
+}
+
+type topUpScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTopUp(row topUpScanner) (*entity.TopUp, error) {
+	var topUp entity.TopUp
+	var amount blockchainlogic.Amount
+	var decidedAt sql.NullTime
+	err := row.Scan(&topUp.ID, &topUp.UserID, &amount, &topUp.Status, &topUp.TxID, &topUp.Reason, &topUp.DecidedBy, &topUp.CreatedAt, &decidedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrTopUpNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	topUp.Amount = amount.String()
+	if decidedAt.Valid {
+		topUp.DecidedAt = &decidedAt.Time
+	}
+
+	return &topUp, nil
+}
+
+func recordTopUpDecision(ctx context.Context, dbTx *sql.Tx, id int64, decision entity.TopUpDecision) error {
+	_, err := dbTx.ExecContext(ctx, "INSERT INTO top_up_decisions (top_up_id, decision, actor, reason) VALUES ($1, $2, $3, $4)",
+		id, decision.Decision, decision.Actor, decision.Reason)
+
+	return err
+}
@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+// GetTreasury returns the balance of the treasury at address and a page of
+// its outgoing transactions, newest first.
+func (br *BlockchainRepo) GetTreasury(ctx context.Context, address string, filter entity.HistoryFilter) (*entity.Treasury, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "get treasury repo")
+	defer span.Finish()
+
+	balance, err := br.chain.GetWalletBalance(address)
+	if err != nil {
+		return nil, err
+	}
+	filter.Direction = string(blockchainlogic.DirectionOutgoing)
+	history, err := br.walletHistory(address, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Treasury{
+		Address:    address,
+		Supply:     blockchainlogic.GenesisSupply().String(),
+		Balance:    balance.String(),
+		Outflows:   history.Entries,
+		NextCursor: history.NextCursor,
+	}, nil
+}
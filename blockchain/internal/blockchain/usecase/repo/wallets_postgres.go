@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	pb "github.com/damndelion/blockchain_justCode/pkg/protobuf/userService/gw"
+)
+
+// GetWalletAddress returns the address of the user wallet selected by wallet,
+// which is either the address or the label of the wallet. An empty selector
+// selects the default wallet.
+func (br *BlockchainRepo) GetWalletAddress(ctx context.Context, userID, wallet string) (string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get wallet address repo")
+	defer span.Finish()
+	if wallet == "" {
+		return br.GetWallet(spanCtx, userID)
+	}
+
+	selected, err := br.userWallet(spanCtx, userID, wallet)
+	if err != nil {
+		return "", err
+	}
+
+	return selected.Address, nil
+}
+
+// userWallet returns the user wallet selected by wallet, the default one when
+// it is empty.
+func (br *BlockchainRepo) userWallet(ctx context.Context, userID, wallet string) (*pb.UserWallet, error) {
+	wallets, err := br.userGrpcTransport.ListUserWallets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range wallets {
+		if (wallet == "" && w.IsDefault) || (wallet != "" && (w.Address == wallet || w.Label == wallet)) {
+			return w, nil
+		}
+	}
+	if wallet == "" {
+		// Users.wallet set before user wallets were recorded.
+		address, err := br.GetWallet(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pb.UserWallet{Address: address, IsDefault: true, Custodial: true}, nil
+	}
+
+	return nil, entity.ErrWalletNotFound
+}
+
+// AddExternalWallet records a non-custodial wallet of the user. Its key stays
+// with the user, who signs the transactions built for it.
+func (br *BlockchainRepo) AddExternalWallet(ctx context.Context, userID, address, label string, isDefault bool) (*entity.UserWallet, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "add external wallet repo")
+	defer span.Finish()
+	if !blockchainlogic.ValidateAddress(address) {
+		return nil, errors.New("address is not valid")
+	}
+	_, err := br.chain.Keystore().Get(address)
+	if err == nil {
+		return nil, entity.ErrCustodialAddress
+	}
+	if !errors.Is(err, blockchainlogic.ErrKeyNotFound) {
+		return nil, err
+	}
+	user, err := br.userGrpcTransport.GetUserByID(spanCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Valid {
+		return nil, errors.New("user is not valid")
+	}
+
+	wallet, err := br.userGrpcTransport.AddUserWallet(spanCtx, userID, address, label, isDefault, false)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := br.chain.GetWalletBalance(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserWallet(wallet, balance), nil
+}
+
+func (br *BlockchainRepo) GetWallets(ctx context.Context, userID string) ([]*entity.UserWallet, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get wallets repo")
+	defer span.Finish()
+	wallets, _, err := br.userWallets(spanCtx, userID)
+
+	return wallets, err
+}
+
+func (br *BlockchainRepo) GetTotalBalance(ctx context.Context, userID string) (*entity.TotalBalance, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get total balance repo")
+	defer span.Finish()
+	wallets, total, err := br.userWallets(spanCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TotalBalance{Total: total.String(), Wallets: wallets}, nil
+}
+
+// GetUserAddresses returns every address of the wallets of the user, the
+// receive and change addresses of HD wallets included.
+func (br *BlockchainRepo) GetUserAddresses(ctx context.Context, userID string) ([]string, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "get user addresses repo")
+	defer span.Finish()
+	list, err := br.userGrpcTransport.ListUserWallets(spanCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, w := range list {
+		group, err := br.chain.WalletAddresses(w.Address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, group...)
+	}
+
+	return addresses, nil
+}
+
+// userWallets returns the wallets of the user with their balances, and the
+// sum of the balances.
+func (br *BlockchainRepo) userWallets(ctx context.Context, userID string) ([]*entity.UserWallet, blockchainlogic.Amount, error) {
+	list, err := br.userGrpcTransport.ListUserWallets(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total blockchainlogic.Amount
+	wallets := make([]*entity.UserWallet, 0, len(list))
+	for _, w := range list {
+		balance, err := br.chain.GetWalletBalance(w.Address)
+		if err != nil {
+			return nil, 0, err
+		}
+		total += balance
+		wallets = append(wallets, toUserWallet(w, balance))
+	}
+
+	return wallets, total, nil
+}
+
+func toUserWallet(w *pb.UserWallet, balance blockchainlogic.Amount) *entity.UserWallet {
+	return &entity.UserWallet{
+		Address:   w.Address,
+		Label:     w.Label,
+		Default:   w.IsDefault,
+		Custodial: w.Custodial,
+		CreatedAt: time.Unix(w.CreatedAt, 0).UTC(),
+		Balance:   balance.String(),
+	}
+}
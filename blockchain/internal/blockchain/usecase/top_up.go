@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+// TopUpPolicy limits the top ups paid by the treasury. Zero limits and
+// threshold are off.
+type TopUpPolicy struct {
+	// DailyLimit caps the top ups of a user over the last 24 hours.
+	DailyLimit    blockchainlogic.Amount
+	LifetimeLimit blockchainlogic.Amount
+	// ApprovalThreshold holds larger top ups for an admin to approve.
+	ApprovalThreshold blockchainlogic.Amount
+	// RequireKYC refuses top ups to users who are not valid.
+	RequireKYC bool
+}
+
+// NewTopUpPolicy parses the top up policy of the config.
+func NewTopUpPolicy(cfg blockchain.TopUp) (TopUpPolicy, error) {
+	p := TopUpPolicy{RequireKYC: cfg.RequireKYC}
+	for _, limit := range []struct {
+		name  string
+		value string
+		dst   *blockchainlogic.Amount
+	}{
+		{"daily limit", cfg.DailyLimit, &p.DailyLimit},
+		{"lifetime limit", cfg.LifetimeLimit, &p.LifetimeLimit},
+		{"approval threshold", cfg.ApprovalThreshold, &p.ApprovalThreshold},
+	} {
+		amount, err := blockchainlogic.ParseAmount(limit.value)
+		if err != nil || amount < 0 {
+			return TopUpPolicy{}, fmt.Errorf("top up %s %q is not valid", limit.name, limit.value)
+		}
+		*limit.dst = amount
+	}
+
+	return p, nil
+}
+
+// SetTopUpPolicy sets the policy applied to top ups.
+func (b *Blockchain) SetTopUpPolicy(p TopUpPolicy) {
+	b.topUps.Lock()
+	defer b.topUps.Unlock()
+	b.topUpPolicy = p
+}
+
+// TopUp pays amount from the treasury to the default wallet of the user to,
+// within the top up policy. Top ups above the approval threshold are
+// returned pending an admin decision. Refused top ups are recorded as denied
+// and return ErrKYCRequired or ErrTopUpLimit. Paid top ups are recorded
+// before the payment, so they count towards the limits even when it can not
+// be settled.
+func (b *Blockchain) TopUp(ctx context.Context, to string, amount blockchainlogic.Amount) (*entity.TopUp, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "top up use case")
+	defer span.Finish()
+	b.topUps.Lock()
+	defer b.topUps.Unlock()
+
+	policy := b.topUpPolicy
+	if policy.RequireKYC {
+		valid, err := b.repo.IsUserValid(spanCtx, to)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, b.denyTopUp(spanCtx, to, amount, entity.ErrKYCRequired)
+		}
+	}
+
+	// The limits are checked against the totals the repo reads in the
+	// transaction recording the top up.
+	var refused error
+	topUp, err := b.repo.CreateTopUpWithinLimits(spanCtx, to, amount, time.Now().Add(-24*time.Hour),
+		func(recent, lifetime blockchainlogic.Amount) (string, entity.TopUpDecision) {
+			refused = nil
+			switch {
+			case policy.DailyLimit > 0 && recent+amount > policy.DailyLimit:
+				refused = fmt.Errorf("%w: %s of the daily %s used", entity.ErrTopUpLimit, recent, policy.DailyLimit)
+			case policy.LifetimeLimit > 0 && lifetime+amount > policy.LifetimeLimit:
+				refused = fmt.Errorf("%w: %s of the lifetime %s used", entity.ErrTopUpLimit, lifetime, policy.LifetimeLimit)
+			case policy.ApprovalThreshold > 0 && amount > policy.ApprovalThreshold:
+				return entity.TopUpPending, entity.TopUpDecision{
+					Decision: entity.DecisionHeld,
+					Actor:    entity.DecisionActorPolicy,
+					Reason:   fmt.Sprintf("above the approval threshold %s", policy.ApprovalThreshold),
+				}
+			default:
+				return entity.TopUpPaying, entity.TopUpDecision{
+					Decision: entity.DecisionApproved,
+					Actor:    entity.DecisionActorPolicy,
+				}
+			}
+
+			return entity.TopUpDenied, entity.TopUpDecision{
+				Decision: entity.DecisionDenied,
+				Actor:    entity.DecisionActorPolicy,
+				Reason:   refused.Error(),
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	if refused != nil {
+		return nil, refused
+	}
+	if topUp.Status != entity.TopUpPaying {
+		return topUp, nil
+	}
+
+	return b.payTopUp(spanCtx, topUp, amount)
+}
+
+// denyTopUp records a top up refused by the policy and returns reason.
+func (b *Blockchain) denyTopUp(ctx context.Context, to string, amount blockchainlogic.Amount, reason error) error {
+	_, err := b.repo.CreateTopUp(ctx, to, amount, entity.TopUpDenied, "", entity.TopUpDecision{
+		Decision: entity.DecisionDenied,
+		Actor:    entity.DecisionActorPolicy,
+		Reason:   reason.Error(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return reason
+}
+
+// payTopUp sends amount from the treasury to the user of a top up claimed
+// as paying, and settles it as approved or, when the payment fails, as
+// failed. A top up whose payment can not be settled stays paying, so it is
+// never paid twice. The repo is called in place, its wait group is only
+// marked done.
+func (b *Blockchain) payTopUp(ctx context.Context, topUp *entity.TopUp, amount blockchainlogic.Amount) (*entity.TopUp, error) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	txID, err := b.repo.TopUp(ctx, b.cfg.Genesis.Recipient, topUp.UserID, amount, &wg)
+	if err != nil {
+		_, settleErr := b.repo.SettleTopUp(ctx, topUp.ID, entity.TopUpFailed, "", err.Error())
+
+		return nil, errors.Join(err, settleErr)
+	}
+	settled, err := b.repo.SettleTopUp(ctx, topUp.ID, entity.TopUpApproved, txID, "")
+	if err != nil {
+		return nil, fmt.Errorf("top up %d paid by %s: %w", topUp.ID, txID, err)
+	}
+
+	return settled, nil
+}
+
+// TopUps returns the top ups with the given status, oldest first.
+func (b *Blockchain) TopUps(ctx context.Context, status string, limit, offset int) ([]*entity.TopUp, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "top ups use case")
+	defer span.Finish()
+
+	return b.repo.GetTopUps(spanCtx, status, limit, offset)
+}
+
+// ApproveTopUp pays a pending top up on behalf of the admin. The top up is
+// claimed before it is paid, so of concurrent approvals, on any instance,
+// one pays it and the others get ErrTopUpNotPending.
+func (b *Blockchain) ApproveTopUp(ctx context.Context, id int64, adminID string) (*entity.TopUp, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "approve top up use case")
+	defer span.Finish()
+	b.topUps.Lock()
+	defer b.topUps.Unlock()
+
+	topUp, err := b.repo.GetTopUp(spanCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if topUp.Status != entity.TopUpPending {
+		return nil, entity.ErrTopUpNotPending
+	}
+	amount, err := blockchainlogic.ParseAmount(topUp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := b.repo.DecideTopUp(spanCtx, id, entity.TopUpPaying, "", entity.TopUpDecision{
+		Decision: entity.DecisionApproved,
+		Actor:    adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.payTopUp(spanCtx, claimed, amount)
+}
+
+// RejectTopUp refuses a pending top up on behalf of the admin.
+func (b *Blockchain) RejectTopUp(ctx context.Context, id int64, adminID, reason string) (*entity.TopUp, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "reject top up use case")
+	defer span.Finish()
+	b.topUps.Lock()
+	defer b.topUps.Unlock()
+
+	return b.repo.DecideTopUp(spanCtx, id, entity.TopUpRejected, "", entity.TopUpDecision{
+		Decision: entity.DecisionRejected,
+		Actor:    adminID,
+		Reason:   reason,
+	})
+}
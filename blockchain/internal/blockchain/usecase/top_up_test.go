@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/damndelion/blockchain_justCode/config/blockchain"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/entity"
+	"github.com/damndelion/blockchain_justCode/internal/blockchain/mocks"
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+const treasury = "1Pq4qTbgTH4KhmFiPQ91YXVyyK5oo6aX1G"
+
+func newTopUpUseCase(repoMock *mocks.ChainRepo) *Blockchain {
+	cfg := &blockchain.Config{}
+	cfg.Genesis.Recipient = treasury
+	b := NewBlockchain(repoMock, cfg, nil)
+	b.SetTopUpPolicy(TopUpPolicy{
+		DailyLimit:        100 * blockchainlogic.Coin(),
+		LifetimeLimit:     1000 * blockchainlogic.Coin(),
+		ApprovalThreshold: 50 * blockchainlogic.Coin(),
+		RequireKYC:        true,
+	})
+
+	return b
+}
+
+// expectPayment makes the mocked repo pay amount to the user, failing with
+// err when it is set, and settle top up id.
+func expectPayment(repoMock *mocks.ChainRepo, id int64, userID string, amount blockchainlogic.Amount, err error) {
+	txID, status := "9a1c", entity.TopUpApproved
+	if err != nil {
+		txID, status = "", entity.TopUpFailed
+	}
+	repoMock.On("TopUp", mock.Anything, treasury, userID, amount, mock.Anything).
+		Run(func(args mock.Arguments) { args.Get(4).(*sync.WaitGroup).Done() }).
+		Return(txID, err)
+	repoMock.On("SettleTopUp", mock.Anything, id, status, txID, mock.Anything).
+		Return(&entity.TopUp{ID: id, UserID: userID, Status: status, TxID: txID}, nil)
+}
+
+func decision(d, actor string) any {
+	return mock.MatchedBy(func(got entity.TopUpDecision) bool { return got.Decision == d && got.Actor == actor })
+}
+
+func TestBlockchain_TopUpPolicy(t *testing.T) {
+	coin := blockchainlogic.Coin()
+	payErr := errors.New("not enough funds")
+	tests := []struct {
+		name     string
+		valid    bool
+		recent   blockchainlogic.Amount
+		lifetime blockchainlogic.Amount
+		amount   blockchainlogic.Amount
+		// status is the one recorded before any payment, want the one
+		// returned.
+		status  string
+		want    string
+		payErr  error
+		wantErr error
+	}{
+		{name: "Paid", valid: true, amount: 10 * coin, status: entity.TopUpPaying, want: entity.TopUpApproved},
+		{name: "Payment failed", valid: true, amount: 10 * coin, status: entity.TopUpPaying, payErr: payErr, wantErr: payErr},
+		{name: "Held above the threshold", valid: true, amount: 60 * coin, status: entity.TopUpPending},
+		{name: "Not verified", amount: 10 * coin, status: entity.TopUpDenied, wantErr: entity.ErrKYCRequired},
+		{name: "Daily limit", valid: true, recent: 95 * coin, lifetime: 95 * coin, amount: 10 * coin, status: entity.TopUpDenied, wantErr: entity.ErrTopUpLimit},
+		{name: "Lifetime limit", valid: true, lifetime: 995 * coin, amount: 10 * coin, status: entity.TopUpDenied, wantErr: entity.ErrTopUpLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := &mocks.ChainRepo{}
+			repoMock.On("IsUserValid", mock.Anything, "7").Return(tt.valid, nil)
+			// The repo decides on the top up with the totals it reads.
+			var status string
+			repoMock.On("CreateTopUpWithinLimits", mock.Anything, "7", tt.amount, mock.Anything, mock.Anything).
+				Return(func(_ context.Context, userID string, _ blockchainlogic.Amount, _ time.Time,
+					decide func(recent, lifetime blockchainlogic.Amount) (string, entity.TopUpDecision),
+				) (*entity.TopUp, error) {
+					status, _ = decide(tt.recent, tt.lifetime)
+
+					return &entity.TopUp{ID: 1, UserID: userID, Status: status}, nil
+				})
+			if tt.status == entity.TopUpPaying {
+				expectPayment(repoMock, 1, "7", tt.amount, tt.payErr)
+			}
+			repoMock.On("CreateTopUp", mock.Anything, "7", tt.amount, entity.TopUpDenied, mock.Anything, mock.Anything).
+				Return(&entity.TopUp{ID: 1, UserID: "7", Status: entity.TopUpDenied}, nil)
+
+			topUp, err := newTopUpUseCase(repoMock).TopUp(context.Background(), "7", tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TopUp() error = %v, want %v", err, tt.wantErr)
+			}
+			want := tt.want
+			if want == "" {
+				want = tt.status
+			}
+			if err == nil && topUp.Status != want {
+				t.Errorf("TopUp() status = %s, want %s", topUp.Status, want)
+			}
+			// Every outcome is recorded, denials included.
+			if tt.valid && status != tt.status {
+				t.Errorf("TopUp() recorded status %s, want %s", status, tt.status)
+			}
+			if !tt.valid {
+				repoMock.AssertCalled(t, "CreateTopUp", mock.Anything, "7", tt.amount, entity.TopUpDenied, mock.Anything, mock.Anything)
+			}
+			if tt.status == entity.TopUpPaying {
+				repoMock.AssertNumberOfCalls(t, "SettleTopUp", 1)
+			} else {
+				repoMock.AssertNotCalled(t, "TopUp", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestBlockchain_DecideTopUp(t *testing.T) {
+	amount := 60 * blockchainlogic.Coin()
+	pending := &entity.TopUp{ID: 3, UserID: "7", Amount: amount.String(), Status: entity.TopUpPending}
+
+	repoMock := &mocks.ChainRepo{}
+	repoMock.On("GetTopUp", mock.Anything, int64(3)).Return(pending, nil)
+	// The top up is claimed before it is paid.
+	repoMock.On("DecideTopUp", mock.Anything, int64(3), entity.TopUpPaying, "", decision(entity.DecisionApproved, "1")).
+		Return(&entity.TopUp{ID: 3, UserID: "7", Status: entity.TopUpPaying}, nil)
+	expectPayment(repoMock, 3, "7", amount, nil)
+	b := newTopUpUseCase(repoMock)
+
+	topUp, err := b.ApproveTopUp(context.Background(), 3, "1")
+	if err != nil || topUp.Status != entity.TopUpApproved || topUp.TxID != "9a1c" {
+		t.Fatalf("ApproveTopUp() = %+v, %v, want paid", topUp, err)
+	}
+
+	// Another instance claimed it first.
+	claimed := &entity.TopUp{ID: 6, UserID: "7", Amount: amount.String(), Status: entity.TopUpPending}
+	repoMock.On("GetTopUp", mock.Anything, int64(6)).Return(claimed, nil)
+	repoMock.On("DecideTopUp", mock.Anything, int64(6), entity.TopUpPaying, "", mock.Anything).Return(nil, entity.ErrTopUpNotPending)
+	if _, err = b.ApproveTopUp(context.Background(), 6, "1"); !errors.Is(err, entity.ErrTopUpNotPending) {
+		t.Errorf("ApproveTopUp() of a claimed top up error = %v, want %v", err, entity.ErrTopUpNotPending)
+	}
+
+	decided := &entity.TopUp{ID: 4, UserID: "7", Amount: amount.String(), Status: entity.TopUpRejected}
+	repoMock.On("GetTopUp", mock.Anything, int64(4)).Return(decided, nil)
+	if _, err = b.ApproveTopUp(context.Background(), 4, "1"); !errors.Is(err, entity.ErrTopUpNotPending) {
+		t.Errorf("ApproveTopUp() of a rejected top up error = %v, want %v", err, entity.ErrTopUpNotPending)
+	}
+
+	repoMock.On("DecideTopUp", mock.Anything, int64(5), entity.TopUpRejected, "", decision(entity.DecisionRejected, "1")).
+		Return(&entity.TopUp{ID: 5, Status: entity.TopUpRejected}, nil)
+	if topUp, err = b.RejectTopUp(context.Background(), 5, "1", "duplicate"); err != nil || topUp.Status != entity.TopUpRejected {
+		t.Errorf("RejectTopUp() = %+v, %v, want rejected", topUp, err)
+	}
+	repoMock.AssertNumberOfCalls(t, "TopUp", 1)
+}
+
+func TestNewTopUpPolicy(t *testing.T) {
+	p, err := NewTopUpPolicy(blockchain.TopUp{DailyLimit: "100", LifetimeLimit: "0", ApprovalThreshold: "12.5", RequireKYC: true})
+	if err != nil || p.DailyLimit != 100*blockchainlogic.Coin() || p.LifetimeLimit != 0 || !p.RequireKYC {
+		t.Errorf("NewTopUpPolicy() = %+v, %v", p, err)
+	}
+	if _, err = NewTopUpPolicy(blockchain.TopUp{DailyLimit: "lots", LifetimeLimit: "0", ApprovalThreshold: "0"}); err == nil {
+		t.Errorf("NewTopUpPolicy() with a bad limit error = nil, want an error")
+	}
+}
@@ -65,6 +65,10 @@ func Run(cfg *user.Config) {
 	if err != nil {
 		l.Error(err)
 	}
+	err = db.AutoMigrate(&userEntity.UserWallet{})
+	if err != nil {
+		l.Error(err)
+	}
 
 	handler := gin.New()
 	v1.NewUserRouter(handler, l, userUseCase, cfg)
@@ -2,13 +2,17 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/damndelion/blockchain_justCode/internal/user/controller/http/v1/dto"
+	userEntity "github.com/damndelion/blockchain_justCode/internal/user/entity"
 	"github.com/damndelion/blockchain_justCode/internal/user/usecase/repo"
 	"github.com/damndelion/blockchain_justCode/pkg/logger"
 	pb "github.com/damndelion/blockchain_justCode/pkg/protobuf/userService/gw"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Service struct {
@@ -106,10 +110,70 @@ func (s *Service) SetUserWallet(ctx context.Context, request *pb.SetUserWalletRe
 			ErrorMessage: fmt.Sprintf("SetUserWallet err: %v", err),
 		}
 
-		return grpcErr, err
+		return grpcErr, walletStatus(err)
 	}
 
 	return &pb.SetUserWalletResponse{
 		ErrorMessage: "",
 	}, err
 }
+
+func (s *Service) ListUserWallets(ctx context.Context, request *pb.ListUserWalletsRequest) (*pb.ListUserWalletsResponse, error) {
+	id, err := strconv.Atoi(request.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("ListUserWallets err: %w", err)
+	}
+	wallets, err := s.repo.GetUserWallets(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to ListUserWallets err: %v", err)
+
+		return nil, fmt.Errorf("ListUserWallets err: %w", err)
+	}
+
+	resp := &pb.ListUserWalletsResponse{Wallets: make([]*pb.UserWallet, 0, len(wallets))}
+	for _, wallet := range wallets {
+		resp.Wallets = append(resp.Wallets, &pb.UserWallet{
+			Address:   wallet.Address,
+			Label:     wallet.Label,
+			IsDefault: wallet.IsDefault,
+			CreatedAt: wallet.CreatedAt.Unix(),
+			Custodial: wallet.Custodial,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *Service) AddUserWallet(ctx context.Context, request *pb.AddUserWalletRequest) (*pb.UserWallet, error) {
+	id, err := strconv.Atoi(request.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("AddUserWallet err: %w", err)
+	}
+	wallet, err := s.repo.AddUserWallet(ctx, id, request.Address, request.Label, request.IsDefault, request.Custodial)
+	if err != nil {
+		s.logger.Error("failed to AddUserWallet err: %v", err)
+
+		return nil, walletStatus(fmt.Errorf("AddUserWallet err: %w", err))
+	}
+
+	return &pb.UserWallet{
+		Address:   wallet.Address,
+		Label:     wallet.Label,
+		IsDefault: wallet.IsDefault,
+		CreatedAt: wallet.CreatedAt.Unix(),
+		Custodial: wallet.Custodial,
+	}, nil
+}
+
+// walletStatus gives a taken wallet label or address the AlreadyExists code,
+// with the message of the error, so clients can tell them from other
+// failures and from each other.
+func walletStatus(err error) error {
+	for _, taken := range []error{userEntity.ErrWalletLabelTaken, userEntity.ErrWalletAddressTaken} {
+		if errors.Is(err, taken) {
+			return status.Error(codes.AlreadyExists, taken.Error())
+		}
+	}
+
+	return err
+}
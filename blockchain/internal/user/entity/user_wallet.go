@@ -0,0 +1,28 @@
+package userentity
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrWalletLabelTaken is returned when a user already has a wallet with the
+// label.
+var ErrWalletLabelTaken = errors.New("wallet label is already used")
+
+// ErrWalletAddressTaken is returned when the address is already a wallet of
+// a user.
+var ErrWalletAddressTaken = errors.New("wallet address is already used")
+
+// UserWallet is a row of user_wallets. The gorm tags follow the migrations,
+// so AutoMigrate keeps the constraints of their table and gives a new one
+// the same unique constraints.
+type UserWallet struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id" gorm:"not null;uniqueIndex:user_wallets_user_id_label_key,priority:1"`
+	Address   string `json:"address" gorm:"size:255;not null;unique"`
+	Label     string `json:"label" gorm:"size:64;not null;uniqueIndex:user_wallets_user_id_label_key,priority:2"`
+	IsDefault bool   `json:"is_default" gorm:"not null"`
+	// Custodial wallets have their keys held by the blockchain service.
+	Custodial bool      `json:"custodial" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+}
@@ -55,6 +55,8 @@ type (
 		CreateUser(ctx context.Context, user dto.UserCreateRequest) (int, error)
 		UpdateUser(ctx context.Context, userData dto.UserUpdateRequest, email string) error
 		SetUserWallet(ctx context.Context, userID, address string) error
+		GetUserWallets(ctx context.Context, userID int) ([]*userEntity.UserWallet, error)
+		AddUserWallet(ctx context.Context, userID int, address, label string, isDefault, custodial bool) (*userEntity.UserWallet, error)
 		DeleteUser(ctx context.Context, id int) error
 
 		GetUsersDetailsInfo(ctx context.Context) (usersInfo []*userEntity.UserInfo, err error)
@@ -42,17 +42,13 @@ func (ur *UserRepo) CreateUser(ctx context.Context, userRequest dto.UserCreateRe
 	if res.Error != nil {
 		return 0, res.Error
 	}
-
-	return user.ID, nil
-}
-
-func (ur *UserRepo) SetUserWallet(_ context.Context, userID, address string) error {
-	err := ur.DB.Model(&userEntity.User{}).Where("id = ?", userID).Update("wallet", address).Error
-	if err != nil {
-		return err
+	if user.Wallet != "" {
+		if _, err = ur.AddUserWallet(ctx, user.ID, user.Wallet, "", true, true); err != nil {
+			return 0, err
+		}
 	}
 
-	return nil
+	return user.ID, nil
 }
 
 func (ur *UserRepo) CreateUserDetailInfo(_ context.Context, userData dto.UserDetailRequest, id int) error {
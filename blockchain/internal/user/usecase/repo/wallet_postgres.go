@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	userEntity "github.com/damndelion/blockchain_justCode/internal/user/entity"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func (ur *UserRepo) GetUserWallets(ctx context.Context, userID int) (wallets []*userEntity.UserWallet, err error) {
+	res := ur.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&wallets)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return wallets, nil
+}
+
+// AddUserWallet records a wallet of the user. The first wallet of a user is
+// always the default one, and an empty label is replaced by the first free
+// numbered one. A label the user already has gives ErrWalletLabelTaken, an
+// address that is already a wallet ErrWalletAddressTaken.
+func (ur *UserRepo) AddUserWallet(ctx context.Context, userID int, address, label string, isDefault, custodial bool) (*userEntity.UserWallet, error) {
+	wallet := userEntity.UserWallet{
+		UserID:    userID,
+		Address:   address,
+		Label:     label,
+		Custodial: custodial,
+	}
+	err := ur.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&userEntity.UserWallet{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if wallet.Label == "" {
+			label, err := freeWalletLabel(tx, userID)
+			if err != nil {
+				return err
+			}
+			wallet.Label = label
+		}
+		if count == 0 || isDefault {
+			if err := moveDefaultWallet(tx, userID, address); err != nil {
+				return err
+			}
+			wallet.IsDefault = true
+		}
+
+		return tx.Create(&wallet).Error
+	})
+	if err != nil {
+		return nil, walletTaken(err)
+	}
+
+	return &wallet, nil
+}
+
+// SetUserWallet makes address the default wallet of the user, adding it to
+// the user wallets when it is not one of them yet.
+func (ur *UserRepo) SetUserWallet(ctx context.Context, userID, address string) error {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return err
+	}
+
+	err = ur.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := moveDefaultWallet(tx, id, address); err != nil {
+			return err
+		}
+		res := tx.Model(&userEntity.UserWallet{}).Where("user_id = ? AND address = ?", id, address).Update("is_default", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+
+		label, err := freeWalletLabel(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&userEntity.UserWallet{
+			UserID:    id,
+			Address:   address,
+			Label:     label,
+			IsDefault: true,
+			Custodial: true,
+		}).Error
+	})
+
+	return walletTaken(err)
+}
+
+// freeWalletLabel returns the first numbered label the user does not have.
+func freeWalletLabel(tx *gorm.DB, userID int) (string, error) {
+	var labels []string
+	if err := tx.Model(&userEntity.UserWallet{}).Where("user_id = ?", userID).Pluck("label", &labels).Error; err != nil {
+		return "", err
+	}
+
+	return nextWalletLabel(labels), nil
+}
+
+// nextWalletLabel returns the first of "wallet N", counting from the number
+// of labels, that is not one of labels.
+func nextWalletLabel(labels []string) string {
+	taken := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		taken[label] = true
+	}
+	for n := len(labels) + 1; ; n++ {
+		if label := fmt.Sprintf("wallet %d", n); !taken[label] {
+			return label
+		}
+	}
+}
+
+// walletTaken turns a violation of the unique labels of the wallets of a
+// user, possible when wallets are added concurrently, into
+// ErrWalletLabelTaken, and one of the unique addresses into
+// ErrWalletAddressTaken.
+func walletTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	switch pgErr.ConstraintName {
+	case "user_wallets_user_id_label_key":
+		return userEntity.ErrWalletLabelTaken
+	case "user_wallets_address_key":
+		return userEntity.ErrWalletAddressTaken
+	}
+
+	return err
+}
+
+// moveDefaultWallet unsets the current default wallet of the user and points
+// Users.wallet, which holds the default address, to address.
+func moveDefaultWallet(tx *gorm.DB, userID int, address string) error {
+	if err := tx.Model(&userEntity.UserWallet{}).Where("user_id = ? AND is_default", userID).Update("is_default", false).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&userEntity.User{}).Where("id = ?", userID).Update("wallet", address).Error
+}
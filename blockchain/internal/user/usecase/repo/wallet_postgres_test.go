@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	userEntity "github.com/damndelion/blockchain_justCode/internal/user/entity"
+)
+
+func TestNextWalletLabel(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		want   string
+	}{
+		{name: "First wallet", want: "wallet 1"},
+		{name: "Numbered", labels: []string{"wallet 1", "wallet 2"}, want: "wallet 3"},
+		{name: "Named", labels: []string{"savings"}, want: "wallet 2"},
+		{name: "Next one taken", labels: []string{"wallet 1", "wallet 3"}, want: "wallet 4"},
+		{name: "Gap below the count", labels: []string{"wallet 2", "wallet 3", "wallet 4"}, want: "wallet 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextWalletLabel(tt.labels); got != tt.want {
+				t.Errorf("nextWalletLabel(%q) = %q, want %q", tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalletTaken(t *testing.T) {
+	other := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Label", err: &pgconn.PgError{Code: "23505", ConstraintName: "user_wallets_user_id_label_key"}, want: userEntity.ErrWalletLabelTaken},
+		{name: "Address", err: &pgconn.PgError{Code: "23505", ConstraintName: "user_wallets_address_key"}, want: userEntity.ErrWalletAddressTaken},
+		{name: "Second default", err: &pgconn.PgError{Code: "23505", ConstraintName: "user_wallets_default_idx"}},
+		{name: "Other violation", err: &pgconn.PgError{Code: "23503", ConstraintName: "user_wallets_user_id_fkey"}},
+		{name: "Other error", err: other, want: other},
+		{name: "No error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want == nil {
+				want = tt.err
+			}
+			if got := walletTaken(tt.err); !errors.Is(got, want) {
+				t.Errorf("walletTaken() = %v, want %v", got, want)
+			}
+		})
+	}
+}
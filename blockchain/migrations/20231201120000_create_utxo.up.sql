@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS utxo (
+                      tx_id VARCHAR(64) NOT NULL,
+                      out_idx INT NOT NULL,
+                      value DOUBLE PRECISION NOT NULL,
+                      pub_key_hash BYTEA NOT NULL,
+                      block_hash VARCHAR(64) NOT NULL,
+                      PRIMARY KEY (tx_id, out_idx)
+);
+
+CREATE INDEX IF NOT EXISTS utxo_pub_key_hash_idx ON utxo (pub_key_hash);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS address_tx;
+DROP TABLE IF EXISTS tx_index;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tx_index (
+                      tx_id VARCHAR(64) PRIMARY KEY,
+                      block_hash VARCHAR(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS address_tx (
+                      id SERIAL PRIMARY KEY,
+                      pub_key_hash BYTEA NOT NULL,
+                      tx_id VARCHAR(64) NOT NULL,
+                      block_hash VARCHAR(64) NOT NULL,
+                      UNIQUE (pub_key_hash, tx_id)
+);
+
+CREATE INDEX IF NOT EXISTS address_tx_pub_key_hash_idx ON address_tx (pub_key_hash, id);
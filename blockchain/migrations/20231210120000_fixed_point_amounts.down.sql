@@ -0,0 +1,11 @@
+UPDATE blocks SET transactions = (
+    SELECT COALESCE(jsonb_agg(
+        jsonb_set(tx, '{Vout}', COALESCE((
+            SELECT jsonb_agg(jsonb_set(vout, '{Value}', to_jsonb((vout->>'Value')::numeric / 100000000)) ORDER BY i)
+            FROM jsonb_array_elements(tx->'Vout') WITH ORDINALITY AS o(vout, i)
+        ), '[]'::jsonb))
+        ORDER BY n), '[]'::jsonb)
+    FROM jsonb_array_elements(blocks.transactions::jsonb) WITH ORDINALITY AS t(tx, n)
+);
+
+ALTER TABLE utxo ALTER COLUMN value TYPE DOUBLE PRECISION USING value::double precision / 100000000;
@@ -0,0 +1,20 @@
+-- Amounts become int64 base units with 8 decimals, which chain_params
+-- records, and output indexes become integers. Transaction IDs change with
+-- the encoding: run the chain rewrite (make chain-rewrite) after this
+-- migration.
+UPDATE blocks SET transactions = (
+    SELECT COALESCE(jsonb_agg(
+        jsonb_set(
+            jsonb_set(tx, '{Vin}', COALESCE((
+                SELECT jsonb_agg(jsonb_set(vin, '{Vout}', to_jsonb(round((vin->>'Vout')::numeric)::bigint)) ORDER BY i)
+                FROM jsonb_array_elements(tx->'Vin') WITH ORDINALITY AS v(vin, i)
+            ), '[]'::jsonb)),
+            '{Vout}', COALESCE((
+                SELECT jsonb_agg(jsonb_set(vout, '{Value}', to_jsonb(round((vout->>'Value')::numeric * 100000000)::bigint)) ORDER BY i)
+                FROM jsonb_array_elements(tx->'Vout') WITH ORDINALITY AS o(vout, i)
+            ), '[]'::jsonb))
+        ORDER BY n), '[]'::jsonb)
+    FROM jsonb_array_elements(blocks.transactions::jsonb) WITH ORDINALITY AS t(tx, n)
+);
+
+ALTER TABLE utxo ALTER COLUMN value TYPE BIGINT USING round(value * 100000000)::bigint;
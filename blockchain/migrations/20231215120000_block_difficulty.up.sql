@@ -0,0 +1,3 @@
+-- Blocks stored so far were mined with the fixed difficulty of 12 bits.
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS difficulty INT NOT NULL DEFAULT 12;
+ALTER TABLE blocks ALTER COLUMN difficulty DROP DEFAULT;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS wallet_keys (
+                      address VARCHAR(64) PRIMARY KEY,
+                      key_type INT NOT NULL,
+                      public_key BYTEA NOT NULL,
+                      encrypted_key BYTEA NOT NULL,
+                      created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
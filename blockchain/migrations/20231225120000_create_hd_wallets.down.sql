@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS wallet_keys_hd_wallet_id_idx;
+ALTER TABLE wallet_keys DROP COLUMN IF EXISTS idx;
+ALTER TABLE wallet_keys DROP COLUMN IF EXISTS chain;
+ALTER TABLE wallet_keys DROP COLUMN IF EXISTS hd_wallet_id;
+DROP TABLE IF EXISTS hd_wallets;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS hd_wallets (
+                      id SERIAL PRIMARY KEY,
+                      root_address VARCHAR(64) NOT NULL UNIQUE,
+                      encrypted_seed BYTEA NOT NULL,
+                      next_receive INT NOT NULL,
+                      next_change INT NOT NULL,
+                      created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE wallet_keys ADD COLUMN IF NOT EXISTS hd_wallet_id INT REFERENCES hd_wallets (id);
+ALTER TABLE wallet_keys ADD COLUMN IF NOT EXISTS chain INT;
+ALTER TABLE wallet_keys ADD COLUMN IF NOT EXISTS idx INT;
+
+CREATE INDEX IF NOT EXISTS wallet_keys_hd_wallet_id_idx ON wallet_keys (hd_wallet_id, chain, idx);
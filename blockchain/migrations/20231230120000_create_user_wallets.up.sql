@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_wallets (
+                      id SERIAL PRIMARY KEY,
+                      user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+                      address VARCHAR(255) NOT NULL UNIQUE,
+                      label VARCHAR(64) NOT NULL,
+                      is_default BOOLEAN NOT NULL DEFAULT FALSE,
+                      created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+                      UNIQUE (user_id, label)
+);
+
+-- At most one default wallet per user. Users.wallet mirrors its address.
+CREATE UNIQUE INDEX IF NOT EXISTS user_wallets_default_idx ON user_wallets (user_id) WHERE is_default;
+
+INSERT INTO user_wallets (user_id, address, label, is_default)
+SELECT id, wallet, 'default', TRUE FROM Users WHERE wallet <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+ALTER TABLE user_wallets DROP COLUMN IF EXISTS custodial;
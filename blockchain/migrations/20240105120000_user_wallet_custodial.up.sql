@@ -0,0 +1,2 @@
+-- Wallets created before non-custodial wallets have their keys in the keystore.
+ALTER TABLE user_wallets ADD COLUMN IF NOT EXISTS custodial BOOLEAN NOT NULL DEFAULT TRUE;
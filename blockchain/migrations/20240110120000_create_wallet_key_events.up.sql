@@ -0,0 +1,15 @@
+-- Audit trail of private keys exported from and imported into the keystore.
+CREATE TABLE IF NOT EXISTS wallet_key_events (
+                      id SERIAL PRIMARY KEY,
+                      user_id VARCHAR(64) NOT NULL,
+                      address VARCHAR(64) NOT NULL,
+                      action VARCHAR(16) NOT NULL,
+                      format VARCHAR(16) NOT NULL,
+                      source VARCHAR(16) NOT NULL,
+                      success BOOLEAN NOT NULL,
+                      error TEXT NOT NULL DEFAULT '',
+                      created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS wallet_key_events_user_id_idx ON wallet_key_events (user_id, created_at);
+CREATE INDEX IF NOT EXISTS wallet_key_events_address_idx ON wallet_key_events (address);
@@ -0,0 +1,4 @@
+-- Blocks commit to the Merkle root of their transactions. Blocks stored so far
+-- have none: run the chain rewrite (make chain-rewrite) after this migration.
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS merkle_root BYTEA NOT NULL DEFAULT '';
+ALTER TABLE blocks ALTER COLUMN merkle_root DROP DEFAULT;
@@ -0,0 +1,3 @@
+-- Converted blocks are only stored in data: restore a backup taken before the
+-- conversion to keep them.
+ALTER TABLE blocks DROP COLUMN IF EXISTS data;
@@ -0,0 +1,6 @@
+-- Blocks are stored in their canonical binary encoding. Rows stored so far
+-- keep their JSON transactions until converted, and transaction IDs change
+-- with the encoding: run the chain rewrite (make chain-rewrite) after this
+-- migration.
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS data BYTEA;
+ALTER TABLE blocks ALTER COLUMN transactions DROP NOT NULL;
@@ -0,0 +1,10 @@
+-- Side branch blocks can not be kept without their flag. The main chain is
+-- read in id order again, which only matches height order on chains that
+-- never reorganised.
+DELETE FROM blocks WHERE NOT main_chain;
+DROP INDEX IF EXISTS blocks_previous_hash_idx;
+DROP INDEX IF EXISTS blocks_main_chain_height_idx;
+DROP INDEX IF EXISTS blocks_hash_idx;
+ALTER TABLE blocks DROP COLUMN IF EXISTS main_chain;
+ALTER TABLE blocks DROP COLUMN IF EXISTS work;
+ALTER TABLE blocks DROP COLUMN IF EXISTS height;
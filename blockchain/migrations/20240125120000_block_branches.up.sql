@@ -0,0 +1,21 @@
+-- Blocks of side branches are kept for chain reorganisations. Every block
+-- records its height, the work of its branch up to it and whether it is on
+-- the main chain. Blocks stored so far form the main chain in id order.
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS height INT;
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS work NUMERIC(100, 0);
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS main_chain BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE blocks b SET height = c.height, work = c.work
+FROM (SELECT id,
+             ROW_NUMBER() OVER (ORDER BY id) - 1 AS height,
+             SUM(POWER(2::NUMERIC, difficulty)) OVER (ORDER BY id) AS work
+      FROM blocks) c
+WHERE b.id = c.id;
+
+ALTER TABLE blocks ALTER COLUMN height SET NOT NULL;
+ALTER TABLE blocks ALTER COLUMN work SET NOT NULL;
+ALTER TABLE blocks ALTER COLUMN main_chain DROP DEFAULT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS blocks_hash_idx ON blocks (hash);
+CREATE UNIQUE INDEX IF NOT EXISTS blocks_main_chain_height_idx ON blocks (height) WHERE main_chain;
+CREATE INDEX IF NOT EXISTS blocks_previous_hash_idx ON blocks (previous_hash);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS top_up_decisions;
+DROP TABLE IF EXISTS top_ups;
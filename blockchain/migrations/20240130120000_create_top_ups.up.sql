@@ -0,0 +1,27 @@
+-- Top ups paid by the treasury, in base units, and every decision taken on
+-- them by the top up policy or an admin.
+CREATE TABLE IF NOT EXISTS top_ups (
+                      id BIGSERIAL PRIMARY KEY,
+                      user_id VARCHAR(64) NOT NULL,
+                      amount BIGINT NOT NULL CHECK (amount > 0),
+                      status VARCHAR(16) NOT NULL,
+                      tx_id VARCHAR(64) NOT NULL DEFAULT '',
+                      reason TEXT NOT NULL DEFAULT '',
+                      decided_by VARCHAR(64) NOT NULL DEFAULT '',
+                      created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+                      decided_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS top_ups_user_id_idx ON top_ups (user_id, created_at);
+CREATE INDEX IF NOT EXISTS top_ups_status_idx ON top_ups (status, id);
+
+CREATE TABLE IF NOT EXISTS top_up_decisions (
+                      id BIGSERIAL PRIMARY KEY,
+                      top_up_id BIGINT NOT NULL REFERENCES top_ups(id) ON DELETE CASCADE,
+                      decision VARCHAR(16) NOT NULL,
+                      actor VARCHAR(64) NOT NULL,
+                      reason TEXT NOT NULL DEFAULT '',
+                      created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS top_up_decisions_top_up_id_idx ON top_up_decisions (top_up_id);
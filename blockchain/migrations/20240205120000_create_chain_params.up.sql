@@ -0,0 +1,12 @@
+-- Parameters a stored chain is bound to. Amounts are stored in base units,
+-- so the decimals must not change once blocks are stored: chains migrated by
+-- 20231210120000_fixed_point_amounts use 8. Empty chains take the configured
+-- decimals on their first start.
+CREATE TABLE IF NOT EXISTS chain_params (
+                      name VARCHAR(64) PRIMARY KEY,
+                      value TEXT NOT NULL
+);
+
+INSERT INTO chain_params (name, value)
+SELECT 'decimals', '8' WHERE EXISTS (SELECT 1 FROM blocks)
+ON CONFLICT (name) DO NOTHING;
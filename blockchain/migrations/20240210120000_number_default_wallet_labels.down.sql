@@ -0,0 +1,3 @@
+-- Renamed wallets can not be told from ones numbered by the service; the
+-- labels are kept.
+SELECT 1;
@@ -0,0 +1,7 @@
+-- Wallets recorded from Users.wallet were labelled 'default'; new wallets
+-- without a label are numbered, the first one 'wallet 1'. Give the recorded
+-- wallets the label they would get now, unless the user already has it.
+UPDATE user_wallets w SET label = 'wallet 1'
+WHERE w.label = 'default' AND w.is_default
+  AND w.address = (SELECT u.wallet FROM Users u WHERE u.id = w.user_id)
+  AND NOT EXISTS (SELECT 1 FROM user_wallets o WHERE o.user_id = w.user_id AND o.label = 'wallet 1');
@@ -0,0 +1,198 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrBlockExists is returned when a received block is already stored.
+	ErrBlockExists = errors.New("block is already stored")
+	// ErrOrphanBlock is returned when the parent of a received block is not
+	// stored.
+	ErrOrphanBlock = errors.New("parent block is unknown")
+	// ErrInvalidBlock is returned when a received block breaks a consensus
+	// rule. The wrapped message says which.
+	ErrInvalidBlock = errors.New("invalid block")
+)
+
+// maxFutureBlockTime is how far ahead of the local clock a received block
+// may be timestamped.
+const maxFutureBlockTime = 2 * time.Hour
+
+// AddBlock validates a block mined elsewhere and adds it to the chain. A
+// block extending the tip is appended and its transactions leave the
+// mempool. A block building on another stored block is kept on a side
+// branch; once that branch holds more work than the main chain, the chain is
+// reorganised onto it.
+func (bc *Blockchain) AddBlock(block *Block) error {
+	bc.mining.Lock()
+	defer bc.mining.Unlock()
+
+	if _, err := bc.store.BlockNode(block.Hash); err == nil {
+		return ErrBlockExists
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if bc.invalid[block.PrevHash] {
+		bc.invalid[block.Hash] = true
+
+		return fmt.Errorf("%w %s: parent block is invalid", ErrInvalidBlock, block.Hash)
+	}
+	tip, err := bc.store.Tip()
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// A node without a chain takes the genesis block of its peers.
+		if block.PrevHash != "0" {
+			return ErrOrphanBlock
+		}
+		err = newChainVerifier().checkBlock(0, block)
+	case err != nil:
+		return err
+	case block.PrevHash != tip.Hash:
+		return bc.addSideBlock(block, tip)
+	default:
+		var height int
+		height, err = bc.store.Height()
+		if err != nil {
+			return err
+		}
+		err = bc.checkBlockHeader(tip, height+1, block)
+		if err == nil {
+			err = bc.checkBlockTransactions(block, height+1)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w %s: %v", ErrInvalidBlock, block.Hash, err)
+	}
+	err = bc.store.AddBlock(block)
+	if err != nil {
+		return err
+	}
+	bc.mempool.Remove(block.Transactions)
+	bc.notifyBlock(block)
+
+	return nil
+}
+
+// addSideBlock stores block, whose parent is not the tip, on a side branch
+// and reorganises the chain when the branch holds more work than the main
+// chain. Ties keep the main chain.
+func (bc *Blockchain) addSideBlock(block, tip *Block) error {
+	parentNode, err := bc.store.BlockNode(block.PrevHash)
+	if errors.Is(err, ErrNotFound) {
+		return ErrOrphanBlock
+	}
+	if err != nil {
+		return err
+	}
+	parent, err := bc.store.Block(block.PrevHash)
+	if err != nil {
+		return err
+	}
+	err = bc.checkBlockHeader(parent, parentNode.Height+1, block)
+	if err != nil {
+		return fmt.Errorf("%w %s: %v", ErrInvalidBlock, block.Hash, err)
+	}
+	err = bc.store.AddSideBlock(block)
+	if err != nil {
+		return err
+	}
+
+	node, err := bc.store.BlockNode(block.Hash)
+	if err != nil {
+		return err
+	}
+	tipNode, err := bc.store.BlockNode(tip.Hash)
+	if err != nil {
+		return err
+	}
+	if node.Work.Cmp(tipNode.Work) <= 0 {
+		return nil
+	}
+
+	return bc.reorganize(node)
+}
+
+// checkBlockHeader checks the rules block at height must follow as the child
+// of parent, whatever the branch: difficulty, timestamp, Merkle root, proof of
+// work and the shape of its transactions.
+func (bc *Blockchain) checkBlockHeader(parent *Block, height int, block *Block) error {
+	difficulty, err := bc.nextBlockDifficulty(parent, height)
+	if err != nil {
+		return err
+	}
+	if block.Difficulty != difficulty {
+		return fmt.Errorf("difficulty %d, want %d", block.Difficulty, difficulty)
+	}
+	if block.Timestamp.After(time.Now().Add(maxFutureBlockTime)) {
+		return errors.New("timestamp is too far in the future")
+	}
+	if !bytes.Equal(block.MerkleRoot, block.HashTransactions()) {
+		return errors.New("merkle root does not match the transactions")
+	}
+	if !NewProof(block).Validate() {
+		return errors.New("proof of work is invalid")
+	}
+	if len(block.Transactions) == 0 || !block.Transactions[0].IsCoinbase() {
+		return errors.New("block does not start with a coinbase transaction")
+	}
+	for _, tx := range block.Transactions {
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			return fmt.Errorf("transaction %x: id does not match its hash", tx.ID)
+		}
+	}
+
+	return nil
+}
+
+// checkBlockTransactions checks the transactions of block at height against
+// the utxo set, which must be the one of its parent, the tip.
+func (bc *Blockchain) checkBlockTransactions(block *Block, height int) error {
+	var fees Amount
+	spent := make(map[string]bool)
+	for _, tx := range block.Transactions[1:] {
+		for _, in := range tx.Vin {
+			key := outpoint(in.Txid, in.Vout)
+			if spent[key] {
+				return fmt.Errorf("transaction %x double spends output %s", tx.ID, key)
+			}
+			spent[key] = true
+		}
+		unspent, err := bc.inputsUnspent(tx)
+		if err != nil {
+			return err
+		}
+		if !unspent {
+			return fmt.Errorf("transaction %x spends an output that is spent or does not exist", tx.ID)
+		}
+		fee, err := bc.checkTransactionAt(tx, height, block.Timestamp)
+		if err != nil {
+			return fmt.Errorf("transaction %x: %w", tx.ID, err)
+		}
+		fees += fee
+	}
+
+	return checkCoinbase(block.Transactions[0], BlockSubsidy()+fees)
+}
+
+// PendingTransaction returns a mempool transaction by its hex encoded ID.
+func (bc *Blockchain) PendingTransaction(txID string) (*Transaction, bool) {
+	return bc.mempool.Get(txID)
+}
+
+// HasTransaction reports whether the transaction is pending or confirmed.
+func (bc *Blockchain) HasTransaction(txID []byte) (bool, error) {
+	if _, ok := bc.mempool.Get(hex.EncodeToString(txID)); ok {
+		return true, nil
+	}
+	_, err := bc.store.TransactionBlock(txID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
@@ -0,0 +1,144 @@
+package blockchainlogic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a quantity of coins expressed in base units.
+type Amount int64
+
+// MaxDecimals is the largest supported precision. It keeps the total supply
+// well inside int64.
+const MaxDecimals = 8
+
+// DefaultDecimals is the precision existing chains were migrated with.
+const DefaultDecimals = 8
+
+var (
+	decimals = DefaultDecimals
+	coin     = pow10(DefaultDecimals)
+)
+
+var ErrInvalidAmount = errors.New("amount is not valid")
+
+func pow10(n int) Amount {
+	p := Amount(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
+
+// SetDecimals sets the number of decimal places of one coin. It must be
+// called before the chain is opened and never changed for an existing chain,
+// as stored amounts are not rescaled.
+func SetDecimals(n int) error {
+	if n < 0 || n > MaxDecimals {
+		return fmt.Errorf("decimals must be between 0 and %d", MaxDecimals)
+	}
+	decimals = n
+	coin = pow10(n)
+
+	return nil
+}
+
+// Decimals returns the number of decimal places of one coin.
+func Decimals() int {
+	return decimals
+}
+
+// Coin returns the number of base units in one coin.
+func Coin() Amount {
+	return coin
+}
+
+// ParseAmount parses a decimal string such as "12.5" into base units. It
+// rejects more fractional digits than the configured precision.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" && frac == "" || hasFrac && frac == "" || len(frac) > decimals {
+		return 0, ErrInvalidAmount
+	}
+	for _, part := range []string{whole, frac} {
+		if strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+			return 0, ErrInvalidAmount
+		}
+	}
+
+	var units Amount
+	if whole != "" {
+		w, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || Amount(w) > Amount(math.MaxInt64)/coin {
+			return 0, ErrInvalidAmount
+		}
+		units = Amount(w) * coin
+	}
+	if frac != "" {
+		f, err := strconv.ParseInt(frac, 10, 64)
+		if err != nil {
+			return 0, ErrInvalidAmount
+		}
+		f *= int64(pow10(decimals - len(frac)))
+		if units > Amount(math.MaxInt64)-Amount(f) {
+			return 0, ErrInvalidAmount
+		}
+		units += Amount(f)
+	}
+
+	if neg {
+		return -units, nil
+	}
+
+	return units, nil
+}
+
+// String formats the amount as a decimal string with the configured precision.
+func (a Amount) String() string {
+	sign := ""
+	u := uint64(a)
+	if a < 0 {
+		sign = "-"
+		u = uint64(-a)
+	}
+	if decimals == 0 {
+		return sign + strconv.FormatUint(u, 10)
+	}
+
+	c := uint64(coin)
+
+	return fmt.Sprintf("%s%d.%0*d", sign, u/c, decimals, u%c)
+}
+
+// Float returns the amount in coins. It is meant for display and price
+// conversion only.
+func (a Amount) Float() float64 {
+	return float64(a) / float64(coin)
+}
+
+// sumOutputs adds up output values. It rejects non-positive values and
+// overflowing sums.
+func sumOutputs(outs []TXOutput) (Amount, error) {
+	var total Amount
+	for _, out := range outs {
+		if out.Value <= 0 {
+			return 0, fmt.Errorf("output value %d is not positive", out.Value)
+		}
+		if total > Amount(math.MaxInt64)-out.Value {
+			return 0, errors.New("output values overflow")
+		}
+		total += out.Value
+	}
+
+	return total, nil
+}
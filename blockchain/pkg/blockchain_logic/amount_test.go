@@ -0,0 +1,76 @@
+package blockchainlogic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "1", want: 100000000},
+		{in: "12.5", want: 1250000000},
+		{in: "0.00000001", want: 1},
+		{in: ".5", want: 50000000},
+		{in: "-2.25", want: -225000000},
+		{in: "0.000000001", wantErr: true},
+		{in: "1.", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "1,5", wantErr: true},
+		{in: "92233720368.54775808", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("ParseAmount(%q) error = %v, want ErrInvalidAmount", tt.in, err)
+			}
+
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{in: 0, want: "0.00000000"},
+		{in: 1, want: "0.00000001"},
+		{in: 1250000000, want: "12.50000000"},
+		{in: -225000000, want: "-2.25000000"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+		if back, err := ParseAmount(tt.want); err != nil || back != tt.in {
+			t.Errorf("ParseAmount(%q) = %d, %v, want %d", tt.want, back, err, tt.in)
+		}
+	}
+}
+
+func TestSetDecimals(t *testing.T) {
+	defer SetDecimals(DefaultDecimals) //nolint:errcheck // restores the default
+
+	if err := SetDecimals(MaxDecimals + 1); err == nil {
+		t.Fatalf("SetDecimals(%d) error = nil, want error", MaxDecimals+1)
+	}
+	if err := SetDecimals(2); err != nil {
+		t.Fatalf("SetDecimals(2) error = %v", err)
+	}
+	if got, _ := ParseAmount("3.14"); got != 314 {
+		t.Errorf("ParseAmount(\"3.14\") with 2 decimals = %d, want 314", got)
+	}
+	if got := Amount(314).String(); got != "3.14" {
+		t.Errorf("Amount(314).String() with 2 decimals = %q, want \"3.14\"", got)
+	}
+}
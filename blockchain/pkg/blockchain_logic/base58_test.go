@@ -0,0 +1,21 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBase58RoundTrip(t *testing.T) {
+	tests := [][]byte{
+		{0x00, 0x5a, 0x01},
+		{0x00, 0x00, 0x00, 0x7f},
+		{0x2f, 0xff},
+		{0x00, 0x00},
+	}
+	for _, in := range tests {
+		encoded := Base58Encode(in)
+		if out := Base58Decode(encoded); !bytes.Equal(out, in) {
+			t.Errorf("Base58Decode(%q) = %x, want %x", encoded, out, in)
+		}
+	}
+}
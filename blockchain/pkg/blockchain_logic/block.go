@@ -2,7 +2,6 @@ package blockchainlogic
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"time"
 )
 
@@ -10,12 +9,23 @@ type Block struct {
 	Hash         string
 	Transactions []*Transaction
 	PrevHash     string
-	Timestamp    time.Time
-	Nonce        int
+	// MerkleRoot is the root of the Merkle tree over the transaction IDs,
+	// committed to by the proof of work.
+	MerkleRoot []byte
+	Timestamp  time.Time
+	Nonce      int
+	// Difficulty is the number of leading zero bits the block hash needs.
+	Difficulty int
 }
 
-func CreateBlock(transactions []*Transaction, prevHash string) *Block {
-	block := &Block{"", transactions, prevHash, time.Now(), 0}
+func CreateBlock(transactions []*Transaction, prevHash string, difficulty int) *Block {
+	block := &Block{
+		Transactions: transactions,
+		PrevHash:     prevHash,
+		Timestamp:    time.Now(),
+		Difficulty:   difficulty,
+	}
+	block.MerkleRoot = block.HashTransactions()
 	pow := NewProof(block)
 	nonce, hash := pow.Run()
 	block.Hash = hash[:]
@@ -25,17 +35,33 @@ func CreateBlock(transactions []*Transaction, prevHash string) *Block {
 }
 
 func Genesis(coinbase *Transaction) *Block {
-	return CreateBlock([]*Transaction{coinbase}, "0")
+	return CreateBlock([]*Transaction{coinbase}, "0", difficultyPolicy.Initial)
 }
 
+// HashTransactions returns the Merkle root of the transaction IDs of b.
 func (b *Block) HashTransactions() []byte {
-	var txHashes [][]byte
-	var txHash [32]byte
+	txIDs := make([][]byte, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		txIDs[i] = tx.ID
+	}
 
-	for _, tx := range b.Transactions {
-		txHashes = append(txHashes, tx.ID)
+	return MerkleRoot(txIDs)
+}
+
+// MerkleProof returns the Merkle branch of the transaction with the given ID,
+// or ErrNotFound when b does not hold it.
+func (b *Block) MerkleProof(txID []byte) (*MerkleProof, error) {
+	txIDs := make([][]byte, len(b.Transactions))
+	index := -1
+	for i, tx := range b.Transactions {
+		txIDs[i] = tx.ID
+		if index < 0 && bytes.Equal(tx.ID, txID) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, ErrNotFound
 	}
-	txHash = sha256.Sum256(bytes.Join(txHashes, []byte{}))
 
-	return txHash[:]
+	return NewMerkleProof(txIDs, index)
 }
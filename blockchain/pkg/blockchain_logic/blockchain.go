@@ -2,11 +2,9 @@ package blockchainlogic
 
 import (
 	"bytes"
-	"crypto/rsa"
+	"context"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -18,276 +16,416 @@ import (
 const genesisCoinbaseData = "The Times 03/Jan/2009 Chancellor on brink of second bailout for banks"
 
 type Blockchain struct {
-	tip string
-	DB  *sql.DB
-	mu  *sync.Mutex
+	store   ChainStore
+	mu      *sync.Mutex
+	mempool *Mempool
+	// minerAddress receives the subsidy and fees of mined blocks.
+	minerAddress string
+	minFee       Amount
+	// mining serializes block production, so mu is free while a proof of
+	// work runs.
+	mining *sync.Mutex
+	// sending serializes building transactions and adding them to the
+	// mempool, so concurrent sends do not pick the same outputs.
+	sending  *sync.Mutex
+	observer MiningObserver
+	keys     Keystore
+	// listeners are told about new blocks and pending transactions.
+	listeners []ChainObserver
+	// invalid holds the side branch blocks found to break a consensus rule
+	// when reorganising, and their descendants. It is guarded by mining.
+	invalid map[string]bool
+	// regtest is set for regtest chains only.
+	regtest *regtestChain
 }
 
-func CreateBlockchain(db *sql.DB, address string) *Blockchain {
+// MiningObserver receives statistics of the blocks mined by MineBlock.
+type MiningObserver interface {
+	// ObserveHashrate reports the hashes per second of the last proof of work.
+	ObserveHashrate(hashesPerSecond float64)
+	// BlockMined is called after a mined block is stored.
+	BlockMined()
+}
+
+type noopObserver struct{}
+
+func (noopObserver) ObserveHashrate(float64) {}
+func (noopObserver) BlockMined()             {}
+
+// ChainObserver is told about blocks added to the chain, blocks leaving the
+// main chain in a reorganisation, transactions accepted into the mempool and
+// addresses derived for HD wallets, after they are stored. It must not block.
+type ChainObserver interface {
+	BlockAdded(block *Block)
+	// BlockDisconnected is called for every block a reorganisation removes
+	// from the main chain, the former tip first, before the blocks of the
+	// new branch are added.
+	BlockDisconnected(block *Block)
+	TransactionAccepted(tx *Transaction)
+	// AddressDerived is called when derived is added to the HD wallet
+	// owning address.
+	AddressDerived(address, derived string)
+}
+
+// CreateBlockchain opens the chain kept in store, mining its genesis block
+// paying address when it is empty.
+func CreateBlockchain(store ChainStore, keys Keystore, address string) *Blockchain {
 	if !ValidateAddress(address) {
 		log.Panic("ERROR: Address is not valid")
 	}
-	// Check if the blocks table is empty
-	var blockCount int
-	err := db.QueryRow("SELECT COUNT(*) FROM blocks").Scan(&blockCount)
+	height, err := store.Height()
 	if err != nil {
 		log.Fatal(err)
 	}
-	if blockCount == 0 {
-		var lastHash string
-
+	if height < 0 {
 		cbtx := NewCoinbaseTX(address, genesisCoinbaseData)
-		genesis := Genesis(cbtx)
-		jsonData, err := json.Marshal(genesis.Transactions)
-		if err != nil {
-			log.Fatal(err)
-		}
-		transactionsString := string(jsonData)
-
-		_, err = db.Exec("INSERT INTO blocks (hash, transactions, previous_hash, timestamp, nonce) VALUES ($1, $2, $3, $4, $5)",
-			genesis.Hash, transactionsString, genesis.PrevHash, genesis.Timestamp, 0)
+		err = store.AddBlock(Genesis(cbtx))
 		if err != nil {
 			log.Fatal(err)
 		}
-
-		lastHash = genesis.Hash
-		mu := &sync.Mutex{}
-		chain := Blockchain{lastHash, db, mu}
-
-		return &chain
 	}
 
-	return NewBlockchain(db, address)
+	return NewBlockchain(store, keys, address)
 }
 
-func NewBlockchain(db *sql.DB, address string) *Blockchain {
+func NewBlockchain(store ChainStore, keys Keystore, address string) *Blockchain {
 	if !ValidateAddress(address) {
 		log.Panic("ERROR: Address is not valid")
 	}
-	var lastHash string
-	var lastBlockHash string
-	err := db.QueryRow("SELECT hash FROM blocks ORDER BY id DESC LIMIT 1").Scan(&lastBlockHash)
-	if err != nil {
-		log.Fatal(err)
-	}
-	lastHash = lastBlockHash
-	if err != nil {
-		log.Panic(err)
+
+	return &Blockchain{
+		store:        store,
+		mu:           &sync.Mutex{},
+		mining:       &sync.Mutex{},
+		sending:      &sync.Mutex{},
+		mempool:      NewMempool(),
+		minerAddress: address,
+		observer:     noopObserver{},
+		keys:         keys,
+		invalid:      make(map[string]bool),
 	}
-	mu := &sync.Mutex{}
-	chain := Blockchain{lastHash, db, mu}
+}
+
+// Store returns the store keeping the chain.
+func (bc *Blockchain) Store() ChainStore {
+	return bc.store
+}
 
-	return &chain
+// Keystore returns the store of the wallet keys used by Send.
+func (bc *Blockchain) Keystore() Keystore {
+	return bc.keys
 }
 
-func (bc *Blockchain) MineBlock(transactions []*Transaction) error {
+// SetMiningObserver sets the receiver of mining statistics.
+func (bc *Blockchain) SetMiningObserver(o MiningObserver) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
-	var lastHash string
-	// Retrieve the hash of the latest block
-	err := bc.DB.QueryRow("SELECT hash FROM blocks ORDER BY id DESC LIMIT 1").Scan(&lastHash)
-	if err != nil {
-		return err
+	bc.observer = o
+}
+
+// AddChainObserver registers o for the blocks and transactions accepted by
+// the chain.
+func (bc *Blockchain) AddChainObserver(o ChainObserver) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.listeners = append(bc.listeners, o)
+}
+
+func (bc *Blockchain) chainObservers() []ChainObserver {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return bc.listeners[:len(bc.listeners):len(bc.listeners)]
+}
+
+func (bc *Blockchain) notifyBlock(block *Block) {
+	for _, o := range bc.chainObservers() {
+		o.BlockAdded(block)
 	}
-	newBlock := CreateBlock(transactions, lastHash)
-	transactionsJSON, err := json.Marshal(transactions)
-	if err != nil {
-		return err
+}
+
+func (bc *Blockchain) notifyDisconnected(block *Block) {
+	for _, o := range bc.chainObservers() {
+		o.BlockDisconnected(block)
 	}
-	// Insert the new block into the database
-	_, err = bc.DB.Exec("INSERT INTO blocks (hash, transactions, previous_hash, timestamp, nonce) VALUES ($1, $2, $3, $4, $5)",
-		newBlock.Hash, string(transactionsJSON), newBlock.PrevHash, newBlock.Timestamp, newBlock.Nonce)
+}
 
+// acceptTransaction queues tx in the mempool and notifies the observers.
+func (bc *Blockchain) acceptTransaction(tx *Transaction) error {
+	err := bc.mempool.Add(tx)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	for _, o := range bc.chainObservers() {
+		o.TransactionAccepted(tx)
 	}
 
-	bc.tip = newBlock.Hash
+	return nil
+}
+
+// SetMinerAddress sets the address paid by the coinbase of mined blocks. It
+// defaults to the address the chain was opened with.
+func (bc *Blockchain) SetMinerAddress(address string) error {
+	if !ValidateAddress(address) {
+		return errors.New("miner address is not valid")
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.minerAddress = address
 
 	return nil
 }
 
-func (bc *Blockchain) FindUnspentTransactions(pubKeyHash []byte) []Transaction {
-	var unspentTXs []Transaction
-	spentTXOs := make(map[string][]float64)
-	bci := bc.Iterator()
+// SetMinFee sets the minimum fee accepted by Send.
+func (bc *Blockchain) SetMinFee(fee Amount) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.minFee = fee
+}
+
+// MinFee returns the minimum fee accepted by Send.
+func (bc *Blockchain) MinFee() Amount {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
 
-	for {
-		block := bci.Next()
-		if block == nil {
-			break
-		}
-		for _, tx := range block.Transactions {
-			txID := hex.EncodeToString(tx.ID)
-
-		Outputs:
-			for outIdx, out := range tx.Vout {
-				// Was the output spent?
-				if spentTXOs[txID] != nil {
-					for _, spentOut := range spentTXOs[txID] {
-						if spentOut == float64(outIdx) {
-							continue Outputs
-						}
-					}
-				}
-
-				if out.IsLockedWithKey(pubKeyHash) {
-					unspentTXs = append(unspentTXs, *tx)
-				}
-			}
-
-			if !tx.IsCoinbase() {
-				for _, in := range tx.Vin {
-					if in.UsesKey(pubKeyHash) {
-						inTxID := hex.EncodeToString(in.Txid)
-						spentTXOs[inTxID] = append(spentTXOs[inTxID], in.Vout)
-					}
-				}
-			}
-		}
+	return bc.minFee
+}
 
-		if len(block.PrevHash) == 0 {
-			break
+// MineBlock mines a block holding transactions after a coinbase paying the
+// miner address the block subsidy plus the transaction fees. The proof of work
+// stops with ctx.Err() when ctx is done, leaving the chain unchanged.
+func (bc *Blockchain) MineBlock(ctx context.Context, transactions []*Transaction) error {
+	_, err := bc.mineBlock(ctx, transactions)
+
+	return err
+}
+
+// mineBlock mines and stores a block as MineBlock does and returns it.
+func (bc *Blockchain) mineBlock(ctx context.Context, transactions []*Transaction) (*Block, error) {
+	bc.mining.Lock()
+	defer bc.mining.Unlock()
+
+	bc.mu.Lock()
+	minerAddress, observer := bc.minerAddress, bc.observer
+	bc.mu.Unlock()
+
+	parent, err := bc.store.Tip()
+	if err != nil {
+		return nil, err
+	}
+	height, err := bc.store.Height()
+	if err != nil {
+		return nil, err
+	}
+	difficulty, err := bc.nextBlockDifficulty(parent, height+1)
+	if err != nil {
+		return nil, err
+	}
+	var fees Amount
+	for _, tx := range transactions {
+		fee, err := bc.checkTransaction(tx)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %x failed verification: %w", tx.ID, err)
 		}
+		fees += fee
+	}
+	coinbase := newCoinbaseTX(minerAddress, BlockSubsidy()+fees, "")
+	newBlock := &Block{
+		Transactions: append([]*Transaction{coinbase}, transactions...),
+		PrevHash:     parent.Hash,
+		Timestamp:    time.Now(),
+		Difficulty:   difficulty,
+	}
+	newBlock.MerkleRoot = newBlock.HashTransactions()
+
+	pow := NewProof(newBlock)
+	start := time.Now()
+	nonce, hash, err := pow.RunContext(ctx)
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+		observer.ObserveHashrate(float64(pow.Hashes) / elapsed)
 	}
+	if err != nil {
+		return nil, err
+	}
+	newBlock.Nonce = nonce
+	newBlock.Hash = hash
+
+	err = bc.store.AddBlock(newBlock)
+	if err != nil {
+		return nil, err
+	}
+	observer.BlockMined()
+	bc.notifyBlock(newBlock)
 
-	return unspentTXs
+	return newBlock, nil
 }
 
-// Iterator returns a BlockchainIterator.
+// Iterator returns a BlockchainIterator starting at the tip.
 func (bc *Blockchain) Iterator() *BlockchainIterator {
-	bci := &BlockchainIterator{bc.tip, bc.DB}
+	bci := &BlockchainIterator{store: bc.store}
+	tip, err := bc.store.Tip()
+	if err == nil {
+		bci.currentHash = tip.Hash
+	}
 
 	return bci
 }
 
-func (bc *Blockchain) FindUTXO(pubKeyHash []byte) []TXOutput {
-	var UTXOs []TXOutput
-	unspentTransactions := bc.FindUnspentTransactions(pubKeyHash)
+// FindTransaction returns a confirmed transaction by its ID.
+func (bc *Blockchain) FindTransaction(ID []byte) (Transaction, error) {
+	blockHash, err := bc.store.TransactionBlock(ID)
+	if errors.Is(err, ErrNotFound) {
+		return Transaction{}, errors.New(fmt.Sprintf("transaction is not found"))
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
 
-	for _, tx := range unspentTransactions {
-		for _, out := range tx.Vout {
-			if out.IsLockedWithKey(pubKeyHash) {
-				UTXOs = append(UTXOs, out)
-			}
+	block, err := bc.store.Block(blockHash)
+	if err != nil {
+		return Transaction{}, err
+	}
+	for _, tx := range block.Transactions {
+		if bytes.Equal(tx.ID, ID) {
+			return *tx, nil
 		}
 	}
 
-	return UTXOs
+	return Transaction{}, errors.New(fmt.Sprintf("transaction is not found"))
 }
 
-func (bc *Blockchain) FindSpendableOutputs(pubKeyHash []byte, amount float64) (float64, map[string][]float64) {
-	unspentOutputs := make(map[string][]float64)
-	unspentTXs := bc.FindUnspentTransactions(pubKeyHash)
-	accumulated := 0.0
-
-Work:
-	for _, tx := range unspentTXs {
-		txID := hex.EncodeToString(tx.ID)
+// VerifyTransaction checks the input signatures of tx against the outputs
+// they spend and that it does not create more than it spends.
+func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
+	_, err := bc.checkTransaction(tx)
 
-		for outIdx, out := range tx.Vout {
-			if out.IsLockedWithKey(pubKeyHash) && accumulated < amount {
-				accumulated += out.Value
-				unspentOutputs[txID] = append(unspentOutputs[txID], float64(outIdx))
+	return err == nil
+}
 
-				if accumulated >= amount {
-					break Work
-				}
-			}
-		}
+// checkTransaction verifies tx for the next block and returns its fee.
+func (bc *Blockchain) checkTransaction(tx *Transaction) (Amount, error) {
+	height, err := bc.Height()
+	if err != nil {
+		return 0, err
 	}
 
-	return accumulated, unspentOutputs
+	return bc.checkTransactionAt(tx, height+1, time.Now())
 }
 
-func (bc *Blockchain) FindTransaction(ID []byte) (Transaction, error) {
-	bci := bc.Iterator()
-
-	for {
-		block := bci.Next()
-
-		for _, tx := range block.Transactions {
-			if bytes.Compare(tx.ID, ID) == 0 {
-				return *tx, nil
-			}
+// checkTransactionAt verifies tx for a block at height mined at blockTime and
+// returns its fee.
+func (bc *Blockchain) checkTransactionAt(tx *Transaction, height int, blockTime time.Time) (Amount, error) {
+	if tx.IsCoinbase() {
+		return 0, errors.New("coinbase transactions are created by the miner")
+	}
+	spent := make(map[string]bool, len(tx.Vin))
+	for _, in := range tx.Vin {
+		key := outpoint(in.Txid, in.Vout)
+		if spent[key] {
+			return 0, fmt.Errorf("output %s is spent twice", key)
 		}
+		spent[key] = true
+	}
 
-		if len(block.PrevHash) == 0 {
-			break
-		}
+	prevTXs, err := bc.findPrevTransactions(tx)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.verifyScripts(prevTXs); err != nil {
+		return 0, err
+	}
+	if err = checkLocks(tx, height, blockTime, bc.outputHeight); err != nil {
+		return 0, err
 	}
 
-	return Transaction{}, errors.New(fmt.Sprintf("transaction is not found"))
+	return transactionFee(tx, prevTXs)
 }
 
-func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
-	prevTXs := make(map[string]Transaction)
-
-	for _, vin := range tx.Vin {
-		prevTX, err := bc.FindTransaction(vin.Txid)
-		if err != nil {
-			log.Panic(err)
-		}
-		prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
+// SignTransaction signs the inputs of tx with the wallet key.
+func (bc *Blockchain) SignTransaction(tx *Transaction, wallet Wallet) error {
+	prevTXs, err := bc.findPrevTransactions(tx)
+	if err != nil {
+		return err
 	}
 
-	return tx.Verify(prevTXs)
+	return tx.Sign(wallet, prevTXs)
 }
 
-func (bc *Blockchain) SignTransaction(tx *Transaction, privKey *rsa.PrivateKey) {
+func (bc *Blockchain) findPrevTransactions(tx *Transaction) (map[string]Transaction, error) {
 	prevTXs := make(map[string]Transaction)
 
 	for _, vin := range tx.Vin {
 		prevTX, err := bc.FindTransaction(vin.Txid)
 		if err != nil {
-			log.Panic(err)
+			return nil, err
 		}
 		prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
 	}
 
-	tx.Sign(privKey, prevTXs)
+	return prevTXs, nil
 }
 
-func (bc *Blockchain) GetBalance(address string) float64 {
-	chain := NewBlockchain(bc.DB, address)
-
-	balance := 0.0
-	pubKeyHash := Base58Decode([]byte(address))
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
-	UTXOs := chain.FindUTXO(pubKeyHash)
-
-	for _, out := range UTXOs {
-		balance += out.Value
+// Send builds and signs a transaction and queues it in the mempool. A zero
+// fee stands for the minimum fee. It returns the hex encoded ID of the
+// pending transaction.
+func (bc *Blockchain) Send(from, to string, amount, fee Amount) (string, error) {
+	minFee := bc.MinFee()
+	if fee == 0 {
+		fee = minFee
+	}
+	if fee < minFee {
+		return "", fmt.Errorf("%w %s", ErrFeeTooLow, minFee)
 	}
-
-	return balance
-}
-
-func (bc *Blockchain) Send(from, to string, amount float64) error {
 	key := generateTransactionKey(from, to, amount)
 	if !ValidateAddress(from) {
-		return errors.New(fmt.Sprintf("ERROR: Sender address is not valid"))
+		return "", errors.New(fmt.Sprintf("ERROR: Sender address is not valid"))
 	}
 	if !ValidateAddress(to) {
-		return errors.New(fmt.Sprintf("ERROR: Sender address is not valid"))
+		return "", errors.New(fmt.Sprintf("ERROR: Recipient address is not valid"))
 	}
 
-	NewBlockchain(bc.DB, from)
+	tx, err := bc.queueTransaction(from, to, amount, fee, key)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
 
-	tx, err := NewUTXOTransaction(from, to, amount, bc, key)
+// queueTransaction builds a transaction with NewUTXOTransaction and adds it to
+// the mempool. A non-empty idempotency key is refused with
+// ErrAlreadyProcessed once a transaction queued with it is accepted.
+func (bc *Blockchain) queueTransaction(from, to string, amount, fee Amount, key string) (*Transaction, error) {
+	bc.sending.Lock()
+	defer bc.sending.Unlock()
+
+	processedKeysMu.Lock()
+	processed := processedKeys[key]
+	processedKeysMu.Unlock()
+	if key != "" && processed {
+		return nil, ErrAlreadyProcessed
+	}
+
+	tx, err := NewUTXOTransaction(from, to, amount, fee, bc, bc.keys)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = bc.MineBlock([]*Transaction{tx})
+	err = bc.acceptTransaction(tx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if key != "" {
+		processedKeysMu.Lock()
+		processedKeys[key] = true
+		processedKeysMu.Unlock()
+	}
+
+	return tx, nil
 }
 
-func generateTransactionKey(from, to string, amount float64) string {
-	amountStr := fmt.Sprintf("%.2f", amount)
+func generateTransactionKey(from, to string, amount Amount) string {
+	amountStr := amount.String()
 
 	timestamp := time.Now().Unix() / 60
 
@@ -1,40 +1,32 @@
 package blockchainlogic
 
 import (
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"log"
 )
 
+// BlockchainIterator walks the chain from the tip back to genesis.
 type BlockchainIterator struct {
 	currentHash string
-	db          *sql.DB
+	store       ChainStore
 }
 
+// Next returns the current block and moves to its parent. It returns nil
+// past genesis.
 func (i *BlockchainIterator) Next() *Block {
-	var block Block
-	var transactionsJSON string
-
-	// Retrieve the block data from the 'blocks' table using the current hash
-	row := i.db.QueryRow("SELECT hash, transactions, previous_hash, timestamp, nonce FROM blocks WHERE hash = $1", i.currentHash)
-
-	err := row.Scan(&block.Hash, &transactionsJSON, &block.PrevHash, &block.Timestamp, &block.Nonce)
+	if i.currentHash == "" {
+		return nil
+	}
+	block, err := i.store.Block(i.currentHash)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, ErrNotFound) {
 			return nil // End of the blockchain
 		}
 
 		log.Panic(err)
 	}
 
-	if err = json.Unmarshal([]byte(transactionsJSON), &block.Transactions); err != nil {
-		log.Fatal(err)
-
-		return nil
-	}
-
 	i.currentHash = block.PrevHash
 
-	return &block
+	return block
 }
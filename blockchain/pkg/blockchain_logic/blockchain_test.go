@@ -0,0 +1,225 @@
+package blockchainlogic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// testBlockchain opens a chain in memory whose genesis pays a new wallet of
+// the returned keystore.
+func testBlockchain(t *testing.T) (*Blockchain, string) {
+	t.Helper()
+
+	keys := NewWallets()
+	miner, err := CreateWallet(keys)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	return CreateBlockchain(NewMemoryChainStore(), keys, miner), miner
+}
+
+func TestBlockchain_SendAndMine(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	recipient := string(NewWallet().GetAddress())
+
+	txID, err := bc.Send(miner, recipient, 25, 1)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	bc.mineMempool(context.Background(), 10)
+
+	if height, err := bc.Height(); err != nil || height != 1 {
+		t.Fatalf("Height() = %d, %v, want 1", height, err)
+	}
+	if balance, err := bc.GetBalance(recipient); err != nil || balance != 25 {
+		t.Errorf("GetBalance() of the recipient = %v, %v, want 25", balance, err)
+	}
+	want := genesisReward() - 25 + BlockSubsidy()
+	if balance, err := bc.GetBalance(miner); err != nil || balance != want {
+		t.Errorf("GetBalance() of the miner = %v, %v, want %v", balance, err, want)
+	}
+
+	info, err := bc.TransactionInfo(txID)
+	if err != nil {
+		t.Fatalf("TransactionInfo() error = %v", err)
+	}
+	if info.Status != TxStatusConfirmed || info.Height != 1 || info.Confirmations != 1 {
+		t.Errorf("TransactionInfo() = %+v, want confirmed at height 1", info)
+	}
+	if _, err = bc.TransactionProof(txID); err != nil {
+		t.Errorf("TransactionProof() error = %v", err)
+	}
+
+	history, next, err := bc.History([]string{recipient}, HistoryFilter{})
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 1 || history[0].TxID != txID || history[0].Direction != DirectionIncoming || next != 0 {
+		t.Errorf("History() = %+v, %d, want the incoming transfer", history, next)
+	}
+	// The genesis coinbase, the transfer and the coinbase of block 1.
+	txs, err := bc.AddressTransactions(miner, 10, 0)
+	if err != nil || len(txs) != 3 {
+		t.Errorf("AddressTransactions() of the miner = %d transactions, %v, want 3", len(txs), err)
+	}
+
+	if err = bc.Reindex(); err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	if balance, err := bc.GetBalance(recipient); err != nil || balance != 25 {
+		t.Errorf("GetBalance() after Reindex() = %v, %v, want 25", balance, err)
+	}
+
+	report, err := VerifyChain(bc.Store())
+	if err != nil || !report.Valid() {
+		t.Errorf("VerifyChain() = %v, %v, want a valid chain", report, err)
+	}
+}
+
+// TestBlockchain_IterateWhileMining is meant for the race detector: readers
+// walk the chain while blocks are mined.
+func TestBlockchain_IterateWhileMining(t *testing.T) {
+	bc, _ := testBlockchain(t)
+	const blocks = 5
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < blocks; i++ {
+			if err := bc.MineBlock(context.Background(), nil); err != nil {
+				t.Errorf("MineBlock() error = %v", err)
+
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		n := 0
+		for it := bc.Iterator(); it.Next() != nil; {
+			n++
+		}
+		if n < 1 || n > blocks+1 {
+			t.Fatalf("Iterator() walked %d blocks, want 1 to %d", n, blocks+1)
+		}
+		select {
+		case <-done:
+			if height, err := bc.Height(); err != nil || height != blocks {
+				t.Errorf("Height() = %d, %v, want %d", height, err, blocks)
+			}
+
+			return
+		default:
+		}
+	}
+}
+
+func TestBlockchain_AddBlock(t *testing.T) {
+	miner, _ := testBlockchain(t)
+	if err := miner.MineBlock(context.Background(), nil); err != nil {
+		t.Fatalf("MineBlock() error = %v", err)
+	}
+	genesis, err := miner.Store().BlockAt(0)
+	if err != nil {
+		t.Fatalf("BlockAt() error = %v", err)
+	}
+	block, err := miner.Store().Tip()
+	if err != nil {
+		t.Fatalf("Tip() error = %v", err)
+	}
+
+	// A chain without blocks takes the genesis of its peers.
+	bc := NewBlockchain(NewMemoryChainStore(), NewWallets(), string(NewWallet().GetAddress()))
+	if err = bc.AddBlock(block); !errors.Is(err, ErrOrphanBlock) {
+		t.Errorf("AddBlock() before genesis error = %v, want %v", err, ErrOrphanBlock)
+	}
+	if err = bc.AddBlock(genesis); err != nil {
+		t.Fatalf("AddBlock() of genesis error = %v", err)
+	}
+
+	overpaid := *block
+	overpaid.Transactions = []*Transaction{newCoinbaseTX(string(NewWallet().GetAddress()), BlockSubsidy()+1, "")}
+	overpaid.MerkleRoot = overpaid.HashTransactions()
+	overpaid.Nonce, overpaid.Hash = NewProof(&overpaid).Run()
+	if err = bc.AddBlock(&overpaid); !errors.Is(err, ErrInvalidBlock) {
+		t.Errorf("AddBlock() of an overpaying coinbase error = %v, want %v", err, ErrInvalidBlock)
+	}
+	tampered := *block
+	tampered.Nonce++
+	if err = bc.AddBlock(&tampered); !errors.Is(err, ErrInvalidBlock) {
+		t.Errorf("AddBlock() with a broken proof of work error = %v, want %v", err, ErrInvalidBlock)
+	}
+
+	if err = bc.AddBlock(block); err != nil {
+		t.Fatalf("AddBlock() error = %v", err)
+	}
+	if err = bc.AddBlock(block); !errors.Is(err, ErrBlockExists) {
+		t.Errorf("AddBlock() twice error = %v, want %v", err, ErrBlockExists)
+	}
+	if height, err := bc.Height(); err != nil || height != 1 {
+		t.Errorf("Height() = %d, %v, want 1", height, err)
+	}
+}
+
+func TestBlockchain_QueueTransaction(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	recipient := string(NewWallet().GetAddress())
+
+	if _, err := bc.queueTransaction(miner, recipient, 25, 1, "first"); err != nil {
+		t.Fatalf("queueTransaction() error = %v", err)
+	}
+	// The only output of the miner is spent by the pending transaction.
+	if _, err := bc.queueTransaction(miner, recipient, 30, 1, "second"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("queueTransaction() with spent outputs error = %v, want %v", err, ErrInsufficientFunds)
+	}
+	bc.mineMempool(context.Background(), 10)
+
+	// A refused transaction does not use up its idempotency key.
+	if _, err := bc.queueTransaction(miner, recipient, 30, 1, "second"); err != nil {
+		t.Fatalf("queueTransaction() retried error = %v", err)
+	}
+	if _, err := bc.queueTransaction(miner, recipient, 30, 1, "second"); !errors.Is(err, ErrAlreadyProcessed) {
+		t.Errorf("queueTransaction() repeated error = %v, want %v", err, ErrAlreadyProcessed)
+	}
+}
+
+// TestBlockchain_QueueTransactionConcurrent sends from one wallet at once;
+// each transfer must pick outputs of its own.
+func TestBlockchain_QueueTransactionConcurrent(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	const sends = 4
+	for i := 1; i < sends; i++ {
+		if err := bc.MineBlock(context.Background(), nil); err != nil {
+			t.Fatalf("MineBlock() error = %v", err)
+		}
+	}
+	recipient := string(NewWallet().GetAddress())
+
+	var wg sync.WaitGroup
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "send-" + strconv.Itoa(i)
+			if _, err := bc.queueTransaction(miner, recipient, BlockSubsidy()/2, 1, key); err != nil {
+				t.Errorf("queueTransaction() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	bc.mineMempool(context.Background(), 10)
+	if balance, err := bc.GetBalance(recipient); err != nil || balance != sends*(BlockSubsidy()/2) {
+		t.Errorf("GetBalance() of the recipient = %v, %v, want %v", balance, err, sends*(BlockSubsidy()/2))
+	}
+}
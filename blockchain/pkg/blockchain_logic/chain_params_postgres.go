@@ -0,0 +1,63 @@
+package blockchainlogic
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Chain parameters kept in the chain_params table.
+const (
+	// ParamDecimals is the number of decimals of the stored amounts.
+	ParamDecimals = "decimals"
+	// ParamTreasury is the treasury address generated on the first start of
+	// a chain configured without a genesis recipient.
+	ParamTreasury = "treasury"
+)
+
+// ErrDecimalsMismatch is returned when the configured decimals differ from
+// those the stored amounts use.
+var ErrDecimalsMismatch = errors.New("configured decimals do not match the stored chain")
+
+// ChainParam returns the chain parameter name stored in db, or ErrNotFound.
+func ChainParam(db *sql.DB, name string) (string, error) {
+	var value string
+	err := db.QueryRow("SELECT value FROM chain_params WHERE name = $1", name).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+
+	return value, err
+}
+
+// BindChainParam stores value as the chain parameter name unless it is
+// already stored, and returns the stored value. Concurrent callers all get
+// the value of the first one.
+func BindChainParam(db *sql.DB, name, value string) (string, error) {
+	_, err := db.Exec("INSERT INTO chain_params (name, value) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING", name, value)
+	if err != nil {
+		return "", err
+	}
+
+	return ChainParam(db, name)
+}
+
+// BindDecimals checks that the amounts stored in db use the decimals set with
+// SetDecimals. A chain without recorded decimals is bound to them, so they
+// can not change afterwards.
+func BindDecimals(db *sql.DB) error {
+	value, err := BindChainParam(db, ParamDecimals, strconv.Itoa(decimals))
+	if err != nil {
+		return err
+	}
+	stored, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("stored decimals %q: %w", value, err)
+	}
+	if stored != decimals {
+		return fmt.Errorf("%w: stored amounts use %d decimals, the config sets %d", ErrDecimalsMismatch, stored, decimals)
+	}
+
+	return nil
+}
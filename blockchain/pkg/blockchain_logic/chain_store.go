@@ -0,0 +1,290 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// ChainStore keeps the blocks of the chain together with the utxo set and the
+// transaction indexes built from them. Besides the main chain it keeps side
+// branch blocks, which are stored but not applied to the utxo set or the
+// indexes. Implementations are safe for concurrent use.
+type ChainStore interface {
+	// AddBlock appends block to the main chain, applies it to the utxo set
+	// and indexes its transactions, atomically. block must be the child of
+	// the tip, or a genesis block for an empty chain, and may already be
+	// stored as a side branch block. It fails when the block spends an output
+	// that is not in the utxo set.
+	AddBlock(block *Block) error
+	// AddSideBlock stores block off the main chain. Its parent must be
+	// stored.
+	AddSideBlock(block *Block) error
+	// DisconnectTip removes the tip from the main chain, keeping it as a
+	// side branch block, and rolls the utxo set and the indexes back to its
+	// parent, atomically. It returns the removed block.
+	DisconnectTip() (*Block, error)
+	// Tip returns the last block of the main chain, or ErrNotFound for an
+	// empty chain.
+	Tip() (*Block, error)
+	// Height returns the height of the tip, -1 for an empty chain.
+	Height() (int, error)
+	// Block returns the block with the given hash on any branch, or
+	// ErrNotFound.
+	Block(hash string) (*Block, error)
+	// BlockAt returns the main chain block at height, genesis being 0, or
+	// ErrNotFound.
+	BlockAt(height int) (*Block, error)
+	// BlockHeight returns the height of the main chain block with the given
+	// hash, or ErrNotFound.
+	BlockHeight(hash string) (int, error)
+	// BlockNode returns where the block with the given hash sits among the
+	// stored branches, or ErrNotFound.
+	BlockNode(hash string) (BlockNode, error)
+	// ChainTips returns the stored blocks no other block builds on, the tip
+	// of the main chain included, most work first.
+	ChainTips() ([]BlockNode, error)
+	// ForEachBlock calls fn for every main chain block from genesis to tip.
+	// fn must not add blocks.
+	ForEachBlock(fn func(height int, block *Block) error) error
+
+	// TransactionBlock returns the hash of the block holding the confirmed
+	// transaction txID, or ErrNotFound.
+	TransactionBlock(txID []byte) (string, error)
+	// IsUnspent reports whether output vout of txID is in the utxo set.
+	IsUnspent(txID []byte, vout int) (bool, error)
+	// UnspentOutputs returns the unspent outputs locked with any of
+	// pubKeyHashes, largest first.
+	UnspentOutputs(pubKeyHashes [][]byte) ([]SpendableOutput, error)
+	// AddressTransactions returns the confirmed transactions of the
+	// addresses selected by query, newest first.
+	AddressTransactions(query AddressTxQuery) ([]AddressTx, error)
+
+	// Reindex rebuilds the utxo set and the transaction indexes from the
+	// blocks.
+	Reindex() error
+	// Reset drops every block, leaving an empty chain. Only regtest chains
+	// reset their store.
+	Reset() error
+}
+
+// BlockNode places a stored block in the tree of branches.
+type BlockNode struct {
+	Hash     string
+	PrevHash string
+	Height   int
+	// Work is the work of the branch from genesis up to the block, the
+	// expected number of hashes to mine it.
+	Work      *big.Int
+	MainChain bool
+}
+
+// blockWork returns the expected number of hashes to mine a block at
+// difficulty.
+func blockWork(difficulty int) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(difficulty))
+}
+
+// childNode returns the node of block given the node of its parent, which is
+// nil for a genesis block.
+func childNode(parent *BlockNode, block *Block) BlockNode {
+	node := BlockNode{Hash: block.Hash, PrevHash: block.PrevHash, Work: blockWork(block.Difficulty)}
+	if parent != nil {
+		node.Height = parent.Height + 1
+		node.Work.Add(node.Work, parent.Work)
+	}
+
+	return node
+}
+
+// checkExtends checks that block can be appended to the main chain ending
+// with the block hashed tip, empty for an empty chain.
+func checkExtends(tip string, block *Block) error {
+	if tip == "" && block.PrevHash != "0" || tip != "" && block.PrevHash != tip {
+		return fmt.Errorf("block %s does not extend the tip", block.Hash)
+	}
+
+	return nil
+}
+
+// sortTips orders chain tips by decreasing work, then by hash so the order is
+// stable.
+func sortTips(tips []BlockNode) {
+	sort.Slice(tips, func(i, j int) bool {
+		if c := tips[i].Work.Cmp(tips[j].Work); c != 0 {
+			return c > 0
+		}
+
+		return tips[i].Hash < tips[j].Hash
+	})
+}
+
+// AddressTxQuery selects transactions sending from or paying to any of
+// PubKeyHashes. Zero values disable the corresponding filter.
+type AddressTxQuery struct {
+	PubKeyHashes [][]byte
+	// From and To bound the block timestamp, To being exclusive.
+	From time.Time
+	To   time.Time
+	// Before keeps transactions older than the given Seq.
+	Before int64
+	Offset int
+	Limit  int
+}
+
+// AddressTx is a confirmed transaction of an address. Seq grows with every
+// indexed address and transaction pair; a transaction of several of the
+// queried addresses is returned once, with its highest Seq.
+type AddressTx struct {
+	Seq       int64
+	TxID      string
+	BlockHash string
+}
+
+// addressTxEntry is an indexed address and transaction pair, for stores
+// filtering the index themselves.
+type addressTxEntry struct {
+	AddressTx
+	PubKeyHash []byte
+	Timestamp  time.Time
+}
+
+// selectAddressTxs applies query to entries the way the Postgres store does
+// in SQL.
+func selectAddressTxs(entries []addressTxEntry, query AddressTxQuery) []AddressTx {
+	type key struct{ txID, blockHash string }
+	latest := make(map[key]AddressTx)
+	for _, e := range entries {
+		if !containsHash(query.PubKeyHashes, e.PubKeyHash) {
+			continue
+		}
+		if !query.From.IsZero() && e.Timestamp.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !e.Timestamp.Before(query.To) {
+			continue
+		}
+		k := key{e.TxID, e.BlockHash}
+		if e.Seq > latest[k].Seq {
+			latest[k] = e.AddressTx
+		}
+	}
+
+	txs := make([]AddressTx, 0, len(latest))
+	for _, tx := range latest {
+		if query.Before <= 0 || tx.Seq < query.Before {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Seq > txs[j].Seq })
+
+	txs = txs[min(query.Offset, len(txs)):]
+	if query.Limit > 0 && len(txs) > query.Limit {
+		txs = txs[:query.Limit]
+	}
+
+	return txs
+}
+
+func containsHash(hashes [][]byte, hash []byte) bool {
+	for _, h := range hashes {
+		if bytes.Equal(h, hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortOutputs orders outputs largest first, then by outpoint so the order is
+// stable.
+func sortOutputs(outputs []SpendableOutput) {
+	sort.Slice(outputs, func(i, j int) bool {
+		if outputs[i].Value != outputs[j].Value {
+			return outputs[i].Value > outputs[j].Value
+		}
+		if c := bytes.Compare(outputs[i].TxID, outputs[j].TxID); c != 0 {
+			return c < 0
+		}
+
+		return outputs[i].Vout < outputs[j].Vout
+	})
+}
+
+// utxoChanges returns the outputs block spends from the utxo set and the
+// outputs it adds, keyed by outpoint. Outputs created and spent within the
+// block appear in neither. unspent reports whether an outpoint is in the
+// utxo set before the block.
+func utxoChanges(block *Block, unspent func(txID []byte, vout int) (bool, error)) (spent []SpendableOutput, created map[string]SpendableOutput, err error) {
+	created = make(map[string]SpendableOutput)
+	spentKeys := make(map[string]bool)
+
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Vin {
+				key := outpoint(in.Txid, in.Vout)
+				if _, ok := created[key]; ok {
+					delete(created, key)
+
+					continue
+				}
+				ok, err := unspent(in.Txid, in.Vout)
+				if err != nil {
+					return nil, nil, err
+				}
+				if !ok || spentKeys[key] {
+					return nil, nil, fmt.Errorf("output %x:%d is already spent or does not exist", in.Txid, in.Vout)
+				}
+				spentKeys[key] = true
+				spent = append(spent, SpendableOutput{TxID: in.Txid, Vout: in.Vout})
+			}
+		}
+
+		for i, out := range tx.Vout {
+			created[outpoint(tx.ID, i)] = SpendableOutput{TxID: tx.ID, Vout: i, Value: out.Value, PubKeyHash: out.PubKeyHash}
+		}
+	}
+
+	return spent, created, nil
+}
+
+// utxoUndo returns the outputs to put back into the utxo set and the ones to
+// remove from it when block is disconnected. output returns an output of a
+// transaction confirmed before block.
+func utxoUndo(block *Block, output func(txID []byte, vout int) (SpendableOutput, error)) (restored, removed []SpendableOutput, err error) {
+	spent, created, err := utxoChanges(block, func([]byte, int) (bool, error) { return true, nil })
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, in := range spent {
+		out, err := output(in.TxID, in.Vout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("output %x:%d spent by block %s: %w", in.TxID, in.Vout, block.Hash, err)
+		}
+		restored = append(restored, out)
+	}
+	for _, out := range created {
+		removed = append(removed, out)
+	}
+
+	return restored, removed, nil
+}
+
+// blockOutput returns output vout of transaction txID stored in block.
+func blockOutput(block *Block, txID []byte, vout int) (SpendableOutput, error) {
+	for _, tx := range block.Transactions {
+		if !bytes.Equal(tx.ID, txID) {
+			continue
+		}
+		if vout < 0 || vout >= len(tx.Vout) {
+			break
+		}
+		out := tx.Vout[vout]
+
+		return SpendableOutput{TxID: tx.ID, Vout: vout, Value: out.Value, PubKeyHash: out.PubKeyHash}, nil
+	}
+
+	return SpendableOutput{}, ErrNotFound
+}
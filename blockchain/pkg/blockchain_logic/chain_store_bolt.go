@@ -0,0 +1,697 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the embedded chain file. blocks maps the hash of a block on any
+// branch to its encoding and nodes to its parent, height and work; chain maps
+// a big endian height to the main chain block hash. The other buckets are
+// indexes of the main chain rebuilt by Reindex.
+var (
+	boltBlocks    = []byte("blocks")
+	boltNodes     = []byte("block_nodes")
+	boltChain     = []byte("chain")
+	boltHeights   = []byte("heights")
+	boltTxIndex   = []byte("tx_index")
+	boltUTXO      = []byte("utxo")
+	boltUTXOOwner = []byte("utxo_owner")
+	boltAddressTx = []byte("address_tx")
+)
+
+var boltIndexes = [][]byte{boltHeights, boltTxIndex, boltUTXO, boltUTXOOwner, boltAddressTx}
+
+// BoltChainStore keeps the chain in a single embedded file.
+type BoltChainStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltChainStore opens the chain file at path, creating it when missing.
+func OpenBoltChainStore(path string) (*BoltChainStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range append([][]byte{boltBlocks, boltNodes, boltChain}, boltIndexes...) {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return boltAddMissingNodes(tx)
+	})
+	if err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return &BoltChainStore{db: db}, nil
+}
+
+// Close releases the chain file.
+func (s *BoltChainStore) Close() error {
+	return s.db.Close()
+}
+
+func boltHeightKey(height int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(height))
+}
+
+func boltOutpointKey(txID []byte, vout int) []byte {
+	return binary.BigEndian.AppendUint32(append([]byte(nil), txID...), uint32(vout))
+}
+
+func boltBlock(tx *bolt.Tx, hash string) (*Block, error) {
+	data := tx.Bucket(boltBlocks).Get([]byte(hash))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+
+	return DeserializeBlock(data)
+}
+
+func boltPutNode(tx *bolt.Tx, node BlockNode) error {
+	var e encoder
+	e.bytes([]byte(node.PrevHash))
+	e.int64(int64(node.Height))
+	e.bytes(node.Work.Bytes())
+
+	return tx.Bucket(boltNodes).Put([]byte(node.Hash), e.buf)
+}
+
+func boltNode(tx *bolt.Tx, hash string) (BlockNode, error) {
+	v := tx.Bucket(boltNodes).Get([]byte(hash))
+	if v == nil {
+		return BlockNode{}, ErrNotFound
+	}
+
+	return boltDecodeNode(tx, []byte(hash), v)
+}
+
+func boltDecodeNode(tx *bolt.Tx, hash, v []byte) (BlockNode, error) {
+	d := decoder{data: v}
+	node := BlockNode{
+		Hash:     string(hash),
+		PrevHash: string(d.bytes("previous hash")),
+		Height:   int(d.int64("height")),
+		Work:     new(big.Int).SetBytes(d.bytes("work")),
+	}
+	if err := d.finish(); err != nil {
+		return BlockNode{}, fmt.Errorf("block node %s: %w", hash, err)
+	}
+	node.MainChain = bytes.Equal(tx.Bucket(boltChain).Get(boltHeightKey(node.Height)), hash)
+
+	return node, nil
+}
+
+// boltNewNode places block, which is not stored yet, under its parent.
+func boltNewNode(tx *bolt.Tx, block *Block) (BlockNode, error) {
+	if block.PrevHash == "0" {
+		return childNode(nil, block), nil
+	}
+	parent, err := boltNode(tx, block.PrevHash)
+	if err != nil {
+		return BlockNode{}, fmt.Errorf("parent of block %s is not stored", block.Hash)
+	}
+
+	return childNode(&parent, block), nil
+}
+
+// boltAddMissingNodes places the main chain blocks of files written before
+// block nodes were stored.
+func boltAddMissingNodes(tx *bolt.Tx) error {
+	if k, _ := tx.Bucket(boltNodes).Cursor().First(); k != nil {
+		return nil
+	}
+
+	var parent *BlockNode
+
+	return tx.Bucket(boltChain).ForEach(func(_, hash []byte) error {
+		block, err := boltBlock(tx, string(hash))
+		if err != nil {
+			return err
+		}
+		node := childNode(parent, block)
+		parent = &node
+
+		return boltPutNode(tx, node)
+	})
+}
+
+func boltTipHeight(tx *bolt.Tx) int {
+	k, _ := tx.Bucket(boltChain).Cursor().Last()
+	if k == nil {
+		return -1
+	}
+
+	return int(binary.BigEndian.Uint64(k))
+}
+
+func (s *BoltChainStore) AddBlock(block *Block) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltHeights).Get([]byte(block.Hash)) != nil {
+			return fmt.Errorf("block %s is already stored", block.Hash)
+		}
+		height, tip := boltTipHeight(tx), ""
+		if height >= 0 {
+			tip = string(tx.Bucket(boltChain).Get(boltHeightKey(height)))
+		}
+		height++
+		err := checkExtends(tip, block)
+		if err != nil {
+			return err
+		}
+
+		if tx.Bucket(boltBlocks).Get([]byte(block.Hash)) == nil {
+			err = boltStoreBlock(tx, block)
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Bucket(boltChain).Put(boltHeightKey(height), []byte(block.Hash))
+		if err != nil {
+			return err
+		}
+
+		return boltIndexBlock(tx, height, block)
+	})
+}
+
+func (s *BoltChainStore) AddSideBlock(block *Block) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltBlocks).Get([]byte(block.Hash)) != nil {
+			return fmt.Errorf("block %s is already stored", block.Hash)
+		}
+
+		return boltStoreBlock(tx, block)
+	})
+}
+
+// boltStoreBlock stores block and its node.
+func boltStoreBlock(tx *bolt.Tx, block *Block) error {
+	node, err := boltNewNode(tx, block)
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(boltBlocks).Put([]byte(block.Hash), block.Serialize())
+	if err != nil {
+		return err
+	}
+
+	return boltPutNode(tx, node)
+}
+
+// boltIndexBlock applies block at height to the utxo set and the indexes.
+func boltIndexBlock(tx *bolt.Tx, height int, block *Block) error {
+	utxo, owners := tx.Bucket(boltUTXO), tx.Bucket(boltUTXOOwner)
+
+	spent, created, err := utxoChanges(block, func(txID []byte, vout int) (bool, error) {
+		return utxo.Get(boltOutpointKey(txID, vout)) != nil, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, out := range spent {
+		d := decoder{data: utxo.Get(boltOutpointKey(out.TxID, out.Vout))}
+		d.int64("value")
+		out.PubKeyHash = d.bytes("public key hash")
+		if err = boltDeleteOutput(utxo, owners, out); err != nil {
+			return err
+		}
+	}
+	for _, out := range created {
+		if err = boltPutOutput(utxo, owners, out); err != nil {
+			return err
+		}
+	}
+
+	err = tx.Bucket(boltHeights).Put([]byte(block.Hash), boltHeightKey(height))
+	if err != nil {
+		return err
+	}
+	txIndex, addressTxs := tx.Bucket(boltTxIndex), tx.Bucket(boltAddressTx)
+	for _, t := range block.Transactions {
+		err = txIndex.Put(t.ID, []byte(block.Hash))
+		if err != nil {
+			return err
+		}
+		for _, pubKeyHash := range participants(t) {
+			seq, err := addressTxs.NextSequence()
+			if err != nil {
+				return err
+			}
+			address, err := addressTxs.CreateBucketIfNotExists(pubKeyHash)
+			if err != nil {
+				return err
+			}
+			var e encoder
+			e.int64(block.Timestamp.UnixNano())
+			e.bytes(t.ID)
+			e.bytes([]byte(block.Hash))
+			if err = address.Put(binary.BigEndian.AppendUint64(nil, seq), e.buf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// boltPutOutput adds out to the utxo set.
+func boltPutOutput(utxo, owners *bolt.Bucket, out SpendableOutput) error {
+	key := boltOutpointKey(out.TxID, out.Vout)
+	var e encoder
+	e.int64(int64(out.Value))
+	e.bytes(out.PubKeyHash)
+	if err := utxo.Put(key, e.buf); err != nil {
+		return err
+	}
+	// Script outputs have no public key hash and are not found by the
+	// wallet lookups.
+	if out.PubKeyHash == nil {
+		return nil
+	}
+	owner, err := owners.CreateBucketIfNotExists(out.PubKeyHash)
+	if err != nil {
+		return err
+	}
+
+	return owner.Put(key, nil)
+}
+
+// boltDeleteOutput removes out, whose PubKeyHash is set, from the utxo set.
+func boltDeleteOutput(utxo, owners *bolt.Bucket, out SpendableOutput) error {
+	key := boltOutpointKey(out.TxID, out.Vout)
+	if out.PubKeyHash != nil {
+		if owner := owners.Bucket(out.PubKeyHash); owner != nil {
+			if err := owner.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return utxo.Delete(key)
+}
+
+func (s *BoltChainStore) DisconnectTip() (*Block, error) {
+	var block *Block
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		height := boltTipHeight(tx)
+		if height < 0 {
+			return ErrNotFound
+		}
+		chain := tx.Bucket(boltChain)
+		var err error
+		block, err = boltBlock(tx, string(chain.Get(boltHeightKey(height))))
+		if err != nil {
+			return err
+		}
+		err = boltUnindexBlock(tx, block)
+		if err != nil {
+			return err
+		}
+
+		return chain.Delete(boltHeightKey(height))
+	})
+
+	return block, err
+}
+
+// boltUnindexBlock rolls block, the main chain tip, back from the utxo set
+// and the indexes.
+func boltUnindexBlock(tx *bolt.Tx, block *Block) error {
+	utxo, owners, txIndex := tx.Bucket(boltUTXO), tx.Bucket(boltUTXOOwner), tx.Bucket(boltTxIndex)
+
+	restored, removed, err := utxoUndo(block, func(txID []byte, vout int) (SpendableOutput, error) {
+		blockHash := txIndex.Get(txID)
+		if blockHash == nil {
+			return SpendableOutput{}, ErrNotFound
+		}
+		prev, err := boltBlock(tx, string(blockHash))
+		if err != nil {
+			return SpendableOutput{}, err
+		}
+
+		return blockOutput(prev, txID, vout)
+	})
+	if err != nil {
+		return err
+	}
+	for _, out := range removed {
+		err = boltDeleteOutput(utxo, owners, out)
+		if err != nil {
+			return err
+		}
+	}
+	for _, out := range restored {
+		err = boltPutOutput(utxo, owners, out)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Bucket(boltHeights).Delete([]byte(block.Hash))
+	if err != nil {
+		return err
+	}
+	addressTxs := tx.Bucket(boltAddressTx)
+	for _, t := range block.Transactions {
+		if bytes.Equal(txIndex.Get(t.ID), []byte(block.Hash)) {
+			if err = txIndex.Delete(t.ID); err != nil {
+				return err
+			}
+		}
+		for _, pubKeyHash := range participants(t) {
+			address := addressTxs.Bucket(pubKeyHash)
+			if address == nil {
+				continue
+			}
+			err = boltDeleteAddressTx(address, t.ID, block.Hash)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// boltDeleteAddressTx removes the entries of transaction txID in block from
+// the address_tx bucket of an address. They are the latest ones, so the
+// bucket is walked backwards.
+func boltDeleteAddressTx(address *bolt.Bucket, txID []byte, blockHash string) error {
+	var keys [][]byte
+	c := address.Cursor()
+	for k, v := c.Last(); k != nil; k, v = c.Prev() {
+		d := decoder{data: v}
+		d.int64("timestamp")
+		id := d.bytes("transaction id")
+		hash := d.bytes("block hash")
+		if err := d.finish(); err != nil {
+			return fmt.Errorf("address transaction %x: %w", k, err)
+		}
+		if string(hash) != blockHash {
+			break
+		}
+		if bytes.Equal(id, txID) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+	}
+	for _, k := range keys {
+		if err := address.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BoltChainStore) Tip() (*Block, error) {
+	var block *Block
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, hash := tx.Bucket(boltChain).Cursor().Last()
+		if hash == nil {
+			return ErrNotFound
+		}
+		var err error
+		block, err = boltBlock(tx, string(hash))
+
+		return err
+	})
+
+	return block, err
+}
+
+func (s *BoltChainStore) Height() (int, error) {
+	var height int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		height = boltTipHeight(tx)
+
+		return nil
+	})
+
+	return height, err
+}
+
+func (s *BoltChainStore) Block(hash string) (*Block, error) {
+	var block *Block
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		block, err = boltBlock(tx, hash)
+
+		return err
+	})
+
+	return block, err
+}
+
+func (s *BoltChainStore) BlockAt(height int) (*Block, error) {
+	if height < 0 {
+		return nil, ErrNotFound
+	}
+	var block *Block
+	err := s.db.View(func(tx *bolt.Tx) error {
+		hash := tx.Bucket(boltChain).Get(boltHeightKey(height))
+		if hash == nil {
+			return ErrNotFound
+		}
+		var err error
+		block, err = boltBlock(tx, string(hash))
+
+		return err
+	})
+
+	return block, err
+}
+
+func (s *BoltChainStore) BlockHeight(hash string) (int, error) {
+	var height int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltHeights).Get([]byte(hash))
+		if v == nil {
+			return ErrNotFound
+		}
+		height = int(binary.BigEndian.Uint64(v))
+
+		return nil
+	})
+
+	return height, err
+}
+
+func (s *BoltChainStore) BlockNode(hash string) (BlockNode, error) {
+	var node BlockNode
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		node, err = boltNode(tx, hash)
+
+		return err
+	})
+
+	return node, err
+}
+
+func (s *BoltChainStore) ChainTips() ([]BlockNode, error) {
+	var tips []BlockNode
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var nodes []BlockNode
+		parents := make(map[string]bool)
+		err := tx.Bucket(boltNodes).ForEach(func(hash, v []byte) error {
+			node, err := boltDecodeNode(tx, hash, v)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, node)
+			parents[node.PrevHash] = true
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			if !parents[node.Hash] {
+				tips = append(tips, node)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortTips(tips)
+
+	return tips, nil
+}
+
+func (s *BoltChainStore) ForEachBlock(fn func(height int, block *Block) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltChain).ForEach(func(k, hash []byte) error {
+			block, err := boltBlock(tx, string(hash))
+			if err != nil {
+				return err
+			}
+
+			return fn(int(binary.BigEndian.Uint64(k)), block)
+		})
+	})
+}
+
+func (s *BoltChainStore) TransactionBlock(txID []byte) (string, error) {
+	var blockHash string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltTxIndex).Get(txID)
+		if v == nil {
+			return ErrNotFound
+		}
+		blockHash = string(v)
+
+		return nil
+	})
+
+	return blockHash, err
+}
+
+func (s *BoltChainStore) IsUnspent(txID []byte, vout int) (bool, error) {
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket(boltUTXO).Get(boltOutpointKey(txID, vout)) != nil
+
+		return nil
+	})
+
+	return ok, err
+}
+
+func (s *BoltChainStore) UnspentOutputs(pubKeyHashes [][]byte) ([]SpendableOutput, error) {
+	var outputs []SpendableOutput
+	err := s.db.View(func(tx *bolt.Tx) error {
+		utxo, owners := tx.Bucket(boltUTXO), tx.Bucket(boltUTXOOwner)
+		for _, pubKeyHash := range pubKeyHashes {
+			owner := owners.Bucket(pubKeyHash)
+			if len(pubKeyHash) == 0 || owner == nil {
+				continue
+			}
+			err := owner.ForEach(func(key, _ []byte) error {
+				d := decoder{data: utxo.Get(key)}
+				out := SpendableOutput{
+					TxID:       append([]byte(nil), key[:len(key)-4]...),
+					Vout:       int(binary.BigEndian.Uint32(key[len(key)-4:])),
+					Value:      Amount(d.int64("value")),
+					PubKeyHash: d.bytes("public key hash"),
+				}
+				if err := d.finish(); err != nil {
+					return fmt.Errorf("utxo %x: %w", key, err)
+				}
+				outputs = append(outputs, out)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortOutputs(outputs)
+
+	return outputs, nil
+}
+
+func (s *BoltChainStore) AddressTransactions(query AddressTxQuery) ([]AddressTx, error) {
+	var entries []addressTxEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		addressTxs := tx.Bucket(boltAddressTx)
+		for _, pubKeyHash := range query.PubKeyHashes {
+			address := addressTxs.Bucket(pubKeyHash)
+			if len(pubKeyHash) == 0 || address == nil {
+				continue
+			}
+			err := address.ForEach(func(k, v []byte) error {
+				d := decoder{data: v}
+				timestamp := time.Unix(0, d.int64("timestamp")).UTC()
+				txID := d.bytes("transaction id")
+				blockHash := d.bytes("block hash")
+				if err := d.finish(); err != nil {
+					return fmt.Errorf("address transaction %x: %w", k, err)
+				}
+				entries = append(entries, addressTxEntry{
+					AddressTx: AddressTx{
+						Seq:       int64(binary.BigEndian.Uint64(k)),
+						TxID:      hex.EncodeToString(txID),
+						BlockHash: string(blockHash),
+					},
+					PubKeyHash: pubKeyHash,
+					Timestamp:  timestamp,
+				})
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return selectAddressTxs(entries, query), nil
+}
+
+func (s *BoltChainStore) Reindex() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltIndexes {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(boltChain).ForEach(func(k, hash []byte) error {
+			block, err := boltBlock(tx, string(hash))
+			if err == nil {
+				err = boltIndexBlock(tx, int(binary.BigEndian.Uint64(k)), block)
+			}
+			if err != nil {
+				return fmt.Errorf("reindex block %s: %w", hash, err)
+			}
+
+			return nil
+		})
+	})
+}
+
+func (s *BoltChainStore) Reset() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range append([][]byte{boltBlocks, boltNodes, boltChain}, boltIndexes...) {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
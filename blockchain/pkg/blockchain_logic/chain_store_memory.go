@@ -0,0 +1,366 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+)
+
+// MemoryChainStore keeps the chain in memory. It is meant for tests and
+// throwaway nodes; blocks are kept encoded so callers can not change them.
+type MemoryChainStore struct {
+	mu       sync.RWMutex
+	blocks   map[string][]byte
+	nodes    map[string]BlockNode
+	chain    []string
+	txBlocks map[string]string
+	utxo     map[string]SpendableOutput
+	seq      int64
+	txs      []addressTxEntry
+}
+
+// NewMemoryChainStore returns an empty in-memory chain.
+func NewMemoryChainStore() *MemoryChainStore {
+	s := &MemoryChainStore{blocks: make(map[string][]byte), nodes: make(map[string]BlockNode)}
+	s.resetIndexes()
+
+	return s
+}
+
+func (s *MemoryChainStore) resetIndexes() {
+	s.txBlocks = make(map[string]string)
+	s.utxo = make(map[string]SpendableOutput)
+	s.seq = 0
+	s.txs = nil
+}
+
+func (s *MemoryChainStore) AddBlock(block *Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, stored := s.nodes[block.Hash]
+	if stored && node.MainChain {
+		return fmt.Errorf("block %s is already stored", block.Hash)
+	}
+	tip := ""
+	if len(s.chain) > 0 {
+		tip = s.chain[len(s.chain)-1]
+	}
+	err := checkExtends(tip, block)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		node, err = s.newNode(block)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.index(block)
+	if err != nil {
+		return err
+	}
+	node.MainChain = true
+	s.nodes[block.Hash] = node
+	s.blocks[block.Hash] = block.Serialize()
+	s.chain = append(s.chain, block.Hash)
+
+	return nil
+}
+
+func (s *MemoryChainStore) AddSideBlock(block *Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[block.Hash]; ok {
+		return fmt.Errorf("block %s is already stored", block.Hash)
+	}
+	node, err := s.newNode(block)
+	if err != nil {
+		return err
+	}
+	s.nodes[block.Hash] = node
+	s.blocks[block.Hash] = block.Serialize()
+
+	return nil
+}
+
+// newNode places block, which is not stored yet, under its parent.
+func (s *MemoryChainStore) newNode(block *Block) (BlockNode, error) {
+	if block.PrevHash == "0" {
+		return childNode(nil, block), nil
+	}
+	parent, ok := s.nodes[block.PrevHash]
+	if !ok {
+		return BlockNode{}, fmt.Errorf("parent of block %s is not stored", block.Hash)
+	}
+
+	return childNode(&parent, block), nil
+}
+
+// index applies block to the utxo set and the indexes. It changes nothing
+// when the block does not apply.
+func (s *MemoryChainStore) index(block *Block) error {
+	spent, created, err := utxoChanges(block, func(txID []byte, vout int) (bool, error) {
+		_, ok := s.utxo[outpoint(txID, vout)]
+
+		return ok, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, out := range spent {
+		delete(s.utxo, outpoint(out.TxID, out.Vout))
+	}
+	for key, out := range created {
+		s.utxo[key] = out
+	}
+	for _, tx := range block.Transactions {
+		txID := hex.EncodeToString(tx.ID)
+		s.txBlocks[txID] = block.Hash
+		for _, pubKeyHash := range participants(tx) {
+			s.seq++
+			s.txs = append(s.txs, addressTxEntry{
+				AddressTx:  AddressTx{Seq: s.seq, TxID: txID, BlockHash: block.Hash},
+				PubKeyHash: pubKeyHash,
+				Timestamp:  block.Timestamp,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryChainStore) DisconnectTip() (*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.chain) == 0 {
+		return nil, ErrNotFound
+	}
+	hash := s.chain[len(s.chain)-1]
+	block, err := s.decode(hash)
+	if err != nil {
+		return nil, err
+	}
+	restored, removed, err := utxoUndo(block, func(txID []byte, vout int) (SpendableOutput, error) {
+		blockHash, ok := s.txBlocks[hex.EncodeToString(txID)]
+		if !ok {
+			return SpendableOutput{}, ErrNotFound
+		}
+		prev, err := s.decode(blockHash)
+		if err != nil {
+			return SpendableOutput{}, err
+		}
+
+		return blockOutput(prev, txID, vout)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, out := range removed {
+		delete(s.utxo, outpoint(out.TxID, out.Vout))
+	}
+	for _, out := range restored {
+		s.utxo[outpoint(out.TxID, out.Vout)] = out
+	}
+	for _, tx := range block.Transactions {
+		txID := hex.EncodeToString(tx.ID)
+		if s.txBlocks[txID] == hash {
+			delete(s.txBlocks, txID)
+		}
+	}
+	s.txs = slices.DeleteFunc(s.txs, func(e addressTxEntry) bool { return e.BlockHash == hash })
+	node := s.nodes[hash]
+	node.MainChain = false
+	s.nodes[hash] = node
+	s.chain = s.chain[:len(s.chain)-1]
+
+	return block, nil
+}
+
+func (s *MemoryChainStore) decode(hash string) (*Block, error) {
+	data, ok := s.blocks[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return DeserializeBlock(data)
+}
+
+func (s *MemoryChainStore) Tip() (*Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.chain) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.decode(s.chain[len(s.chain)-1])
+}
+
+func (s *MemoryChainStore) Height() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.chain) - 1, nil
+}
+
+func (s *MemoryChainStore) Block(hash string) (*Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.decode(hash)
+}
+
+func (s *MemoryChainStore) BlockAt(height int) (*Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if height < 0 || height >= len(s.chain) {
+		return nil, ErrNotFound
+	}
+
+	return s.decode(s.chain[height])
+}
+
+func (s *MemoryChainStore) BlockHeight(hash string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node, ok := s.nodes[hash]
+	if !ok || !node.MainChain {
+		return 0, ErrNotFound
+	}
+
+	return node.Height, nil
+}
+
+func (s *MemoryChainStore) BlockNode(hash string) (BlockNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node, ok := s.nodes[hash]
+	if !ok {
+		return BlockNode{}, ErrNotFound
+	}
+	node.Work = new(big.Int).Set(node.Work)
+
+	return node, nil
+}
+
+func (s *MemoryChainStore) ChainTips() ([]BlockNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	parents := make(map[string]bool, len(s.nodes))
+	for _, node := range s.nodes {
+		parents[node.PrevHash] = true
+	}
+	var tips []BlockNode
+	for hash, node := range s.nodes {
+		if !parents[hash] {
+			node.Work = new(big.Int).Set(node.Work)
+			tips = append(tips, node)
+		}
+	}
+	sortTips(tips)
+
+	return tips, nil
+}
+
+func (s *MemoryChainStore) ForEachBlock(fn func(height int, block *Block) error) error {
+	s.mu.RLock()
+	chain := slices.Clone(s.chain)
+	s.mu.RUnlock()
+
+	for height, hash := range chain {
+		block, err := s.Block(hash)
+		if err != nil {
+			return err
+		}
+		err = fn(height, block)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryChainStore) TransactionBlock(txID []byte) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blockHash, ok := s.txBlocks[hex.EncodeToString(txID)]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return blockHash, nil
+}
+
+func (s *MemoryChainStore) IsUnspent(txID []byte, vout int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.utxo[outpoint(txID, vout)]
+
+	return ok, nil
+}
+
+func (s *MemoryChainStore) UnspentOutputs(pubKeyHashes [][]byte) ([]SpendableOutput, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var outputs []SpendableOutput
+	for _, out := range s.utxo {
+		if out.PubKeyHash != nil && containsHash(pubKeyHashes, out.PubKeyHash) {
+			outputs = append(outputs, out)
+		}
+	}
+	sortOutputs(outputs)
+
+	return outputs, nil
+}
+
+func (s *MemoryChainStore) AddressTransactions(query AddressTxQuery) ([]AddressTx, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return selectAddressTxs(s.txs, query), nil
+}
+
+func (s *MemoryChainStore) Reindex() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetIndexes()
+	for _, hash := range s.chain {
+		block, err := s.decode(hash)
+		if err == nil {
+			err = s.index(block)
+		}
+		if err != nil {
+			return fmt.Errorf("reindex block %s: %w", hash, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryChainStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocks = make(map[string][]byte)
+	s.nodes = make(map[string]BlockNode)
+	s.chain = nil
+	s.resetIndexes()
+
+	return nil
+}
@@ -0,0 +1,535 @@
+package blockchainlogic
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// blockColumns selects a stored block, kept in its canonical encoding. The
+// other columns of the blocks table copy header fields for lookups.
+const blockColumns = "data"
+
+// PostgresChainStore keeps the chain in the blocks, utxo, tx_index and
+// address_tx tables. Blocks of every branch are stored; main_chain flags the
+// ones the utxo set and the indexes are built from.
+type PostgresChainStore struct {
+	db *sql.DB
+}
+
+// NewPostgresChainStore returns the chain stored in db. Chains stored before
+// the utxo and index tables existed are indexed once.
+func NewPostgresChainStore(db *sql.DB) (*PostgresChainStore, error) {
+	s := &PostgresChainStore{db: db}
+
+	var indexed, empty bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM utxo) AND EXISTS (SELECT 1 FROM tx_index), NOT EXISTS (SELECT 1 FROM blocks)").Scan(&indexed, &empty)
+	if err != nil {
+		return nil, err
+	}
+	if !indexed && !empty {
+		err = s.Reindex()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanBlock decodes a row selected with blockColumns.
+func scanBlock(row rowScanner) (*Block, error) {
+	var data []byte
+
+	err := row.Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrLegacyBlock
+	}
+	block, err := DeserializeBlock(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode block: %w", err)
+	}
+
+	return block, nil
+}
+
+// getBlock returns the single block selected by query, or ErrNotFound.
+func getBlock(db *sql.DB, query string, args ...any) (*Block, error) {
+	block, err := scanBlock(db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return block, err
+}
+
+func (s *PostgresChainStore) AddBlock(block *Block) error {
+	dbTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	tip := ""
+	err = dbTx.QueryRow("SELECT hash FROM blocks WHERE main_chain ORDER BY height DESC LIMIT 1 FOR UPDATE").Scan(&tip)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if tip == block.Hash {
+		return fmt.Errorf("block %s is already stored", block.Hash)
+	}
+	err = checkExtends(tip, block)
+	if err != nil {
+		return err
+	}
+
+	res, err := dbTx.Exec("UPDATE blocks SET main_chain = TRUE WHERE hash = $1", block.Hash)
+	if err != nil {
+		return err
+	}
+	stored, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		err = insertBlock(dbTx, block, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = applyBlockToUTXO(dbTx, block)
+	if err != nil {
+		return err
+	}
+
+	err = indexBlock(dbTx, block)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+func (s *PostgresChainStore) AddSideBlock(block *Block) error {
+	dbTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	err = insertBlock(dbTx, block, false)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// insertBlock stores block under its parent.
+func insertBlock(dbTx *sql.Tx, block *Block, mainChain bool) error {
+	var parent *BlockNode
+	if block.PrevHash != "0" {
+		node, err := scanBlockNode(dbTx.QueryRow("SELECT "+blockNodeColumns+" FROM blocks WHERE hash = $1", block.PrevHash))
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("parent of block %s is not stored", block.Hash)
+		}
+		if err != nil {
+			return err
+		}
+		parent = &node
+	}
+	node := childNode(parent, block)
+
+	_, err := dbTx.Exec("INSERT INTO blocks (hash, previous_hash, merkle_root, timestamp, nonce, difficulty, data, height, work, main_chain) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		block.Hash, block.PrevHash, block.MerkleRoot, block.Timestamp, block.Nonce, block.Difficulty, block.Serialize(), node.Height, node.Work.String(), mainChain)
+
+	return err
+}
+
+// blockNodeColumns selects a stored block node.
+const blockNodeColumns = "hash, previous_hash, height, work::TEXT, main_chain"
+
+// scanBlockNode decodes a row selected with blockNodeColumns.
+func scanBlockNode(row rowScanner) (BlockNode, error) {
+	var node BlockNode
+	var work string
+
+	err := row.Scan(&node.Hash, &node.PrevHash, &node.Height, &work, &node.MainChain)
+	if err != nil {
+		return BlockNode{}, err
+	}
+	var ok bool
+	node.Work, ok = new(big.Int).SetString(work, 10)
+	if !ok {
+		return BlockNode{}, fmt.Errorf("block %s: invalid work %q", node.Hash, work)
+	}
+
+	return node, nil
+}
+
+func (s *PostgresChainStore) DisconnectTip() (*Block, error) {
+	dbTx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	block, err := scanBlock(dbTx.QueryRow("SELECT " + blockColumns + " FROM blocks WHERE main_chain ORDER BY height DESC LIMIT 1 FOR UPDATE"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The utxo table records the block each output was created in.
+	outputBlocks := make(map[string]string)
+	restored, _, err := utxoUndo(block, func(txID []byte, vout int) (SpendableOutput, error) {
+		prev, err := scanBlock(dbTx.QueryRow("SELECT b."+blockColumns+" FROM tx_index t JOIN blocks b ON b.hash = t.block_hash WHERE t.tx_id = $1", hex.EncodeToString(txID)))
+		if errors.Is(err, sql.ErrNoRows) {
+			return SpendableOutput{}, ErrNotFound
+		}
+		if err != nil {
+			return SpendableOutput{}, err
+		}
+		outputBlocks[outpoint(txID, vout)] = prev.Hash
+
+		return blockOutput(prev, txID, vout)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range []string{"utxo", "tx_index", "address_tx"} {
+		_, err = dbTx.Exec("DELETE FROM "+table+" WHERE block_hash = $1", block.Hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, out := range restored {
+		pubKeyHash := out.PubKeyHash
+		if pubKeyHash == nil {
+			pubKeyHash = []byte{}
+		}
+		_, err = dbTx.Exec("INSERT INTO utxo (tx_id, out_idx, value, pub_key_hash, block_hash) VALUES ($1, $2, $3, $4, $5)",
+			hex.EncodeToString(out.TxID), out.Vout, int64(out.Value), pubKeyHash, outputBlocks[outpoint(out.TxID, out.Vout)])
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = dbTx.Exec("UPDATE blocks SET main_chain = FALSE WHERE hash = $1", block.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return block, dbTx.Commit()
+}
+
+func (s *PostgresChainStore) Tip() (*Block, error) {
+	return getBlock(s.db, "SELECT "+blockColumns+" FROM blocks WHERE main_chain ORDER BY height DESC LIMIT 1")
+}
+
+func (s *PostgresChainStore) Height() (int, error) {
+	var height int
+	err := s.db.QueryRow("SELECT COALESCE(MAX(height), -1) FROM blocks WHERE main_chain").Scan(&height)
+
+	return height, err
+}
+
+func (s *PostgresChainStore) Block(hash string) (*Block, error) {
+	return getBlock(s.db, "SELECT "+blockColumns+" FROM blocks WHERE hash = $1", hash)
+}
+
+func (s *PostgresChainStore) BlockAt(height int) (*Block, error) {
+	if height < 0 {
+		return nil, ErrNotFound
+	}
+
+	return getBlock(s.db, "SELECT "+blockColumns+" FROM blocks WHERE main_chain AND height = $1", height)
+}
+
+func (s *PostgresChainStore) BlockHeight(hash string) (int, error) {
+	var height int
+	err := s.db.QueryRow("SELECT height FROM blocks WHERE hash = $1 AND main_chain", hash).Scan(&height)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+
+	return height, err
+}
+
+func (s *PostgresChainStore) BlockNode(hash string) (BlockNode, error) {
+	node, err := scanBlockNode(s.db.QueryRow("SELECT "+blockNodeColumns+" FROM blocks WHERE hash = $1", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return BlockNode{}, ErrNotFound
+	}
+
+	return node, err
+}
+
+func (s *PostgresChainStore) ChainTips() ([]BlockNode, error) {
+	rows, err := s.db.Query("SELECT " + blockNodeColumns + " FROM blocks b WHERE NOT EXISTS (SELECT 1 FROM blocks c WHERE c.previous_hash = b.hash) ORDER BY work DESC, hash")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tips []BlockNode
+	for rows.Next() {
+		node, err := scanBlockNode(rows)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, node)
+	}
+
+	return tips, rows.Err()
+}
+
+func (s *PostgresChainStore) ForEachBlock(fn func(height int, block *Block) error) error {
+	return forEachBlock(s.db, fn)
+}
+
+// forEachBlock calls fn for every main chain block from genesis to tip
+// together with its height.
+func forEachBlock(db *sql.DB, fn func(height int, block *Block) error) error {
+	rows, err := db.Query("SELECT " + blockColumns + " FROM blocks WHERE main_chain ORDER BY height")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for height := 0; rows.Next(); height++ {
+		block, err := scanBlock(rows)
+		if err != nil {
+			return err
+		}
+
+		err = fn(height, block)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *PostgresChainStore) TransactionBlock(txID []byte) (string, error) {
+	var blockHash string
+	err := s.db.QueryRow("SELECT block_hash FROM tx_index WHERE tx_id = $1", hex.EncodeToString(txID)).Scan(&blockHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+
+	return blockHash, err
+}
+
+func (s *PostgresChainStore) IsUnspent(txID []byte, vout int) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM utxo WHERE tx_id = $1 AND out_idx = $2)",
+		hex.EncodeToString(txID), vout).Scan(&exists)
+
+	return exists, err
+}
+
+func (s *PostgresChainStore) UnspentOutputs(pubKeyHashes [][]byte) ([]SpendableOutput, error) {
+	rows, err := s.db.Query("SELECT tx_id, out_idx, value, pub_key_hash FROM utxo WHERE pub_key_hash = ANY($1) ORDER BY value DESC, tx_id, out_idx", pubKeyHashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outputs []SpendableOutput
+	for rows.Next() {
+		var txID string
+		var out SpendableOutput
+
+		err = rows.Scan(&txID, &out.Vout, &out.Value, &out.PubKeyHash)
+		if err != nil {
+			return nil, err
+		}
+		out.TxID, err = hex.DecodeString(txID)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, out)
+	}
+
+	return outputs, rows.Err()
+}
+
+func (s *PostgresChainStore) AddressTransactions(query AddressTxQuery) ([]AddressTx, error) {
+	sqlQuery, args := addressTxQuery(query)
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []AddressTx
+	for rows.Next() {
+		var tx AddressTx
+		if err = rows.Scan(&tx.Seq, &tx.TxID, &tx.BlockHash); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+
+	return txs, rows.Err()
+}
+
+// addressTxQuery builds the address_tx query for query. A transaction
+// touching several of the addresses is listed once, under its highest id.
+func addressTxQuery(query AddressTxQuery) (string, []any) {
+	var sb strings.Builder
+	args := []any{query.PubKeyHashes}
+
+	sb.WriteString("SELECT MAX(a.id), a.tx_id, a.block_hash FROM address_tx a JOIN blocks b ON b.hash = a.block_hash WHERE a.pub_key_hash = ANY($1)")
+	if !query.From.IsZero() {
+		args = append(args, query.From)
+		fmt.Fprintf(&sb, " AND b.timestamp >= $%d", len(args))
+	}
+	if !query.To.IsZero() {
+		args = append(args, query.To)
+		fmt.Fprintf(&sb, " AND b.timestamp < $%d", len(args))
+	}
+	sb.WriteString(" GROUP BY a.tx_id, a.block_hash")
+	if query.Before > 0 {
+		args = append(args, query.Before)
+		fmt.Fprintf(&sb, " HAVING MAX(a.id) < $%d", len(args))
+	}
+	sb.WriteString(" ORDER BY 1 DESC")
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+	}
+	if query.Offset > 0 {
+		args = append(args, query.Offset)
+		fmt.Fprintf(&sb, " OFFSET $%d", len(args))
+	}
+
+	return sb.String(), args
+}
+
+func (s *PostgresChainStore) Reindex() error {
+	dbTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	for _, table := range []string{"utxo", "tx_index", "address_tx"} {
+		_, err = dbTx.Exec("DELETE FROM " + table)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = forEachBlock(s.db, func(_ int, block *Block) error {
+		err := applyBlockToUTXO(dbTx, block)
+		if err == nil {
+			err = indexBlock(dbTx, block)
+		}
+		if err != nil {
+			return fmt.Errorf("reindex block %s: %w", block.Hash, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+func (s *PostgresChainStore) Reset() error {
+	dbTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	for _, table := range []string{"address_tx", "tx_index", "utxo", "blocks"} {
+		_, err = dbTx.Exec("DELETE FROM " + table)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbTx.Commit()
+}
+
+// applyBlockToUTXO removes the outputs spent by the block from the utxo table
+// and adds the outputs it creates.
+func applyBlockToUTXO(dbTx *sql.Tx, block *Block) error {
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Vin {
+				res, err := dbTx.Exec("DELETE FROM utxo WHERE tx_id = $1 AND out_idx = $2",
+					hex.EncodeToString(in.Txid), in.Vout)
+				if err != nil {
+					return err
+				}
+				spent, err := res.RowsAffected()
+				if err != nil {
+					return err
+				}
+				if spent == 0 {
+					return fmt.Errorf("output %x:%d is already spent or does not exist", in.Txid, in.Vout)
+				}
+			}
+		}
+
+		txID := hex.EncodeToString(tx.ID)
+		for outIdx, out := range tx.Vout {
+			// Script outputs have no public key hash and are not found by
+			// the wallet lookups.
+			pubKeyHash := out.PubKeyHash
+			if pubKeyHash == nil {
+				pubKeyHash = []byte{}
+			}
+			_, err := dbTx.Exec("INSERT INTO utxo (tx_id, out_idx, value, pub_key_hash, block_hash) VALUES ($1, $2, $3, $4, $5)",
+				txID, outIdx, int64(out.Value), pubKeyHash, block.Hash)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexBlock records in which block each transaction of the block is stored
+// and which addresses take part in it.
+func indexBlock(dbTx *sql.Tx, block *Block) error {
+	for _, tx := range block.Transactions {
+		txID := hex.EncodeToString(tx.ID)
+
+		_, err := dbTx.Exec("INSERT INTO tx_index (tx_id, block_hash) VALUES ($1, $2)", txID, block.Hash)
+		if err != nil {
+			return err
+		}
+
+		for _, pubKeyHash := range participants(tx) {
+			_, err = dbTx.Exec("INSERT INTO address_tx (pub_key_hash, tx_id, block_hash) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+				pubKeyHash, txID, block.Hash)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,560 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/damndelion/blockchain_justCode/pkg/postgres"
+)
+
+// chainStores returns a fresh store of every embedded implementation, and
+// of the Postgres one when TEST_PG_URL names a migrated database. The
+// Postgres stores of a test share that database, opening one empties it.
+func chainStores() map[string]func(t *testing.T) ChainStore {
+	stores := map[string]func(t *testing.T) ChainStore{
+		"memory": func(*testing.T) ChainStore { return NewMemoryChainStore() },
+		"bolt": func(t *testing.T) ChainStore {
+			s, err := OpenBoltChainStore(filepath.Join(t.TempDir(), "chain.db"))
+			if err != nil {
+				t.Fatalf("OpenBoltChainStore() error = %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+
+			return s
+		},
+	}
+	if url := os.Getenv("TEST_PG_URL"); url != "" {
+		stores["postgres"] = func(t *testing.T) ChainStore {
+			_, db, err := postgres.New(url)
+			if err != nil {
+				t.Fatalf("postgres.New() error = %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			s, err := NewPostgresChainStore(db)
+			if err != nil {
+				t.Fatalf("NewPostgresChainStore() error = %v", err)
+			}
+			if err = s.Reset(); err != nil {
+				t.Fatalf("Reset() error = %v", err)
+			}
+
+			return s
+		}
+	}
+
+	return stores
+}
+
+func TestChainStore(t *testing.T) {
+	for name, open := range chainStores() {
+		t.Run(name, func(t *testing.T) {
+			owner, blocks := testChain(t)
+			s := open(t)
+
+			if height, err := s.Height(); err != nil || height != -1 {
+				t.Fatalf("Height() of an empty store = %d, %v, want -1", height, err)
+			}
+			if _, err := s.Tip(); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Tip() of an empty store error = %v, want %v", err, ErrNotFound)
+			}
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+			checkChainStore(t, s, owner, blocks)
+
+			if err := s.Reindex(); err != nil {
+				t.Fatalf("Reindex() error = %v", err)
+			}
+			checkChainStore(t, s, owner, blocks)
+		})
+	}
+}
+
+func checkChainStore(t *testing.T, s ChainStore, owner *Wallet, blocks []*Block) {
+	t.Helper()
+	genesis, spend := blocks[0], blocks[1].Transactions[0]
+	ownerHash := HashPubKey(owner.PublicKey)
+
+	if height, err := s.Height(); err != nil || height != 1 {
+		t.Errorf("Height() = %d, %v, want 1", height, err)
+	}
+	tip, err := s.Tip()
+	if err != nil || !bytes.Equal(tip.Serialize(), blocks[1].Serialize()) {
+		t.Errorf("Tip() = %v, %v, want block 1", tip, err)
+	}
+	if block, err := s.BlockAt(0); err != nil || block.Hash != genesis.Hash {
+		t.Errorf("BlockAt(0) = %v, %v, want genesis", block, err)
+	}
+	if _, err = s.BlockAt(2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("BlockAt(2) error = %v, want %v", err, ErrNotFound)
+	}
+	if height, err := s.BlockHeight(tip.Hash); err != nil || height != 1 {
+		t.Errorf("BlockHeight() = %d, %v, want 1", height, err)
+	}
+	if _, err = s.Block("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Block() of a missing hash error = %v, want %v", err, ErrNotFound)
+	}
+
+	if hash, err := s.TransactionBlock(spend.ID); err != nil || hash != tip.Hash {
+		t.Errorf("TransactionBlock() = %q, %v, want %q", hash, err, tip.Hash)
+	}
+	if _, err = s.TransactionBlock([]byte("missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TransactionBlock() of a missing transaction error = %v, want %v", err, ErrNotFound)
+	}
+	if ok, err := s.IsUnspent(genesis.Transactions[0].ID, 0); err != nil || ok {
+		t.Errorf("IsUnspent() of a spent output = %v, %v, want false", ok, err)
+	}
+	if ok, err := s.IsUnspent(spend.ID, 0); err != nil || !ok {
+		t.Errorf("IsUnspent() of an unspent output = %v, %v, want true", ok, err)
+	}
+
+	outputs, err := s.UnspentOutputs([][]byte{ownerHash, spend.Vout[0].PubKeyHash})
+	if err != nil {
+		t.Fatalf("UnspentOutputs() error = %v", err)
+	}
+	if len(outputs) != 2 || outputs[0].Value != spend.Vout[1].Value || outputs[1].Value != spend.Vout[0].Value {
+		t.Errorf("UnspentOutputs() = %+v, want both outputs of the spend, largest first", outputs)
+	}
+
+	txs, err := s.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{ownerHash}})
+	if err != nil {
+		t.Fatalf("AddressTransactions() error = %v", err)
+	}
+	if len(txs) != 2 || txs[0].TxID != hex.EncodeToString(spend.ID) || txs[1].BlockHash != genesis.Hash {
+		t.Fatalf("AddressTransactions() = %+v, want the spend then the genesis coinbase", txs)
+	}
+	older, err := s.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{ownerHash}, Before: txs[0].Seq})
+	if err != nil || len(older) != 1 || older[0] != txs[1] {
+		t.Errorf("AddressTransactions() before the spend = %+v, %v, want the genesis coinbase", older, err)
+	}
+	later, err := s.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{ownerHash}, From: tip.Timestamp})
+	if err != nil || len(later) != 1 || later[0] != txs[0] {
+		t.Errorf("AddressTransactions() from the tip timestamp = %+v, %v, want the spend", later, err)
+	}
+	page, err := s.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{ownerHash}, Offset: 1, Limit: 1})
+	if err != nil || len(page) != 1 || page[0] != txs[1] {
+		t.Errorf("AddressTransactions() second page = %+v, %v, want the genesis coinbase", page, err)
+	}
+
+	var walked []string
+	err = s.ForEachBlock(func(height int, block *Block) error {
+		if height != len(walked) {
+			t.Errorf("ForEachBlock() height = %d, want %d", height, len(walked))
+		}
+		walked = append(walked, block.Hash)
+
+		return nil
+	})
+	if err != nil || len(walked) != 2 || walked[0] != genesis.Hash {
+		t.Errorf("ForEachBlock() walked %v, %v, want genesis then block 1", walked, err)
+	}
+}
+
+func TestChainStore_RejectsDoubleSpend(t *testing.T) {
+	for name, open := range chainStores() {
+		t.Run(name, func(t *testing.T) {
+			_, blocks := testChain(t)
+			s := open(t)
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+
+			again := CreateBlock(blocks[1].Transactions, blocks[1].Hash, Difficulty)
+			if err := s.AddBlock(again); err == nil {
+				t.Fatalf("AddBlock() of a double spend error = nil, want error")
+			}
+			if height, err := s.Height(); err != nil || height != 1 {
+				t.Errorf("Height() after a rejected block = %d, %v, want 1", height, err)
+			}
+			if _, err := s.Block(again.Hash); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Block() of a rejected block error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestChainStore_Reset(t *testing.T) {
+	for name, open := range chainStores() {
+		t.Run(name, func(t *testing.T) {
+			owner, blocks := testChain(t)
+			s := open(t)
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+
+			if err := s.Reset(); err != nil {
+				t.Fatalf("Reset() error = %v", err)
+			}
+			if height, err := s.Height(); err != nil || height != -1 {
+				t.Errorf("Height() after Reset() = %d, %v, want -1", height, err)
+			}
+			if _, err := s.Block(blocks[1].Hash); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Block() after Reset() error = %v, want %v", err, ErrNotFound)
+			}
+
+			// The chain can be built again from genesis.
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() after Reset() error = %v", err)
+				}
+			}
+			checkChainStore(t, s, owner, blocks)
+		})
+	}
+}
+
+func TestBoltChainStore_Reopen(t *testing.T) {
+	owner, blocks := testChain(t)
+	path := filepath.Join(t.TempDir(), "chain.db")
+
+	s, err := OpenBoltChainStore(path)
+	if err != nil {
+		t.Fatalf("OpenBoltChainStore() error = %v", err)
+	}
+	for _, block := range blocks {
+		if err = s.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock() error = %v", err)
+		}
+	}
+	if err = s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	s, err = OpenBoltChainStore(path)
+	if err != nil {
+		t.Fatalf("OpenBoltChainStore() error = %v", err)
+	}
+	defer s.Close()
+	checkChainStore(t, s, owner, blocks)
+}
+
+func TestChainStore_SideBranch(t *testing.T) {
+	for name, open := range chainStores() {
+		t.Run(name, func(t *testing.T) {
+			owner, blocks := testChain(t)
+			genesis, spend := blocks[0], blocks[1].Transactions[0]
+			s := open(t)
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+
+			coinbase := newCoinbaseTX(string(owner.GetAddress()), BlockSubsidy(), "")
+			side := CreateBlock([]*Transaction{coinbase}, genesis.Hash, Difficulty)
+			if err := s.AddSideBlock(side); err != nil {
+				t.Fatalf("AddSideBlock() error = %v", err)
+			}
+			orphan := CreateBlock([]*Transaction{coinbase}, "missing", Difficulty)
+			if err := s.AddSideBlock(orphan); err == nil {
+				t.Errorf("AddSideBlock() of an orphan error = nil, want error")
+			}
+			if err := s.AddBlock(side); err == nil {
+				t.Errorf("AddBlock() of a block not extending the tip error = nil, want error")
+			}
+			checkChainStore(t, s, owner, blocks)
+
+			// Two blocks of difficulty Difficulty.
+			node, err := s.BlockNode(side.Hash)
+			if err != nil || node.Height != 1 || node.MainChain || node.Work.Cmp(blockWork(Difficulty+1)) != 0 {
+				t.Errorf("BlockNode() of the side block = %+v, %v, want height 1 off the main chain with the work of two blocks", node, err)
+			}
+			if _, err = s.BlockHeight(side.Hash); !errors.Is(err, ErrNotFound) {
+				t.Errorf("BlockHeight() of the side block error = %v, want %v", err, ErrNotFound)
+			}
+			tips, err := s.ChainTips()
+			if err != nil || len(tips) != 2 || tips[0].MainChain == tips[1].MainChain {
+				t.Errorf("ChainTips() = %+v, %v, want the tip and the side block", tips, err)
+			}
+
+			disconnected, err := s.DisconnectTip()
+			if err != nil || disconnected.Hash != blocks[1].Hash {
+				t.Fatalf("DisconnectTip() = %v, %v, want block 1", disconnected, err)
+			}
+			if height, err := s.Height(); err != nil || height != 0 {
+				t.Errorf("Height() after DisconnectTip() = %d, %v, want 0", height, err)
+			}
+			if ok, err := s.IsUnspent(genesis.Transactions[0].ID, 0); err != nil || !ok {
+				t.Errorf("IsUnspent() of the output spent by the disconnected block = %v, %v, want true", ok, err)
+			}
+			if ok, err := s.IsUnspent(spend.ID, 0); err != nil || ok {
+				t.Errorf("IsUnspent() of an output of the disconnected block = %v, %v, want false", ok, err)
+			}
+			if _, err = s.TransactionBlock(spend.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("TransactionBlock() of a disconnected transaction error = %v, want %v", err, ErrNotFound)
+			}
+			txs, err := s.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{HashPubKey(owner.PublicKey)}})
+			if err != nil || len(txs) != 1 || txs[0].BlockHash != genesis.Hash {
+				t.Errorf("AddressTransactions() after DisconnectTip() = %+v, %v, want the genesis coinbase", txs, err)
+			}
+
+			if err = s.AddBlock(side); err != nil {
+				t.Fatalf("AddBlock() of the side block error = %v", err)
+			}
+			if height, err := s.BlockHeight(side.Hash); err != nil || height != 1 {
+				t.Errorf("BlockHeight() of the connected side block = %d, %v, want 1", height, err)
+			}
+			if node, err = s.BlockNode(blocks[1].Hash); err != nil || node.MainChain {
+				t.Errorf("BlockNode() of the disconnected block = %+v, %v, want it off the main chain", node, err)
+			}
+			if ok, err := s.IsUnspent(coinbase.ID, 0); err != nil || !ok {
+				t.Errorf("IsUnspent() of the side block coinbase = %v, %v, want true", ok, err)
+			}
+
+			// Back to the first branch.
+			if _, err = s.DisconnectTip(); err != nil {
+				t.Fatalf("DisconnectTip() error = %v", err)
+			}
+			if err = s.AddBlock(blocks[1]); err != nil {
+				t.Fatalf("AddBlock() of the disconnected block error = %v", err)
+			}
+			checkChainStore(t, s, owner, blocks)
+			if err = s.Reindex(); err != nil {
+				t.Fatalf("Reindex() error = %v", err)
+			}
+			checkChainStore(t, s, owner, blocks)
+		})
+	}
+}
+
+// utxoSnapshot returns the unspent outputs and indexed transactions of every
+// address of hashes, in a fixed order.
+func utxoSnapshot(t *testing.T, s ChainStore, hashes [][]byte) map[string][]string {
+	t.Helper()
+
+	snapshot := make(map[string][]string, len(hashes))
+	for _, hash := range hashes {
+		address := AddressFromPubKeyHash(hash)
+		outputs, err := s.UnspentOutputs([][]byte{hash})
+		if err != nil {
+			t.Fatalf("UnspentOutputs() error = %v", err)
+		}
+		entries := make([]string, 0, len(outputs))
+		for _, out := range outputs {
+			entries = append(entries, fmt.Sprintf("utxo %x:%d %s", out.TxID, out.Vout, out.Value))
+		}
+		sort.Strings(entries)
+		txs, err := s.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{hash}})
+		if err != nil {
+			t.Fatalf("AddressTransactions() error = %v", err)
+		}
+		for _, tx := range txs {
+			entries = append(entries, fmt.Sprintf("tx %s in %s", tx.TxID, tx.BlockHash))
+		}
+		snapshot[address] = entries
+	}
+
+	return snapshot
+}
+
+// TestChainStore_ReindexMatchesIncremental checks that the utxo set and the
+// indexes maintained block by block, forwards and backwards, are the ones
+// Reindex builds from the blocks.
+func TestChainStore_ReindexMatchesIncremental(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	addresses := []string{miner}
+	for i := 0; i < 4; i++ {
+		recipient, err := CreateWallet(bc.Keystore())
+		if err != nil {
+			t.Fatalf("CreateWallet() error = %v", err)
+		}
+		if _, err = bc.Send(miner, recipient, Amount(100+i), 0); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if i > 0 {
+			// Spend an output of the previous block.
+			if _, err = bc.Send(addresses[i], recipient, 40, 0); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+		}
+		bc.mineMempool(context.Background(), 10)
+		addresses = append(addresses, recipient)
+	}
+	height, err := bc.Height()
+	if err != nil {
+		t.Fatalf("Height() error = %v", err)
+	}
+	blocks := make([]*Block, 0, height+1)
+	for h := 0; h <= height; h++ {
+		block, err := bc.Store().BlockAt(h)
+		if err != nil {
+			t.Fatalf("BlockAt() error = %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	hashes := pubKeyHashes(addresses)
+
+	for name, open := range chainStores() {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+			incremental := utxoSnapshot(t, s, hashes)
+			if err := s.Reindex(); err != nil {
+				t.Fatalf("Reindex() error = %v", err)
+			}
+			if got := utxoSnapshot(t, s, hashes); !reflect.DeepEqual(got, incremental) {
+				t.Errorf("after Reindex() = %v, want %v", got, incremental)
+			}
+
+			for i := 0; i < 2; i++ {
+				if _, err := s.DisconnectTip(); err != nil {
+					t.Fatalf("DisconnectTip() error = %v", err)
+				}
+			}
+			disconnected := utxoSnapshot(t, s, hashes)
+			// The same as a store that never had the disconnected blocks.
+			fresh := open(t)
+			for _, block := range blocks[:len(blocks)-2] {
+				if err := fresh.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+			if want := utxoSnapshot(t, fresh, hashes); !reflect.DeepEqual(disconnected, want) {
+				t.Errorf("after DisconnectTip() = %v, want %v", disconnected, want)
+			}
+			if err := s.Reindex(); err != nil {
+				t.Fatalf("Reindex() error = %v", err)
+			}
+			if got := utxoSnapshot(t, s, hashes); !reflect.DeepEqual(got, disconnected) {
+				t.Errorf("after DisconnectTip() and Reindex() = %v, want %v", got, disconnected)
+			}
+		})
+	}
+}
+
+// TestChainStore_AddressTransactionsQuery checks the paging and the time
+// bounds of every store against the transactions of the chain, so the stores
+// filtering the index themselves answer as the Postgres query does.
+func TestChainStore_AddressTransactionsQuery(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	recipient, err := CreateWallet(bc.Keystore())
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err = bc.Send(miner, recipient, Amount(10+i), 0); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if i%2 == 1 {
+			// A transaction of both queried addresses is listed once.
+			if _, err = bc.Send(recipient, miner, Amount(5+i), 0); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+		}
+		bc.mineMempool(context.Background(), 10)
+		// Timestamps Postgres, keeping microseconds, tells apart.
+		time.Sleep(time.Millisecond)
+	}
+	height, err := bc.Height()
+	if err != nil {
+		t.Fatalf("Height() error = %v", err)
+	}
+	blocks := make([]*Block, 0, height+1)
+	for h := 0; h <= height; h++ {
+		block, err := bc.Store().BlockAt(h)
+		if err != nil {
+			t.Fatalf("BlockAt() error = %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	hashes := pubKeyHashes([]string{miner, recipient})
+
+	// chainTxs lists the transactions of the addresses in blocks within
+	// [from, to), newest first.
+	chainTxs := func(from, to time.Time) []string {
+		var txs []string
+		for _, block := range blocks {
+			if (!from.IsZero() && block.Timestamp.Before(from)) || (!to.IsZero() && !block.Timestamp.Before(to)) {
+				continue
+			}
+			for _, tx := range block.Transactions {
+				for _, hash := range participants(tx) {
+					if containsHash(hashes, hash) {
+						txs = append([]string{hex.EncodeToString(tx.ID) + "@" + block.Hash}, txs...)
+
+						break
+					}
+				}
+			}
+		}
+
+		return txs
+	}
+	from, to := blocks[2].Timestamp, blocks[len(blocks)-1].Timestamp
+	all, bounded := chainTxs(time.Time{}, time.Time{}), chainTxs(from, to)
+	if len(bounded) < 3 || len(bounded) == len(all) {
+		t.Fatalf("the chain has %d transactions within the bounds of %d, want a few but not all", len(bounded), len(all))
+	}
+
+	for name, open := range chainStores() {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			for _, block := range blocks {
+				if err := s.AddBlock(block); err != nil {
+					t.Fatalf("AddBlock() error = %v", err)
+				}
+			}
+			list := func(query AddressTxQuery) ([]AddressTx, []string) {
+				t.Helper()
+				query.PubKeyHashes = hashes
+				txs, err := s.AddressTransactions(query)
+				if err != nil {
+					t.Fatalf("AddressTransactions() error = %v", err)
+				}
+				ids := make([]string, 0, len(txs))
+				for _, tx := range txs {
+					ids = append(ids, tx.TxID+"@"+tx.BlockHash)
+				}
+
+				return txs, ids
+			}
+			first, _ := list(AddressTxQuery{})
+			if len(first) != len(all) {
+				t.Fatalf("AddressTransactions() = %d transactions, want %d", len(first), len(all))
+			}
+
+			tests := []struct {
+				name  string
+				query AddressTxQuery
+				want  []string
+			}{
+				{name: "All", want: all},
+				{name: "Limit", query: AddressTxQuery{Limit: 3}, want: all[:3]},
+				{name: "Offset", query: AddressTxQuery{Offset: 2}, want: all[2:]},
+				{name: "Page", query: AddressTxQuery{Offset: 2, Limit: 3}, want: all[2:5]},
+				{name: "Offset past the end", query: AddressTxQuery{Offset: len(all), Limit: 3}, want: []string{}},
+				{name: "Before", query: AddressTxQuery{Before: first[2].Seq}, want: all[3:]},
+				{name: "Page before", query: AddressTxQuery{Before: first[1].Seq, Offset: 1, Limit: 2}, want: all[3:5]},
+				{name: "From", query: AddressTxQuery{From: from}, want: chainTxs(from, time.Time{})},
+				{name: "To", query: AddressTxQuery{To: to}, want: chainTxs(time.Time{}, to)},
+				{name: "Bounded", query: AddressTxQuery{From: from, To: to}, want: bounded},
+				{name: "Bounded page", query: AddressTxQuery{From: from, To: to, Offset: 1, Limit: 2}, want: bounded[1:3]},
+				{name: "Bounded before", query: AddressTxQuery{From: from, To: to, Before: first[0].Seq, Limit: 1}, want: bounded[:1]},
+			}
+			for _, tt := range tests {
+				if _, got := list(tt.query); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s: AddressTransactions() = %v, want %v", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
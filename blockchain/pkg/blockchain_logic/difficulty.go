@@ -0,0 +1,118 @@
+package blockchainlogic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Difficulty is the number of leading zero bits a block hash needs. It is the
+// difficulty every block was mined with before it was stored per block.
+const Difficulty = 12
+
+const (
+	// MaxDifficulty keeps the proof of work target above zero.
+	MaxDifficulty = 255
+	// maxRetargetStep bounds the change of difficulty at a single retarget,
+	// a factor of four in expected work either way.
+	maxRetargetStep = 2
+)
+
+// DifficultyPolicy describes how the proof of work difficulty evolves.
+type DifficultyPolicy struct {
+	// Initial is the difficulty of the genesis block.
+	Initial int
+	// Min is the lowest difficulty a retarget may reach.
+	Min int
+	// RetargetInterval is the number of blocks between two retargets.
+	RetargetInterval int
+	// TargetBlockTime is the block time retargets aim for.
+	TargetBlockTime time.Duration
+}
+
+// DefaultDifficultyPolicy keeps the difficulty existing chains were mined with.
+var DefaultDifficultyPolicy = DifficultyPolicy{
+	Initial:          Difficulty,
+	Min:              1,
+	RetargetInterval: 10,
+	TargetBlockTime:  10 * time.Second,
+}
+
+var difficultyPolicy = DefaultDifficultyPolicy
+
+// SetDifficultyPolicy sets the difficulty policy used for mining and chain
+// verification. It must be called before the chain is opened. The retarget
+// interval must not change for an existing chain, as verification expects
+// difficulty changes at its multiples only.
+func SetDifficultyPolicy(p DifficultyPolicy) error {
+	if p.Min < 1 || p.Min > MaxDifficulty {
+		return errors.New("minimum difficulty must be between 1 and 255")
+	}
+	if p.Initial < p.Min || p.Initial > MaxDifficulty {
+		return errors.New("initial difficulty must be between the minimum and 255")
+	}
+	if p.RetargetInterval < 1 {
+		return errors.New("retarget interval must be positive")
+	}
+	if p.TargetBlockTime <= 0 {
+		return errors.New("target block time must be positive")
+	}
+	difficultyPolicy = p
+
+	return nil
+}
+
+// isRetargetHeight reports whether the block at height may change difficulty.
+func isRetargetHeight(height int) bool {
+	return height > 0 && height%difficultyPolicy.RetargetInterval == 0
+}
+
+// retargetWindowStart returns the height of the first block whose timestamp
+// is used when retargeting at height.
+func retargetWindowStart(height int) int {
+	return max(0, height-difficultyPolicy.RetargetInterval-1)
+}
+
+// nextDifficulty returns the difficulty of the block at height given the
+// difficulty of its parent and the time elapsed from the block at
+// retargetWindowStart(height) to the parent. Outside retarget heights the
+// parent difficulty is kept. Otherwise it moves by log2 of the ratio between
+// the expected and the actual elapsed time, bounded by maxRetargetStep.
+func nextDifficulty(height, parentDifficulty int, elapsed time.Duration) int {
+	if !isRetargetHeight(height) {
+		return parentDifficulty
+	}
+
+	blocks := height - 1 - retargetWindowStart(height)
+	if blocks == 0 {
+		return parentDifficulty
+	}
+	expected := time.Duration(blocks) * difficultyPolicy.TargetBlockTime
+	// Clock skew can make the elapsed time zero or negative, treat it as
+	// the fastest possible window.
+	elapsed = max(elapsed, time.Millisecond)
+
+	step := int(math.Round(math.Log2(float64(expected) / float64(elapsed))))
+	step = min(max(step, -maxRetargetStep), maxRetargetStep)
+
+	return min(max(parentDifficulty+step, difficultyPolicy.Min), MaxDifficulty)
+}
+
+// checkDifficulty validates the difficulty of the block at height given the
+// difficulty of its parent and the elapsed time nextDifficulty takes. The
+// genesis block may have any difficulty in range, the others must have the
+// one nextDifficulty retargets to, as for blocks added to the chain.
+func checkDifficulty(height, difficulty, parentDifficulty int, elapsed time.Duration) error {
+	if difficulty < 1 || difficulty > MaxDifficulty {
+		return errors.New("difficulty is out of range")
+	}
+	if height == 0 {
+		return nil
+	}
+	if want := nextDifficulty(height, parentDifficulty, elapsed); difficulty != want {
+		return fmt.Errorf("difficulty %d, want %d", difficulty, want)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package blockchainlogic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextDifficulty(t *testing.T) {
+	// With the default policy a retarget at height 20 looks at blocks 9 to 19,
+	// ten block times of 10s.
+	tests := []struct {
+		name    string
+		height  int
+		elapsed time.Duration
+		want    int
+	}{
+		{name: "Not a retarget height", height: 21, elapsed: time.Second, want: 12},
+		{name: "On target", height: 20, elapsed: 100 * time.Second, want: 12},
+		{name: "Twice too fast", height: 20, elapsed: 50 * time.Second, want: 13},
+		{name: "Twice too slow", height: 20, elapsed: 200 * time.Second, want: 11},
+		{name: "Far too fast is bounded", height: 20, elapsed: time.Second, want: 12 + maxRetargetStep},
+		{name: "Far too slow is bounded", height: 20, elapsed: time.Hour, want: 12 - maxRetargetStep},
+		{name: "Clock skew", height: 20, elapsed: -time.Minute, want: 12 + maxRetargetStep},
+		{name: "First window", height: 10, elapsed: 90 * time.Second, want: 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDifficulty(tt.height, 12, tt.elapsed); got != tt.want {
+				t.Errorf("nextDifficulty() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDifficulty_Min(t *testing.T) {
+	policy := difficultyPolicy
+	t.Cleanup(func() { difficultyPolicy = policy })
+	if err := SetDifficultyPolicy(DifficultyPolicy{Initial: 12, Min: 11, RetargetInterval: 10, TargetBlockTime: 10 * time.Second}); err != nil {
+		t.Fatalf("SetDifficultyPolicy() error = %v", err)
+	}
+
+	if got := nextDifficulty(20, 12, time.Hour); got != 11 {
+		t.Errorf("nextDifficulty() = %d, want 11", got)
+	}
+}
+
+func TestCheckDifficulty(t *testing.T) {
+	tests := []struct {
+		name    string
+		height  int
+		diff    int
+		parent  int
+		elapsed time.Duration
+		wantErr bool
+	}{
+		{name: "Genesis", height: 0, diff: 20},
+		{name: "Unchanged", height: 5, diff: 12, parent: 12, elapsed: time.Second},
+		{name: "Retarget", height: 20, diff: 14, parent: 12, elapsed: time.Second},
+		{name: "Retarget on target", height: 20, diff: 12, parent: 12, elapsed: 100 * time.Second},
+		{name: "Retarget skipped", height: 20, diff: 12, parent: 12, elapsed: time.Second, wantErr: true},
+		{name: "Wrong retarget", height: 20, diff: 13, parent: 12, elapsed: time.Second, wantErr: true},
+		{name: "Change outside a retarget", height: 21, diff: 13, parent: 12, wantErr: true},
+		{name: "Step too large", height: 20, diff: 15, parent: 12, elapsed: time.Second, wantErr: true},
+		{name: "Out of range", height: 0, diff: 256, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkDifficulty(tt.height, tt.diff, tt.parent, tt.elapsed); (err != nil) != tt.wantErr {
+				t.Errorf("checkDifficulty() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProofOfWork_StoredDifficulty(t *testing.T) {
+	block := CreateBlock([]*Transaction{NewCoinbaseTX(string(NewWallet().GetAddress()), "")}, "0", 8)
+
+	if !NewProof(block).Validate() {
+		t.Fatalf("Validate() rejected a block mined at its stored difficulty")
+	}
+	block.Difficulty = Difficulty
+	if NewProof(block).Validate() {
+		t.Errorf("Validate() accepted a block under another difficulty")
+	}
+}
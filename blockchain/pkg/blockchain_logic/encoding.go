@@ -0,0 +1,292 @@
+package blockchainlogic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Blocks and transactions are encoded in a canonical binary format, the same
+// for hashing, storage and the wire. Every encoding starts with a version
+// byte. Integers are big endian, int64 two's complement; byte strings and
+// lists are prefixed with their uint32 length. Empty and nil byte strings
+// encode alike, so a value has exactly one encoding.
+//
+// Transaction, version 1:
+//
+//	version  uint8
+//	id       bytes
+//	inputs   uint32 count, each:
+//	  txid bytes, vout int64, signature bytes, pub_key bytes,
+//	  unlock_script bytes, sequence int64
+//	outputs  uint32 count, each:
+//	  value int64, pub_key_hash bytes, script bytes
+//	lock_time int64
+//
+// Block, version 1:
+//
+//	version      uint8
+//	hash         bytes
+//	prev_hash    bytes
+//	merkle_root  bytes
+//	timestamp    int64, Unix nanoseconds
+//	nonce        int64
+//	difficulty   int64
+//	transactions uint32 count, each a length prefixed transaction
+//
+// The proof of work hashes a header of the block fields, not an encoding of
+// it: prev_hash, merkle_root, then timestamp, nonce and difficulty as int64.
+// Headers before the timestamp was hashed lack it; chains mined with them
+// are mined again by RewriteChain.
+const (
+	TxEncodingVersion    byte = 1
+	BlockEncodingVersion byte = 1
+)
+
+// ErrEncoding is returned when data is not a valid encoding.
+var ErrEncoding = errors.New("invalid encoding")
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint8(v byte) {
+	e.buf = append(e.buf, v)
+}
+
+func (e *encoder) uint32(v int) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v))
+}
+
+func (e *encoder) int64(v int64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.uint32(len(v))
+	e.buf = append(e.buf, v...)
+}
+
+// decoder reads an encoding front to back. The first error sticks, later
+// reads return zero values.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", ErrEncoding, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *decoder) next(n int, what string) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data) {
+		d.fail("%s: need %d bytes, %d left", what, n, len(d.data))
+
+		return nil
+	}
+	v := d.data[:n]
+	d.data = d.data[n:]
+
+	return v
+}
+
+func (d *decoder) uint8(what string) byte {
+	v := d.next(1, what)
+	if v == nil {
+		return 0
+	}
+
+	return v[0]
+}
+
+func (d *decoder) uint32(what string) int {
+	v := d.next(4, what)
+	if v == nil {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint32(v))
+}
+
+func (d *decoder) int64(what string) int64 {
+	v := d.next(8, what)
+	if v == nil {
+		return 0
+	}
+
+	return int64(binary.BigEndian.Uint64(v))
+}
+
+func (d *decoder) bytes(what string) []byte {
+	n := d.uint32(what + " length")
+	v := d.next(n, what)
+	if len(v) == 0 {
+		return nil
+	}
+
+	return append([]byte(nil), v...)
+}
+
+// count reads a list length. Every element takes at least minSize bytes, so
+// longer lists can not fit in the remaining data.
+func (d *decoder) count(what string, minSize int) int {
+	n := d.uint32(what + " count")
+	if d.err == nil && n > len(d.data)/minSize {
+		d.fail("%d %s do not fit in %d bytes", n, what, len(d.data))
+
+		return 0
+	}
+
+	return n
+}
+
+func (d *decoder) version(want byte, what string) {
+	if v := d.uint8(what + " version"); d.err == nil && v != want {
+		d.fail("unsupported %s version %d", what, v)
+	}
+}
+
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.fail("%d trailing bytes", len(d.data))
+	}
+
+	return d.err
+}
+
+func (e *encoder) transaction(tx *Transaction) {
+	e.uint8(TxEncodingVersion)
+	e.bytes(tx.ID)
+	e.uint32(len(tx.Vin))
+	for _, in := range tx.Vin {
+		e.bytes(in.Txid)
+		e.int64(int64(in.Vout))
+		e.bytes(in.Signature)
+		e.bytes(in.PubKey)
+		e.bytes(in.UnlockScript)
+		e.int64(in.Sequence)
+	}
+	e.uint32(len(tx.Vout))
+	for _, out := range tx.Vout {
+		e.int64(int64(out.Value))
+		e.bytes(out.PubKeyHash)
+		e.bytes(out.Script)
+	}
+	e.int64(tx.LockTime)
+}
+
+// Minimal encoded sizes of list elements.
+const (
+	minInputSize  = 4 + 8 + 4 + 4 + 4 + 8
+	minOutputSize = 8 + 4 + 4
+	minTxSize     = 4 + 1 + 4 + 4 + 4 + 8
+)
+
+func (d *decoder) transaction() *Transaction {
+	var tx Transaction
+
+	d.version(TxEncodingVersion, "transaction")
+	tx.ID = d.bytes("id")
+	if n := d.count("inputs", minInputSize); n > 0 {
+		tx.Vin = make([]TXInput, n)
+	}
+	for i := range tx.Vin {
+		in := &tx.Vin[i]
+		in.Txid = d.bytes("input txid")
+		in.Vout = int(d.int64("input vout"))
+		in.Signature = d.bytes("input signature")
+		in.PubKey = d.bytes("input public key")
+		in.UnlockScript = d.bytes("input unlocking script")
+		in.Sequence = d.int64("input sequence")
+	}
+	if n := d.count("outputs", minOutputSize); n > 0 {
+		tx.Vout = make([]TXOutput, n)
+	}
+	for i := range tx.Vout {
+		out := &tx.Vout[i]
+		out.Value = Amount(d.int64("output value"))
+		out.PubKeyHash = d.bytes("output public key hash")
+		out.Script = d.bytes("output script")
+	}
+	tx.LockTime = d.int64("lock time")
+
+	return &tx
+}
+
+// Serialize returns the canonical encoding of tx.
+func (tx Transaction) Serialize() []byte {
+	var e encoder
+	e.transaction(&tx)
+
+	return e.buf
+}
+
+// DeserializeTransaction decodes a transaction encoded with Serialize.
+func DeserializeTransaction(data []byte) (*Transaction, error) {
+	d := decoder{data: data}
+	tx := d.transaction()
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Serialize returns the canonical encoding of b.
+func (b *Block) Serialize() []byte {
+	var e encoder
+
+	e.uint8(BlockEncodingVersion)
+	e.bytes([]byte(b.Hash))
+	e.bytes([]byte(b.PrevHash))
+	e.bytes(b.MerkleRoot)
+	e.int64(b.Timestamp.UnixNano())
+	e.int64(int64(b.Nonce))
+	e.int64(int64(b.Difficulty))
+	e.uint32(len(b.Transactions))
+	for _, tx := range b.Transactions {
+		var txEnc encoder
+		txEnc.transaction(tx)
+		e.bytes(txEnc.buf)
+	}
+
+	return e.buf
+}
+
+// DeserializeBlock decodes a block encoded with Serialize.
+func DeserializeBlock(data []byte) (*Block, error) {
+	var block Block
+	d := decoder{data: data}
+
+	d.version(BlockEncodingVersion, "block")
+	block.Hash = string(d.bytes("hash"))
+	block.PrevHash = string(d.bytes("previous hash"))
+	block.MerkleRoot = d.bytes("merkle root")
+	block.Timestamp = time.Unix(0, d.int64("timestamp")).UTC()
+	block.Nonce = int(d.int64("nonce"))
+	block.Difficulty = int(d.int64("difficulty"))
+	if n := d.count("transactions", minTxSize); n > 0 {
+		block.Transactions = make([]*Transaction, n)
+	}
+	for i := range block.Transactions {
+		txDec := decoder{data: d.next(d.uint32("transaction length"), "transaction")}
+		if d.err != nil {
+			break
+		}
+		block.Transactions[i] = txDec.transaction()
+		if err := txDec.finish(); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+
+	return &block, nil
+}
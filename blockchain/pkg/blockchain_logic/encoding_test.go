@@ -0,0 +1,146 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// goldenTx covers every field of a transaction with fixed values.
+func goldenTx() *Transaction {
+	return &Transaction{
+		ID: []byte{0xaa, 0xbb},
+		Vin: []TXInput{
+			{Txid: []byte{0x01, 0x02, 0x03}, Vout: 1, Signature: []byte{0x04}, PubKey: []byte{0x05, 0x06}, Sequence: 7},
+			{Txid: []byte{0x08}, Vout: 0, UnlockScript: Script{Op1}},
+		},
+		Vout: []TXOutput{
+			{Value: 100000000, PubKeyHash: []byte{0x09, 0x0a}},
+			{Value: 5, Script: Script{OpReturn}},
+		},
+		LockTime: 500,
+	}
+}
+
+func goldenBlock() *Block {
+	return &Block{
+		Hash:         "00ab",
+		Transactions: []*Transaction{goldenTx()},
+		PrevHash:     "0",
+		MerkleRoot:   []byte{0xcc},
+		Timestamp:    time.Unix(1700000000, 5).UTC(),
+		Nonce:        42,
+		Difficulty:   16,
+	}
+}
+
+// The golden encodings are spelled out field by field; they must only change
+// together with the encoding version.
+const (
+	goldenTxHex = "01" + // version
+		"00000002" + "aabb" + // id
+		"00000002" + // inputs
+		"00000003" + "010203" + "0000000000000001" + "00000001" + "04" + "00000002" + "0506" + "00000000" + "0000000000000007" +
+		"00000001" + "08" + "0000000000000000" + "00000000" + "00000000" + "00000001" + "51" + "0000000000000000" +
+		"00000002" + // outputs
+		"0000000005f5e100" + "00000002" + "090a" + "00000000" +
+		"0000000000000005" + "00000000" + "00000001" + "6a" +
+		"00000000000001f4" // lock time
+	// goldenTxID is the SHA-256 of goldenTxHex without the id, signature and
+	// unlocking script.
+	goldenTxID     = "1105babc9147e1a0de218b1d9556de03fd51a66a52425e25d2d1a0b1d341df2b"
+	goldenBlockHex = "01" + // version
+		"00000004" + "30306162" + // hash
+		"00000001" + "30" + // previous hash
+		"00000001" + "cc" + // merkle root
+		"17979cfe362a0005" + // timestamp
+		"000000000000002a" + // nonce
+		"0000000000000010" + // difficulty
+		"00000001" + // transactions
+		"00000082" + goldenTxHex
+)
+
+func TestTransaction_SerializeGolden(t *testing.T) {
+	tx := goldenTx()
+	if got := hex.EncodeToString(tx.Serialize()); got != goldenTxHex {
+		t.Errorf("Serialize() = %s, want %s", got, goldenTxHex)
+	}
+	if got := hex.EncodeToString(tx.Hash()); got != goldenTxID {
+		t.Errorf("Hash() = %s, want %s", got, goldenTxID)
+	}
+}
+
+func TestBlock_SerializeGolden(t *testing.T) {
+	if got := hex.EncodeToString(goldenBlock().Serialize()); got != goldenBlockHex {
+		t.Errorf("Serialize() = %s, want %s", got, goldenBlockHex)
+	}
+}
+
+func TestEncoding_RoundTrip(t *testing.T) {
+	owner := NewWallet()
+	coinbase := NewCoinbaseTX(string(owner.GetAddress()), "")
+	spend, _ := newSignedSpend(t, owner, NewWallet())
+
+	for _, tx := range []*Transaction{goldenTx(), coinbase, spend, {}} {
+		got, err := DeserializeTransaction(tx.Serialize())
+		if err != nil {
+			t.Fatalf("DeserializeTransaction() error = %v", err)
+		}
+		if !bytes.Equal(got.Serialize(), tx.Serialize()) || !bytes.Equal(got.Hash(), tx.Hash()) {
+			t.Errorf("DeserializeTransaction() = %+v, want %+v", got, tx)
+		}
+	}
+
+	block := goldenBlock()
+	block.Transactions = append(block.Transactions, coinbase, spend)
+	got, err := DeserializeBlock(block.Serialize())
+	if err != nil {
+		t.Fatalf("DeserializeBlock() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Header(), block.Header()) {
+		t.Errorf("DeserializeBlock() header = %+v, want %+v", got.Header(), block.Header())
+	}
+	if !bytes.Equal(got.Serialize(), block.Serialize()) {
+		t.Errorf("DeserializeBlock() transactions do not round trip")
+	}
+}
+
+func TestEncoding_Invalid(t *testing.T) {
+	tx, _ := hex.DecodeString(goldenTxHex)
+	block, _ := hex.DecodeString(goldenBlockHex)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"Empty", nil, "version"},
+		{"Unknown version", append([]byte{2}, tx[1:]...), "unsupported transaction version 2"},
+		{"Truncated", tx[:len(tx)-1], "lock time"},
+		{"Trailing bytes", append(append([]byte{}, tx...), 0), "trailing"},
+		{"Oversized length", append([]byte{1, 0xff, 0xff, 0xff, 0xff}, tx[5:]...), "id"},
+		{"Oversized count", append(append([]byte{1, 0, 0, 0, 0}, 0x7f, 0xff, 0xff, 0xff), tx[11:]...), "inputs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DeserializeTransaction(tt.data)
+			if !errors.Is(err, ErrEncoding) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("DeserializeTransaction() error = %v, want %v about %q", err, ErrEncoding, tt.want)
+			}
+		})
+	}
+
+	// The embedded transaction is one byte shorter than its length prefix.
+	short := append([]byte{}, block...)
+	short[len(short)-len(tx)-1]--
+	if _, err := DeserializeBlock(short); !errors.Is(err, ErrEncoding) {
+		t.Errorf("DeserializeBlock() with a short transaction error = %v, want %v", err, ErrEncoding)
+	}
+	if _, err := DeserializeBlock(tx); !errors.Is(err, ErrEncoding) {
+		t.Errorf("DeserializeBlock() of a transaction error = %v, want %v", err, ErrEncoding)
+	}
+}
@@ -0,0 +1,213 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned when a block or transaction is not stored.
+var ErrNotFound = errors.New("not found")
+
+// BlockInfo is a stored block together with its position in the chain.
+type BlockInfo struct {
+	Block         *Block
+	Height        int
+	Confirmations int
+}
+
+// TxInfo is a transaction together with the block that confirmed it. Pending
+// transactions have no block and a Height of -1.
+type TxInfo struct {
+	Tx            *Transaction
+	Status        TxStatus
+	BlockHash     string
+	Height        int
+	Timestamp     time.Time
+	Confirmations int
+}
+
+// Height returns the height of the chain tip, -1 for an empty chain.
+func (bc *Blockchain) Height() (int, error) {
+	return bc.store.Height()
+}
+
+func (bc *Blockchain) blockInfo(block *Block, height, tipHeight int) *BlockInfo {
+	return &BlockInfo{
+		Block:         block,
+		Height:        height,
+		Confirmations: tipHeight - height + 1,
+	}
+}
+
+// BlockByHash returns the main chain block with the given hash. Side branch
+// blocks are not found.
+func (bc *Blockchain) BlockByHash(hash string) (*BlockInfo, error) {
+	block, err := bc.store.Block(hash)
+	if err != nil {
+		return nil, err
+	}
+	height, err := bc.store.BlockHeight(hash)
+	if err != nil {
+		return nil, err
+	}
+	tipHeight, err := bc.Height()
+	if err != nil {
+		return nil, err
+	}
+
+	return bc.blockInfo(block, height, tipHeight), nil
+}
+
+// BlockByHeight returns the block at the given height, genesis being 0.
+func (bc *Blockchain) BlockByHeight(height int) (*BlockInfo, error) {
+	block, err := bc.store.BlockAt(height)
+	if err != nil {
+		return nil, err
+	}
+	tipHeight, err := bc.Height()
+	if err != nil {
+		return nil, err
+	}
+
+	return bc.blockInfo(block, height, tipHeight), nil
+}
+
+// Blocks returns up to limit blocks in descending height order starting at
+// height from. A negative from starts at the tip.
+func (bc *Blockchain) Blocks(from, limit int) ([]*BlockInfo, error) {
+	tipHeight, err := bc.Height()
+	if err != nil {
+		return nil, err
+	}
+	if from < 0 || from > tipHeight {
+		from = tipHeight
+	}
+
+	var blocks []*BlockInfo
+	for height := from; height >= 0 && len(blocks) < limit; height-- {
+		block, err := bc.store.BlockAt(height)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, bc.blockInfo(block, height, tipHeight))
+	}
+
+	return blocks, nil
+}
+
+// TransactionInfo returns a pending or confirmed transaction by its hex encoded ID.
+func (bc *Blockchain) TransactionInfo(txID string) (*TxInfo, error) {
+	if tx, ok := bc.mempool.Get(txID); ok {
+		return &TxInfo{Tx: tx, Status: TxStatusPending, Height: -1}, nil
+	}
+
+	id, err := hex.DecodeString(txID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction id: %w", err)
+	}
+
+	blockHash, err := bc.store.TransactionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := bc.BlockByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return confirmedTxInfo(block, txID)
+}
+
+// TxProof is the Merkle branch of a confirmed transaction together with the
+// block that holds it.
+type TxProof struct {
+	Block *BlockInfo
+	Proof *MerkleProof
+}
+
+// TransactionProof returns the Merkle branch of a confirmed transaction by its
+// hex encoded ID. Pending transactions have none and are not found.
+func (bc *Blockchain) TransactionProof(txID string) (*TxProof, error) {
+	id, err := hex.DecodeString(txID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction id: %w", err)
+	}
+
+	blockHash, err := bc.store.TransactionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := bc.BlockByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := block.Block.MerkleProof(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxProof{Block: block, Proof: proof}, nil
+}
+
+func confirmedTxInfo(block *BlockInfo, txID string) (*TxInfo, error) {
+	for _, tx := range block.Block.Transactions {
+		if hex.EncodeToString(tx.ID) == txID {
+			return &TxInfo{
+				Tx:            tx,
+				Status:        TxStatusConfirmed,
+				BlockHash:     block.Block.Hash,
+				Height:        block.Height,
+				Timestamp:     block.Block.Timestamp,
+				Confirmations: block.Confirmations,
+			}, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// AddressTransactions returns confirmed transactions sending from or paying to
+// the address, newest first.
+func (bc *Blockchain) AddressTransactions(address string, limit, offset int) ([]*TxInfo, error) {
+	if !ValidateAddress(address) {
+		return nil, errors.New("address is not valid")
+	}
+	pubKeyHash := addressPubKeyHash(address)
+
+	if limit <= 0 {
+		return nil, nil
+	}
+	entries, err := bc.store.AddressTransactions(AddressTxQuery{
+		PubKeyHashes: [][]byte{pubKeyHash},
+		Offset:       offset,
+		Limit:        limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[string]*BlockInfo)
+	txs := make([]*TxInfo, 0, len(entries))
+	for _, e := range entries {
+		block, ok := blocks[e.BlockHash]
+		if !ok {
+			block, err = bc.BlockByHash(e.BlockHash)
+			if err != nil {
+				return nil, err
+			}
+			blocks[e.BlockHash] = block
+		}
+
+		info, err := confirmedTxInfo(block, e.TxID)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, info)
+	}
+
+	return txs, nil
+}
@@ -0,0 +1,140 @@
+package blockchainlogic
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrGenesisMismatch is returned when a store holds another chain than the
+// one of the genesis parameters it is opened with.
+var ErrGenesisMismatch = errors.New("stored genesis block does not match the genesis parameters")
+
+// GenesisParams fix the genesis block, so every node opening a chain with the
+// same parameters has the same block 0. Its supply is set by
+// SetGenesisSupply.
+type GenesisParams struct {
+	// Recipient receives the genesis supply. It is the treasury funding top
+	// ups.
+	Recipient string
+	Timestamp time.Time
+	// Message is the data of the genesis coinbase.
+	Message string
+}
+
+// genesisSupply is the genesis coinbase reward, zero for the default one.
+var genesisSupply Amount
+
+// SetGenesisSupply sets the amount paid by the genesis coinbase, in base
+// units. Like the decimals, it must be set before the chain is opened and
+// never changed for an existing chain.
+func SetGenesisSupply(supply Amount) error {
+	if supply <= 0 {
+		return fmt.Errorf("%w: genesis supply %s", ErrInvalidAmount, supply)
+	}
+	genesisSupply = supply
+
+	return nil
+}
+
+// GenesisSupply returns the amount paid by the genesis coinbase, in base
+// units.
+func GenesisSupply() Amount {
+	return genesisReward()
+}
+
+// Block returns the genesis block of p, mined at the initial difficulty.
+func (p GenesisParams) Block() (*Block, error) {
+	return p.block(genesisReward())
+}
+
+func (p GenesisParams) block(supply Amount) (*Block, error) {
+	if !ValidateAddress(p.Recipient) {
+		return nil, fmt.Errorf("genesis recipient %q is not valid", p.Recipient)
+	}
+	if p.Message == "" {
+		// An empty message would be replaced by random data.
+		return nil, errors.New("genesis message is empty")
+	}
+
+	block := &Block{
+		Transactions: []*Transaction{newCoinbaseTX(p.Recipient, supply, p.Message)},
+		PrevHash:     "0",
+		Timestamp:    p.Timestamp.UTC(),
+		Difficulty:   difficultyPolicy.Initial,
+	}
+	block.MerkleRoot = block.HashTransactions()
+	block.Nonce, block.Hash = NewProof(block).Run()
+
+	return block, nil
+}
+
+// OpenBlockchain opens the chain of p kept in store, adding its genesis block
+// when the store is empty and refusing a store holding another genesis block
+// with ErrGenesisMismatch. The key of the recipient must be in keys, as it
+// funds top ups; blocks are mined to it unless SetMinerAddress is called.
+func OpenBlockchain(store ChainStore, keys Keystore, p GenesisParams) (*Blockchain, error) {
+	genesis, err := p.Block()
+	if err != nil {
+		return nil, err
+	}
+	_, err = keys.Get(p.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("treasury %s: %w", p.Recipient, err)
+	}
+	err = openGenesis(store, genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlockchain(store, keys, p.Recipient), nil
+}
+
+// openGenesis adds genesis to an empty store, or checks it is the block 0 of
+// the stored chain.
+func openGenesis(store ChainStore, genesis *Block) error {
+	stored, err := store.BlockAt(0)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return store.AddBlock(genesis)
+	case err != nil:
+		return err
+	case stored.Hash != genesis.Hash:
+		return fmt.Errorf("%w: block 0 is %s, want %s", ErrGenesisMismatch, stored.Hash, genesis.Hash)
+	}
+
+	return nil
+}
+
+// StoredGenesis returns the genesis parameters and supply reproducing block 0
+// of store, to pin a chain whose genesis block was made before it was fixed
+// in config. Block 0 must have the initial difficulty of the current policy.
+func StoredGenesis(store ChainStore) (GenesisParams, Amount, error) {
+	stored, err := store.BlockAt(0)
+	if err != nil {
+		return GenesisParams{}, 0, err
+	}
+	if len(stored.Transactions) != 1 || !stored.Transactions[0].IsCoinbase() || len(stored.Transactions[0].Vout) != 1 {
+		return GenesisParams{}, 0, fmt.Errorf("block 0 %s does not hold a single coinbase", stored.Hash)
+	}
+	if stored.Difficulty != difficultyPolicy.Initial {
+		return GenesisParams{}, 0, fmt.Errorf("block 0 has difficulty %d, set the initial difficulty to it", stored.Difficulty)
+	}
+
+	coinbase := stored.Transactions[0]
+	p := GenesisParams{
+		Recipient: coinbase.Vout[0].Address(),
+		Timestamp: stored.Timestamp.UTC(),
+		Message:   string(coinbase.Vin[0].PubKey),
+	}
+	supply := coinbase.Vout[0].Value
+	genesis, err := p.block(supply)
+	if err != nil {
+		return GenesisParams{}, 0, err
+	}
+	if genesis.Hash != stored.Hash {
+		return GenesisParams{}, 0, fmt.Errorf("%w: block 0 %s is not reproduced by its parameters, rewrite the chain first", ErrGenesisMismatch, stored.Hash)
+	}
+
+	return p, supply, nil
+}
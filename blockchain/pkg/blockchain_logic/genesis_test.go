@@ -0,0 +1,118 @@
+package blockchainlogic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func testGenesisParams(t *testing.T, keys Keystore) GenesisParams {
+	t.Helper()
+
+	recipient, err := CreateWallet(keys)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	return GenesisParams{
+		Recipient: recipient,
+		Timestamp: time.Date(2024, time.January, 3, 18, 15, 5, 0, time.UTC),
+		Message:   genesisCoinbaseData,
+	}
+}
+
+func TestGenesisParams_Block(t *testing.T) {
+	p := testGenesisParams(t, NewWallets())
+
+	a, err := p.Block()
+	if err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+	b, _ := p.Block()
+	if a.Hash != b.Hash || !a.Timestamp.Equal(p.Timestamp) {
+		t.Errorf("Block() = %s at %v, then %s, want the same block at %v", a.Hash, a.Timestamp, b.Hash, p.Timestamp)
+	}
+
+	p.Message = ""
+	if _, err = p.Block(); err == nil {
+		t.Errorf("Block() without a message error = nil, want an error")
+	}
+}
+
+func TestOpenBlockchain(t *testing.T) {
+	keys := NewWallets()
+	p := testGenesisParams(t, keys)
+	store := NewMemoryChainStore()
+
+	bc, err := OpenBlockchain(store, keys, p)
+	if err != nil {
+		t.Fatalf("OpenBlockchain() error = %v", err)
+	}
+	genesis, _ := p.Block()
+	checkTip(t, bc, genesis, 0)
+	checkBalance(t, bc, p.Recipient, genesisReward())
+
+	// Reopening checks block 0 against the parameters.
+	if _, err = OpenBlockchain(store, keys, p); err != nil {
+		t.Errorf("OpenBlockchain() of an opened store error = %v", err)
+	}
+	other := p
+	other.Timestamp = p.Timestamp.Add(time.Second)
+	if _, err = OpenBlockchain(store, keys, other); !errors.Is(err, ErrGenesisMismatch) {
+		t.Errorf("OpenBlockchain() with another timestamp error = %v, want %v", err, ErrGenesisMismatch)
+	}
+
+	if _, err = OpenBlockchain(NewMemoryChainStore(), NewWallets(), p); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("OpenBlockchain() without the treasury key error = %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestSetGenesisSupply(t *testing.T) {
+	t.Cleanup(func() { genesisSupply = 0 })
+	if err := SetGenesisSupply(0); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("SetGenesisSupply(0) error = %v, want %v", err, ErrInvalidAmount)
+	}
+	if err := SetGenesisSupply(21 * Coin()); err != nil {
+		t.Fatalf("SetGenesisSupply() error = %v", err)
+	}
+
+	keys := NewWallets()
+	p := testGenesisParams(t, keys)
+	bc, err := OpenBlockchain(NewMemoryChainStore(), keys, p)
+	if err != nil {
+		t.Fatalf("OpenBlockchain() error = %v", err)
+	}
+	checkBalance(t, bc, p.Recipient, 21*Coin())
+	report, err := VerifyChain(bc.Store())
+	if err != nil || !report.Valid() {
+		t.Errorf("VerifyChain() = %v, %v, want valid", report, err)
+	}
+}
+
+func TestStoredGenesis(t *testing.T) {
+	keys := NewWallets()
+	recipient, err := CreateWallet(keys)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	// A genesis block made before it was fixed in config.
+	store := NewMemoryChainStore()
+	if err = store.AddBlock(Genesis(NewCoinbaseTX(recipient, genesisCoinbaseData))); err != nil {
+		t.Fatalf("AddBlock() error = %v", err)
+	}
+
+	p, supply, err := StoredGenesis(store)
+	if err != nil {
+		t.Fatalf("StoredGenesis() error = %v", err)
+	}
+	if p.Recipient != recipient || p.Message != genesisCoinbaseData || supply != genesisReward() {
+		t.Errorf("StoredGenesis() = %+v, %s", p, supply)
+	}
+	if _, err = OpenBlockchain(store, keys, p); err != nil {
+		t.Errorf("OpenBlockchain() with the stored parameters error = %v", err)
+	}
+
+	if _, _, err = StoredGenesis(NewMemoryChainStore()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("StoredGenesis() of an empty store error = %v, want %v", err, ErrNotFound)
+	}
+}
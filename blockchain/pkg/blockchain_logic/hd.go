@@ -0,0 +1,148 @@
+package blockchainlogic
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// HD wallets follow BIP39 for the mnemonic and seed, and SLIP-0010, the
+// BIP32 derivation for NIST P-256, for the keys. Since the node holds the
+// seed, every level is hardened: m/44'/1'/0'/chain'/index'.
+const (
+	hdSeedKey       = "Nist256p1 seed"
+	hdHardened      = uint32(1) << 31
+	hdPurpose       = 44
+	hdCoinType      = 1
+	hdAccountIndex  = 0
+	mnemonicEntropy = 128
+)
+
+// Chains of an HD wallet.
+const (
+	ChainReceive uint32 = 0
+	ChainChange  uint32 = 1
+)
+
+var (
+	ErrInvalidMnemonic = errors.New("mnemonic is not valid")
+	// ErrNotHD is returned for addresses of standalone keys.
+	ErrNotHD = errors.New("address does not belong to an HD wallet")
+)
+
+// NewMnemonic returns a new random 12 word BIP39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropy)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// MnemonicSeed validates mnemonic and returns its BIP39 seed.
+func MnemonicSeed(mnemonic string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, ErrInvalidMnemonic
+	}
+
+	return seed, nil
+}
+
+// hdKey is an extended private key.
+type hdKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// hdMasterKey derives the master key of seed.
+func hdMasterKey(seed []byte) *hdKey {
+	n := elliptic.P256().Params().N
+	data := seed
+	for {
+		mac := hmac.New(sha512.New, []byte(hdSeedKey))
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		key := new(big.Int).SetBytes(sum[:32])
+		if key.Sign() > 0 && key.Cmp(n) < 0 {
+			return &hdKey{key, sum[32:]}
+		}
+		// Out of range, retry with the whole output as SLIP-0010 requires.
+		data = sum
+	}
+}
+
+// child derives the hardened child index of k.
+func (k *hdKey) child(index uint32) *hdKey {
+	n := elliptic.P256().Params().N
+
+	data := make([]byte, 37)
+	k.key.FillBytes(data[1:33])
+	binary.BigEndian.PutUint32(data[33:], index|hdHardened)
+	for {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) < 0 {
+			key := tweak.Add(tweak, k.key)
+			key.Mod(key, n)
+			if key.Sign() > 0 {
+				return &hdKey{key, sum[32:]}
+			}
+		}
+		data[0] = 1
+		copy(data[1:33], sum[32:])
+	}
+}
+
+// wallet returns the P-256 wallet of the key.
+func (k *hdKey) wallet() (*Wallet, error) {
+	raw := make([]byte, 32)
+	k.key.FillBytes(raw)
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	point := ecdhKey.PublicKey().Bytes()
+
+	private := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).Set(k.key),
+	}
+	wallet := &Wallet{KeyType: KeyTypeECDSA}
+	wallet.SigningKey, err = x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	wallet.PublicKey, err = x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// DeriveWallet returns the wallet at index of chain of the HD wallet of seed.
+func DeriveWallet(seed []byte, chain, index uint32) (*Wallet, error) {
+	key := hdMasterKey(seed).child(hdPurpose).child(hdCoinType).child(hdAccountIndex).child(chain).child(index)
+
+	return key.wallet()
+}
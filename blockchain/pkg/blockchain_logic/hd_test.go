@@ -0,0 +1,134 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestHDKey_SLIP10Vector(t *testing.T) {
+	// SLIP-0010 test vector 1 for nist256p1.
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	master := hdMasterKey(seed)
+	if got := hex.EncodeToString(master.key.Bytes()); got != "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2" {
+		t.Errorf("master key = %s", got)
+	}
+	if got := hex.EncodeToString(master.chainCode); got != "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea" {
+		t.Errorf("master chain code = %s", got)
+	}
+	if got := hex.EncodeToString(master.child(0).key.Bytes()); got != "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c" {
+		t.Errorf("m/0' key = %s", got)
+	}
+}
+
+func TestDeriveWallet(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatalf("NewMnemonic() error = %v", err)
+	}
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		t.Fatalf("MnemonicSeed() error = %v", err)
+	}
+
+	first, _ := DeriveWallet(seed, ChainReceive, 0)
+	again, _ := DeriveWallet(seed, ChainReceive, 0)
+	change, _ := DeriveWallet(seed, ChainChange, 0)
+	if string(first.GetAddress()) != string(again.GetAddress()) {
+		t.Errorf("DeriveWallet() is not deterministic")
+	}
+	if string(first.GetAddress()) == string(change.GetAddress()) {
+		t.Errorf("receive and change chains derive the same address")
+	}
+
+	sig, err := first.Sign([]byte("payload"))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !VerifySignature(first.PublicKey, []byte("payload"), sig) {
+		t.Errorf("derived key signature does not verify")
+	}
+}
+
+func TestMnemonicSeed(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		wantErr  bool
+	}{
+		{name: "Valid", mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+		{name: "Spacing and case", mnemonic: "  Abandon abandon abandon abandon abandon abandon\tabandon abandon abandon abandon abandon ABOUT "},
+		{name: "Bad checksum", mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", wantErr: true},
+		{name: "Unknown word", mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon satoshi", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MnemonicSeed(tt.mnemonic)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MnemonicSeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWallets_HD(t *testing.T) {
+	ks := NewWallets()
+	seed, _ := MnemonicSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+
+	root, err := ks.PutSeed(seed, 1, 0)
+	if err != nil {
+		t.Fatalf("PutSeed() error = %v", err)
+	}
+	if again, err := ks.PutSeed(seed, 1, 0); !errors.Is(err, ErrKeyExists) || again != root {
+		t.Errorf("PutSeed() of a stored seed = %q, %v, want %q, %v", again, err, root, ErrKeyExists)
+	}
+
+	bc := NewBlockchain(NewMemoryChainStore(), ks, root)
+	change, err := bc.changeAddress(ks, root)
+	if err != nil {
+		t.Fatalf("changeAddress() error = %v", err)
+	}
+	next, err := bc.changeAddress(ks, root)
+	if err != nil {
+		t.Fatalf("changeAddress() error = %v", err)
+	}
+	if change == root || change == next {
+		t.Errorf("changeAddress() did not derive fresh addresses: %s, %s", change, next)
+	}
+	if _, err = ks.Get(change); err != nil {
+		t.Errorf("Get() of a derived address error = %v", err)
+	}
+
+	group, err := ks.Group(change)
+	if err != nil {
+		t.Fatalf("Group() error = %v", err)
+	}
+	if len(group) != 3 || group[0] != root {
+		t.Errorf("Group() = %v, want the root and two change addresses", group)
+	}
+
+	standalone, _ := CreateWallet(ks)
+	if got, err := bc.changeAddress(ks, standalone); err != nil || got != standalone {
+		t.Errorf("changeAddress() of a standalone key = %q, %v, want %q", got, err, standalone)
+	}
+	if _, err = ks.NextAddress(standalone, ChainReceive); !errors.Is(err, ErrNotHD) {
+		t.Errorf("NextAddress() of a standalone key error = %v, want %v", err, ErrNotHD)
+	}
+
+	// Deleting the wallet removes every derived key.
+	if err = ks.Delete(change); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Delete() of a derived address error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if err = ks.Delete(root); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	for _, address := range group {
+		if _, err = ks.Get(address); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Get(%s) of a deleted wallet error = %v, want %v", address, err, ErrKeyNotFound)
+		}
+	}
+	if _, err = ks.PutSeed(seed, 1, 0); err != nil {
+		t.Errorf("PutSeed() of a deleted seed error = %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package blockchainlogic
+
+import (
+	"errors"
+)
+
+// hdGapLimit is the number of consecutive unused addresses after which a
+// restore stops scanning a chain.
+const hdGapLimit = 20
+
+// CreateHDWallet generates a mnemonic, stores its HD wallet in the keystore
+// and returns the wallet address with the mnemonic. The mnemonic is not
+// stored, it is the only backup of the wallet.
+func (bc *Blockchain) CreateHDWallet() (address, mnemonic string, err error) {
+	mnemonic, err = NewMnemonic()
+	if err != nil {
+		return "", "", err
+	}
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		return "", "", err
+	}
+	address, err = bc.keys.PutSeed(seed, 1, 0)
+	if err != nil {
+		return "", "", err
+	}
+
+	return address, mnemonic, nil
+}
+
+// HDWalletAddress returns the address identifying the HD wallet of mnemonic.
+func HDWalletAddress(mnemonic string) (string, error) {
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		return "", err
+	}
+	root, err := DeriveWallet(seed, ChainReceive, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return string(root.GetAddress()), nil
+}
+
+// RestoreWallet stores the HD wallet of mnemonic in the keystore and returns
+// its address. Receive and change addresses are derived up to the last one
+// seen on chain. It returns the address and ErrKeyExists when the wallet is
+// already stored.
+func (bc *Blockchain) RestoreWallet(mnemonic string) (string, error) {
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		return "", err
+	}
+
+	var used [2]uint32
+	for _, chain := range []uint32{ChainReceive, ChainChange} {
+		used[chain], err = bc.usedAddresses(seed, chain)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return bc.keys.PutSeed(seed, used[ChainReceive], used[ChainChange])
+}
+
+// usedAddresses returns the number of keys of chain to derive to cover every
+// address that appears on chain, scanning until hdGapLimit unused ones.
+func (bc *Blockchain) usedAddresses(seed []byte, chain uint32) (uint32, error) {
+	var used uint32
+	for index, gap := uint32(0), 0; gap < hdGapLimit; index++ {
+		w, err := DeriveWallet(seed, chain, index)
+		if err != nil {
+			return 0, err
+		}
+		txs, err := bc.store.AddressTransactions(AddressTxQuery{PubKeyHashes: [][]byte{HashPubKey(w.PublicKey)}, Limit: 1})
+		if err != nil {
+			return 0, err
+		}
+		if len(txs) > 0 {
+			used = index + 1
+			gap = 0
+		} else {
+			gap++
+		}
+	}
+
+	return used, nil
+}
+
+// WalletAddresses returns every address of the wallet owning address. An
+// address without a key in the keystore is returned alone.
+func (bc *Blockchain) WalletAddresses(address string) ([]string, error) {
+	if !ValidateAddress(address) {
+		return nil, errors.New("address is not valid")
+	}
+	addresses, err := bc.keys.Group(address)
+	if errors.Is(err, ErrKeyNotFound) {
+		return []string{address}, nil
+	}
+
+	return addresses, err
+}
+
+// NextAddress derives the next key of chain of the HD wallet owning address
+// and tells the observers about it.
+func (bc *Blockchain) NextAddress(address string, chain uint32) (string, error) {
+	return bc.nextAddress(bc.keys, address, chain)
+}
+
+func (bc *Blockchain) nextAddress(keys Keystore, address string, chain uint32) (string, error) {
+	derived, err := keys.NextAddress(address, chain)
+	if err != nil {
+		return "", err
+	}
+	for _, o := range bc.chainObservers() {
+		o.AddressDerived(address, derived)
+	}
+
+	return derived, nil
+}
+
+// changeAddress returns the address receiving the change of a spend from
+// address: a fresh change address of its HD wallet in keys, or address
+// itself for standalone keys.
+func (bc *Blockchain) changeAddress(keys Keystore, address string) (string, error) {
+	change, err := bc.nextAddress(keys, address, ChainChange)
+	if errors.Is(err, ErrNotHD) {
+		return address, nil
+	}
+
+	return change, err
+}
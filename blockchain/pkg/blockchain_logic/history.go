@@ -0,0 +1,204 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Direction tells whether a transaction moved funds into or out of a wallet.
+type Direction string
+
+const (
+	DirectionIncoming Direction = "in"
+	DirectionOutgoing Direction = "out"
+)
+
+const historyBatchSize = 100
+
+// HistoryFilter selects entries of a wallet history. Zero values disable the
+// corresponding filter.
+type HistoryFilter struct {
+	// From and To bound the block timestamp, To being exclusive.
+	From time.Time
+	To   time.Time
+	// Direction keeps only incoming or outgoing transactions.
+	Direction Direction
+	// Cursor continues a previous page; entries older than it are returned.
+	Cursor int64
+	Limit  int
+}
+
+// HistoryEntry is a confirmed transaction seen from one wallet.
+type HistoryEntry struct {
+	Cursor    int64
+	TxID      string
+	Direction Direction
+	// Counterparty is the address funds were sent to or received from. It is
+	// empty for coinbase transactions.
+	Counterparty string
+	// Amount is what the counterparty received for outgoing transactions, or
+	// what the wallet received for incoming ones.
+	Amount Amount
+	// Change is what an outgoing transaction paid back to the wallet.
+	Change        Amount
+	BlockHash     string
+	Height        int
+	Timestamp     time.Time
+	Confirmations int
+}
+
+// History returns confirmed transactions of the wallet made of addresses,
+// newest first, and the cursor of the next page, which is 0 when there are no
+// more entries. Transfers between the addresses count as change.
+func (bc *Blockchain) History(addresses []string, filter HistoryFilter) ([]*HistoryEntry, int64, error) {
+	for _, address := range addresses {
+		if !ValidateAddress(address) {
+			return nil, 0, fmt.Errorf("address is not valid")
+		}
+	}
+	hashes := pubKeyHashes(addresses)
+
+	blocks := make(map[string]*BlockInfo)
+	entries := make([]*HistoryEntry, 0, filter.Limit)
+	cursor := filter.Cursor
+
+	for {
+		batch, err := bc.store.AddressTransactions(AddressTxQuery{
+			PubKeyHashes: hashes,
+			From:         filter.From,
+			To:           filter.To,
+			Before:       cursor,
+			Limit:        historyBatchSize,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, r := range batch {
+			cursor = r.Seq
+
+			block, ok := blocks[r.BlockHash]
+			if !ok {
+				block, err = bc.BlockByHash(r.BlockHash)
+				if err != nil {
+					return nil, 0, err
+				}
+				blocks[r.BlockHash] = block
+			}
+			info, err := confirmedTxInfo(block, r.TxID)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			entry := historyEntry(hashes, info)
+			if filter.Direction != "" && entry.Direction != filter.Direction {
+				continue
+			}
+			entry.Cursor = r.Seq
+			entries = append(entries, entry)
+
+			if filter.Limit > 0 && len(entries) == filter.Limit {
+				return entries, cursor, nil
+			}
+		}
+
+		if len(batch) < historyBatchSize {
+			return entries, 0, nil
+		}
+	}
+}
+
+// historyEntry describes tx from the point of view of the wallet owning
+// pubKeyHashes. A transaction is outgoing when the wallet signed any of its
+// inputs.
+func historyEntry(pubKeyHashes [][]byte, info *TxInfo) *HistoryEntry {
+	owned := func(pubKeyHash []byte) bool {
+		for _, h := range pubKeyHashes {
+			if bytes.Equal(h, pubKeyHash) {
+				return true
+			}
+		}
+
+		return false
+	}
+	tx := info.Tx
+	entry := &HistoryEntry{
+		TxID:          fmt.Sprintf("%x", tx.ID),
+		Direction:     DirectionIncoming,
+		BlockHash:     info.BlockHash,
+		Height:        info.Height,
+		Timestamp:     info.Timestamp,
+		Confirmations: info.Confirmations,
+	}
+
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Vin {
+			if owned(in.pubKeyHash()) {
+				entry.Direction = DirectionOutgoing
+
+				break
+			}
+		}
+	}
+
+	var own, other Amount
+	for _, out := range tx.Vout {
+		if owned(out.PubKeyHash) {
+			own += out.Value
+
+			continue
+		}
+		other += out.Value
+		if entry.Direction == DirectionOutgoing && entry.Counterparty == "" {
+			entry.Counterparty = out.Address()
+		}
+	}
+
+	if entry.Direction == DirectionOutgoing {
+		entry.Amount = other
+		entry.Change = own
+		if entry.Counterparty == "" {
+			// Sent to itself.
+			entry.Counterparty = AddressFromPubKeyHash(pubKeyHashes[0])
+		}
+	} else {
+		entry.Amount = own
+		if hash := tx.Vin[0].pubKeyHash(); !tx.IsCoinbase() && hash != nil {
+			entry.Counterparty = AddressFromPubKeyHash(hash)
+		}
+	}
+
+	return entry
+}
+
+// WalletActivity describes tx of info from the point of view of the wallet
+// made of addresses, as History does.
+func WalletActivity(addresses []string, info *TxInfo) *HistoryEntry {
+	return historyEntry(pubKeyHashes(addresses), info)
+}
+
+// Addresses returns the addresses tx spends from and pays to, without
+// duplicates. Coinbase inputs and non-standard outputs have none.
+func (tx *Transaction) Addresses() []string {
+	var addresses []string
+	seen := make(map[string]bool)
+	add := func(address string) {
+		if address != "" && !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Vin {
+			if hash := in.pubKeyHash(); hash != nil {
+				add(AddressFromPubKeyHash(hash))
+			}
+		}
+	}
+	for _, out := range tx.Vout {
+		add(out.Address())
+	}
+
+	return addresses
+}
@@ -0,0 +1,64 @@
+package blockchainlogic
+
+import (
+	"testing"
+)
+
+func TestHistoryEntry(t *testing.T) {
+	alice, bob := NewWallet(), NewWallet()
+	aliceHash := HashPubKey(alice.PublicKey)
+	bobHash := HashPubKey(bob.PublicKey)
+	aliceAddr, bobAddr := string(alice.GetAddress()), string(bob.GetAddress())
+
+	coinbase := NewCoinbaseTX(aliceAddr, "")
+	spend := &Transaction{
+		Vin: []TXInput{{Txid: coinbase.ID, Vout: 0, PubKey: alice.PublicKey}},
+		Vout: []TXOutput{
+			*NewTXOutput(3, bobAddr),
+			*NewTXOutput(7, aliceAddr),
+		},
+	}
+	spend.ID = spend.Hash()
+
+	// An HD wallet spend paying its change to another address of the wallet.
+	aliceChange := NewWallet()
+	hdSpend := &Transaction{
+		Vin: []TXInput{{Txid: coinbase.ID, Vout: 0, PubKey: alice.PublicKey}},
+		Vout: []TXOutput{
+			*NewTXOutput(3, bobAddr),
+			*NewTXOutput(7, string(aliceChange.GetAddress())),
+		},
+	}
+	hdSpend.ID = hdSpend.Hash()
+	aliceWallet := [][]byte{aliceHash, HashPubKey(aliceChange.PublicKey)}
+
+	tests := []struct {
+		name         string
+		tx           *Transaction
+		pubKeyHashes [][]byte
+		direction    Direction
+		counterparty string
+		amount       Amount
+		change       Amount
+	}{
+		{name: "coinbase", tx: coinbase, pubKeyHashes: [][]byte{aliceHash}, direction: DirectionIncoming, amount: genesisReward()},
+		{name: "sender", tx: spend, pubKeyHashes: [][]byte{aliceHash}, direction: DirectionOutgoing, counterparty: bobAddr, amount: 3, change: 7},
+		{name: "recipient", tx: spend, pubKeyHashes: [][]byte{bobHash}, direction: DirectionIncoming, counterparty: aliceAddr, amount: 3},
+		{name: "change to another address", tx: hdSpend, pubKeyHashes: aliceWallet, direction: DirectionOutgoing, counterparty: bobAddr, amount: 3, change: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := historyEntry(tt.pubKeyHashes, &TxInfo{Tx: tt.tx, Status: TxStatusConfirmed})
+
+			if entry.Direction != tt.direction {
+				t.Errorf("Direction = %q, want %q", entry.Direction, tt.direction)
+			}
+			if entry.Counterparty != tt.counterparty {
+				t.Errorf("Counterparty = %q, want %q", entry.Counterparty, tt.counterparty)
+			}
+			if entry.Amount != tt.amount || entry.Change != tt.change {
+				t.Errorf("Amount, Change = %v, %v, want %v, %v", entry.Amount, entry.Change, tt.amount, tt.change)
+			}
+		})
+	}
+}
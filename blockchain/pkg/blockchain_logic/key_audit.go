@@ -0,0 +1,36 @@
+package blockchainlogic
+
+import "database/sql"
+
+// Actions and sources of key events.
+const (
+	KeyActionExport = "export"
+	KeyActionImport = "import"
+	KeySourceAPI    = "api"
+	KeySourceCLI    = "cli"
+)
+
+// KeyEvent is an attempt to export a private key from the keystore or to
+// import one into it. Failed attempts are recorded too.
+type KeyEvent struct {
+	// UserID is the user the key was exported for or bound to, empty for
+	// operators using the CLI without a user.
+	UserID  string
+	Address string
+	Action  string
+	Format  string
+	Source  string
+	Err     error
+}
+
+// RecordKeyEvent appends e to the wallet_key_events audit table.
+func RecordKeyEvent(db *sql.DB, e KeyEvent) error {
+	var message string
+	if e.Err != nil {
+		message = e.Err.Error()
+	}
+	_, err := db.Exec("INSERT INTO wallet_key_events (user_id, address, action, format, source, success, error) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		e.UserID, e.Address, e.Action, e.Format, e.Source, e.Err == nil, message)
+
+	return err
+}
@@ -0,0 +1,439 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Formats of exported keys.
+const (
+	// KeyFormatPEM is a PKCS #8 EncryptedPrivateKeyInfo, PBES2 with PBKDF2
+	// and AES-256-CBC, as written by openssl pkcs8 -topk8 -v2 aes-256-cbc.
+	KeyFormatPEM = "pem"
+	// KeyFormatJSON is a keyfile holding the PKCS #8 key encrypted with
+	// AES-256-GCM under a scrypt derived key.
+	KeyFormatJSON = "json"
+)
+
+// MinPassphraseLen is the shortest passphrase keys are exported with.
+const MinPassphraseLen = 8
+
+const (
+	pemEncryptedKey  = "ENCRYPTED PRIVATE KEY"
+	pbkdf2Iterations = 600000
+	keyfileVersion   = 1
+	scryptN          = 1 << 17
+	scryptR          = 8
+	scryptP          = 1
+	kdfKeyLen        = 32
+	kdfSaltLen       = 16
+)
+
+var (
+	ErrWrongPassphrase = errors.New("passphrase is wrong or the key is corrupted")
+	ErrKeyFormat       = errors.New("key is not an encrypted PEM key nor a JSON keyfile")
+	ErrShortPassphrase = fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLen)
+)
+
+var (
+	oidPBES2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1  = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256    = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	aesCBCKeyLengths = map[string]int{
+		oidAES128CBC.String(): 16,
+		oidAES192CBC.String(): 24,
+		oidAES256CBC.String(): 32,
+	}
+)
+
+// encryptedPrivateKeyInfo and the PBES2 structures follow RFC 5958 and
+// RFC 8018.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// keyfile is the JSON keyfile format.
+type keyfile struct {
+	Version int           `json:"version"`
+	Address string        `json:"address"`
+	KeyType string        `json:"key_type"`
+	Crypto  keyfileCrypto `json:"crypto"`
+}
+
+type keyfileCrypto struct {
+	Cipher     string           `json:"cipher"`
+	Ciphertext string           `json:"ciphertext"`
+	Nonce      string           `json:"nonce"`
+	KDF        string           `json:"kdf"`
+	KDFParams  keyfileKDFParams `json:"kdfparams"`
+}
+
+type keyfileKDFParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// ExportKey encrypts the private key of w with passphrase in format.
+func ExportKey(w *Wallet, format, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLen {
+		return nil, ErrShortPassphrase
+	}
+	der, err := privateKeyBytes(w)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case KeyFormatPEM:
+		return exportPEM(der, passphrase)
+	case KeyFormatJSON:
+		return exportKeyfile(w, der, passphrase)
+	default:
+		return nil, fmt.Errorf("unknown key format %q", format)
+	}
+}
+
+// DetectKeyFormat returns the format of an exported key.
+func DetectKeyFormat(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("-----BEGIN")):
+		return KeyFormatPEM, nil
+	case bytes.HasPrefix(data, []byte("{")):
+		return KeyFormatJSON, nil
+	default:
+		return "", ErrKeyFormat
+	}
+}
+
+// ImportKey decrypts a key exported with ExportKey, or any PBES2 encrypted
+// PKCS #8 key using AES-CBC, and returns its wallet.
+func ImportKey(data []byte, passphrase string) (*Wallet, error) {
+	format, err := DetectKeyFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	if format == KeyFormatPEM {
+		der, err := importPEM(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		return walletFromPKCS8(der)
+	}
+
+	der, address, err := importKeyfile(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := walletFromPKCS8(der)
+	if err != nil {
+		return nil, err
+	}
+	if address != string(wallet.GetAddress()) {
+		return nil, errors.New("keyfile address does not match its key")
+	}
+
+	return wallet, nil
+}
+
+func exportPEM(der []byte, passphrase string) ([]byte, error) {
+	salt, iv := make([]byte, kdfSaltLen), make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, kdfKeyLen, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padded := pkcs7Pad(der, aes.BlockSize)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	info, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemEncryptedKey, Bytes: info}), nil
+}
+
+func importPEM(data []byte, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemEncryptedKey {
+		return nil, ErrKeyFormat
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, fmt.Errorf("parse encrypted key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported key encryption %v", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("parse encryption parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation %v", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("parse key derivation parameters: %w", err)
+	}
+	keyLen, ok := aesCBCKeyLengths[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cipher %v", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid cipher IV")
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported key derivation hash %v", kdf.PRF.Algorithm)
+	}
+	if kdf.IterationCount < 1 || kdf.IterationCount > 10*pbkdf2Iterations {
+		return nil, fmt.Errorf("unsupported iteration count %d", kdf.IterationCount)
+	}
+
+	key := pbkdf2.Key([]byte(passphrase), kdf.Salt, kdf.IterationCount, keyLen, prf)
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrWrongPassphrase
+	}
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plain, info.EncryptedData)
+
+	return pkcs7Unpad(plain, aes.BlockSize)
+}
+
+func exportKeyfile(w *Wallet, der []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, kdfSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, kdfKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	address := string(w.GetAddress())
+
+	return json.MarshalIndent(keyfile{
+		Version: keyfileVersion,
+		Address: address,
+		KeyType: w.KeyType.String(),
+		Crypto: keyfileCrypto{
+			Cipher:     "aes-256-gcm",
+			Ciphertext: hex.EncodeToString(aead.Seal(nil, nonce, der, []byte(address))),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        "scrypt",
+			KDFParams: keyfileKDFParams{
+				N:     scryptN,
+				R:     scryptR,
+				P:     scryptP,
+				DKLen: kdfKeyLen,
+				Salt:  hex.EncodeToString(salt),
+			},
+		},
+	}, "", "  ")
+}
+
+// importKeyfile decrypts a JSON keyfile and returns the PKCS #8 key and the
+// address it claims.
+func importKeyfile(data []byte, passphrase string) ([]byte, string, error) {
+	var kf keyfile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, "", fmt.Errorf("parse keyfile: %w", err)
+	}
+	if kf.Version != keyfileVersion {
+		return nil, "", fmt.Errorf("unsupported keyfile version %d", kf.Version)
+	}
+	c, p := kf.Crypto, kf.Crypto.KDFParams
+	if c.Cipher != "aes-256-gcm" || c.KDF != "scrypt" || p.DKLen != kdfKeyLen {
+		return nil, "", fmt.Errorf("unsupported keyfile encryption %s with %s", c.Cipher, c.KDF)
+	}
+	// Bound the work and memory an imported keyfile can ask for.
+	if p.N < 2 || p.N > 1<<20 || p.R < 1 || p.R > 32 || p.P < 1 || p.P > 16 {
+		return nil, "", errors.New("unsupported keyfile scrypt parameters")
+	}
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, "", fmt.Errorf("keyfile salt: %w", err)
+	}
+	nonce, err := hex.DecodeString(c.Nonce)
+	if err != nil {
+		return nil, "", fmt.Errorf("keyfile nonce: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(c.Ciphertext)
+	if err != nil {
+		return nil, "", fmt.Errorf("keyfile ciphertext: %w", err)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, p.DKLen)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, "", errors.New("invalid keyfile nonce")
+	}
+	der, err := aead.Open(nil, nonce, ciphertext, []byte(kf.Address))
+	if err != nil {
+		return nil, "", ErrWrongPassphrase
+	}
+
+	return der, kf.Address, nil
+}
+
+// walletFromPKCS8 builds the wallet of a PKCS #8 encoded private key.
+func walletFromPKCS8(der []byte) (*Wallet, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	wallet := &Wallet{SigningKey: der}
+	var public any
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA keys are supported")
+		}
+		wallet.KeyType, public = KeyTypeECDSA, &k.PublicKey
+	case ed25519.PrivateKey:
+		wallet.KeyType, public = KeyTypeEd25519, k.Public()
+	case *rsa.PrivateKey:
+		wallet.KeyType, public = KeyTypeRSA, &k.PublicKey
+		wallet.PrivateKey, wallet.SigningKey = k, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	wallet.PublicKey, err = x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrWrongPassphrase
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, ErrWrongPassphrase
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, ErrWrongPassphrase
+		}
+	}
+
+	return data[:len(data)-n], nil
+}
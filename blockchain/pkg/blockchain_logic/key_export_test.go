@@ -0,0 +1,94 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const testPassphrase = "correct horse battery"
+
+func TestExportKey_RoundTrip(t *testing.T) {
+	for _, format := range []string{KeyFormatPEM, KeyFormatJSON} {
+		for _, keyType := range []KeyType{KeyTypeRSA, KeyTypeECDSA, KeyTypeEd25519} {
+			t.Run(format+"/"+keyType.String(), func(t *testing.T) {
+				wallet := NewWalletWithKeyType(keyType)
+
+				data, err := ExportKey(wallet, format, testPassphrase)
+				if err != nil {
+					t.Fatalf("ExportKey() error = %v", err)
+				}
+				if len(wallet.SigningKey) > 0 && bytes.Contains(data, wallet.SigningKey) {
+					t.Fatalf("ExportKey() output holds the plain private key")
+				}
+
+				got, err := ImportKey(data, testPassphrase)
+				if err != nil {
+					t.Fatalf("ImportKey() error = %v", err)
+				}
+				if got.KeyType != keyType {
+					t.Errorf("ImportKey() key type = %v, want %v", got.KeyType, keyType)
+				}
+				if !bytes.Equal(got.GetAddress(), wallet.GetAddress()) {
+					t.Errorf("ImportKey() address = %s, want %s", got.GetAddress(), wallet.GetAddress())
+				}
+				sig, err := got.Sign([]byte("payload"))
+				if err != nil {
+					t.Fatalf("Sign() error = %v", err)
+				}
+				if !VerifySignature(wallet.PublicKey, []byte("payload"), sig) {
+					t.Errorf("imported key does not match the public key")
+				}
+			})
+		}
+	}
+}
+
+func TestImportKey_WrongPassphrase(t *testing.T) {
+	wallet := NewWallet()
+	for _, format := range []string{KeyFormatPEM, KeyFormatJSON} {
+		data, err := ExportKey(wallet, format, testPassphrase)
+		if err != nil {
+			t.Fatalf("ExportKey(%s) error = %v", format, err)
+		}
+		if _, err = ImportKey(data, "wrong passphrase"); !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("ImportKey(%s) error = %v, want %v", format, err, ErrWrongPassphrase)
+		}
+	}
+}
+
+func TestImportKey_AddressMismatch(t *testing.T) {
+	data, err := ExportKey(NewWallet(), KeyFormatJSON, testPassphrase)
+	if err != nil {
+		t.Fatalf("ExportKey() error = %v", err)
+	}
+	var kf keyfile
+	if err = json.Unmarshal(data, &kf); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	kf.Address = string(NewWallet().GetAddress())
+	tampered, err := json.Marshal(kf)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	// The address is authenticated by the cipher, so a swapped one fails
+	// decryption before the key is compared with it.
+	if _, err = ImportKey(tampered, testPassphrase); err == nil {
+		t.Errorf("ImportKey() with a swapped address error = nil, want an error")
+	}
+}
+
+func TestExportKey_Invalid(t *testing.T) {
+	wallet := NewWallet()
+	if _, err := ExportKey(wallet, KeyFormatPEM, "short"); !errors.Is(err, ErrShortPassphrase) {
+		t.Errorf("ExportKey() short passphrase error = %v, want %v", err, ErrShortPassphrase)
+	}
+	if _, err := ExportKey(wallet, "der", testPassphrase); err == nil {
+		t.Errorf("ExportKey() unknown format error = nil, want an error")
+	}
+	if _, err := ImportKey([]byte("not a key"), testPassphrase); !errors.Is(err, ErrKeyFormat) {
+		t.Errorf("ImportKey() error = %v, want %v", err, ErrKeyFormat)
+	}
+}
@@ -0,0 +1,164 @@
+package blockchainlogic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrKeyNotFound = errors.New("wallet key is not found")
+	ErrKeyExists   = errors.New("wallet key already exists")
+)
+
+// Keystore stores the keys of the wallets managed by this node.
+type Keystore interface {
+	// Put stores w under its address. It returns ErrKeyExists when the
+	// address is already stored.
+	Put(w *Wallet) error
+	// Get returns the wallet of address, or ErrKeyNotFound.
+	Get(address string) (*Wallet, error)
+	// Delete undoes Put or PutSeed: it removes the key of address, or the HD
+	// wallet identified by address with every key derived from it. It
+	// returns ErrKeyNotFound for other addresses, derived ones included.
+	Delete(address string) error
+	// Addresses returns the addresses of the stored wallets.
+	Addresses() ([]string, error)
+	// PutSeed stores seed as an HD wallet, derives its first receive and
+	// change keys and returns the address of receive key 0, which identifies
+	// the wallet. It returns that address and ErrKeyExists when the seed is
+	// already stored.
+	PutSeed(seed []byte, receive, change uint32) (string, error)
+	// NextAddress derives and stores the next key of chain of the HD wallet
+	// owning address. It returns ErrNotHD for standalone keys.
+	NextAddress(address string, chain uint32) (string, error)
+	// Group returns the addresses of the wallet owning address: every
+	// derived address of an HD wallet, or address alone for standalone keys.
+	Group(address string) ([]string, error)
+}
+
+// CreateWallet generates a wallet, stores it in ks and returns its address.
+func CreateWallet(ks Keystore) (string, error) {
+	wallet := NewWallet()
+	err := ks.Put(wallet)
+	if err != nil {
+		return "", err
+	}
+
+	return string(wallet.GetAddress()), nil
+}
+
+// MasterKeySize is the size of the AES-256 key encrypting stored private keys.
+const MasterKeySize = 32
+
+// ParseMasterKey decodes a hex encoded keystore master key.
+func ParseMasterKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d hex encoded bytes", MasterKeySize)
+	}
+
+	return key, nil
+}
+
+// keyCipher encrypts private keys with AES-GCM. The wallet address is the
+// additional data, so a ciphertext can not be moved to another address.
+type keyCipher struct {
+	aead cipher.AEAD
+}
+
+func newKeyCipher(masterKey []byte) (*keyCipher, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes", MasterKeySize)
+	}
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyCipher{aead}, nil
+}
+
+// seal returns the nonce followed by the encrypted PKCS #8 private key of w.
+func (c *keyCipher) seal(address string, w *Wallet) ([]byte, error) {
+	plain, err := privateKeyBytes(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sealBytes(address, plain)
+}
+
+// privateKeyBytes returns the PKCS #8 encoded private key of w.
+func privateKeyBytes(w *Wallet) ([]byte, error) {
+	if w.KeyType != KeyTypeRSA {
+		return w.SigningKey, nil
+	}
+	if w.PrivateKey == nil {
+		return nil, errors.New("wallet has no private key")
+	}
+
+	return x509.MarshalPKCS8PrivateKey(w.PrivateKey)
+}
+
+// sealBytes encrypts plain bound to label and prepends the nonce.
+func (c *keyCipher) sealBytes(label string, plain []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, plain, []byte(label)), nil
+}
+
+// openBytes decrypts data sealed with sealBytes under label.
+func (c *keyCipher) openBytes(label string, sealed []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, []byte(label))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", label, err)
+	}
+
+	return plain, nil
+}
+
+// open decrypts a private key sealed for address and rebuilds its wallet.
+func (c *keyCipher) open(address string, keyType KeyType, publicKey, sealed []byte) (*Wallet, error) {
+	plain, err := c.openBytes(address, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := &Wallet{KeyType: keyType, PublicKey: publicKey}
+	if keyType != KeyTypeRSA {
+		wallet.SigningKey = plain
+
+		return wallet, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key of %s is not an RSA key", address)
+	}
+	wallet.PrivateKey = rsaKey
+
+	return wallet, nil
+}
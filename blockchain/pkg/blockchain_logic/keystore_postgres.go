@@ -0,0 +1,292 @@
+package blockchainlogic
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// PostgresKeystore stores wallet keys in the wallet_keys table. Private keys
+// are encrypted with AES-GCM under the master key. Every wallet is a single
+// row, so concurrent inserts do not interfere.
+type PostgresKeystore struct {
+	db     *sql.DB
+	cipher *keyCipher
+}
+
+// NewPostgresKeystore returns a keystore in db encrypting keys with the
+// 32 byte masterKey.
+func NewPostgresKeystore(db *sql.DB, masterKey []byte) (*PostgresKeystore, error) {
+	c, err := newKeyCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresKeystore{db, c}, nil
+}
+
+// Put stores w under its address.
+func (ks *PostgresKeystore) Put(w *Wallet) error {
+	return ks.insertKey(ks.db, w, sql.NullInt64{}, 0, 0)
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertKey stores w, as key index of chain of HD wallet hdWalletID when it
+// is valid.
+func (ks *PostgresKeystore) insertKey(db execer, w *Wallet, hdWalletID sql.NullInt64, chain, index uint32) error {
+	address := string(w.GetAddress())
+	sealed, err := ks.cipher.seal(address, w)
+	if err != nil {
+		return err
+	}
+
+	var chainArg, indexArg sql.NullInt64
+	if hdWalletID.Valid {
+		chainArg = sql.NullInt64{Int64: int64(chain), Valid: true}
+		indexArg = sql.NullInt64{Int64: int64(index), Valid: true}
+	}
+	res, err := db.Exec(`INSERT INTO wallet_keys (address, key_type, public_key, encrypted_key, hd_wallet_id, chain, idx)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (address) DO NOTHING`,
+		address, int(w.KeyType), w.PublicKey, sealed, hdWalletID, chainArg, indexArg)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyExists
+	}
+
+	return nil
+}
+
+// Get returns the wallet of address with its decrypted private key.
+func (ks *PostgresKeystore) Get(address string) (*Wallet, error) {
+	var keyType int
+	var publicKey, sealed []byte
+	err := ks.db.QueryRow("SELECT key_type, public_key, encrypted_key FROM wallet_keys WHERE address = $1", address).
+		Scan(&keyType, &publicKey, &sealed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ks.cipher.open(address, KeyType(keyType), publicKey, sealed)
+}
+
+// Delete removes the standalone key of address, or the HD wallet whose root
+// address it is with its keys, in one database transaction.
+func (ks *PostgresKeystore) Delete(address string) error {
+	dbTx, err := ks.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	res, err := dbTx.Exec("DELETE FROM wallet_keys WHERE hd_wallet_id = (SELECT id FROM hd_wallets WHERE root_address = $1)", address)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		res, err = dbTx.Exec("DELETE FROM hd_wallets WHERE root_address = $1", address)
+	} else {
+		res, err = dbTx.Exec("DELETE FROM wallet_keys WHERE address = $1 AND hd_wallet_id IS NULL", address)
+	}
+	if err != nil {
+		return err
+	}
+	n, err = res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyNotFound
+	}
+
+	return dbTx.Commit()
+}
+
+// Addresses returns the addresses of the stored wallets.
+func (ks *PostgresKeystore) Addresses() ([]string, error) {
+	rows, err := ks.db.Query("SELECT address FROM wallet_keys ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []string
+	for rows.Next() {
+		var address string
+		err = rows.Scan(&address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+// nextIndexColumns are the hd_wallets columns holding the next key index of
+// each chain.
+var nextIndexColumns = map[uint32]string{
+	ChainReceive: "next_receive",
+	ChainChange:  "next_change",
+}
+
+// PutSeed stores seed encrypted in hd_wallets and derives its first keys in
+// one database transaction.
+func (ks *PostgresKeystore) PutSeed(seed []byte, receive, change uint32) (string, error) {
+	root, err := DeriveWallet(seed, ChainReceive, 0)
+	if err != nil {
+		return "", err
+	}
+	rootAddress := string(root.GetAddress())
+	sealedSeed, err := ks.cipher.sealBytes(seedLabel(rootAddress), seed)
+	if err != nil {
+		return "", err
+	}
+	counts := [2]uint32{max(receive, 1), change}
+
+	dbTx, err := ks.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	var id int64
+	err = dbTx.QueryRow(`INSERT INTO hd_wallets (root_address, encrypted_seed, next_receive, next_change)
+		VALUES ($1, $2, $3, $4) ON CONFLICT (root_address) DO NOTHING RETURNING id`,
+		rootAddress, sealedSeed, counts[ChainReceive], counts[ChainChange]).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rootAddress, ErrKeyExists
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for chain, count := range counts {
+		for i := uint32(0); i < count; i++ {
+			w, err := DeriveWallet(seed, uint32(chain), i)
+			if err != nil {
+				return "", err
+			}
+			err = ks.insertKey(dbTx, w, sql.NullInt64{Int64: id, Valid: true}, uint32(chain), i)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return rootAddress, dbTx.Commit()
+}
+
+// NextAddress derives the next key of chain of the HD wallet owning address.
+// The hd_wallets row is locked, so concurrent calls get distinct keys.
+func (ks *PostgresKeystore) NextAddress(address string, chain uint32) (string, error) {
+	column, ok := nextIndexColumns[chain]
+	if !ok {
+		return "", fmt.Errorf("unknown chain %d", chain)
+	}
+	hdWalletID, err := ks.hdWalletID(address)
+	if err != nil {
+		return "", err
+	}
+
+	dbTx, err := ks.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	var rootAddress string
+	var sealedSeed []byte
+	var index uint32
+	err = dbTx.QueryRow("SELECT root_address, encrypted_seed, "+column+" FROM hd_wallets WHERE id = $1 FOR UPDATE", hdWalletID.Int64).
+		Scan(&rootAddress, &sealedSeed, &index)
+	if err != nil {
+		return "", err
+	}
+	seed, err := ks.cipher.openBytes(seedLabel(rootAddress), sealedSeed)
+	if err != nil {
+		return "", err
+	}
+
+	w, err := DeriveWallet(seed, chain, index)
+	if err != nil {
+		return "", err
+	}
+	err = ks.insertKey(dbTx, w, hdWalletID, chain, index)
+	if err != nil {
+		return "", err
+	}
+	_, err = dbTx.Exec("UPDATE hd_wallets SET "+column+" = "+column+" + 1 WHERE id = $1", hdWalletID.Int64)
+	if err != nil {
+		return "", err
+	}
+
+	return string(w.GetAddress()), dbTx.Commit()
+}
+
+// Group returns the addresses of the wallet owning address.
+func (ks *PostgresKeystore) Group(address string) ([]string, error) {
+	hdWalletID, err := ks.hdWalletID(address)
+	if errors.Is(err, ErrNotHD) {
+		return []string{address}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ks.db.Query("SELECT address FROM wallet_keys WHERE hd_wallet_id = $1 ORDER BY chain, idx", hdWalletID.Int64)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []string
+	for rows.Next() {
+		var a string
+		err = rows.Scan(&a)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+
+	return addresses, rows.Err()
+}
+
+// hdWalletID returns the HD wallet of address, ErrNotHD for standalone keys
+// or ErrKeyNotFound.
+func (ks *PostgresKeystore) hdWalletID(address string) (sql.NullInt64, error) {
+	var id sql.NullInt64
+	err := ks.db.QueryRow("SELECT hd_wallet_id FROM wallet_keys WHERE address = $1", address).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return id, ErrKeyNotFound
+	}
+	if err != nil {
+		return id, err
+	}
+	if !id.Valid {
+		return id, ErrNotHD
+	}
+
+	return id, nil
+}
+
+// seedLabel binds an encrypted seed to its HD wallet.
+func seedLabel(rootAddress string) string {
+	return "seed:" + rootAddress
+}
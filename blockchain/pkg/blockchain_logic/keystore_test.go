@@ -0,0 +1,123 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testKeyCipher(t *testing.T) *keyCipher {
+	t.Helper()
+
+	c, err := newKeyCipher(bytes.Repeat([]byte{7}, MasterKeySize))
+	if err != nil {
+		t.Fatalf("newKeyCipher() error = %v", err)
+	}
+
+	return c
+}
+
+func TestKeyCipher_RoundTrip(t *testing.T) {
+	c := testKeyCipher(t)
+
+	for _, keyType := range []KeyType{KeyTypeRSA, KeyTypeECDSA, KeyTypeEd25519} {
+		t.Run(keyType.String(), func(t *testing.T) {
+			wallet := NewWalletWithKeyType(keyType)
+			address := string(wallet.GetAddress())
+
+			sealed, err := c.seal(address, wallet)
+			if err != nil {
+				t.Fatalf("seal() error = %v", err)
+			}
+			if len(wallet.SigningKey) > 0 && bytes.Contains(sealed, wallet.SigningKey) {
+				t.Fatalf("seal() output holds the plain private key")
+			}
+
+			got, err := c.open(address, keyType, wallet.PublicKey, sealed)
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+			sig, err := got.Sign([]byte("payload"))
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if !VerifySignature(wallet.PublicKey, []byte("payload"), sig) {
+				t.Errorf("decrypted key does not match the public key")
+			}
+		})
+	}
+}
+
+func TestKeyCipher_Tampered(t *testing.T) {
+	c := testKeyCipher(t)
+	wallet := NewWallet()
+	address := string(wallet.GetAddress())
+	sealed, err := c.seal(address, wallet)
+	if err != nil {
+		t.Fatalf("seal() error = %v", err)
+	}
+
+	other := string(NewWallet().GetAddress())
+	if _, err = c.open(other, wallet.KeyType, wallet.PublicKey, sealed); err == nil {
+		t.Errorf("open() under another address error = nil, want error")
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err = c.open(address, wallet.KeyType, wallet.PublicKey, sealed); err == nil {
+		t.Errorf("open() of a modified ciphertext error = nil, want error")
+	}
+
+	wrongKey, _ := newKeyCipher(bytes.Repeat([]byte{8}, MasterKeySize))
+	sealed[len(sealed)-1] ^= 1
+	if _, err = wrongKey.open(address, wallet.KeyType, wallet.PublicKey, sealed); err == nil {
+		t.Errorf("open() under another master key error = nil, want error")
+	}
+}
+
+func TestParseMasterKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "Valid", key: "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"},
+		{name: "Too short", key: "0001", wantErr: true},
+		{name: "Not hex", key: "zz0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMasterKey(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("ParseMasterKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWallets_Keystore(t *testing.T) {
+	ks := NewWallets()
+
+	address, err := CreateWallet(ks)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	wallet, err := ks.Get(address)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if err = ks.Put(wallet); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Put() of a stored wallet error = %v, want %v", err, ErrKeyExists)
+	}
+	if _, err = ks.Get(string(NewWallet().GetAddress())); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() of an unknown address error = %v, want %v", err, ErrKeyNotFound)
+	}
+
+	if err = ks.Delete(address); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = ks.Get(address); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() of a deleted address error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if err = ks.Delete(address); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Delete() of a deleted address error = %v, want %v", err, ErrKeyNotFound)
+	}
+}
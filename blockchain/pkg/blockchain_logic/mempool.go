@@ -0,0 +1,239 @@
+package blockchainlogic
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// TxStatus is the state of a transaction as seen by this node.
+type TxStatus string
+
+const (
+	TxStatusPending   TxStatus = "pending"
+	TxStatusConfirmed TxStatus = "confirmed"
+)
+
+var (
+	// ErrDoubleSpend is returned when a transaction spends an output that a
+	// pending transaction already spends.
+	ErrDoubleSpend = errors.New("output is already spent by a pending transaction")
+	// ErrTxExists is returned when the transaction is already in the mempool.
+	ErrTxExists = errors.New("transaction is already pending")
+)
+
+// Mempool holds signed transactions waiting to be mined.
+type Mempool struct {
+	mu    sync.Mutex
+	txs   map[string]*Transaction
+	order []string
+	// spent maps an outpoint to the pending transaction spending it.
+	spent map[string]string
+	ready chan struct{}
+}
+
+// NewMempool creates an empty Mempool.
+func NewMempool() *Mempool {
+	return &Mempool{
+		txs:   make(map[string]*Transaction),
+		spent: make(map[string]string),
+		ready: make(chan struct{}, 1),
+	}
+}
+
+func outpoint(txID []byte, vout int) string {
+	return fmt.Sprintf("%x:%d", txID, vout)
+}
+
+// Add queues a transaction. It rejects transactions spending outputs that are
+// already spent by another pending transaction.
+func (mp *Mempool) Add(tx *Transaction) error {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	txID := hex.EncodeToString(tx.ID)
+	if _, ok := mp.txs[txID]; ok {
+		return ErrTxExists
+	}
+
+	for _, in := range tx.Vin {
+		if _, ok := mp.spent[outpoint(in.Txid, in.Vout)]; ok {
+			return ErrDoubleSpend
+		}
+	}
+
+	for _, in := range tx.Vin {
+		mp.spent[outpoint(in.Txid, in.Vout)] = txID
+	}
+	mp.txs[txID] = tx
+	mp.order = append(mp.order, txID)
+
+	select {
+	case mp.ready <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Get returns a pending transaction by its hex encoded ID.
+func (mp *Mempool) Get(txID string) (*Transaction, bool) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	tx, ok := mp.txs[txID]
+
+	return tx, ok
+}
+
+// IsSpent reports whether a pending transaction spends the output.
+func (mp *Mempool) IsSpent(txID []byte, vout int) bool {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	_, ok := mp.spent[outpoint(txID, vout)]
+
+	return ok
+}
+
+// Len returns the number of pending transactions.
+func (mp *Mempool) Len() int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return len(mp.order)
+}
+
+// Batch returns up to n of the oldest pending transactions.
+func (mp *Mempool) Batch(n int) []*Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if n <= 0 || n > len(mp.order) {
+		n = len(mp.order)
+	}
+	batch := make([]*Transaction, 0, n)
+	for _, txID := range mp.order[:n] {
+		batch = append(batch, mp.txs[txID])
+	}
+
+	return batch
+}
+
+// Remove drops transactions from the mempool and releases the outputs they spend.
+func (mp *Mempool) Remove(txs []*Transaction) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	removed := make(map[string]bool, len(txs))
+	for _, tx := range txs {
+		txID := hex.EncodeToString(tx.ID)
+		if _, ok := mp.txs[txID]; !ok {
+			continue
+		}
+		for _, in := range tx.Vin {
+			delete(mp.spent, outpoint(in.Txid, in.Vout))
+		}
+		delete(mp.txs, txID)
+		removed[txID] = true
+	}
+
+	order := mp.order[:0]
+	for _, txID := range mp.order {
+		if !removed[txID] {
+			order = append(order, txID)
+		}
+	}
+	mp.order = order
+}
+
+// Clear drops every pending transaction.
+func (mp *Mempool) Clear() {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	mp.txs = make(map[string]*Transaction)
+	mp.spent = make(map[string]string)
+	mp.order = nil
+}
+
+// RunMiner packages pending transactions into blocks until ctx is done. A
+// block is mined as soon as maxTxs transactions are pending, or every
+// interval when there is at least one.
+func (bc *Blockchain) RunMiner(ctx context.Context, maxTxs int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			bc.mineMempool(ctx, maxTxs)
+		case <-bc.mempool.ready:
+			if bc.mempool.Len() >= maxTxs {
+				bc.mineMempool(ctx, maxTxs)
+			}
+		}
+	}
+}
+
+func (bc *Blockchain) mineMempool(ctx context.Context, maxTxs int) {
+	for bc.mempool.Len() > 0 {
+		valid, err := bc.pendingBatch(maxTxs)
+		if err != nil {
+			log.Printf("mempool: check inputs: %v", err)
+
+			return
+		}
+		if len(valid) == 0 {
+			continue
+		}
+
+		err = bc.MineBlock(ctx, valid)
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if err != nil {
+			log.Printf("mempool: mine block: %v", err)
+
+			return
+		}
+		bc.mempool.Remove(valid)
+
+		if bc.mempool.Len() < maxTxs {
+			return
+		}
+	}
+}
+
+// pendingBatch returns the valid transactions among up to maxTxs of the
+// oldest pending ones, all of them for a non-positive maxTxs. The invalid
+// ones leave the mempool.
+func (bc *Blockchain) pendingBatch(maxTxs int) ([]*Transaction, error) {
+	batch := bc.mempool.Batch(maxTxs)
+
+	valid := make([]*Transaction, 0, len(batch))
+	var invalid []*Transaction
+	for _, tx := range batch {
+		unspent, err := bc.inputsUnspent(tx)
+		if err != nil {
+			return nil, err
+		}
+		if unspent && bc.VerifyTransaction(tx) {
+			valid = append(valid, tx)
+		} else {
+			invalid = append(invalid, tx)
+		}
+	}
+	if len(invalid) > 0 {
+		log.Printf("mempool: dropping %d transactions that failed verification", len(invalid))
+		bc.mempool.Remove(invalid)
+	}
+
+	return valid, nil
+}
@@ -0,0 +1,72 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func newPendingTx(prevID []byte, vout int) *Transaction {
+	tx := &Transaction{
+		Vin:  []TXInput{{Txid: prevID, Vout: vout}},
+		Vout: []TXOutput{{Value: 1}},
+	}
+	tx.ID = tx.Hash()
+
+	return tx
+}
+
+func TestMempool_Add(t *testing.T) {
+	mp := NewMempool()
+	prevID := []byte{0x01}
+
+	first := newPendingTx(prevID, 0)
+	if err := mp.Add(first); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := mp.Add(first); !errors.Is(err, ErrTxExists) {
+		t.Errorf("Add() of the same transaction error = %v, want %v", err, ErrTxExists)
+	}
+
+	doubleSpend := newPendingTx(prevID, 0)
+	doubleSpend.Vout[0].Value = 2
+	doubleSpend.ID = doubleSpend.Hash()
+	if err := mp.Add(doubleSpend); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("Add() of a double spend error = %v, want %v", err, ErrDoubleSpend)
+	}
+
+	if err := mp.Add(newPendingTx(prevID, 1)); err != nil {
+		t.Errorf("Add() of another output error = %v", err)
+	}
+	if mp.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", mp.Len())
+	}
+}
+
+func TestMempool_BatchRemove(t *testing.T) {
+	mp := NewMempool()
+	var txs []*Transaction
+	for i := 0; i < 3; i++ {
+		tx := newPendingTx([]byte{byte(i)}, 0)
+		txs = append(txs, tx)
+		if err := mp.Add(tx); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	batch := mp.Batch(2)
+	if len(batch) != 2 || batch[0] != txs[0] || batch[1] != txs[1] {
+		t.Fatalf("Batch(2) did not return the two oldest transactions")
+	}
+
+	mp.Remove(batch)
+	if mp.Len() != 1 {
+		t.Errorf("Len() after Remove() = %d, want 1", mp.Len())
+	}
+	if mp.IsSpent(txs[0].Vin[0].Txid, 0) {
+		t.Errorf("IsSpent() of a released output = true, want false")
+	}
+	if _, ok := mp.Get(hex.EncodeToString(txs[2].ID)); !ok {
+		t.Errorf("Get() of the remaining transaction = false, want true")
+	}
+}
@@ -0,0 +1,188 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Leaves and inner nodes are hashed with different prefixes, so an inner
+// node can never be passed off as a transaction ID.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+var (
+	// ErrInvalidHeader is returned when a block header does not carry a valid
+	// proof of work.
+	ErrInvalidHeader = errors.New("invalid block header")
+	// ErrNotIncluded is returned when a Merkle proof does not lead to the
+	// Merkle root of the block header.
+	ErrNotIncluded = errors.New("transaction is not included in the block")
+)
+
+// MerkleProof is the Merkle branch of a transaction: the sibling hashes on
+// the path from its leaf to the root, bottom up. Index and Count fix the
+// side of every sibling and the levels where the node has none.
+type MerkleProof struct {
+	// Index is the position of the transaction in the block.
+	Index int
+	// Count is the number of transactions in the block.
+	Count  int
+	Branch [][]byte
+}
+
+// BlockHeader is a block without its transactions, all a lightweight client
+// needs to check an inclusion proof.
+type BlockHeader struct {
+	Hash       string
+	PrevHash   string
+	MerkleRoot []byte
+	Timestamp  time.Time
+	Nonce      int
+	Difficulty int
+}
+
+// Header returns the header of b.
+func (b *Block) Header() BlockHeader {
+	return BlockHeader{
+		Hash:       b.Hash,
+		PrevHash:   b.PrevHash,
+		MerkleRoot: b.MerkleRoot,
+		Timestamp:  b.Timestamp,
+		Nonce:      b.Nonce,
+		Difficulty: b.Difficulty,
+	}
+}
+
+func merkleLeaf(txID []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write(txID)
+
+	return h.Sum(nil)
+}
+
+func merkleNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+
+	return h.Sum(nil)
+}
+
+// MerkleRoot returns the root of the Merkle tree over txIDs. Nodes are paired
+// left to right on every level; the last node of a level with an odd number
+// of nodes is carried up unchanged. The root of no transactions is the hash
+// of nothing.
+func MerkleRoot(txIDs [][]byte) []byte {
+	if len(txIDs) == 0 {
+		root := sha256.Sum256(nil)
+
+		return root[:]
+	}
+
+	level := merkleLeaves(txIDs)
+	for len(level) > 1 {
+		level = merkleParents(level)
+	}
+
+	return level[0]
+}
+
+// merkleLeaves returns the leaf hashes of txIDs.
+func merkleLeaves(txIDs [][]byte) [][]byte {
+	leaves := make([][]byte, len(txIDs))
+	for i, id := range txIDs {
+		leaves[i] = merkleLeaf(id)
+	}
+
+	return leaves
+}
+
+// merkleParents returns the level above level.
+func merkleParents(level [][]byte) [][]byte {
+	parents := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			parents = append(parents, level[i])
+		} else {
+			parents = append(parents, merkleNode(level[i], level[i+1]))
+		}
+	}
+
+	return parents
+}
+
+// NewMerkleProof returns the Merkle branch of the transaction at index among
+// txIDs.
+func NewMerkleProof(txIDs [][]byte, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(txIDs) {
+		return nil, fmt.Errorf("transaction index %d out of range [0, %d)", index, len(txIDs))
+	}
+
+	proof := &MerkleProof{Index: index, Count: len(txIDs)}
+	level := merkleLeaves(txIDs)
+	for i := index; len(level) > 1; i /= 2 {
+		if sibling := i ^ 1; sibling < len(level) {
+			proof.Branch = append(proof.Branch, level[sibling])
+		}
+		level = merkleParents(level)
+	}
+
+	return proof, nil
+}
+
+// VerifyMerkleProof reports whether proof leads from txID to root.
+func VerifyMerkleProof(txID, root []byte, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.Count {
+		return false
+	}
+
+	hash := merkleLeaf(txID)
+	branch := proof.Branch
+	for i, n := proof.Index, proof.Count; n > 1; i, n = i/2, (n+1)/2 {
+		if i^1 >= n {
+			// The last node of an odd level has no sibling.
+			continue
+		}
+		if len(branch) == 0 {
+			return false
+		}
+		if i%2 == 0 {
+			hash = merkleNode(hash, branch[0])
+		} else {
+			hash = merkleNode(branch[0], hash)
+		}
+		branch = branch[1:]
+	}
+
+	return len(branch) == 0 && bytes.Equal(hash, root)
+}
+
+// VerifyInclusion checks that header carries a valid proof of work and that
+// proof shows the transaction txID under its Merkle root. It needs neither
+// the block nor the chain; whether the header is part of the best chain is
+// up to the caller.
+func VerifyInclusion(header BlockHeader, txID []byte, proof *MerkleProof) error {
+	block := &Block{
+		Hash:       header.Hash,
+		PrevHash:   header.PrevHash,
+		MerkleRoot: header.MerkleRoot,
+		Timestamp:  header.Timestamp,
+		Nonce:      header.Nonce,
+		Difficulty: header.Difficulty,
+	}
+	if header.Difficulty <= 0 || header.Difficulty > 256 || !NewProof(block).Validate() {
+		return ErrInvalidHeader
+	}
+	if !VerifyMerkleProof(txID, header.MerkleRoot, proof) {
+		return ErrNotIncluded
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func testTxIDs(n int) [][]byte {
+	ids := make([][]byte, n)
+	for i := range ids {
+		ids[i] = []byte(fmt.Sprintf("transaction %d", i))
+	}
+
+	return ids
+}
+
+func TestMerkleRoot(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	tests := []struct {
+		name string
+		ids  [][]byte
+		want []byte
+	}{
+		{"Single", [][]byte{a}, merkleLeaf(a)},
+		{"Pair", [][]byte{a, b}, merkleNode(merkleLeaf(a), merkleLeaf(b))},
+		{"Odd", [][]byte{a, b, c}, merkleNode(merkleNode(merkleLeaf(a), merkleLeaf(b)), merkleLeaf(c))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MerkleRoot(tt.ids); !bytes.Equal(got, tt.want) {
+				t.Errorf("MerkleRoot() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+
+	// Repeating the last transaction must not yield the same root.
+	if bytes.Equal(MerkleRoot([][]byte{a, b, c}), MerkleRoot([][]byte{a, b, c, c})) {
+		t.Errorf("MerkleRoot() does not tell a repeated last transaction apart")
+	}
+}
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 13; n++ {
+		ids := testTxIDs(n)
+		root := MerkleRoot(ids)
+		for i := range ids {
+			proof, err := NewMerkleProof(ids, i)
+			if err != nil {
+				t.Fatalf("NewMerkleProof(%d of %d) error = %v", i, n, err)
+			}
+			if !VerifyMerkleProof(ids[i], root, proof) {
+				t.Errorf("VerifyMerkleProof(%d of %d) = false, want true", i, n)
+			}
+			if n > 1 && VerifyMerkleProof(ids[(i+1)%n], root, proof) {
+				t.Errorf("VerifyMerkleProof(%d of %d) accepts another transaction", i, n)
+			}
+		}
+	}
+
+	if _, err := NewMerkleProof(testTxIDs(3), 3); err == nil {
+		t.Errorf("NewMerkleProof() out of range error = nil, want error")
+	}
+}
+
+func TestVerifyMerkleProof_Tampered(t *testing.T) {
+	ids := testTxIDs(6)
+	root := MerkleRoot(ids)
+
+	tests := []struct {
+		name   string
+		tamper func(p *MerkleProof)
+	}{
+		{"Index", func(p *MerkleProof) { p.Index = 3 }},
+		{"Count", func(p *MerkleProof) { p.Count = 3 }},
+		{"Sibling", func(p *MerkleProof) { p.Branch[1] = merkleLeaf([]byte("other")) }},
+		{"Short branch", func(p *MerkleProof) { p.Branch = p.Branch[:len(p.Branch)-1] }},
+		{"Long branch", func(p *MerkleProof) { p.Branch = append(p.Branch, root) }},
+		{"Negative index", func(p *MerkleProof) { p.Index = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := NewMerkleProof(ids, 2)
+			if err != nil {
+				t.Fatalf("NewMerkleProof() error = %v", err)
+			}
+			tt.tamper(proof)
+			if VerifyMerkleProof(ids[2], root, proof) {
+				t.Errorf("VerifyMerkleProof() = true, want false")
+			}
+		})
+	}
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	_, blocks := testChain(t)
+	block := blocks[1]
+	block.Transactions = append(block.Transactions, blocks[0].Transactions[0])
+	block.MerkleRoot = block.HashTransactions()
+	block.Nonce, block.Hash = NewProof(block).Run()
+
+	tx := block.Transactions[0]
+	proof, err := block.MerkleProof(tx.ID)
+	if err != nil {
+		t.Fatalf("MerkleProof() error = %v", err)
+	}
+	if err = VerifyInclusion(block.Header(), tx.ID, proof); err != nil {
+		t.Errorf("VerifyInclusion() error = %v", err)
+	}
+
+	if _, err = block.MerkleProof([]byte("missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("MerkleProof() of a missing transaction error = %v, want %v", err, ErrNotFound)
+	}
+
+	forged := block.Header()
+	forged.MerkleRoot = MerkleRoot([][]byte{tx.ID})
+	if err = VerifyInclusion(forged, tx.ID, &MerkleProof{Index: 0, Count: 1}); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("VerifyInclusion() with a forged root error = %v, want %v", err, ErrInvalidHeader)
+	}
+
+	if err = VerifyInclusion(block.Header(), []byte("missing"), proof); !errors.Is(err, ErrNotIncluded) {
+		t.Errorf("VerifyInclusion() of another transaction error = %v, want %v", err, ErrNotIncluded)
+	}
+}
@@ -2,37 +2,51 @@ package blockchainlogic
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"log"
 	"math"
 	"math/big"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-const Difficulty = 12
+// cancelCheckInterval is the number of nonces a worker tries between two
+// checks for cancellation.
+const cancelCheckInterval = 1 << 12
 
 type ProofOfWork struct {
 	Block  *Block
 	Target *big.Int
+	// Hashes is the number of hashes computed by the last run.
+	Hashes uint64
 }
 
+// NewProof returns the proof of work of b for the difficulty stored in the
+// block, so blocks keep validating after a retarget.
 func NewProof(b *Block) *ProofOfWork {
 	target := big.NewInt(1)
-	target.Lsh(target, uint(256-Difficulty))
-	pow := &ProofOfWork{b, target}
+	target.Lsh(target, uint(256-b.Difficulty))
+	pow := &ProofOfWork{Block: b, Target: target}
 
 	return pow
 }
 
+// InitData returns the block header hashed by the proof of work with nonce:
+// the previous hash, the Merkle root, the timestamp in Unix nanoseconds, the
+// nonce and the difficulty. Committing to the timestamp keeps it from being
+// changed without mining the block again, as retargets and lock times
+// depend on it.
 func (pow *ProofOfWork) InitData(nonce int) string {
 	data := strings.Join(
 		[]string{
-			pow.Block.PrevHash,
-			string(pow.Block.HashTransactions()),
+			pow.headerPrefix(),
 			string(ToHex(int64(nonce))),
-			string(ToHex(int64(Difficulty))),
+			string(ToHex(int64(pow.Block.Difficulty))),
 		},
 		"",
 	)
@@ -40,27 +54,89 @@ func (pow *ProofOfWork) InitData(nonce int) string {
 	return data
 }
 
+// headerPrefix returns the part of the header hashed before the nonce.
+func (pow *ProofOfWork) headerPrefix() string {
+	return pow.Block.PrevHash + string(pow.Block.MerkleRoot) + string(ToHex(pow.Block.Timestamp.UnixNano()))
+}
+
+// Run searches a nonce solving the proof of work. It can not be interrupted,
+// use RunContext for blocks mined on a live chain.
 func (pow *ProofOfWork) Run() (int, string) {
-	var intHash big.Int
-	var hash [32]byte
+	nonce, hash, err := pow.RunContext(context.Background())
+	if err != nil {
+		log.Panic(err)
+	}
 
-	nonce := 0
+	return nonce, hash
+}
 
-	for nonce < math.MaxInt64 {
-		data := pow.InitData(nonce)
-		hash = sha256.Sum256([]byte(data))
+// RunContext searches a nonce solving the proof of work on GOMAXPROCS
+// workers, worker i trying the nonces i, i+n, i+2n and so on. It returns
+// ctx.Err() when ctx is done before a nonce is found.
+func (pow *ProofOfWork) RunContext(ctx context.Context) (int, string, error) {
+	// Everything but the nonce is constant, encode it once.
+	prefix := []byte(pow.headerPrefix())
+	workers := runtime.GOMAXPROCS(0)
+
+	type solution struct {
+		nonce int
+		hash  [32]byte
+	}
+	found := make(chan solution, 1)
+	var done atomic.Bool
+	var hashes atomic.Uint64
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+
+			data := make([]byte, len(prefix)+16)
+			copy(data, prefix)
+			binary.BigEndian.PutUint64(data[len(prefix)+8:], uint64(pow.Block.Difficulty))
+			var intHash big.Int
+			tried := uint64(0)
+			defer func() { hashes.Add(tried) }()
+
+			for nonce := start; nonce >= 0 && nonce < math.MaxInt64; nonce += workers {
+				if tried%cancelCheckInterval == 0 && (done.Load() || ctx.Err() != nil) {
+					return
+				}
+				binary.BigEndian.PutUint64(data[len(prefix):], uint64(nonce))
+				hash := sha256.Sum256(data)
+				tried++
+
+				intHash.SetBytes(hash[:])
+				if intHash.Cmp(pow.Target) == -1 {
+					if done.CompareAndSwap(false, true) {
+						found <- solution{nonce, hash}
+					}
+
+					return
+				}
+			}
+		}(w)
+	}
 
-		intHash.SetBytes(hash[:])
+	wg.Wait()
+	pow.Hashes = hashes.Load()
 
-		if intHash.Cmp(pow.Target) == -1 {
-			break
+	select {
+	case s := <-found:
+		return s.nonce, hex.EncodeToString(s.hash[:]), nil
+	default:
+		if err := ctx.Err(); err != nil {
+			return 0, "", err
 		}
-		nonce++
-	}
+		log.Panic("proof of work: nonce space exhausted")
 
-	return nonce, hex.EncodeToString(hash[:])
+		return 0, "", nil
+	}
 }
 
+// Validate reports whether the block nonce solves the proof of work and
+// yields the stored block hash.
 func (pow *ProofOfWork) Validate() bool {
 	var intHash big.Int
 
@@ -69,7 +145,7 @@ func (pow *ProofOfWork) Validate() bool {
 	hash := sha256.Sum256([]byte(data))
 	intHash.SetBytes(hash[:])
 
-	return intHash.Cmp(pow.Target) == -1
+	return intHash.Cmp(pow.Target) == -1 && hex.EncodeToString(hash[:]) == pow.Block.Hash
 }
 
 func ToHex(num int64) []byte {
@@ -0,0 +1,54 @@
+package blockchainlogic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProofOfWork_RunContext(t *testing.T) {
+	block := &Block{
+		Transactions: []*Transaction{NewCoinbaseTX(string(NewWallet().GetAddress()), "")},
+		PrevHash:     "0",
+		Timestamp:    time.Now(),
+		Difficulty:   16,
+	}
+
+	pow := NewProof(block)
+	nonce, hash, err := pow.RunContext(context.Background())
+	if err != nil {
+		t.Fatalf("RunContext() error = %v", err)
+	}
+	if pow.Hashes == 0 {
+		t.Errorf("RunContext() counted no hashes")
+	}
+
+	block.Nonce = nonce
+	block.Hash = hash
+	if !NewProof(block).Validate() {
+		t.Errorf("RunContext() nonce %d does not solve the proof of work", nonce)
+	}
+
+	// The proof of work commits to the timestamp.
+	block.Timestamp = block.Timestamp.Add(time.Hour)
+	if NewProof(block).Validate() {
+		t.Errorf("Validate() accepted a block with a changed timestamp")
+	}
+}
+
+func TestProofOfWork_RunContextCancel(t *testing.T) {
+	// Practically unsolvable, the run only ends through ctx.
+	block := &Block{PrevHash: "0", Difficulty: 200}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := NewProof(block).RunContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("RunContext() took %s to stop", elapsed)
+	}
+}
@@ -0,0 +1,150 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// MaxRawTxSize bounds the size of a serialized transaction submitted from
+// outside the node.
+const MaxRawTxSize = 100 * 1024
+
+// pubKeyHashLen is the length of a RIPEMD-160 public key hash.
+const pubKeyHashLen = 20
+
+// ErrInvalidTransaction is returned for submitted transactions that can not
+// be accepted. The wrapped message says why.
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// TxTemplate is an unsigned transaction together with what its signers need:
+// the output spent by every input and the hash its signature must commit to.
+type TxTemplate struct {
+	Tx     *Transaction
+	Fee    Amount
+	Inputs []TemplateInput
+}
+
+// TemplateInput describes input i of a TxTemplate.
+type TemplateInput struct {
+	Address string
+	Value   Amount
+	SigHash []byte
+}
+
+// BuildTransaction builds an unsigned transaction paying amount to to from
+// the outputs of the wallet owning from. Change goes where NewUTXOTransaction
+// sends it: to a fresh change address of HD wallets in the keystore, or back
+// to from for standalone and non-custodial keys. A template that is never
+// submitted leaves its change address unused, like a receive address that is
+// never paid. A zero fee stands for the minimum fee. The private keys are not
+// needed: every input is signed outside the node over its
+// TemplateInput.SigHash, and the signed transaction is handed back to
+// SubmitTransaction.
+func (bc *Blockchain) BuildTransaction(from, to string, amount, fee Amount) (*TxTemplate, error) {
+	minFee := bc.MinFee()
+	if fee == 0 {
+		fee = minFee
+	}
+	if fee < minFee {
+		return nil, fmt.Errorf("%w %s", ErrFeeTooLow, minFee)
+	}
+	if amount <= 0 || fee < 0 {
+		return nil, ErrInvalidAmount
+	}
+	if !ValidateAddress(from) {
+		return nil, errors.New("sender address is not valid")
+	}
+	if !ValidateAddress(to) {
+		return nil, errors.New("recipient address is not valid")
+	}
+
+	addresses, err := bc.WalletAddresses(from)
+	if err != nil {
+		return nil, err
+	}
+	total := amount + fee
+	acc, spendable, err := bc.FindSpendableOutputs(pubKeyHashes(addresses), total)
+	if err != nil {
+		return nil, err
+	}
+	if acc < total {
+		return nil, ErrInsufficientFunds
+	}
+
+	template := &TxTemplate{Fee: fee, Inputs: make([]TemplateInput, 0, len(spendable))}
+	inputs := make([]TXInput, 0, len(spendable))
+	for _, out := range spendable {
+		inputs = append(inputs, TXInput{Txid: out.TxID, Vout: out.Vout})
+		template.Inputs = append(template.Inputs, TemplateInput{
+			Address: AddressFromPubKeyHash(out.PubKeyHash),
+			Value:   out.Value,
+		})
+	}
+	outputs := []TXOutput{*NewTXOutput(amount, to)}
+	if acc > total {
+		change, err := bc.changeAddress(bc.keys, from)
+		if errors.Is(err, ErrKeyNotFound) {
+			change = from
+		} else if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, *NewTXOutput(acc-total, change))
+	}
+
+	tx := &Transaction{Vin: inputs, Vout: outputs}
+	tx.ID = tx.Hash()
+	prevTXs, err := bc.findPrevTransactions(tx)
+	if err != nil {
+		return nil, err
+	}
+	sigHashes, err := tx.sigHashes(prevTXs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range template.Inputs {
+		template.Inputs[i].SigHash = sigHashes[i]
+	}
+	template.Tx = tx
+
+	return template, nil
+}
+
+// SubmitTransaction verifies a transaction signed outside the node and queues
+// it in the mempool. Its ID is recomputed, as it covers the public keys set by
+// the signers. It returns the hex encoded ID of the pending transaction.
+func (bc *Blockchain) SubmitTransaction(tx *Transaction) (string, error) {
+	if len(tx.Vin) == 0 || len(tx.Vout) == 0 {
+		return "", fmt.Errorf("%w: transaction needs inputs and outputs", ErrInvalidTransaction)
+	}
+	if tx.IsCoinbase() {
+		return "", fmt.Errorf("%w: coinbase transactions are created by the miner", ErrInvalidTransaction)
+	}
+	for i, out := range tx.Vout {
+		if err := out.checkLock(); err != nil {
+			return "", fmt.Errorf("%w: output %d: %v", ErrInvalidTransaction, i, err)
+		}
+	}
+	tx.ID = tx.Hash()
+
+	unspent, err := bc.inputsUnspent(tx)
+	if err != nil {
+		return "", err
+	}
+	if !unspent {
+		return "", fmt.Errorf("%w: an input is spent or does not exist", ErrInvalidTransaction)
+	}
+	fee, err := bc.checkTransaction(tx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+	if minFee := bc.MinFee(); fee < minFee {
+		return "", fmt.Errorf("%w %s", ErrFeeTooLow, minFee)
+	}
+
+	if err = bc.acceptTransaction(tx); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
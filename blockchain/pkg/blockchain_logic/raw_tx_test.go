@@ -0,0 +1,112 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestExternallySignedTransaction(t *testing.T) {
+	owner, recipient := NewWallet(), NewWallet()
+	prevTx := NewCoinbaseTX(string(owner.GetAddress()), "")
+	prevTXs := map[string]Transaction{hex.EncodeToString(prevTx.ID): *prevTx}
+
+	// The template the node hands out: inputs without keys nor signatures.
+	unsigned := &Transaction{
+		Vin: []TXInput{{Txid: prevTx.ID, Vout: 0}},
+		Vout: []TXOutput{
+			*NewTXOutput(10, string(recipient.GetAddress())),
+			*NewTXOutput(genesisReward()-11, string(owner.GetAddress())),
+		},
+	}
+	unsigned.ID = unsigned.Hash()
+	sigHashes, err := unsigned.sigHashes(prevTXs)
+	if err != nil {
+		t.Fatalf("sigHashes() error = %v", err)
+	}
+
+	// The signer decodes the template, signs the sighash and sets its key.
+	tx, err := DeserializeTransaction(unsigned.Serialize())
+	if err != nil {
+		t.Fatalf("DeserializeTransaction() error = %v", err)
+	}
+	tx.Vin[0].PubKey = owner.PublicKey
+	tx.Vin[0].Signature, err = owner.Sign(sigHashes[0])
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	// The node decodes the signed transaction.
+	signed, err := DeserializeTransaction(tx.Serialize())
+	if err != nil {
+		t.Fatalf("DeserializeTransaction() error = %v", err)
+	}
+	signed.ID = signed.Hash()
+	if bytes.Equal(signed.ID, unsigned.ID) {
+		t.Errorf("ID does not cover the input public keys")
+	}
+	if !signed.Verify(prevTXs) {
+		t.Errorf("Verify() = false, want true")
+	}
+
+	signed.Vout[1].PubKeyHash = HashPubKey(recipient.PublicKey)
+	if signed.Verify(prevTXs) {
+		t.Errorf("Verify() with a redirected change = true, want false")
+	}
+}
+
+func TestDeserializeTransaction_Invalid(t *testing.T) {
+	if _, err := DeserializeTransaction([]byte("not a transaction")); err == nil {
+		t.Errorf("DeserializeTransaction() error = nil, want an error")
+	}
+}
+
+func TestBlockchain_BuildTransactionChange(t *testing.T) {
+	seed, err := MnemonicSeed(DefaultRegtestMnemonic)
+	if err != nil {
+		t.Fatalf("MnemonicSeed() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		// from stores the spending wallet in keys, or not, and returns it.
+		from func(keys Keystore) (string, error)
+		hd   bool
+	}{
+		{name: "HD wallet", from: func(keys Keystore) (string, error) { return keys.PutSeed(seed, 1, 0) }, hd: true},
+		{name: "Standalone key", from: CreateWallet},
+		{name: "Non-custodial", from: func(Keystore) (string, error) { return string(NewWallet().GetAddress()), nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := NewWallets()
+			from, err := tt.from(keys)
+			if err != nil {
+				t.Fatalf("storing the wallet error = %v", err)
+			}
+			bc := CreateBlockchain(NewMemoryChainStore(), keys, from)
+
+			template, err := bc.BuildTransaction(from, string(NewWallet().GetAddress()), 10, 0)
+			if err != nil {
+				t.Fatalf("BuildTransaction() error = %v", err)
+			}
+			if len(template.Tx.Vout) != 2 {
+				t.Fatalf("BuildTransaction() made %d outputs, want a payment and change", len(template.Tx.Vout))
+			}
+			change := AddressFromPubKeyHash(template.Tx.Vout[1].PubKeyHash)
+			if !tt.hd {
+				if change != from {
+					t.Errorf("change goes to %s, want %s", change, from)
+				}
+
+				return
+			}
+			group, err := keys.Group(from)
+			if err != nil {
+				t.Fatalf("Group() error = %v", err)
+			}
+			if change == from || len(group) != 2 || group[1] != change {
+				t.Errorf("change goes to %s, want a new change address of %v", change, group)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package blockchainlogic
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultRegtestMnemonic is the seed of regtest chains unless configured,
+// the BIP39 test mnemonic. Its wallet can be restored like any other.
+const DefaultRegtestMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// RegtestDifficultyPolicy makes proof of work trivial and never retargets,
+// however fast blocks are mined.
+var RegtestDifficultyPolicy = DifficultyPolicy{
+	Initial:          1,
+	Min:              1,
+	RetargetInterval: math.MaxInt32,
+	TargetBlockTime:  time.Second,
+}
+
+// regtestGenesisTime is the timestamp of every regtest genesis block.
+var regtestGenesisTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ErrNotRegtest is returned by the regtest operations of other chains.
+var ErrNotRegtest = errors.New("chain is not in regtest mode")
+
+// regtestChain is the state of a regtest chain.
+type regtestChain struct {
+	genesis *Block
+	// faucet receives the genesis coinbase and funds addresses.
+	faucet string
+}
+
+// regtestGenesis returns the genesis block paying faucet. It depends on
+// faucet, the genesis supply and the difficulty policy only.
+func regtestGenesis(faucet string) (*Block, error) {
+	return GenesisParams{Recipient: faucet, Timestamp: regtestGenesisTime, Message: genesisCoinbaseData}.Block()
+}
+
+// OpenRegtestBlockchain opens a regtest chain kept in store. The HD wallet of
+// mnemonic is stored in keys; its address is the faucet and the miner
+// address. The genesis block pays the faucet with a fixed timestamp, so every
+// node configured with the same mnemonic starts from the same block. A store
+// holding another genesis block is refused with ErrGenesisMismatch.
+// SetDifficultyPolicy must have been called with RegtestDifficultyPolicy.
+func OpenRegtestBlockchain(store ChainStore, keys Keystore, mnemonic string) (*Blockchain, error) {
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	faucet, err := keys.PutSeed(seed, 1, 0)
+	if err != nil && !errors.Is(err, ErrKeyExists) {
+		return nil, err
+	}
+	genesis, err := regtestGenesis(faucet)
+	if err != nil {
+		return nil, err
+	}
+	err = openGenesis(store, genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	bc := NewBlockchain(store, keys, faucet)
+	bc.regtest = &regtestChain{genesis: genesis, faucet: faucet}
+
+	return bc, nil
+}
+
+// Faucet returns the faucet address of a regtest chain, which stands in for
+// the treasury, or an empty string for other chains.
+func (bc *Blockchain) Faucet() string {
+	if bc.regtest == nil {
+		return ""
+	}
+
+	return bc.regtest.faucet
+}
+
+// GenerateBlocks mines n blocks on a regtest chain, the first one holding
+// the valid pending transactions. It returns the hashes of the new blocks.
+func (bc *Blockchain) GenerateBlocks(ctx context.Context, n int) ([]string, error) {
+	if bc.regtest == nil {
+		return nil, ErrNotRegtest
+	}
+
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		txs, err := bc.pendingBatch(0)
+		if err != nil {
+			return hashes, err
+		}
+		block, err := bc.mineBlock(ctx, txs)
+		if err != nil {
+			return hashes, err
+		}
+		bc.mempool.Remove(txs)
+		hashes = append(hashes, block.Hash)
+	}
+
+	return hashes, nil
+}
+
+// Fund sends amount from the faucet of a regtest chain to address and mines
+// a block confirming it. It returns the hex encoded transaction ID. Unlike
+// Send, the same funding may be repeated at once. Mining blocks with
+// GenerateBlocks refills the faucet.
+func (bc *Blockchain) Fund(ctx context.Context, address string, amount Amount) (string, error) {
+	if bc.regtest == nil {
+		return "", ErrNotRegtest
+	}
+	if !ValidateAddress(address) {
+		return "", fmt.Errorf("%w: address %q is not valid", ErrInvalidTransaction, address)
+	}
+
+	tx, err := bc.queueTransaction(bc.regtest.faucet, address, amount, bc.MinFee(), "")
+	if err != nil {
+		return "", err
+	}
+	_, err = bc.GenerateBlocks(ctx, 1)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
+
+// ResetRegtest drops every block and pending transaction of a regtest chain
+// and starts it over from its genesis block, which it returns.
+func (bc *Blockchain) ResetRegtest() (*Block, error) {
+	if bc.regtest == nil {
+		return nil, ErrNotRegtest
+	}
+	bc.mining.Lock()
+	defer bc.mining.Unlock()
+
+	err := bc.store.Reset()
+	if err != nil {
+		return nil, err
+	}
+	bc.mempool.Clear()
+	bc.invalid = make(map[string]bool)
+	err = bc.store.AddBlock(bc.regtest.genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	return bc.regtest.genesis, nil
+}
@@ -0,0 +1,110 @@
+package blockchainlogic
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func useRegtestPolicy(t *testing.T) {
+	t.Helper()
+
+	policy := difficultyPolicy
+	t.Cleanup(func() { difficultyPolicy = policy })
+	if err := SetDifficultyPolicy(RegtestDifficultyPolicy); err != nil {
+		t.Fatalf("SetDifficultyPolicy() error = %v", err)
+	}
+}
+
+func openRegtest(t *testing.T, store ChainStore) *Blockchain {
+	t.Helper()
+
+	bc, err := OpenRegtestBlockchain(store, NewWallets(), DefaultRegtestMnemonic)
+	if err != nil {
+		t.Fatalf("OpenRegtestBlockchain() error = %v", err)
+	}
+
+	return bc
+}
+
+func TestOpenRegtestBlockchain_DeterministicGenesis(t *testing.T) {
+	useRegtestPolicy(t)
+
+	a := openRegtest(t, NewMemoryChainStore())
+	b := openRegtest(t, NewMemoryChainStore())
+	genesisA, errA := a.Store().BlockAt(0)
+	genesisB, errB := b.Store().BlockAt(0)
+	if errA != nil || errB != nil || genesisA.Hash != genesisB.Hash {
+		t.Fatalf("genesis blocks = %v, %v, want the same block", genesisA, genesisB)
+	}
+
+	// Reopening the store keeps its chain.
+	if _, err := OpenRegtestBlockchain(a.Store(), NewWallets(), DefaultRegtestMnemonic); err != nil {
+		t.Errorf("OpenRegtestBlockchain() of an opened store error = %v", err)
+	}
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	if _, err := OpenRegtestBlockchain(a.Store(), NewWallets(), mnemonic); !errors.Is(err, ErrGenesisMismatch) {
+		t.Errorf("OpenRegtestBlockchain() with another seed error = %v, want %v", err, ErrGenesisMismatch)
+	}
+}
+
+func TestBlockchain_GenerateFundReset(t *testing.T) {
+	useRegtestPolicy(t)
+	ctx := context.Background()
+
+	bc := openRegtest(t, NewMemoryChainStore())
+	genesis, _ := bc.Store().BlockAt(0)
+
+	hashes, err := bc.GenerateBlocks(ctx, 3)
+	if err != nil || len(hashes) != 3 {
+		t.Fatalf("GenerateBlocks() = %v, %v, want 3 blocks", hashes, err)
+	}
+	tip, _ := bc.Store().Tip()
+	checkTip(t, bc, tip, 3)
+	if tip.Hash != hashes[2] {
+		t.Errorf("Tip() = %s, want %s", tip.Hash, hashes[2])
+	}
+
+	recipient := string(NewWallet().GetAddress())
+	txID, err := bc.Fund(ctx, recipient, 25*Coin())
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	if info, err := bc.TransactionInfo(txID); err != nil || info.Status != TxStatusConfirmed {
+		t.Errorf("TransactionInfo() = %+v, %v, want confirmed", info, err)
+	}
+	checkBalance(t, bc, recipient, 25*Coin())
+	if _, err = bc.Fund(ctx, recipient, 25*Coin()); err != nil {
+		t.Fatalf("Fund() again error = %v", err)
+	}
+	checkBalance(t, bc, recipient, 50*Coin())
+	if _, err = bc.Fund(ctx, "not an address", Coin()); !errors.Is(err, ErrInvalidTransaction) {
+		t.Errorf("Fund() of a bad address error = %v, want %v", err, ErrInvalidTransaction)
+	}
+
+	pending, _ := pendingSend(t, bc, bc.regtest.faucet, Coin())
+	block, err := bc.ResetRegtest()
+	if err != nil || block.Hash != genesis.Hash {
+		t.Fatalf("ResetRegtest() = %v, %v, want genesis %s", block, err, genesis.Hash)
+	}
+	checkTip(t, bc, genesis, 0)
+	checkBalance(t, bc, recipient, 0)
+	if _, ok := bc.PendingTransaction(hex.EncodeToString(pending.ID)); ok {
+		t.Errorf("PendingTransaction() after ResetRegtest() = true, want false")
+	}
+}
+
+func TestBlockchain_RegtestOnly(t *testing.T) {
+	bc := CreateBlockchain(NewMemoryChainStore(), NewWallets(), string(NewWallet().GetAddress()))
+
+	if _, err := bc.GenerateBlocks(context.Background(), 1); !errors.Is(err, ErrNotRegtest) {
+		t.Errorf("GenerateBlocks() error = %v, want %v", err, ErrNotRegtest)
+	}
+	if bc.Faucet() != "" {
+		t.Errorf("Faucet() = %q, want none", bc.Faucet())
+	}
+	if _, err := bc.ResetRegtest(); !errors.Is(err, ErrNotRegtest) {
+		t.Errorf("ResetRegtest() error = %v, want %v", err, ErrNotRegtest)
+	}
+}
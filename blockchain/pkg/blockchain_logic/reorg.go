@@ -0,0 +1,167 @@
+package blockchainlogic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// reorganize switches the main chain to the branch ending with newTip, which
+// holds more work. The main chain is disconnected down to the fork point and
+// the branch connected block by block, checking each against the utxo set it
+// builds on. When a branch block turns out invalid, the branch is marked
+// invalid from it onwards and the previous main chain is restored.
+//
+// Transactions of the disconnected blocks that are not confirmed by the
+// branch go back to the mempool when they are still valid. Transactions
+// spending the outputs of another disconnected transaction are dropped, as
+// the mempool only takes transactions spending confirmed outputs.
+func (bc *Blockchain) reorganize(newTip BlockNode) error {
+	var branch []*Block
+	node := newTip
+	for !node.MainChain {
+		if bc.invalid[node.Hash] {
+			bc.invalid[newTip.Hash] = true
+
+			return fmt.Errorf("%w %s: branch holds invalid block %s", ErrInvalidBlock, newTip.Hash, node.Hash)
+		}
+		block, err := bc.store.Block(node.Hash)
+		if err != nil {
+			return err
+		}
+		branch = append(branch, block)
+		node, err = bc.store.BlockNode(node.PrevHash)
+		if err != nil {
+			return err
+		}
+	}
+	slices.Reverse(branch)
+	fork := node
+
+	disconnected, err := bc.disconnectTo(fork.Height)
+	if err != nil {
+		return err
+	}
+	err = bc.connectBranch(fork.Height, branch)
+	if err != nil {
+		if _, restoreErr := bc.disconnectTo(fork.Height); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("restore main chain: %w", restoreErr))
+		}
+		for i := len(disconnected) - 1; i >= 0; i-- {
+			if restoreErr := bc.store.AddBlock(disconnected[i]); restoreErr != nil {
+				return errors.Join(err, fmt.Errorf("restore main chain: %w", restoreErr))
+			}
+		}
+
+		return err
+	}
+
+	for _, block := range disconnected {
+		bc.notifyDisconnected(block)
+	}
+	for _, block := range branch {
+		bc.mempool.Remove(block.Transactions)
+	}
+	for i := len(disconnected) - 1; i >= 0; i-- {
+		bc.restoreTransactions(disconnected[i])
+	}
+	for _, block := range branch {
+		bc.notifyBlock(block)
+	}
+
+	return nil
+}
+
+// disconnectTo disconnects main chain blocks down to height and returns them,
+// the former tip first.
+func (bc *Blockchain) disconnectTo(height int) ([]*Block, error) {
+	var disconnected []*Block
+	for {
+		tip, err := bc.store.Height()
+		if err != nil {
+			return disconnected, err
+		}
+		if tip <= height {
+			return disconnected, nil
+		}
+		block, err := bc.store.DisconnectTip()
+		if err != nil {
+			return disconnected, err
+		}
+		disconnected = append(disconnected, block)
+	}
+}
+
+// connectBranch appends branch to the main chain, whose tip is at forkHeight.
+func (bc *Blockchain) connectBranch(forkHeight int, branch []*Block) error {
+	for i, block := range branch {
+		err := bc.checkBlockTransactions(block, forkHeight+1+i)
+		if err != nil {
+			for _, invalid := range branch[i:] {
+				bc.invalid[invalid.Hash] = true
+			}
+
+			return fmt.Errorf("%w %s: %v", ErrInvalidBlock, block.Hash, err)
+		}
+		err = bc.store.AddBlock(block)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreTransactions returns the transactions of a disconnected block to
+// the mempool, unless they are confirmed again or no longer valid.
+func (bc *Blockchain) restoreTransactions(block *Block) {
+	for _, tx := range block.Transactions[1:] {
+		if _, err := bc.store.TransactionBlock(tx.ID); err == nil {
+			continue
+		}
+		unspent, err := bc.inputsUnspent(tx)
+		if err != nil || !unspent {
+			continue
+		}
+		if _, err = bc.checkTransaction(tx); err != nil {
+			continue
+		}
+		// A pending transaction may spend the same outputs; it is kept.
+		_ = bc.acceptTransaction(tx)
+	}
+}
+
+// nextBlockDifficulty returns the difficulty of the block at height mined on
+// top of parent, on any branch.
+func (bc *Blockchain) nextBlockDifficulty(parent *Block, height int) (int, error) {
+	if !isRetargetHeight(height) {
+		return parent.Difficulty, nil
+	}
+
+	start, err := bc.ancestor(parent, height-1, retargetWindowStart(height))
+	if err != nil {
+		return 0, err
+	}
+
+	return nextDifficulty(height, parent.Difficulty, parent.Timestamp.Sub(start.Timestamp)), nil
+}
+
+// ancestor returns the block at height on the branch of block, which sits at
+// blockHeight.
+func (bc *Blockchain) ancestor(block *Block, blockHeight, height int) (*Block, error) {
+	for ; blockHeight > height; blockHeight-- {
+		node, err := bc.store.BlockNode(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if node.MainChain {
+			return bc.store.BlockAt(height)
+		}
+		block, err = bc.store.Block(block.PrevHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return block, nil
+}
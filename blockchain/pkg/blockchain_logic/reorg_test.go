@@ -0,0 +1,181 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// mineOn mines a block on parent holding txs after a coinbase paying the
+// block subsidy to miner.
+func mineOn(parent *Block, miner string, txs ...*Transaction) *Block {
+	coinbase := newCoinbaseTX(miner, BlockSubsidy(), "")
+
+	return CreateBlock(append([]*Transaction{coinbase}, txs...), parent.Hash, parent.Difficulty)
+}
+
+// addBlocks adds blocks to bc in order.
+func addBlocks(t *testing.T, bc *Blockchain, blocks ...*Block) {
+	t.Helper()
+
+	for _, block := range blocks {
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock() error = %v", err)
+		}
+	}
+}
+
+// pendingSend sends amount from the miner to a new address and returns the
+// pending transaction and the address.
+func pendingSend(t *testing.T, bc *Blockchain, miner string, amount Amount) (*Transaction, string) {
+	t.Helper()
+
+	recipient := string(NewWallet().GetAddress())
+	txID, err := bc.Send(miner, recipient, amount, 0)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	tx, ok := bc.PendingTransaction(txID)
+	if !ok {
+		t.Fatalf("PendingTransaction() of the sent transaction = false")
+	}
+
+	return tx, recipient
+}
+
+func checkTip(t *testing.T, bc *Blockchain, want *Block, height int) {
+	t.Helper()
+
+	tip, err := bc.Store().Tip()
+	if err != nil || tip.Hash != want.Hash {
+		t.Fatalf("Tip() = %v, %v, want %s", tip, err, want.Hash)
+	}
+	if got, err := bc.Height(); err != nil || got != height {
+		t.Errorf("Height() = %d, %v, want %d", got, err, height)
+	}
+}
+
+func checkBalance(t *testing.T, bc *Blockchain, address string, want Amount) {
+	t.Helper()
+
+	if balance, err := bc.GetBalance(address); err != nil || balance != want {
+		t.Errorf("GetBalance() = %v, %v, want %v", balance, err, want)
+	}
+}
+
+func TestBlockchain_Reorganize(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	genesis, err := bc.Store().Tip()
+	if err != nil {
+		t.Fatalf("Tip() error = %v", err)
+	}
+	tx, recipient := pendingSend(t, bc, miner, 10)
+	other := string(NewWallet().GetAddress())
+
+	a1 := mineOn(genesis, miner, tx)
+	b1 := mineOn(genesis, other)
+	b2 := mineOn(b1, other)
+	a2 := mineOn(a1, miner)
+	a3 := mineOn(a2, miner)
+
+	addBlocks(t, bc, a1, b1)
+	// A side branch with as much work does not take over.
+	checkTip(t, bc, a1, 1)
+	checkBalance(t, bc, recipient, 10)
+
+	addBlocks(t, bc, b2)
+	checkTip(t, bc, b2, 2)
+	checkBalance(t, bc, recipient, 0)
+	checkBalance(t, bc, other, 2*BlockSubsidy())
+	if _, ok := bc.PendingTransaction(hex.EncodeToString(tx.ID)); !ok {
+		t.Errorf("PendingTransaction() of the disconnected transaction = false, want true")
+	}
+	info, err := bc.TransactionInfo(hex.EncodeToString(tx.ID))
+	if err != nil || info.Status != TxStatusPending {
+		t.Errorf("TransactionInfo() of the disconnected transaction = %+v, %v, want pending", info, err)
+	}
+	if node, err := bc.Store().BlockNode(a1.Hash); err != nil || node.MainChain {
+		t.Errorf("BlockNode() of the disconnected block = %+v, %v, want it off the main chain", node, err)
+	}
+
+	addBlocks(t, bc, a2)
+	checkTip(t, bc, b2, 2)
+	addBlocks(t, bc, a3)
+	checkTip(t, bc, a3, 3)
+	checkBalance(t, bc, recipient, 10)
+	checkBalance(t, bc, other, 0)
+	if bc.mempool.Len() != 0 {
+		t.Errorf("mempool holds %d transactions, want the confirmed transaction gone", bc.mempool.Len())
+	}
+
+	tips, err := bc.Store().ChainTips()
+	if err != nil || len(tips) != 2 || tips[0].Hash != a3.Hash || tips[1].Hash != b2.Hash {
+		t.Errorf("ChainTips() = %+v, %v, want a3 then b2", tips, err)
+	}
+	report, err := VerifyChain(bc.Store())
+	if err != nil || !report.Valid() {
+		t.Errorf("VerifyChain() = %v, %v, want a valid chain", report, err)
+	}
+}
+
+func TestBlockchain_ReorganizeDropsConflicts(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	genesis, err := bc.Store().Tip()
+	if err != nil {
+		t.Fatalf("Tip() error = %v", err)
+	}
+	// Both transactions spend the genesis coinbase.
+	tx, recipient := pendingSend(t, bc, miner, 10)
+	bc.mempool.Remove([]*Transaction{tx})
+	conflict, conflictRecipient := pendingSend(t, bc, miner, 20)
+	bc.mempool.Remove([]*Transaction{conflict})
+
+	a1 := mineOn(genesis, miner, tx)
+	b1 := mineOn(genesis, miner, conflict)
+	b2 := mineOn(b1, miner)
+	addBlocks(t, bc, a1, b1, b2)
+
+	checkTip(t, bc, b2, 2)
+	checkBalance(t, bc, recipient, 0)
+	checkBalance(t, bc, conflictRecipient, 20)
+	if bc.mempool.Len() != 0 {
+		t.Errorf("mempool holds %d transactions, want the conflicting transaction dropped", bc.mempool.Len())
+	}
+}
+
+func TestBlockchain_ReorganizeToInvalidBranch(t *testing.T) {
+	bc, miner := testBlockchain(t)
+	genesis, err := bc.Store().Tip()
+	if err != nil {
+		t.Fatalf("Tip() error = %v", err)
+	}
+	tx, recipient := pendingSend(t, bc, miner, 10)
+
+	// The header of b1 is valid, its transaction spends an output that does
+	// not exist.
+	bogus := &Transaction{
+		Vin:  []TXInput{{Txid: []byte("missing"), Vout: 0}},
+		Vout: []TXOutput{*NewTXOutput(10, miner)},
+	}
+	bogus.ID = bogus.Hash()
+	a1 := mineOn(genesis, miner, tx)
+	b1 := mineOn(genesis, miner, bogus)
+	b2 := mineOn(b1, miner)
+	b3 := mineOn(b2, miner)
+
+	addBlocks(t, bc, a1, b1)
+	if err = bc.AddBlock(b2); !errors.Is(err, ErrInvalidBlock) {
+		t.Fatalf("AddBlock() of a branch with an invalid block error = %v, want %v", err, ErrInvalidBlock)
+	}
+	checkTip(t, bc, a1, 1)
+	checkBalance(t, bc, recipient, 10)
+	if err = bc.AddBlock(b3); !errors.Is(err, ErrInvalidBlock) {
+		t.Errorf("AddBlock() on an invalid branch error = %v, want %v", err, ErrInvalidBlock)
+	}
+	checkTip(t, bc, a1, 1)
+
+	report, err := VerifyChain(bc.Store())
+	if err != nil || !report.Valid() {
+		t.Errorf("VerifyChain() = %v, %v, want a valid chain", report, err)
+	}
+}
@@ -0,0 +1,251 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrLegacyBlock is returned when reading a block still stored as JSON, before
+// its conversion with ConvertBlockRows.
+var ErrLegacyBlock = errors.New("block is stored in the legacy JSON format")
+
+// ConvertBlockRows encodes the blocks still stored as JSON in the canonical
+// binary format and drops their JSON transactions. Block hashes and
+// transaction IDs are kept as stored; they are recomputed by RewriteChain. It
+// returns the number of converted blocks.
+func ConvertBlockRows(db *sql.DB) (int, error) {
+	rows, err := db.Query("SELECT id, hash, transactions, previous_hash, merkle_root, timestamp, nonce, difficulty FROM blocks WHERE data IS NULL ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	var blocks []*Block
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var block Block
+		var transactionsJSON string
+		err = rows.Scan(&id, &block.Hash, &transactionsJSON, &block.PrevHash, &block.MerkleRoot, &block.Timestamp, &block.Nonce, &block.Difficulty)
+		if err != nil {
+			rows.Close()
+
+			return 0, err
+		}
+		err = json.Unmarshal([]byte(transactionsJSON), &block.Transactions)
+		if err != nil {
+			rows.Close()
+
+			return 0, fmt.Errorf("decode transactions of block %s: %w", block.Hash, err)
+		}
+		blocks = append(blocks, &block)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	dbTx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	for i, block := range blocks {
+		_, err = dbTx.Exec("UPDATE blocks SET data = $1, transactions = NULL WHERE id = $2", block.Serialize(), ids[i])
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err = dbTx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(blocks), nil
+}
+
+// ChainNeedsRewrite reports whether blocks are still stored as JSON, the
+// stored transaction IDs were computed with an older transaction encoding,
+// or the blocks were mined before they committed to a Merkle root or to
+// their timestamp. It happens after a change of the transaction or block
+// format; such chains are converted with ConvertBlockRows and RewriteChain.
+func ChainNeedsRewrite(db *sql.DB) (bool, error) {
+	var legacy bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM blocks WHERE data IS NULL)").Scan(&legacy)
+	if err != nil || legacy {
+		return legacy, err
+	}
+
+	genesis, err := getBlock(db, "SELECT "+blockColumns+" FROM blocks ORDER BY id LIMIT 1")
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if len(genesis.MerkleRoot) == 0 || !NewProof(genesis).Validate() {
+		return true, nil
+	}
+	for _, tx := range genesis.Transactions {
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RewriteChain recomputes every transaction ID with the current encoding,
+// re-signs the inputs with the keys in keys and mines every main chain block
+// again on top of its rewritten parent, committing to its Merkle root and
+// timestamp. Block timestamps and the genesis difficulty are kept, the
+// difficulty of the other blocks is retargeted with the current policy, so
+// it should match the block times of the chain. Side branches are dropped.
+// The utxo set and indexes are rebuilt afterwards. It returns the number of
+// rewritten blocks.
+func RewriteChain(db *sql.DB, keys Keystore) (int, error) {
+	var blocks []*Block
+	var ids []int64
+	rows, err := db.Query("SELECT id, " + blockColumns + " FROM blocks WHERE main_chain ORDER BY height")
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var id int64
+		block, err := scanBlock(idScanner{rows, &id})
+		if err != nil {
+			rows.Close()
+
+			return 0, err
+		}
+		blocks = append(blocks, block)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Transactions already rewritten, by their new ID.
+	rewritten := make(map[string]Transaction)
+	newIDs := make(map[string][]byte)
+	prevHash := "0"
+	works := make([]*big.Int, len(blocks))
+
+	for height, block := range blocks {
+		for _, tx := range block.Transactions {
+			oldID := hex.EncodeToString(tx.ID)
+			err = rewriteTransaction(tx, newIDs, rewritten, keys)
+			if err != nil {
+				return 0, fmt.Errorf("block %s: %w", block.Hash, err)
+			}
+			newIDs[oldID] = tx.ID
+			rewritten[hex.EncodeToString(tx.ID)] = *tx
+		}
+
+		work := blockWork(block.Difficulty)
+		if height > 0 {
+			parent := blocks[height-1]
+			elapsed := parent.Timestamp.Sub(blocks[retargetWindowStart(height)].Timestamp)
+			block.Difficulty = nextDifficulty(height, parent.Difficulty, elapsed)
+			work = new(big.Int).Add(works[height-1], blockWork(block.Difficulty))
+		}
+		works[height] = work
+
+		block.PrevHash = prevHash
+		block.MerkleRoot = block.HashTransactions()
+		nonce, hash := NewProof(block).Run()
+		block.Nonce = nonce
+		block.Hash = hash
+		prevHash = hash
+	}
+
+	dbTx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer dbTx.Rollback() //nolint:errcheck // no-op after commit
+
+	// Side branch blocks point at the old hashes of their parents.
+	_, err = dbTx.Exec("DELETE FROM blocks WHERE NOT main_chain")
+	if err != nil {
+		return 0, err
+	}
+	for i, block := range blocks {
+		_, err = dbTx.Exec("UPDATE blocks SET hash = $1, previous_hash = $2, merkle_root = $3, nonce = $4, difficulty = $5, work = $6, data = $7 WHERE id = $8",
+			block.Hash, block.PrevHash, block.MerkleRoot, block.Nonce, block.Difficulty, works[i].String(), block.Serialize(), ids[i])
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, table := range []string{"utxo", "tx_index", "address_tx"} {
+		_, err = dbTx.Exec("DELETE FROM " + table)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(blocks), nil
+}
+
+// rewriteTransaction points the inputs of tx at the new IDs of the
+// transactions they spend, then recomputes its ID and signatures. Every input
+// is signed with the key of the address it spends from. Transactions already
+// in the current format are kept, so a chain that only lacks Merkle roots
+// needs no keys.
+func rewriteTransaction(tx *Transaction, newIDs map[string][]byte, rewritten map[string]Transaction, keys Keystore) error {
+	if tx.IsCoinbase() {
+		tx.ID = tx.Hash()
+
+		return nil
+	}
+	current := bytes.Equal(tx.ID, tx.Hash())
+	for _, in := range tx.Vin {
+		current = current && bytes.Equal(newIDs[hex.EncodeToString(in.Txid)], in.Txid)
+	}
+	if current {
+		return nil
+	}
+
+	signers := make([]Wallet, len(tx.Vin))
+	for i, in := range tx.Vin {
+		newID, ok := newIDs[hex.EncodeToString(in.Txid)]
+		if !ok {
+			return fmt.Errorf("transaction %x spends unknown transaction %x", tx.ID, in.Txid)
+		}
+		tx.Vin[i].Txid = newID
+		if len(in.UnlockScript) > 0 {
+			return fmt.Errorf("transaction %x: inputs unlocked by a script can not be signed again", tx.ID)
+		}
+
+		address := AddressFromPubKeyHash(HashPubKey(in.PubKey))
+		wallet, err := keys.Get(address)
+		if err != nil {
+			return fmt.Errorf("transaction %x: key of %s: %w", tx.ID, address, err)
+		}
+		signers[i] = *wallet
+	}
+
+	tx.ID = tx.Hash()
+
+	return tx.SignInputs(signers, rewritten)
+}
+
+// idScanner scans a leading id column before the block columns.
+type idScanner struct {
+	row rowScanner
+	id  *int64
+}
+
+func (s idScanner) Scan(dest ...any) error {
+	return s.row.Scan(append([]any{s.id}, dest...)...)
+}
@@ -0,0 +1,73 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestRewriteTransaction(t *testing.T) {
+	owner := NewWallet()
+	wallets := &Wallets{Wallets: map[string]*Wallet{string(owner.GetAddress()): owner}}
+
+	coinbase := NewCoinbaseTX(string(owner.GetAddress()), "")
+	spend, _ := newSignedSpend(t, owner, owner)
+
+	// Simulate IDs computed with an older encoding.
+	oldCoinbaseID := []byte("old coinbase id")
+	coinbase.ID = oldCoinbaseID
+	spend.Vin[0].Txid = oldCoinbaseID
+	spend.ID = []byte("old spend id")
+
+	newIDs := make(map[string][]byte)
+	rewritten := make(map[string]Transaction)
+
+	if err := rewriteTransaction(coinbase, newIDs, rewritten, wallets); err != nil {
+		t.Fatalf("rewriteTransaction(coinbase) error = %v", err)
+	}
+	newIDs[hex.EncodeToString(oldCoinbaseID)] = coinbase.ID
+	rewritten[hex.EncodeToString(coinbase.ID)] = *coinbase
+
+	if err := rewriteTransaction(spend, newIDs, rewritten, wallets); err != nil {
+		t.Fatalf("rewriteTransaction(spend) error = %v", err)
+	}
+
+	if string(spend.Vin[0].Txid) != string(coinbase.ID) {
+		t.Errorf("input points at %x, want rewritten coinbase %x", spend.Vin[0].Txid, coinbase.ID)
+	}
+	if string(spend.ID) != string(spend.Hash()) {
+		t.Errorf("ID %x does not match hash %x", spend.ID, spend.Hash())
+	}
+	if !spend.Verify(rewritten) {
+		t.Errorf("Verify() of rewritten transaction = false, want true")
+	}
+}
+
+func TestRewriteTransaction_MissingKey(t *testing.T) {
+	owner := NewWallet()
+	spend, prevTXs := newSignedSpend(t, owner, owner)
+
+	spend.ID = []byte("old spend id")
+
+	newIDs := map[string][]byte{hex.EncodeToString(spend.Vin[0].Txid): spend.Vin[0].Txid}
+	wallets := &Wallets{Wallets: map[string]*Wallet{}}
+
+	if err := rewriteTransaction(spend, newIDs, prevTXs, wallets); err == nil {
+		t.Errorf("rewriteTransaction() without the owner key error = nil, want error")
+	}
+}
+
+func TestRewriteTransaction_Current(t *testing.T) {
+	owner := NewWallet()
+	spend, prevTXs := newSignedSpend(t, owner, owner)
+	signature := spend.Vin[0].Signature
+
+	newIDs := map[string][]byte{hex.EncodeToString(spend.Vin[0].Txid): spend.Vin[0].Txid}
+	wallets := &Wallets{Wallets: map[string]*Wallet{}}
+
+	if err := rewriteTransaction(spend, newIDs, prevTXs, wallets); err != nil {
+		t.Fatalf("rewriteTransaction() of a current transaction error = %v", err)
+	}
+	if string(spend.Vin[0].Signature) != string(signature) {
+		t.Errorf("rewriteTransaction() signed a current transaction again")
+	}
+}
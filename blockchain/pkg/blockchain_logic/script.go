@@ -0,0 +1,695 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Script is a program of the stack based transaction script language. An
+// output is locked with a locking script; the input spending it provides an
+// unlocking script pushing the data the locking script needs, typically
+// signatures and public keys. The input is valid when running the unlocking
+// script, then the locking script on the resulting stack succeeds with a true
+// value on top of the stack.
+type Script []byte
+
+// Opcodes of the script language. Their values follow Bitcoin script. Bytes
+// 0x01 to 0x4b push the next that many bytes.
+const (
+	Op0                   byte = 0x00
+	OpPushData1           byte = 0x4c
+	OpPushData2           byte = 0x4d
+	Op1Negate             byte = 0x4f
+	Op1                   byte = 0x51
+	Op16                  byte = 0x60
+	OpNop                 byte = 0x61
+	OpIf                  byte = 0x63
+	OpNotIf               byte = 0x64
+	OpElse                byte = 0x67
+	OpEndIf               byte = 0x68
+	OpVerify              byte = 0x69
+	OpReturn              byte = 0x6a
+	OpDrop                byte = 0x75
+	OpDup                 byte = 0x76
+	OpSwap                byte = 0x7c
+	OpSize                byte = 0x82
+	OpEqual               byte = 0x87
+	OpEqualVerify         byte = 0x88
+	OpSHA256              byte = 0xa8
+	OpHash160             byte = 0xa9
+	OpCheckSig            byte = 0xac
+	OpCheckSigVerify      byte = 0xad
+	OpCheckMultiSig       byte = 0xae
+	OpCheckMultiSigVerify byte = 0xaf
+	OpCheckLockTimeVerify byte = 0xb1
+	OpCheckSequenceVerify byte = 0xb2
+)
+
+// Limits of the script interpreter.
+const (
+	MaxScriptSize = 10000
+	// MaxScriptOps bounds the opcodes other than pushes run by a script. A
+	// multisig check counts one more for each of its public keys.
+	MaxScriptOps          = 201
+	MaxStackSize          = 1000
+	MaxScriptElementSize  = 520
+	MaxMultisigPubKeys    = 20
+	maxScriptNumLen       = 4
+	maxLockTimeScriptNums = 5
+)
+
+// LockTimeThreshold separates the two meanings of a lock time: below it, a
+// block height, from it on, a Unix time in seconds.
+const LockTimeThreshold = 500000000
+
+// ErrScriptFailed is returned when a script does not succeed. The wrapped
+// message says why.
+var ErrScriptFailed = errors.New("script failed")
+
+// scriptError returns an ErrScriptFailed with a reason.
+func scriptError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrScriptFailed, fmt.Sprintf(format, args...))
+}
+
+// P2PKHScript returns the pay-to-pubkey-hash locking script, spent with a
+// signature and the public key hashing to pubKeyHash:
+//
+//	OP_DUP OP_HASH160 <pubKeyHash> OP_EQUALVERIFY OP_CHECKSIG
+func P2PKHScript(pubKeyHash []byte) Script {
+	s := Script{OpDup, OpHash160}
+	s = s.AddData(pubKeyHash)
+
+	return append(s, OpEqualVerify, OpCheckSig)
+}
+
+// MultisigScript returns a locking script spent with m signatures of the
+// keys in pubKeys, given in the order of their keys:
+//
+//	<m> <pubKey>... <n> OP_CHECKMULTISIG
+func MultisigScript(m int, pubKeys [][]byte) (Script, error) {
+	n := len(pubKeys)
+	if n == 0 || n > MaxMultisigPubKeys {
+		return nil, fmt.Errorf("multisig needs 1 to %d public keys, got %d", MaxMultisigPubKeys, n)
+	}
+	if m < 1 || m > n {
+		return nil, fmt.Errorf("multisig needs 1 to %d signatures, got %d", n, m)
+	}
+
+	s := Script{}.AddInt(int64(m))
+	for _, pubKey := range pubKeys {
+		s = s.AddData(pubKey)
+	}
+
+	return append(s.AddInt(int64(n)), OpCheckMultiSig), nil
+}
+
+// LockTimeScript prefixes lock with an absolute timelock: the output can only
+// be spent by a transaction whose LockTime is at least lockTime, a block
+// height or a Unix time as told by LockTimeThreshold.
+//
+//	<lockTime> OP_CHECKLOCKTIMEVERIFY OP_DROP <lock>
+func LockTimeScript(lockTime int64, lock Script) Script {
+	s := Script{}.AddInt(lockTime)
+
+	return append(append(s, OpCheckLockTimeVerify, OpDrop), lock...)
+}
+
+// RelativeLockScript prefixes lock with a relative timelock: the output can
+// only be spent by an input whose Sequence is at least blocks, so once it is
+// buried under that many blocks.
+//
+//	<blocks> OP_CHECKSEQUENCEVERIFY OP_DROP <lock>
+func RelativeLockScript(blocks int64, lock Script) Script {
+	s := Script{}.AddInt(blocks)
+
+	return append(append(s, OpCheckSequenceVerify, OpDrop), lock...)
+}
+
+// HashLockScript prefixes lock with a hash lock: the unlocking script must
+// push, last, the preimage whose SHA-256 is hash.
+//
+//	OP_SHA256 <hash> OP_EQUALVERIFY <lock>
+func HashLockScript(hash []byte, lock Script) Script {
+	s := Script{OpSHA256}.AddData(hash)
+
+	return append(append(s, OpEqualVerify), lock...)
+}
+
+// UnlockScript returns the script pushing items in order, so the last one
+// ends on top of the stack.
+func UnlockScript(items ...[]byte) Script {
+	s := Script{}
+	for _, item := range items {
+		s = s.AddData(item)
+	}
+
+	return s
+}
+
+// AddData appends the shortest push of data.
+func (s Script) AddData(data []byte) Script {
+	n := len(data)
+	switch {
+	case n == 0:
+		return append(s, Op0)
+	case n < int(OpPushData1):
+		s = append(s, byte(n))
+	case n <= 0xff:
+		s = append(s, OpPushData1, byte(n))
+	default:
+		s = append(s, OpPushData2, byte(n), byte(n>>8))
+	}
+
+	return append(s, data...)
+}
+
+// AddInt appends the push of the script number n.
+func (s Script) AddInt(n int64) Script {
+	switch {
+	case n == 0:
+		return append(s, Op0)
+	case n == -1:
+		return append(s, Op1Negate)
+	case n >= 1 && n <= 16:
+		return append(s, Op1+byte(n-1))
+	default:
+		return s.AddData(encodeScriptNum(n))
+	}
+}
+
+// P2PKHHash returns the public key hash of a P2PKH locking script.
+func (s Script) P2PKHHash() ([]byte, bool) {
+	if len(s) != 25 || s[0] != OpDup || s[1] != OpHash160 || s[2] != pubKeyHashLen ||
+		s[23] != OpEqualVerify || s[24] != OpCheckSig {
+		return nil, false
+	}
+
+	return append([]byte(nil), s[3:23]...), true
+}
+
+// scriptOp is a parsed instruction: an opcode and the data it pushes.
+type scriptOp struct {
+	code byte
+	data []byte
+}
+
+// parse splits s into instructions.
+func (s Script) parse() ([]scriptOp, error) {
+	if len(s) > MaxScriptSize {
+		return nil, scriptError("script is %d bytes, more than %d", len(s), MaxScriptSize)
+	}
+
+	var ops []scriptOp
+	for i := 0; i < len(s); {
+		code := s[i]
+		i++
+		var n int
+		switch {
+		case code > Op0 && code < OpPushData1:
+			n = int(code)
+		case code == OpPushData1:
+			if i+1 > len(s) {
+				return nil, scriptError("truncated push")
+			}
+			n = int(s[i])
+			i++
+		case code == OpPushData2:
+			if i+2 > len(s) {
+				return nil, scriptError("truncated push")
+			}
+			n = int(binary.LittleEndian.Uint16(s[i:]))
+			i += 2
+		default:
+			ops = append(ops, scriptOp{code: code})
+
+			continue
+		}
+		if i+n > len(s) {
+			return nil, scriptError("truncated push")
+		}
+		ops = append(ops, scriptOp{code: code, data: s[i : i+n]})
+		i += n
+	}
+
+	return ops, nil
+}
+
+// Validate reports whether s parses within the interpreter limits.
+func (s Script) Validate() error {
+	_, err := s.parse()
+
+	return err
+}
+
+// isPush reports whether op only pushes data.
+func (op scriptOp) isPush() bool {
+	return op.code <= OpPushData2 || (op.code >= Op1Negate && op.code <= Op16 && op.code != Op1Negate+1)
+}
+
+// scriptContext is what the signature and timelock checks of an input are
+// evaluated against.
+type scriptContext struct {
+	tx      *Transaction
+	input   int
+	sigHash []byte
+}
+
+// VerifyScript runs unlock then lock for input i of tx, whose signatures
+// commit to sigHash. unlock may only push data.
+func VerifyScript(unlock, lock Script, tx *Transaction, i int, sigHash []byte) error {
+	unlockOps, err := unlock.parse()
+	if err != nil {
+		return err
+	}
+	for _, op := range unlockOps {
+		if !op.isPush() {
+			return scriptError("unlocking script does more than push data")
+		}
+	}
+	lockOps, err := lock.parse()
+	if err != nil {
+		return err
+	}
+
+	vm := &scriptVM{ctx: scriptContext{tx, i, sigHash}}
+	if err = vm.run(unlockOps); err != nil {
+		return err
+	}
+	if err = vm.run(lockOps); err != nil {
+		return err
+	}
+	if len(vm.stack) == 0 || !castToBool(vm.stack[len(vm.stack)-1]) {
+		return scriptError("script ends with a false value")
+	}
+
+	return nil
+}
+
+// scriptVM holds the stack shared by the unlocking and locking scripts.
+type scriptVM struct {
+	ctx   scriptContext
+	stack [][]byte
+	// ops counts the opcodes other than pushes.
+	ops int
+}
+
+func (vm *scriptVM) run(ops []scriptOp) error {
+	// conds holds, for every open OP_IF, whether its branch runs.
+	var conds []bool
+	executing := func() bool {
+		for _, c := range conds {
+			if !c {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for _, op := range ops {
+		if len(op.data) > MaxScriptElementSize {
+			return scriptError("pushed %d bytes, more than %d", len(op.data), MaxScriptElementSize)
+		}
+		if !op.isPush() {
+			vm.ops++
+			if vm.ops > MaxScriptOps {
+				return scriptError("more than %d operations", MaxScriptOps)
+			}
+		}
+
+		switch op.code {
+		case OpIf, OpNotIf:
+			cond := false
+			if executing() {
+				v, err := vm.pop()
+				if err != nil {
+					return err
+				}
+				cond = castToBool(v) == (op.code == OpIf)
+			}
+			conds = append(conds, cond)
+
+			continue
+		case OpElse:
+			if len(conds) == 0 {
+				return scriptError("OP_ELSE without OP_IF")
+			}
+			conds[len(conds)-1] = !conds[len(conds)-1]
+
+			continue
+		case OpEndIf:
+			if len(conds) == 0 {
+				return scriptError("OP_ENDIF without OP_IF")
+			}
+			conds = conds[:len(conds)-1]
+
+			continue
+		}
+		if !executing() {
+			continue
+		}
+
+		if err := vm.step(op); err != nil {
+			return err
+		}
+		if len(vm.stack) > MaxStackSize {
+			return scriptError("stack holds more than %d items", MaxStackSize)
+		}
+	}
+	if len(conds) > 0 {
+		return scriptError("OP_IF without OP_ENDIF")
+	}
+
+	return nil
+}
+
+// step runs an instruction other than a flow control one.
+func (vm *scriptVM) step(op scriptOp) error {
+	switch {
+	case op.code == Op0:
+		vm.push(nil)
+
+		return nil
+	case op.code <= OpPushData2:
+		vm.push(op.data)
+
+		return nil
+	case op.code == Op1Negate:
+		vm.push(encodeScriptNum(-1))
+
+		return nil
+	case op.code >= Op1 && op.code <= Op16:
+		vm.push(encodeScriptNum(int64(op.code-Op1) + 1))
+
+		return nil
+	}
+
+	switch op.code {
+	case OpNop:
+	case OpVerify:
+		return vm.verify()
+	case OpReturn:
+		return scriptError("OP_RETURN")
+	case OpDrop:
+		_, err := vm.pop()
+
+		return err
+	case OpDup:
+		v, err := vm.peek(0)
+		if err != nil {
+			return err
+		}
+		vm.push(v)
+	case OpSwap:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.push(b)
+		vm.push(a)
+	case OpSize:
+		v, err := vm.peek(0)
+		if err != nil {
+			return err
+		}
+		vm.push(encodeScriptNum(int64(len(v))))
+	case OpEqual, OpEqualVerify:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.pushBool(bytes.Equal(a, b))
+		if op.code == OpEqualVerify {
+			return vm.verify()
+		}
+	case OpSHA256:
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		h := sha256.Sum256(v)
+		vm.push(h[:])
+	case OpHash160:
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.push(HashPubKey(v))
+	case OpCheckSig, OpCheckSigVerify:
+		pubKey, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		sig, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.pushBool(VerifySignature(pubKey, vm.ctx.sigHash, sig))
+		if op.code == OpCheckSigVerify {
+			return vm.verify()
+		}
+	case OpCheckMultiSig, OpCheckMultiSigVerify:
+		ok, err := vm.checkMultiSig()
+		if err != nil {
+			return err
+		}
+		vm.pushBool(ok)
+		if op.code == OpCheckMultiSigVerify {
+			return vm.verify()
+		}
+	case OpCheckLockTimeVerify:
+		return vm.checkLockTime()
+	case OpCheckSequenceVerify:
+		return vm.checkSequence()
+	default:
+		return scriptError("unknown opcode 0x%02x", op.code)
+	}
+
+	return nil
+}
+
+// checkMultiSig pops <sig>... <m> <pubKey>... <n> and reports whether the
+// m signatures are made by keys among the n, in the same order.
+func (vm *scriptVM) checkMultiSig() (bool, error) {
+	n, err := vm.popInt(maxScriptNumLen)
+	if err != nil {
+		return false, err
+	}
+	if n < 0 || n > MaxMultisigPubKeys {
+		return false, scriptError("multisig with %d public keys", n)
+	}
+	vm.ops += int(n)
+	if vm.ops > MaxScriptOps {
+		return false, scriptError("more than %d operations", MaxScriptOps)
+	}
+	pubKeys := make([][]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		if pubKeys[i], err = vm.pop(); err != nil {
+			return false, err
+		}
+	}
+	m, err := vm.popInt(maxScriptNumLen)
+	if err != nil {
+		return false, err
+	}
+	if m < 0 || m > n {
+		return false, scriptError("multisig with %d signatures of %d keys", m, n)
+	}
+	sigs := make([][]byte, m)
+	for i := m - 1; i >= 0; i-- {
+		if sigs[i], err = vm.pop(); err != nil {
+			return false, err
+		}
+	}
+
+	// Every signature must match a key after the key of the previous one.
+	k := 0
+	for _, sig := range sigs {
+		for k < len(pubKeys) && !VerifySignature(pubKeys[k], vm.ctx.sigHash, sig) {
+			k++
+		}
+		if k == len(pubKeys) {
+			return false, nil
+		}
+		k++
+	}
+
+	return true, nil
+}
+
+// checkLockTime fails unless the transaction lock time is at least the lock
+// time on top of the stack, of the same kind. The stack is left unchanged.
+func (vm *scriptVM) checkLockTime() error {
+	top, err := vm.peek(0)
+	if err != nil {
+		return err
+	}
+	lockTime, err := decodeScriptNum(top, maxLockTimeScriptNums)
+	if err != nil {
+		return err
+	}
+	if lockTime < 0 {
+		return scriptError("negative lock time")
+	}
+	txLockTime := vm.ctx.tx.LockTime
+	if (lockTime < LockTimeThreshold) != (txLockTime < LockTimeThreshold) {
+		return scriptError("lock time %d and transaction lock time %d are not of the same kind", lockTime, txLockTime)
+	}
+	if txLockTime < lockTime {
+		return scriptError("transaction lock time %d is before %d", txLockTime, lockTime)
+	}
+
+	return nil
+}
+
+// checkSequence fails unless the input sequence is at least the number of
+// blocks on top of the stack. The stack is left unchanged.
+func (vm *scriptVM) checkSequence() error {
+	top, err := vm.peek(0)
+	if err != nil {
+		return err
+	}
+	blocks, err := decodeScriptNum(top, maxLockTimeScriptNums)
+	if err != nil {
+		return err
+	}
+	if blocks < 0 {
+		return scriptError("negative relative lock")
+	}
+	if sequence := vm.ctx.tx.Vin[vm.ctx.input].Sequence; sequence < blocks {
+		return scriptError("input sequence %d is below %d", sequence, blocks)
+	}
+
+	return nil
+}
+
+func (vm *scriptVM) push(v []byte) {
+	vm.stack = append(vm.stack, v)
+}
+
+func (vm *scriptVM) pushBool(b bool) {
+	if b {
+		vm.push([]byte{1})
+	} else {
+		vm.push(nil)
+	}
+}
+
+func (vm *scriptVM) pop() ([]byte, error) {
+	if len(vm.stack) == 0 {
+		return nil, scriptError("stack is empty")
+	}
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+
+	return v, nil
+}
+
+// peek returns the item i positions below the top of the stack.
+func (vm *scriptVM) peek(i int) ([]byte, error) {
+	if i >= len(vm.stack) {
+		return nil, scriptError("stack is empty")
+	}
+
+	return vm.stack[len(vm.stack)-1-i], nil
+}
+
+func (vm *scriptVM) popInt(maxLen int) (int64, error) {
+	v, err := vm.pop()
+	if err != nil {
+		return 0, err
+	}
+
+	return decodeScriptNum(v, maxLen)
+}
+
+func (vm *scriptVM) verify() error {
+	v, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	if !castToBool(v) {
+		return scriptError("verify failed")
+	}
+
+	return nil
+}
+
+// castToBool is false for empty values and for any encoding of zero,
+// including negative zero.
+func castToBool(v []byte) bool {
+	for i, b := range v {
+		if b != 0 {
+			return !(i == len(v)-1 && b == 0x80)
+		}
+	}
+
+	return false
+}
+
+// encodeScriptNum encodes n in the minimal little-endian sign and magnitude
+// form of script numbers. Zero is empty.
+func encodeScriptNum(n int64) []byte {
+	if n == 0 {
+		return nil
+	}
+	negative := n < 0
+	abs := uint64(n)
+	if negative {
+		abs = uint64(-n)
+	}
+
+	var b []byte
+	for abs > 0 {
+		b = append(b, byte(abs))
+		abs >>= 8
+	}
+	// The top bit holds the sign, add a byte when the magnitude uses it.
+	if b[len(b)-1]&0x80 != 0 {
+		extra := byte(0)
+		if negative {
+			extra = 0x80
+		}
+		b = append(b, extra)
+	} else if negative {
+		b[len(b)-1] |= 0x80
+	}
+
+	return b
+}
+
+// decodeScriptNum decodes a minimally encoded script number of at most
+// maxLen bytes.
+func decodeScriptNum(b []byte, maxLen int) (int64, error) {
+	if len(b) > maxLen {
+		return 0, scriptError("number is %d bytes, more than %d", len(b), maxLen)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	// The last byte may only be a sign byte when the previous one needs it.
+	if b[len(b)-1]&0x7f == 0 && (len(b) == 1 || b[len(b)-2]&0x80 == 0) {
+		return 0, scriptError("number is not minimally encoded")
+	}
+
+	var n int64
+	for i, v := range b {
+		n |= int64(v) << (8 * i)
+	}
+	if b[len(b)-1]&0x80 != 0 {
+		return -(n &^ (int64(0x80) << (8 * (len(b) - 1)))), nil
+	}
+
+	return n, nil
+}
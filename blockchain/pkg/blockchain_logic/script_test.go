@@ -0,0 +1,307 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+// scriptSpend returns a transaction spending a coinbase output locked with
+// lock, and the sighash of its input.
+func scriptSpend(t *testing.T, lock Script) (*Transaction, map[string]Transaction, []byte) {
+	t.Helper()
+
+	prevTx := &Transaction{
+		Vin:  []TXInput{{Txid: []byte{}, Vout: -1, PubKey: []byte("script test")}},
+		Vout: []TXOutput{*NewScriptOutput(genesisReward(), lock)},
+	}
+	prevTx.ID = prevTx.Hash()
+	prevTXs := map[string]Transaction{hex.EncodeToString(prevTx.ID): *prevTx}
+
+	tx := &Transaction{
+		Vin:  []TXInput{{Txid: prevTx.ID, Vout: 0}},
+		Vout: []TXOutput{*NewTXOutput(10, string(NewWallet().GetAddress()))},
+	}
+
+	return tx, prevTXs, sigHash(t, tx, prevTXs)
+}
+
+func sigHash(t *testing.T, tx *Transaction, prevTXs map[string]Transaction) []byte {
+	t.Helper()
+
+	tx.ID = tx.Hash()
+	hashes, err := tx.sigHashes(prevTXs)
+	if err != nil {
+		t.Fatalf("sigHashes() error = %v", err)
+	}
+
+	return hashes[0]
+}
+
+func sign(t *testing.T, w *Wallet, hash []byte) []byte {
+	t.Helper()
+
+	sig, err := w.Sign(hash)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	return sig
+}
+
+func TestScript_P2PKHTemplate(t *testing.T) {
+	owner, thief := NewWallet(), NewWalletWithKeyType(KeyTypeEd25519)
+	lock := P2PKHScript(HashPubKey(owner.PublicKey))
+
+	out := NewScriptOutput(1, lock)
+	if len(out.Script) != 0 || !bytes.Equal(out.PubKeyHash, HashPubKey(owner.PublicKey)) {
+		t.Fatalf("NewScriptOutput() of a P2PKH script = %+v, want a public key hash output", out)
+	}
+	if !bytes.Equal(out.LockingScript(), lock) {
+		t.Errorf("LockingScript() = %x, want %x", out.LockingScript(), lock)
+	}
+
+	tx, prevTXs, hash := scriptSpend(t, lock)
+	tx.Vin[0].Signature, tx.Vin[0].PubKey = sign(t, owner, hash), owner.PublicKey
+	if err := tx.verifyScripts(prevTXs); err != nil {
+		t.Errorf("verifyScripts() legacy input error = %v", err)
+	}
+
+	tx.Vin[0].Signature, tx.Vin[0].PubKey = nil, nil
+	tx.Vin[0].UnlockScript = UnlockScript(sign(t, owner, hash), owner.PublicKey)
+	if err := tx.verifyScripts(prevTXs); err != nil {
+		t.Errorf("verifyScripts() unlocking script error = %v", err)
+	}
+
+	tx.Vin[0].UnlockScript = UnlockScript(sign(t, thief, hash), thief.PublicKey)
+	if err := tx.verifyScripts(prevTXs); !errors.Is(err, ErrScriptFailed) {
+		t.Errorf("verifyScripts() foreign key error = %v, want %v", err, ErrScriptFailed)
+	}
+}
+
+func TestScript_Multisig(t *testing.T) {
+	keys := []*Wallet{NewWallet(), NewWalletWithKeyType(KeyTypeEd25519), NewWallet()}
+	lock, err := MultisigScript(2, [][]byte{keys[0].PublicKey, keys[1].PublicKey, keys[2].PublicKey})
+	if err != nil {
+		t.Fatalf("MultisigScript() error = %v", err)
+	}
+	tx, prevTXs, hash := scriptSpend(t, lock)
+
+	tests := []struct {
+		name    string
+		signers []*Wallet
+		wantErr bool
+	}{
+		{name: "first and second", signers: []*Wallet{keys[0], keys[1]}},
+		{name: "first and third", signers: []*Wallet{keys[0], keys[2]}},
+		{name: "out of order", signers: []*Wallet{keys[2], keys[0]}, wantErr: true},
+		{name: "one signature", signers: []*Wallet{keys[1]}, wantErr: true},
+		{name: "same key twice", signers: []*Wallet{keys[0], keys[0]}, wantErr: true},
+		{name: "foreign key", signers: []*Wallet{keys[0], NewWallet()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigs := make([][]byte, len(tt.signers))
+			for i, w := range tt.signers {
+				sigs[i] = sign(t, w, hash)
+			}
+			tx.Vin[0].UnlockScript = UnlockScript(sigs...)
+
+			err := tx.verifyScripts(prevTXs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyScripts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err = MultisigScript(3, [][]byte{keys[0].PublicKey}); err == nil {
+		t.Errorf("MultisigScript() 3 of 1 error = nil, want an error")
+	}
+}
+
+func TestScript_HashLock(t *testing.T) {
+	owner := NewWalletWithKeyType(KeyTypeEd25519)
+	preimage := []byte("the secret")
+	hash := sha256.Sum256(preimage)
+	lock := HashLockScript(hash[:], P2PKHScript(HashPubKey(owner.PublicKey)))
+	tx, prevTXs, spendHash := scriptSpend(t, lock)
+	sig := sign(t, owner, spendHash)
+
+	tx.Vin[0].UnlockScript = UnlockScript(sig, owner.PublicKey, preimage)
+	if err := tx.verifyScripts(prevTXs); err != nil {
+		t.Errorf("verifyScripts() with the preimage error = %v", err)
+	}
+	tx.Vin[0].UnlockScript = UnlockScript(sig, owner.PublicKey, []byte("a guess"))
+	if err := tx.verifyScripts(prevTXs); !errors.Is(err, ErrScriptFailed) {
+		t.Errorf("verifyScripts() with a wrong preimage error = %v, want %v", err, ErrScriptFailed)
+	}
+}
+
+func TestScript_LockTime(t *testing.T) {
+	owner := NewWallet()
+	p2pkh := P2PKHScript(HashPubKey(owner.PublicKey))
+	unixTime := int64(1700000000)
+
+	tests := []struct {
+		name     string
+		lock     int64
+		lockTime int64
+		wantErr  bool
+	}{
+		{name: "height reached", lock: 100, lockTime: 100},
+		{name: "height passed", lock: 100, lockTime: 250},
+		{name: "height not reached", lock: 100, lockTime: 99, wantErr: true},
+		{name: "time reached", lock: unixTime, lockTime: unixTime + 60},
+		{name: "time not reached", lock: unixTime, lockTime: unixTime - 1, wantErr: true},
+		{name: "height against time", lock: 100, lockTime: unixTime, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, prevTXs, _ := scriptSpend(t, LockTimeScript(tt.lock, p2pkh))
+			tx.LockTime = tt.lockTime
+			hash := sigHash(t, tx, prevTXs)
+			tx.Vin[0].UnlockScript = UnlockScript(sign(t, owner, hash), owner.PublicKey)
+
+			err := tx.verifyScripts(prevTXs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyScripts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScript_RelativeLock(t *testing.T) {
+	owner := NewWallet()
+	lock := RelativeLockScript(10, P2PKHScript(HashPubKey(owner.PublicKey)))
+
+	for _, tt := range []struct {
+		sequence int64
+		wantErr  bool
+	}{{sequence: 10}, {sequence: 12}, {sequence: 9, wantErr: true}} {
+		tx, prevTXs, _ := scriptSpend(t, lock)
+		tx.Vin[0].Sequence = tt.sequence
+		hash := sigHash(t, tx, prevTXs)
+		tx.Vin[0].UnlockScript = UnlockScript(sign(t, owner, hash), owner.PublicKey)
+
+		err := tx.verifyScripts(prevTXs)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("verifyScripts() sequence %d error = %v, wantErr %v", tt.sequence, err, tt.wantErr)
+		}
+	}
+}
+
+func TestScript_SigHashCoversLocks(t *testing.T) {
+	owner := NewWallet()
+	tx, prevTXs, _ := scriptSpend(t, LockTimeScript(100, P2PKHScript(HashPubKey(owner.PublicKey))))
+	tx.LockTime = 100
+	hash := sigHash(t, tx, prevTXs)
+	tx.Vin[0].UnlockScript = UnlockScript(sign(t, owner, hash), owner.PublicKey)
+
+	tx.LockTime = 200
+	if err := tx.verifyScripts(prevTXs); err == nil {
+		t.Errorf("verifyScripts() after changing the lock time error = nil, want an error")
+	}
+}
+
+func TestCheckLocks(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	outputHeight := func([]byte) (int, error) { return 90, nil }
+
+	tests := []struct {
+		name     string
+		lockTime int64
+		sequence int64
+		wantErr  bool
+	}{
+		{name: "no locks"},
+		{name: "height reached", lockTime: 100},
+		{name: "height not reached", lockTime: 101, wantErr: true},
+		{name: "time reached", lockTime: now.Unix()},
+		{name: "time not reached", lockTime: now.Unix() + 1, wantErr: true},
+		{name: "buried deep enough", sequence: 10},
+		{name: "not buried deep enough", sequence: 11, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &Transaction{
+				Vin:      []TXInput{{Txid: []byte{1}, Vout: 0, Sequence: tt.sequence}},
+				LockTime: tt.lockTime,
+			}
+			err := checkLocks(tx, 100, now, outputHeight)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkLocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyScript_Limits(t *testing.T) {
+	tx := &Transaction{Vin: []TXInput{{}}}
+	many := func(n int, op byte) Script {
+		return bytes.Repeat([]byte{op}, n)
+	}
+
+	tests := []struct {
+		name   string
+		unlock Script
+		lock   Script
+	}{
+		{name: "too many operations", unlock: Script{Op1}, lock: many(MaxScriptOps+1, OpNop)},
+		{name: "script too large", unlock: Script{Op1}, lock: append(many(MaxScriptSize, OpNop), Op1)},
+		{name: "element too large", unlock: UnlockScript(make([]byte, MaxScriptElementSize+1)), lock: Script{OpDrop, Op1}},
+		{name: "stack too large", unlock: many(MaxStackSize+1, Op1), lock: Script{Op1}},
+		{name: "unlocking script runs code", unlock: Script{Op1, OpDup}, lock: Script{OpEqual}},
+		{name: "op return", unlock: Script{Op1}, lock: Script{OpReturn}},
+		{name: "unknown opcode", unlock: Script{Op1}, lock: Script{0xff}},
+		{name: "truncated push", unlock: Script{Op1}, lock: Script{0x05, 1, 2}},
+		{name: "unbalanced if", unlock: Script{Op1}, lock: Script{OpIf, Op1}},
+		{name: "empty stack", unlock: Script{}, lock: Script{OpDrop}},
+		{name: "false result", unlock: Script{Op1}, lock: Script{Op0}},
+		{name: "negative zero is false", unlock: UnlockScript([]byte{0x80}), lock: Script{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyScript(tt.unlock, tt.lock, tx, 0, nil); !errors.Is(err, ErrScriptFailed) {
+				t.Errorf("VerifyScript() error = %v, want %v", err, ErrScriptFailed)
+			}
+		})
+	}
+}
+
+func TestVerifyScript_Conditionals(t *testing.T) {
+	tx := &Transaction{Vin: []TXInput{{}}}
+	// Pushes 2 when given true, 3 otherwise, then checks the result.
+	lock := Script{OpIf, Op1 + 1, OpElse, Op1 + 2, OpEndIf}
+
+	if err := VerifyScript(Script{Op1 + 1, Op1}, append(lock, OpEqual), tx, 0, nil); err != nil {
+		t.Errorf("VerifyScript() true branch error = %v", err)
+	}
+	if err := VerifyScript(Script{Op1 + 2, Op0}, append(lock, OpEqual), tx, 0, nil); err != nil {
+		t.Errorf("VerifyScript() false branch error = %v", err)
+	}
+	if err := VerifyScript(Script{Op1 + 1, Op0}, append(lock, OpEqual), tx, 0, nil); err == nil {
+		t.Errorf("VerifyScript() wrong branch error = nil, want an error")
+	}
+}
+
+func TestScriptNum(t *testing.T) {
+	for _, n := range []int64{0, 1, -1, 16, 127, 128, -128, 255, 256, 32767, -32768, 1 << 31, LockTimeThreshold} {
+		b := encodeScriptNum(n)
+		got, err := decodeScriptNum(b, maxLockTimeScriptNums)
+		if err != nil || got != n {
+			t.Errorf("decodeScriptNum(encodeScriptNum(%d) = %x) = %d, %v", n, b, got, err)
+		}
+	}
+
+	for _, b := range [][]byte{{0x00}, {0x01, 0x00}, {0x80}, {0x05, 0x80}} {
+		if _, err := decodeScriptNum(b, maxScriptNumLen); err == nil {
+			t.Errorf("decodeScriptNum(%x) error = nil, want a non minimal encoding error", b)
+		}
+	}
+	if _, err := decodeScriptNum([]byte{1, 2, 3, 4, 5}, maxScriptNumLen); err == nil {
+		t.Errorf("decodeScriptNum() of 5 bytes error = nil, want an error")
+	}
+}
@@ -0,0 +1,52 @@
+package blockchainlogic
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkLocks rejects tx unless it can be mined in a block at height with
+// time blockTime: its lock time is reached, and every input spends an output
+// buried under at least Sequence blocks. outputHeight returns the height of
+// the block holding a transaction.
+func checkLocks(tx *Transaction, height int, blockTime time.Time, outputHeight func(txID []byte) (int, error)) error {
+	if tx.LockTime < 0 {
+		return fmt.Errorf("negative lock time %d", tx.LockTime)
+	}
+	if tx.LockTime < LockTimeThreshold {
+		if tx.LockTime > int64(height) {
+			return fmt.Errorf("locked until height %d", tx.LockTime)
+		}
+	} else if tx.LockTime > blockTime.Unix() {
+		return fmt.Errorf("locked until %s", time.Unix(tx.LockTime, 0).UTC().Format(time.RFC3339))
+	}
+
+	for i, in := range tx.Vin {
+		if in.Sequence < 0 {
+			return fmt.Errorf("input %d has a negative sequence", i)
+		}
+		if in.Sequence == 0 {
+			continue
+		}
+		prevHeight, err := outputHeight(in.Txid)
+		if err != nil {
+			return err
+		}
+		if int64(height-prevHeight) < in.Sequence {
+			return fmt.Errorf("input %d spends an output buried under %d blocks, %d needed", i, height-prevHeight, in.Sequence)
+		}
+	}
+
+	return nil
+}
+
+// outputHeight returns the height of the block holding the confirmed
+// transaction txID.
+func (bc *Blockchain) outputHeight(txID []byte) (int, error) {
+	blockHash, err := bc.store.TransactionBlock(txID)
+	if err != nil {
+		return 0, err
+	}
+
+	return bc.store.BlockHeight(blockHash)
+}
@@ -1,57 +1,130 @@
 package blockchainlogic
 
 import (
-	"bytes"
-	"crypto"
-	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/sha256"
-	"encoding/gob"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
-	"math/big"
+	"sync"
 )
 
-const reward = 1000000
+const (
+	// genesisRewardCoins is the number of coins paid by the genesis coinbase
+	// unless SetGenesisSupply is called.
+	genesisRewardCoins = 1000000
+	// subsidyCoins is the number of coins a miner earns for a block on top of
+	// the fees of its transactions.
+	subsidyCoins = 50
+)
+
+var ErrFeeTooLow = errors.New("fee is below the minimum fee")
+
+// ErrInsufficientFunds is returned when the spendable outputs of a wallet do
+// not cover an amount and its fee.
+var ErrInsufficientFunds = errors.New("not enough funds")
+
+// ErrAlreadyProcessed is returned by Send for a transfer repeating one queued
+// within the same minute.
+var ErrAlreadyProcessed = errors.New("transaction with this idempotency key is already processed")
+
+// genesisReward returns the genesis coinbase reward in base units.
+func genesisReward() Amount {
+	if genesisSupply > 0 {
+		return genesisSupply
+	}
 
-var processedKeys = make(map[string]bool)
+	return genesisRewardCoins * Coin()
+}
+
+// BlockSubsidy returns the block subsidy in base units.
+func BlockSubsidy() Amount {
+	return subsidyCoins * Coin()
+}
+
+var (
+	processedKeys   = make(map[string]bool)
+	processedKeysMu sync.Mutex
+)
 
 type Transaction struct {
 	ID   []byte
 	Vin  []TXInput
 	Vout []TXOutput
+	// LockTime is the first block height, or Unix time from
+	// LockTimeThreshold on, at which the transaction can be mined. It is
+	// checked by OP_CHECKLOCKTIMEVERIFY.
+	LockTime int64
 }
 
 func (tx Transaction) IsCoinbase() bool {
 	return len(tx.Vin) == 1 && len(tx.Vin[0].Txid) == 0 && tx.Vin[0].Vout == -1
 }
 
-func (tx *Transaction) Sign(privKey *rsa.PrivateKey, prevTXs map[string]Transaction) {
-	if tx.IsCoinbase() {
-		return
+// Sign signs every input of the transaction with the wallet key.
+func (tx *Transaction) Sign(wallet Wallet, prevTXs map[string]Transaction) error {
+	signers := make([]Wallet, len(tx.Vin))
+	for i := range signers {
+		signers[i] = wallet
 	}
 
-	txCopy := tx.TrimmedCopy()
+	return tx.SignInputs(signers, prevTXs)
+}
 
-	for inID, vin := range txCopy.Vin {
-		prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
-		txCopy.Vin[inID].Signature = nil
-		txCopy.Vin[inID].PubKey = prevTx.Vout[int(vin.Vout)].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Vin[inID].PubKey = nil
+// SignInputs signs input i of the transaction with signers[i].
+func (tx *Transaction) SignInputs(signers []Wallet, prevTXs map[string]Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
+	if len(signers) != len(tx.Vin) {
+		return fmt.Errorf("%d signers for %d inputs", len(signers), len(tx.Vin))
+	}
+
+	sigHashes, err := tx.sigHashes(prevTXs)
+	if err != nil {
+		return err
+	}
 
-		hashed := sha256.Sum256(txCopy.ID)
-		signature, err := rsa.SignPKCS1v15(rand.Reader, privKey, crypto.SHA256, hashed[:])
+	for inID := range tx.Vin {
+		signature, err := signers[inID].Sign(sigHashes[inID])
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		tx.Vin[inID].Signature = signature
 	}
+
+	return nil
+}
+
+// sigHashes returns, for every input, the hash its signature commits to: the
+// hash of a trimmed copy of the transaction where only that input carries the
+// lock of the output it spends, its script or, for P2PKH outputs, its
+// PubKeyHash.
+func (tx *Transaction) sigHashes(prevTXs map[string]Transaction) ([][]byte, error) {
+	txCopy := tx.TrimmedCopy()
+	hashes := make([][]byte, len(tx.Vin))
+
+	for inID, vin := range txCopy.Vin {
+		prevTx, ok := prevTXs[hex.EncodeToString(vin.Txid)]
+		if !ok {
+			return nil, fmt.Errorf("previous transaction %x is not found", vin.Txid)
+		}
+		if vin.Vout < 0 || vin.Vout >= len(prevTx.Vout) {
+			return nil, fmt.Errorf("previous transaction %x has no output %d", vin.Txid, vin.Vout)
+		}
+
+		prevOut := prevTx.Vout[vin.Vout]
+		txCopy.Vin[inID].PubKey = prevOut.PubKeyHash
+		if len(prevOut.Script) > 0 {
+			txCopy.Vin[inID].PubKey = prevOut.Script
+		}
+		hashes[inID] = txCopy.Hash()
+		txCopy.Vin[inID].PubKey = nil
+	}
+
+	return hashes, nil
 }
 
 func (tx *Transaction) TrimmedCopy() Transaction {
@@ -59,74 +132,73 @@ func (tx *Transaction) TrimmedCopy() Transaction {
 	var outputs []TXOutput
 
 	for _, vin := range tx.Vin {
-		inputs = append(inputs, TXInput{vin.Txid, vin.Vout, nil, nil})
+		inputs = append(inputs, TXInput{Txid: vin.Txid, Vout: vin.Vout, Sequence: vin.Sequence})
 	}
 
 	for _, vout := range tx.Vout {
-		outputs = append(outputs, TXOutput{vout.Value, vout.PubKeyHash})
+		outputs = append(outputs, TXOutput{vout.Value, vout.PubKeyHash, vout.Script})
 	}
 
-	txCopy := Transaction{tx.ID, inputs, outputs}
+	txCopy := Transaction{tx.ID, inputs, outputs, tx.LockTime}
 
 	return txCopy
 }
 
-func (tx Transaction) Serialize() []byte {
-	var encoded bytes.Buffer
-
-	enc := gob.NewEncoder(&encoded)
-	err := enc.Encode(tx)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	return encoded.Bytes()
-}
-
+// Hash returns the transaction ID. Signatures and unlocking scripts are left
+// out, as transactions are identified before their inputs are signed.
 func (tx *Transaction) Hash() []byte {
 	var hash [32]byte
 
 	txCopy := *tx
 	txCopy.ID = []byte{}
+	txCopy.Vin = make([]TXInput, len(tx.Vin))
+	for i, vin := range tx.Vin {
+		txCopy.Vin[i] = TXInput{Txid: vin.Txid, Vout: vin.Vout, PubKey: vin.PubKey, Sequence: vin.Sequence}
+	}
 
 	hash = sha256.Sum256(txCopy.Serialize())
 
 	return hash[:]
 }
 
+// Verify checks that every input satisfies the locking script of the output
+// it spends, the signature of its owner for P2PKH outputs.
 func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
-	txCopy := tx.TrimmedCopy()
-	curve := elliptic.P256()
+	return tx.verifyScripts(prevTXs) == nil
+}
 
-	for inID, vin := range tx.Vin {
-		prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
-		txCopy.Vin[inID].Signature = nil
-		txCopy.Vin[inID].PubKey = prevTx.Vout[int(vin.Vout)].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Vin[inID].PubKey = nil
+// verifyScripts runs the unlocking script of every input against the locking
+// script of the output it spends.
+func (tx *Transaction) verifyScripts(prevTXs map[string]Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
 
-		r := big.Int{}
-		s := big.Int{}
-		sigLen := len(vin.Signature)
-		r.SetBytes(vin.Signature[:(sigLen / 2)])
-		s.SetBytes(vin.Signature[(sigLen / 2):])
-
-		x := big.Int{}
-		y := big.Int{}
-		keyLen := len(vin.PubKey)
-		x.SetBytes(vin.PubKey[:(keyLen / 2)])
-		y.SetBytes(vin.PubKey[(keyLen / 2):])
-
-		rawPubKey := ecdsa.PublicKey{Curve: curve, X: &x, Y: &y}
-		if !ecdsa.Verify(&rawPubKey, txCopy.ID, &r, &s) {
-			return false
+	sigHashes, err := tx.sigHashes(prevTXs)
+	if err != nil {
+		return err
+	}
+
+	for inID, vin := range tx.Vin {
+		if len(vin.UnlockScript) > 0 && (len(vin.Signature) > 0 || len(vin.PubKey) > 0) {
+			return fmt.Errorf("input %d has both an unlocking script and a signature", inID)
+		}
+		prevOut := prevTXs[hex.EncodeToString(vin.Txid)].Vout[vin.Vout]
+		err = VerifyScript(vin.unlockingScript(), prevOut.LockingScript(), tx, inID, sigHashes[inID])
+		if err != nil {
+			return fmt.Errorf("input %d: %w", inID, err)
 		}
 	}
 
-	return true
+	return nil
 }
 
+// NewCoinbaseTX creates the genesis coinbase transaction paying to.
 func NewCoinbaseTX(to, data string) *Transaction {
+	return newCoinbaseTX(to, genesisReward(), data)
+}
+
+func newCoinbaseTX(to string, value Amount, data string) *Transaction {
 	if data == "" {
 		randData := make([]byte, 20)
 		_, err := rand.Read(randData)
@@ -137,57 +209,110 @@ func NewCoinbaseTX(to, data string) *Transaction {
 		data = fmt.Sprintf("%x", randData)
 	}
 
-	txin := TXInput{[]byte{}, -1, nil, []byte(data)}
-	txout := NewTXOutput(reward, to)
-	tx := Transaction{nil, []TXInput{txin}, []TXOutput{*txout}}
+	txin := TXInput{Txid: []byte{}, Vout: -1, PubKey: []byte(data)}
+	txout := NewTXOutput(value, to)
+	tx := Transaction{Vin: []TXInput{txin}, Vout: []TXOutput{*txout}}
 	tx.ID = tx.Hash()
 
 	return &tx
 }
 
-func NewUTXOTransaction(from, to string, amount float64, bc *Blockchain, key string) (*Transaction, error) {
-	if _, exists := processedKeys[key]; exists {
-		return nil, fmt.Errorf("Transaction with idempotency this key already processed.\n")
+// NewUTXOTransaction builds a transaction paying amount to to and leaving fee
+// to the miner. It spends outputs of every address of the wallet owning from
+// that no pending transaction spends, each input signed with the key of its
+// address in keys. Change goes to a fresh change address of HD wallets, or
+// back to from.
+func NewUTXOTransaction(from, to string, amount, fee Amount, bc *Blockchain, keys Keystore) (*Transaction, error) {
+	if amount <= 0 || fee < 0 {
+		return nil, ErrInvalidAmount
 	}
-	var inputs []TXInput
-	var outputs []TXOutput
 
-	wallets, err := NewWallets()
+	addresses, err := keys.Group(from)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("%v", err))
+		return nil, err
+	}
+	// Wallets of the group by hex encoded public key hash.
+	wallets := make(map[string]*Wallet, len(addresses))
+	hashes := make([][]byte, 0, len(addresses))
+	for _, address := range addresses {
+		wallet, err := keys.Get(address)
+		if err != nil {
+			return nil, err
+		}
+		pubKeyHash := HashPubKey(wallet.PublicKey)
+		wallets[hex.EncodeToString(pubKeyHash)] = wallet
+		hashes = append(hashes, pubKeyHash)
 	}
-	wallet := wallets.GetWallet(from)
-	pubKeyHash := HashPubKey(wallet.PublicKey)
-	acc, validOutputs := bc.FindSpendableOutputs(pubKeyHash, amount)
 
-	if acc < amount {
-		return nil, errors.New(fmt.Sprintf("not enough funds"))
+	total := amount + fee
+	acc, spendable, err := bc.FindSpendableOutputs(hashes, total)
+	if err != nil {
+		return nil, err
+	}
+
+	if acc < total {
+		return nil, ErrInsufficientFunds
 	}
 
 	// Build a list of inputs
-	for txid, outs := range validOutputs {
-		txID, err := hex.DecodeString(txid)
+	inputs := make([]TXInput, 0, len(spendable))
+	signers := make([]Wallet, 0, len(spendable))
+	for _, out := range spendable {
+		wallet := wallets[hex.EncodeToString(out.PubKeyHash)]
+		inputs = append(inputs, TXInput{Txid: out.TxID, Vout: out.Vout, PubKey: wallet.PublicKey})
+		signers = append(signers, *wallet)
+	}
+
+	// Build a list of outputs
+	outputs := []TXOutput{*NewTXOutput(amount, to)}
+	if acc > total {
+		change, err := bc.changeAddress(keys, from)
 		if err != nil {
-			return nil, errors.New(fmt.Sprintf("%v", err))
+			return nil, err
 		}
+		outputs = append(outputs, *NewTXOutput(acc-total, change))
+	}
 
-		for _, out := range outs {
-			input := TXInput{txID, out, nil, wallet.PublicKey}
-			inputs = append(inputs, input)
-		}
+	tx := Transaction{Vin: inputs, Vout: outputs}
+	tx.ID = tx.Hash()
+	prevTXs, err := bc.findPrevTransactions(&tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.SignInputs(signers, prevTXs)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build a list of outputs
-	outputs = append(outputs, *NewTXOutput(amount, to))
-	if acc > amount {
-		outputs = append(outputs, *NewTXOutput(acc-amount, from)) // a change
+	return &tx, nil
+}
+
+// transactionFee returns the fee paid by tx: the value of the outputs it
+// spends minus the value of the outputs it creates.
+func transactionFee(tx *Transaction, prevTXs map[string]Transaction) (Amount, error) {
+	if tx.IsCoinbase() {
+		return 0, nil
 	}
 
-	tx := Transaction{nil, inputs, outputs}
-	tx.ID = tx.Hash()
-	bc.SignTransaction(&tx, wallet.PrivateKey)
+	var inputs Amount
+	for _, vin := range tx.Vin {
+		prevTx, ok := prevTXs[hex.EncodeToString(vin.Txid)]
+		if !ok {
+			return 0, fmt.Errorf("previous transaction %x is not found", vin.Txid)
+		}
+		if vin.Vout < 0 || vin.Vout >= len(prevTx.Vout) {
+			return 0, fmt.Errorf("previous transaction %x has no output %d", vin.Txid, vin.Vout)
+		}
+		inputs += prevTx.Vout[vin.Vout].Value
+	}
 
-	processedKeys[key] = true
+	outputs, err := sumOutputs(tx.Vout)
+	if err != nil {
+		return 0, fmt.Errorf("transaction %x: %w", tx.ID, err)
+	}
+	if outputs > inputs {
+		return 0, fmt.Errorf("transaction %x spends %s but only has %s", tx.ID, outputs, inputs)
+	}
 
-	return &tx, nil
+	return inputs - outputs, nil
 }
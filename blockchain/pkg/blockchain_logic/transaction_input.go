@@ -4,9 +4,15 @@ import "bytes"
 
 type TXInput struct {
 	Txid      []byte
-	Vout      float64
+	Vout      int
 	Signature []byte
 	PubKey    []byte
+	// UnlockScript satisfies the locking script of the spent output. Inputs
+	// spending P2PKH outputs may set Signature and PubKey instead.
+	UnlockScript Script
+	// Sequence is the number of blocks the spent output must be buried under
+	// before the input can be mined, checked by OP_CHECKSEQUENCEVERIFY.
+	Sequence int64
 }
 
 func (in *TXInput) UsesKey(pubKeyHash []byte) bool {
@@ -14,3 +20,23 @@ func (in *TXInput) UsesKey(pubKeyHash []byte) bool {
 
 	return bytes.Compare(lockingHash, pubKeyHash) == 0
 }
+
+// pubKeyHash returns the hash of the public key of the input, nil for inputs
+// unlocked by a script.
+func (in *TXInput) pubKeyHash() []byte {
+	if len(in.PubKey) == 0 {
+		return nil
+	}
+
+	return HashPubKey(in.PubKey)
+}
+
+// unlockingScript returns the unlocking script of the input, the push of its
+// signature and public key for inputs without one.
+func (in *TXInput) unlockingScript() Script {
+	if len(in.UnlockScript) > 0 {
+		return in.UnlockScript
+	}
+
+	return UnlockScript(in.Signature, in.PubKey)
+}
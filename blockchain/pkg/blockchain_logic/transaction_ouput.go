@@ -1,10 +1,17 @@
 package blockchainlogic
 
-import "bytes"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
 
 type TXOutput struct {
-	Value      float64
+	Value      Amount
 	PubKeyHash []byte
+	// Script is the locking script of outputs not paying a single address.
+	// Outputs without one are locked with the P2PKH template of PubKeyHash.
+	Script Script
 }
 
 func (out *TXOutput) Lock(address []byte) {
@@ -17,9 +24,57 @@ func (out *TXOutput) IsLockedWithKey(pubKeyHash []byte) bool {
 	return bytes.Compare(out.PubKeyHash, pubKeyHash) == 0
 }
 
-func NewTXOutput(value float64, address string) *TXOutput {
-	txo := &TXOutput{value, nil}
+// Address returns the address out pays, empty for script outputs.
+func (out *TXOutput) Address() string {
+	if len(out.PubKeyHash) == 0 {
+		return ""
+	}
+
+	return AddressFromPubKeyHash(out.PubKeyHash)
+}
+
+// LockingScript returns the script the input spending out must satisfy.
+func (out *TXOutput) LockingScript() Script {
+	if len(out.Script) > 0 {
+		return out.Script
+	}
+
+	return P2PKHScript(out.PubKeyHash)
+}
+
+// checkLock rejects outputs that can not be spent by any input: they either
+// pay a public key hash or carry a well formed script.
+func (out *TXOutput) checkLock() error {
+	if len(out.Script) == 0 {
+		if len(out.PubKeyHash) != pubKeyHashLen {
+			return errors.New("invalid public key hash")
+		}
+
+		return nil
+	}
+	if len(out.PubKeyHash) > 0 {
+		return errors.New("both a public key hash and a script")
+	}
+	if err := out.Script.Validate(); err != nil {
+		return fmt.Errorf("invalid script: %w", err)
+	}
+
+	return nil
+}
+
+func NewTXOutput(value Amount, address string) *TXOutput {
+	txo := &TXOutput{Value: value}
 	txo.Lock([]byte(address))
 
 	return txo
 }
+
+// NewScriptOutput returns an output locked with script. P2PKH scripts are
+// stored as the public key hash they pay, like the outputs of NewTXOutput.
+func NewScriptOutput(value Amount, script Script) *TXOutput {
+	if pubKeyHash, ok := script.P2PKHHash(); ok {
+		return &TXOutput{Value: value, PubKeyHash: pubKeyHash}
+	}
+
+	return &TXOutput{Value: value, Script: script}
+}
@@ -0,0 +1,71 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func newSignedSpend(t *testing.T, owner *Wallet, signer *Wallet) (*Transaction, map[string]Transaction) {
+	t.Helper()
+
+	prevTx := NewCoinbaseTX(string(owner.GetAddress()), "")
+	prevTXs := map[string]Transaction{hex.EncodeToString(prevTx.ID): *prevTx}
+
+	recipient := NewWallet()
+	tx := &Transaction{
+		Vin:  []TXInput{{Txid: prevTx.ID, Vout: 0, PubKey: signer.PublicKey}},
+		Vout: []TXOutput{*NewTXOutput(10, string(recipient.GetAddress()))},
+	}
+	tx.ID = tx.Hash()
+
+	if err := tx.Sign(*signer, prevTXs); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	return tx, prevTXs
+}
+
+func TestTransaction_SignVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyType KeyType
+	}{
+		{name: "ECDSA P-256", keyType: KeyTypeECDSA},
+		{name: "Ed25519", keyType: KeyTypeEd25519},
+		{name: "Legacy RSA", keyType: KeyTypeRSA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet := NewWalletWithKeyType(tt.keyType)
+			tx, prevTXs := newSignedSpend(t, wallet, wallet)
+
+			if !tx.Verify(prevTXs) {
+				t.Errorf("Verify() = false, want true")
+			}
+
+			tx.Vout[0].Value++
+			if tx.Verify(prevTXs) {
+				t.Errorf("Verify() of tampered transaction = true, want false")
+			}
+		})
+	}
+}
+
+func TestTransaction_VerifyRejectsForeignKey(t *testing.T) {
+	owner := NewWallet()
+	thief := NewWalletWithKeyType(KeyTypeEd25519)
+
+	tx, prevTXs := newSignedSpend(t, owner, thief)
+	if tx.Verify(prevTXs) {
+		t.Errorf("Verify() of input signed by a foreign key = true, want false")
+	}
+}
+
+func TestTransaction_VerifyMissingPrevTx(t *testing.T) {
+	wallet := NewWallet()
+	tx, _ := newSignedSpend(t, wallet, wallet)
+
+	if tx.Verify(map[string]Transaction{}) {
+		t.Errorf("Verify() without previous transactions = true, want false")
+	}
+}
@@ -0,0 +1,27 @@
+package blockchainlogic
+
+// participants returns the public key hashes of the senders and recipients of tx.
+func participants(tx *Transaction) [][]byte {
+	var hashes [][]byte
+	seen := make(map[string]bool)
+
+	add := func(pubKeyHash []byte) {
+		key := string(pubKeyHash)
+		if len(pubKeyHash) == 0 || seen[key] {
+			return
+		}
+		seen[key] = true
+		hashes = append(hashes, pubKeyHash)
+	}
+
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Vin {
+			add(in.pubKeyHash())
+		}
+	}
+	for _, out := range tx.Vout {
+		add(out.PubKeyHash)
+	}
+
+	return hashes
+}
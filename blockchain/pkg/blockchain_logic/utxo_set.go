@@ -0,0 +1,113 @@
+package blockchainlogic
+
+import "errors"
+
+// Reindex rebuilds the utxo set and the transaction indexes by replaying
+// every block from genesis.
+func (bc *Blockchain) Reindex() error {
+	bc.mining.Lock()
+	defer bc.mining.Unlock()
+
+	return bc.store.Reindex()
+}
+
+// inputsUnspent reports whether every input of tx spends an output that is
+// still in the utxo set.
+func (bc *Blockchain) inputsUnspent(tx *Transaction) (bool, error) {
+	if tx.IsCoinbase() {
+		return true, nil
+	}
+
+	for _, in := range tx.Vin {
+		unspent, err := bc.store.IsUnspent(in.Txid, in.Vout)
+		if err != nil || !unspent {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// FindUTXO returns the unspent outputs locked with pubKeyHash.
+func (bc *Blockchain) FindUTXO(pubKeyHash []byte) ([]TXOutput, error) {
+	outputs, err := bc.store.UnspentOutputs([][]byte{pubKeyHash})
+	if err != nil {
+		return nil, err
+	}
+
+	UTXOs := make([]TXOutput, 0, len(outputs))
+	for _, out := range outputs {
+		UTXOs = append(UTXOs, TXOutput{Value: out.Value, PubKeyHash: out.PubKeyHash})
+	}
+
+	return UTXOs, nil
+}
+
+// SpendableOutput is an unspent output selected to fund a transaction.
+type SpendableOutput struct {
+	TxID       []byte
+	Vout       int
+	Value      Amount
+	PubKeyHash []byte
+}
+
+// FindSpendableOutputs collects unspent outputs locked with any of
+// pubKeyHashes, largest first, until their sum covers amount. Outputs spent by
+// pending transactions are skipped. It returns the accumulated sum and the
+// selected outputs.
+func (bc *Blockchain) FindSpendableOutputs(pubKeyHashes [][]byte, amount Amount) (Amount, []SpendableOutput, error) {
+	unspent, err := bc.store.UnspentOutputs(pubKeyHashes)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var outputs []SpendableOutput
+	var accumulated Amount
+
+	for _, out := range unspent {
+		if accumulated >= amount {
+			break
+		}
+		if bc.mempool.IsSpent(out.TxID, out.Vout) {
+			continue
+		}
+		accumulated += out.Value
+		outputs = append(outputs, out)
+	}
+
+	return accumulated, outputs, nil
+}
+
+// GetBalance returns the sum of unspent outputs of the address.
+func (bc *Blockchain) GetBalance(address string) (Amount, error) {
+	if !ValidateAddress(address) {
+		return 0, errors.New("address is not valid")
+	}
+
+	return bc.balance([][]byte{addressPubKeyHash(address)})
+}
+
+// GetWalletBalance returns the sum of unspent outputs of every address of
+// the wallet owning address, see Keystore.Group.
+func (bc *Blockchain) GetWalletBalance(address string) (Amount, error) {
+	addresses, err := bc.WalletAddresses(address)
+	if err != nil {
+		return 0, err
+	}
+
+	return bc.balance(pubKeyHashes(addresses))
+}
+
+func (bc *Blockchain) balance(pubKeyHashes [][]byte) (Amount, error) {
+	outputs, err := bc.store.UnspentOutputs(pubKeyHashes)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance Amount
+	for _, out := range outputs {
+		balance += out.Value
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,231 @@
+package blockchainlogic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChainReport is the outcome of a full chain validation.
+type ChainReport struct {
+	// Blocks is the number of blocks that passed validation.
+	Blocks int
+	// Tip is the hash of the last valid block.
+	Tip string
+	// FirstInvalidHeight is the height of the first block that failed
+	// validation, or -1 when the whole chain is valid.
+	FirstInvalidHeight int
+	InvalidHash        string
+	Reason             error
+}
+
+// Valid reports whether every block passed validation.
+func (r *ChainReport) Valid() bool {
+	return r.FirstInvalidHeight < 0
+}
+
+func (r *ChainReport) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "blocks verified: %d\n", r.Blocks)
+	if r.Tip != "" {
+		fmt.Fprintf(&sb, "last valid block: %s\n", r.Tip)
+	}
+	if r.Valid() {
+		sb.WriteString("chain is valid")
+	} else {
+		fmt.Fprintf(&sb, "first invalid height: %d\ninvalid block: %s\nreason: %v",
+			r.FirstInvalidHeight, r.InvalidHash, r.Reason)
+	}
+
+	return sb.String()
+}
+
+// VerifyChain walks the stored chain from genesis to tip and re-checks every
+// block: difficulty retargets, Merkle root, proof of work, linkage to its parent, transaction IDs, signatures,
+// spends and coinbase rules. It stops at the first invalid block. The returned
+// error is only set when the chain could not be read.
+func VerifyChain(store ChainStore) (*ChainReport, error) {
+	v := newChainVerifier()
+	report := &ChainReport{FirstInvalidHeight: -1}
+
+	errInvalid := errors.New("invalid block")
+	err := store.ForEachBlock(func(height int, block *Block) error {
+		if err := v.checkBlock(height, block); err != nil {
+			report.FirstInvalidHeight = height
+			report.InvalidHash = block.Hash
+			report.Reason = err
+
+			return errInvalid
+		}
+		report.Blocks++
+		report.Tip = block.Hash
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errInvalid) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// chainVerifier validates blocks in height order and tracks the outputs they
+// create and spend.
+type chainVerifier struct {
+	prevHash string
+	// prevDifficulty is the difficulty of the parent block.
+	prevDifficulty int
+	// timestamps holds the timestamp of every block by height, for
+	// retargets.
+	timestamps []time.Time
+	outputs    map[string][]TXOutput
+	// heights holds the height of the block of every transaction.
+	heights map[string]int
+	spent   map[string]bool
+}
+
+func newChainVerifier() *chainVerifier {
+	return &chainVerifier{
+		outputs: make(map[string][]TXOutput),
+		heights: make(map[string]int),
+		spent:   make(map[string]bool),
+	}
+}
+
+func (v *chainVerifier) checkBlock(height int, block *Block) error {
+	if height == 0 {
+		if block.PrevHash != "0" {
+			return fmt.Errorf("genesis block has previous hash %q", block.PrevHash)
+		}
+	} else if block.PrevHash != v.prevHash {
+		return fmt.Errorf("previous hash %s does not match parent %s", block.PrevHash, v.prevHash)
+	}
+
+	var elapsed time.Duration
+	if isRetargetHeight(height) {
+		elapsed = v.timestamps[height-1].Sub(v.timestamps[retargetWindowStart(height)])
+	}
+	err := checkDifficulty(height, block.Difficulty, v.prevDifficulty, elapsed)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(block.MerkleRoot, block.HashTransactions()) {
+		return errors.New("merkle root does not match the transactions")
+	}
+	if !NewProof(block).Validate() {
+		return errors.New("proof of work is invalid")
+	}
+
+	if len(block.Transactions) == 0 {
+		return errors.New("block has no transactions")
+	}
+	if height == 0 && (len(block.Transactions) != 1 || !block.Transactions[0].IsCoinbase()) {
+		return errors.New("genesis block must hold exactly one coinbase transaction")
+	}
+
+	var coinbase *Transaction
+	var fees Amount
+	for i, tx := range block.Transactions {
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			return fmt.Errorf("transaction %x: id does not match its hash", tx.ID)
+		}
+
+		if tx.IsCoinbase() {
+			if i != 0 {
+				return fmt.Errorf("coinbase transaction %x is not the first in the block", tx.ID)
+			}
+			coinbase = tx
+		} else {
+			fee, err := v.checkSpend(tx)
+			if err != nil {
+				return err
+			}
+			err = checkLocks(tx, height, block.Timestamp, v.outputHeight)
+			if err != nil {
+				return fmt.Errorf("transaction %x: %w", tx.ID, err)
+			}
+			fees += fee
+		}
+
+		v.outputs[hex.EncodeToString(tx.ID)] = tx.Vout
+		v.heights[hex.EncodeToString(tx.ID)] = height
+	}
+
+	// Blocks mined before miners were rewarded have no coinbase.
+	if coinbase != nil {
+		limit := BlockSubsidy() + fees
+		if height == 0 {
+			limit = genesisReward()
+		}
+		err := checkCoinbase(coinbase, limit)
+		if err != nil {
+			return err
+		}
+	}
+
+	v.prevHash = block.Hash
+	v.prevDifficulty = block.Difficulty
+	v.timestamps = append(v.timestamps, block.Timestamp)
+
+	return nil
+}
+
+// checkCoinbase rejects coinbase transactions paying more than limit.
+func checkCoinbase(tx *Transaction, limit Amount) error {
+	total, err := sumOutputs(tx.Vout)
+	if err != nil {
+		return fmt.Errorf("coinbase transaction %x: %w", tx.ID, err)
+	}
+	if total > limit {
+		return fmt.Errorf("coinbase transaction %x pays %s, more than the allowed %s", tx.ID, total, limit)
+	}
+
+	return nil
+}
+
+// checkSpend validates a regular transaction and returns its fee.
+func (v *chainVerifier) checkSpend(tx *Transaction) (Amount, error) {
+	prevTXs := make(map[string]Transaction)
+
+	for _, in := range tx.Vin {
+		prevID := hex.EncodeToString(in.Txid)
+		outs, ok := v.outputs[prevID]
+		if !ok {
+			return 0, fmt.Errorf("transaction %x spends unknown transaction %s", tx.ID, prevID)
+		}
+		if in.Vout < 0 || in.Vout >= len(outs) {
+			return 0, fmt.Errorf("transaction %x spends missing output %s:%d", tx.ID, prevID, in.Vout)
+		}
+		key := outpoint(in.Txid, in.Vout)
+		if v.spent[key] {
+			return 0, fmt.Errorf("transaction %x double spends output %s", tx.ID, key)
+		}
+		v.spent[key] = true
+
+		prevTXs[prevID] = Transaction{ID: in.Txid, Vout: outs}
+	}
+
+	fee, err := transactionFee(tx, prevTXs)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.verifyScripts(prevTXs); err != nil {
+		return 0, fmt.Errorf("transaction %x: %w", tx.ID, err)
+	}
+
+	return fee, nil
+}
+
+func (v *chainVerifier) outputHeight(txID []byte) (int, error) {
+	height, ok := v.heights[hex.EncodeToString(txID)]
+	if !ok {
+		return 0, fmt.Errorf("transaction %x is not found", txID)
+	}
+
+	return height, nil
+}
@@ -0,0 +1,157 @@
+package blockchainlogic
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// testChain builds a genesis block paying owner and a block spending it.
+func testChain(t *testing.T) (*Wallet, []*Block) {
+	t.Helper()
+
+	owner := NewWallet()
+	genesis := Genesis(NewCoinbaseTX(string(owner.GetAddress()), genesisCoinbaseData))
+	coinbase := genesis.Transactions[0]
+
+	recipient := NewWallet()
+	tx := &Transaction{
+		Vin: []TXInput{{Txid: coinbase.ID, Vout: 0, PubKey: owner.PublicKey}},
+		Vout: []TXOutput{
+			*NewTXOutput(10, string(recipient.GetAddress())),
+			*NewTXOutput(genesisReward()-10, string(owner.GetAddress())),
+		},
+	}
+	tx.ID = tx.Hash()
+	prevTXs := map[string]Transaction{hex.EncodeToString(coinbase.ID): *coinbase}
+	if err := tx.Sign(*owner, prevTXs); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	return owner, []*Block{genesis, CreateBlock([]*Transaction{tx}, genesis.Hash, Difficulty)}
+}
+
+func verifyBlocks(blocks []*Block) (int, error) {
+	v := newChainVerifier()
+	for height, block := range blocks {
+		if err := v.checkBlock(height, block); err != nil {
+			return height, err
+		}
+	}
+
+	return -1, nil
+}
+
+func TestChainVerifier_Valid(t *testing.T) {
+	_, blocks := testChain(t)
+
+	if height, err := verifyBlocks(blocks); err != nil {
+		t.Errorf("checkBlock() at height %d error = %v", height, err)
+	}
+}
+
+func TestChainVerifier_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, owner *Wallet, blocks []*Block) []*Block
+	}{
+		{
+			name: "Broken link",
+			tamper: func(_ *testing.T, _ *Wallet, blocks []*Block) []*Block {
+				blocks[1].PrevHash = "00ff"
+
+				return blocks
+			},
+		},
+		{
+			name: "Transaction added after mining",
+			tamper: func(_ *testing.T, owner *Wallet, blocks []*Block) []*Block {
+				extra := NewCoinbaseTX(string(owner.GetAddress()), "extra")
+				blocks[1].Transactions = append(blocks[1].Transactions, extra)
+
+				return blocks
+			},
+		},
+		{
+			name: "Tampered output",
+			tamper: func(_ *testing.T, _ *Wallet, blocks []*Block) []*Block {
+				blocks[1].Transactions[0].Vout[0].Value = 500
+
+				return blocks
+			},
+		},
+		{
+			name: "Double spend",
+			tamper: func(t *testing.T, _ *Wallet, blocks []*Block) []*Block {
+				t.Helper()
+				spend := blocks[1].Transactions[0]
+
+				return append(blocks, CreateBlock([]*Transaction{spend}, blocks[1].Hash, Difficulty))
+			},
+		},
+		{
+			name: "Coinbase overpays",
+			tamper: func(_ *testing.T, owner *Wallet, blocks []*Block) []*Block {
+				cb := newCoinbaseTX(string(owner.GetAddress()), BlockSubsidy()+1, "")
+
+				return append(blocks, CreateBlock([]*Transaction{cb}, blocks[1].Hash, Difficulty))
+			},
+		},
+		{
+			name: "Difficulty outside a retarget",
+			tamper: func(_ *testing.T, _ *Wallet, blocks []*Block) []*Block {
+				blocks[1] = CreateBlock(blocks[1].Transactions, blocks[0].Hash, Difficulty-1)
+
+				return blocks
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, blocks := testChain(t)
+			blocks = tt.tamper(t, owner, blocks)
+
+			if height, err := verifyBlocks(blocks); err == nil {
+				t.Errorf("checkBlock() accepted an invalid chain")
+			} else if height == 0 {
+				t.Errorf("checkBlock() rejected the genesis block: %v", err)
+			}
+		})
+	}
+}
+
+func TestChainVerifier_CoinbaseFees(t *testing.T) {
+	tests := []struct {
+		name    string
+		bonus   Amount
+		wantErr bool
+	}{
+		{name: "Subsidy plus fees", bonus: 0},
+		{name: "Less than allowed", bonus: -1},
+		{name: "Overpays the fees", bonus: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, blocks := testChain(t)
+			genesis := blocks[0]
+			coinbase := genesis.Transactions[0]
+
+			const fee = 5
+			tx := &Transaction{
+				Vin:  []TXInput{{Txid: coinbase.ID, Vout: 0, PubKey: owner.PublicKey}},
+				Vout: []TXOutput{*NewTXOutput(genesisReward()-fee, string(owner.GetAddress()))},
+			}
+			tx.ID = tx.Hash()
+			prevTXs := map[string]Transaction{hex.EncodeToString(coinbase.ID): *coinbase}
+			if err := tx.Sign(*owner, prevTXs); err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			reward := newCoinbaseTX(string(owner.GetAddress()), BlockSubsidy()+fee+tt.bonus, "")
+			block := CreateBlock([]*Transaction{reward, tx}, genesis.Hash, Difficulty)
+
+			_, err := verifyBlocks([]*Block{genesis, block})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkBlock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
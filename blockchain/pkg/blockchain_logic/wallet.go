@@ -2,10 +2,16 @@ package blockchainlogic
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"log"
 
 	"golang.org/x/crypto/ripemd160"
@@ -13,35 +19,129 @@ import (
 
 const (
 	version            = byte(0x00)
-	walletFile         = "pkg/blockchain_logic/wallet.dat"
 	addressChecksumLen = 4
 )
 
+// KeyType is the signature scheme of a wallet key.
+type KeyType int
+
+const (
+	// KeyTypeRSA is used by wallets created before ECDSA and Ed25519 support.
+	// It is the zero value so wallets decoded from old wallet files keep working.
+	KeyTypeRSA KeyType = iota
+	KeyTypeECDSA
+	KeyTypeEd25519
+)
+
+// DefaultKeyType is the key type of newly created wallets.
+const DefaultKeyType = KeyTypeECDSA
+
+func (kt KeyType) String() string {
+	switch kt {
+	case KeyTypeRSA:
+		return "rsa"
+	case KeyTypeECDSA:
+		return "ecdsa-p256"
+	case KeyTypeEd25519:
+		return "ed25519"
+	default:
+		return fmt.Sprintf("KeyType(%d)", int(kt))
+	}
+}
+
 // Wallet stores private and public keys.
 type Wallet struct {
+	KeyType KeyType
+	// PrivateKey is only set for legacy RSA wallets.
 	PrivateKey *rsa.PrivateKey
-	PublicKey  []byte
+	// SigningKey is the PKCS #8 encoded ECDSA or Ed25519 private key.
+	SigningKey []byte
+	// PublicKey is the PKIX encoded public key.
+	PublicKey []byte
 }
 
-// NewWallet creates and returns a Wallet.
+// NewWallet creates and returns a Wallet with a key of DefaultKeyType.
 func NewWallet() *Wallet {
-	private, public := newKeyPair()
-	wallet := Wallet{private, public}
+	return NewWalletWithKeyType(DefaultKeyType)
+}
+
+// NewWalletWithKeyType creates and returns a Wallet with a key of the given type.
+func NewWalletWithKeyType(keyType KeyType) *Wallet {
+	wallet, err := newKeyPair(keyType)
+	if err != nil {
+		log.Panic(err)
+	}
 
-	return &wallet
+	return wallet
+}
+
+// Sign signs a transaction input hash with the wallet private key.
+func (w Wallet) Sign(sigHash []byte) ([]byte, error) {
+	if w.KeyType == KeyTypeRSA {
+		if w.PrivateKey == nil {
+			return nil, errors.New("wallet has no private key")
+		}
+		hashed := sha256.Sum256(sigHash)
+
+		return rsa.SignPKCS1v15(rand.Reader, w.PrivateKey, crypto.SHA256, hashed[:])
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(w.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		hashed := sha256.Sum256(sigHash)
+
+		return ecdsa.SignASN1(rand.Reader, k, hashed[:])
+	case ed25519.PrivateKey:
+		return ed25519.Sign(k, sigHash), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// VerifySignature checks sig over sigHash against a PKIX encoded public key.
+// The signature scheme is picked from the public key algorithm.
+func VerifySignature(pubKey, sigHash, sig []byte) bool {
+	key, err := x509.ParsePKIXPublicKey(pubKey)
+	if err != nil {
+		return false
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		hashed := sha256.Sum256(sigHash)
+
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], sig) == nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return false
+		}
+		hashed := sha256.Sum256(sigHash)
+
+		return ecdsa.VerifyASN1(k, hashed[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, sigHash, sig)
+	default:
+		return false
+	}
 }
 
 // GetAddress returns wallet address.
 func (w Wallet) GetAddress() []byte {
-	pubKeyHash := HashPubKey(w.PublicKey)
+	return []byte(AddressFromPubKeyHash(HashPubKey(w.PublicKey)))
+}
 
+// AddressFromPubKeyHash returns the address outputs locked with pubKeyHash pay to.
+func AddressFromPubKeyHash(pubKeyHash []byte) string {
 	versionedPayload := append([]byte{version}, pubKeyHash...)
 	checksum := checksum(versionedPayload)
 
 	fullPayload := append(versionedPayload, checksum...)
-	address := Base58Encode(fullPayload)
 
-	return address
+	return string(Base58Encode(fullPayload))
 }
 
 // HashPubKey hashes public key.
@@ -61,6 +161,9 @@ func HashPubKey(pubKey []byte) []byte {
 // ValidateAddress check if address is valid.
 func ValidateAddress(address string) bool {
 	pubKeyHash := Base58Decode([]byte(address))
+	if len(pubKeyHash) <= addressChecksumLen {
+		return false
+	}
 	actualChecksum := pubKeyHash[len(pubKeyHash)-addressChecksumLen:]
 	version := pubKeyHash[0]
 	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-addressChecksumLen]
@@ -69,6 +172,23 @@ func ValidateAddress(address string) bool {
 	return bytes.Compare(actualChecksum, targetChecksum) == 0
 }
 
+// addressPubKeyHash returns the public key hash encoded in a valid address.
+func addressPubKeyHash(address string) []byte {
+	pubKeyHash := Base58Decode([]byte(address))
+
+	return pubKeyHash[1 : len(pubKeyHash)-addressChecksumLen]
+}
+
+// pubKeyHashes returns the public key hashes of valid addresses.
+func pubKeyHashes(addresses []string) [][]byte {
+	hashes := make([][]byte, len(addresses))
+	for i, address := range addresses {
+		hashes[i] = addressPubKeyHash(address)
+	}
+
+	return hashes
+}
+
 // Checksum generates a checksum for a public key.
 func checksum(payload []byte) []byte {
 	firstSHA := sha256.Sum256(payload)
@@ -77,41 +197,41 @@ func checksum(payload []byte) []byte {
 	return secondSHA[:addressChecksumLen]
 }
 
-// newKeyPair generates new private and public key pair.
-func newKeyPair() (*rsa.PrivateKey, []byte) {
-	private, err := rsa.GenerateKey(rand.Reader, 2048)
+// newKeyPair generates new private and public key pair of the given type.
+func newKeyPair(keyType KeyType) (*Wallet, error) {
+	var private crypto.Signer
+	var err error
+
+	switch keyType {
+	case KeyTypeRSA:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeECDSA:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeEd25519:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", keyType)
+	}
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	publicKey := &private.PublicKey
-	pubKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(private.Public())
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	return private, pubKeyBytes
-}
+	wallet := &Wallet{KeyType: keyType, PublicKey: pubKeyBytes}
+	if keyType == KeyTypeRSA {
+		wallet.PrivateKey = private.(*rsa.PrivateKey)
 
-// ListAddresses lists all addresses in wallet.dat file.
-func ListAddresses() []string {
-	wallets, err := NewWallets()
-	if err != nil {
-		log.Panic(err)
+		return wallet, nil
 	}
-	addresses := wallets.GetAddresses()
 
-	return addresses
-}
-
-// CreateWallet Create new wallet and returns address.
-func CreateWallet() string {
-	wallets, err := NewWallets()
+	wallet.SigningKey, err = x509.MarshalPKCS8PrivateKey(private)
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
-	address := wallets.CreateWallet()
-	wallets.SaveToFile()
 
-	return address
+	return wallet, nil
 }
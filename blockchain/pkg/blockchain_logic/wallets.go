@@ -4,97 +4,210 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
-	"log"
 	"os"
+	"sync"
 )
 
-// Wallets stores a collection of wallets.
+// Wallets stores a collection of wallets in memory. It is the format of the
+// legacy wallet.dat file and a Keystore for tools and tests.
 type Wallets struct {
 	Wallets map[string]*Wallet
+	// accounts holds the HD wallets by the address of receive key 0, owners
+	// maps every derived address to it.
+	accounts map[string]*hdAccount
+	owners   map[string]string
+	mu       sync.RWMutex
 }
 
-// NewWallets creates Wallets and fills it from a file if it exists.
-func NewWallets() (*Wallets, error) {
-	wallets := Wallets{}
-	wallets.Wallets = make(map[string]*Wallet)
+type hdAccount struct {
+	seed      []byte
+	next      [2]uint32
+	addresses []string
+}
+
+// NewWallets returns an empty in-memory keystore.
+func NewWallets() *Wallets {
+	return &Wallets{
+		Wallets:  make(map[string]*Wallet),
+		accounts: make(map[string]*hdAccount),
+		owners:   make(map[string]string),
+	}
+}
 
-	err := wallets.LoadFromFile()
+// LoadWalletFile decodes a legacy gob encoded wallet.dat file.
+func LoadWalletFile(path string) (*Wallets, error) {
+	fileContent, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	wallets := NewWallets()
+	if len(fileContent) == 0 {
+		return wallets, nil
+	}
 
-	return &wallets, err
+	err = gob.NewDecoder(bytes.NewReader(fileContent)).Decode(wallets)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	return wallets, nil
 }
 
-// CreateWallet adds a Wallet to Wallets.
-func (ws *Wallets) CreateWallet() string {
-	wallet := NewWallet()
-	address := fmt.Sprintf("%s", wallet.GetAddress())
+// Put stores w under its address.
+func (ws *Wallets) Put(w *Wallet) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.put(w)
+}
 
-	ws.Wallets[address] = wallet
+func (ws *Wallets) put(w *Wallet) error {
+	address := string(w.GetAddress())
+	if _, ok := ws.Wallets[address]; ok {
+		return ErrKeyExists
+	}
+	if ws.Wallets == nil {
+		ws.Wallets = make(map[string]*Wallet)
+	}
+	ws.Wallets[address] = w
 
-	return address
+	return nil
 }
 
-// GetAddresses returns an array of addresses stored in the wallet file.
-func (ws *Wallets) GetAddresses() []string {
-	var addresses []string
+// Get returns the wallet of address.
+func (ws *Wallets) Get(address string) (*Wallet, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
 
-	for address := range ws.Wallets {
-		addresses = append(addresses, address)
+	w, ok := ws.Wallets[address]
+	if !ok {
+		return nil, ErrKeyNotFound
 	}
 
-	return addresses
+	return w, nil
 }
 
-// GetWallet returns a Wallet by its address.
-func (ws Wallets) GetWallet(address string) Wallet {
-	return *ws.Wallets[address]
+// Delete removes the standalone key of address, or the HD wallet whose root
+// address it is with its keys.
+func (ws *Wallets) Delete(address string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if account, ok := ws.accounts[address]; ok {
+		for _, derived := range account.addresses {
+			delete(ws.Wallets, derived)
+			delete(ws.owners, derived)
+		}
+		delete(ws.accounts, address)
+
+		return nil
+	}
+	if _, ok := ws.owners[address]; ok {
+		return ErrKeyNotFound
+	}
+	if _, ok := ws.Wallets[address]; !ok {
+		return ErrKeyNotFound
+	}
+	delete(ws.Wallets, address)
+
+	return nil
 }
 
-// LoadFromFile loads wallets from the file.
-func (ws *Wallets) LoadFromFile() error {
-	if _, err := os.Stat(walletFile); os.IsNotExist(err) {
-		return err
+// Addresses returns the addresses of the stored wallets.
+func (ws *Wallets) Addresses() ([]string, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	addresses := make([]string, 0, len(ws.Wallets))
+	for address := range ws.Wallets {
+		addresses = append(addresses, address)
 	}
 
-	fileContent, err := os.ReadFile(walletFile)
+	return addresses, nil
+}
+
+// PutSeed stores seed as an HD wallet.
+func (ws *Wallets) PutSeed(seed []byte, receive, change uint32) (string, error) {
+	root, err := DeriveWallet(seed, ChainReceive, 0)
 	if err != nil {
-		log.Panic(err)
+		return "", err
 	}
-	if len(fileContent) == 0 {
-		// The file is empty, create a new Wallets object
-		ws.Wallets = make(map[string]*Wallet)
-		ws.SaveToFile()
+	rootAddress := string(root.GetAddress())
 
-		return nil
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.accounts[rootAddress]; ok {
+		return rootAddress, ErrKeyExists
+	}
+	if ws.accounts == nil {
+		ws.accounts = make(map[string]*hdAccount)
+		ws.owners = make(map[string]string)
+	}
+	account := &hdAccount{seed: seed}
+	ws.accounts[rootAddress] = account
+	for chain, count := range [2]uint32{max(receive, 1), change} {
+		for i := uint32(0); i < count; i++ {
+			_, err = ws.derive(rootAddress, account, uint32(chain))
+			if err != nil {
+				return "", err
+			}
+		}
 	}
 
-	var wallets Wallets
+	return rootAddress, nil
+}
 
-	decoder := gob.NewDecoder(bytes.NewReader(fileContent))
-	err = decoder.Decode(&wallets)
-	if err != nil {
-		log.Panic(err)
+// NextAddress derives the next key of chain of the HD wallet owning address.
+func (ws *Wallets) NextAddress(address string, chain uint32) (string, error) {
+	if chain != ChainReceive && chain != ChainChange {
+		return "", fmt.Errorf("unknown chain %d", chain)
 	}
 
-	ws.Wallets = wallets.Wallets
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 
-	return nil
-}
+	root, ok := ws.owners[address]
+	if !ok {
+		if _, ok = ws.Wallets[address]; ok {
+			return "", ErrNotHD
+		}
+
+		return "", ErrKeyNotFound
+	}
 
-// SaveToFile saves wallets to a file.
-func (ws Wallets) SaveToFile() {
-	var content bytes.Buffer
+	return ws.derive(root, ws.accounts[root], chain)
+}
 
-	encoder := gob.NewEncoder(&content)
-	err := encoder.Encode(ws)
+func (ws *Wallets) derive(root string, account *hdAccount, chain uint32) (string, error) {
+	w, err := DeriveWallet(account.seed, chain, account.next[chain])
 	if err != nil {
-		log.Panic(err)
+		return "", err
 	}
-
-	err = os.WriteFile(walletFile, content.Bytes(), 0600)
+	err = ws.put(w)
 	if err != nil {
-		log.Panic(err)
+		return "", err
 	}
+	account.next[chain]++
+
+	address := string(w.GetAddress())
+	account.addresses = append(account.addresses, address)
+	ws.owners[address] = root
+
+	return address, nil
+}
+
+// Group returns the addresses of the wallet owning address.
+func (ws *Wallets) Group(address string) ([]string, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	if root, ok := ws.owners[address]; ok {
+		return append([]string(nil), ws.accounts[root].addresses...), nil
+	}
+	if _, ok := ws.Wallets[address]; !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return []string{address}, nil
 }
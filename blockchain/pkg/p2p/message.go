@@ -0,0 +1,253 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Every message is framed as
+//
+//	magic   [4]byte "BCJC"
+//	command uint8
+//	length  uint32, big endian
+//	payload [length]byte
+//
+// Blocks and transactions travel in their canonical encoding. The other
+// payloads use the same conventions: big endian integers and byte strings
+// prefixed with their uint32 length.
+var magic = [4]byte{'B', 'C', 'J', 'C'}
+
+// ProtocolVersion is the version announced in the handshake.
+const ProtocolVersion = 1
+
+// maxPayloadSize bounds a message payload.
+const maxPayloadSize = 32 << 20
+
+type command uint8
+
+const (
+	cmdVersion command = iota + 1
+	cmdVerack
+	cmdInv
+	cmdGetBlocks
+	cmdGetData
+	cmdBlock
+	cmdTx
+)
+
+func (c command) String() string {
+	switch c {
+	case cmdVersion:
+		return "version"
+	case cmdVerack:
+		return "verack"
+	case cmdInv:
+		return "inv"
+	case cmdGetBlocks:
+		return "getblocks"
+	case cmdGetData:
+		return "getdata"
+	case cmdBlock:
+		return "block"
+	case cmdTx:
+		return "tx"
+	default:
+		return fmt.Sprintf("command(%d)", uint8(c))
+	}
+}
+
+// invKind tells what an inventory item identifies.
+type invKind uint8
+
+const (
+	invBlock invKind = iota + 1
+	invTx
+)
+
+type message struct {
+	cmd     command
+	payload []byte
+}
+
+// ErrProtocol is returned for malformed messages.
+var ErrProtocol = errors.New("protocol error")
+
+func writeMessage(w io.Writer, m message) error {
+	header := make([]byte, 0, 9)
+	header = append(header, magic[:]...)
+	header = append(header, byte(m.cmd))
+	header = binary.BigEndian.AppendUint32(header, uint32(len(m.payload)))
+
+	_, err := w.Write(append(header, m.payload...))
+
+	return err
+}
+
+func readMessage(r io.Reader) (message, error) {
+	var header [9]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return message{}, err
+	}
+	if !bytes.Equal(header[:4], magic[:]) {
+		return message{}, fmt.Errorf("%w: bad magic %x", ErrProtocol, header[:4])
+	}
+	n := binary.BigEndian.Uint32(header[5:])
+	if n > maxPayloadSize {
+		return message{}, fmt.Errorf("%w: %d byte payload", ErrProtocol, n)
+	}
+	m := message{cmd: command(header[4]), payload: make([]byte, n)}
+	if _, err := io.ReadFull(r, m.payload); err != nil {
+		return message{}, err
+	}
+
+	return m, nil
+}
+
+type payloadWriter struct {
+	buf []byte
+}
+
+func (w *payloadWriter) uint8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *payloadWriter) uint32(v int) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v))
+}
+
+func (w *payloadWriter) int64(v int64) {
+	w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v))
+}
+
+func (w *payloadWriter) bytes(v []byte) {
+	w.uint32(len(v))
+	w.buf = append(w.buf, v...)
+}
+
+// payloadReader reads a payload front to back. The first error sticks.
+type payloadReader struct {
+	data []byte
+	err  error
+}
+
+func (r *payloadReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data) {
+		r.err = fmt.Errorf("%w: truncated payload", ErrProtocol)
+
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *payloadReader) uint8() uint8 {
+	if v := r.next(1); v != nil {
+		return v[0]
+	}
+
+	return 0
+}
+
+func (r *payloadReader) uint32() int {
+	if v := r.next(4); v != nil {
+		return int(binary.BigEndian.Uint32(v))
+	}
+
+	return 0
+}
+
+func (r *payloadReader) int64() int64 {
+	if v := r.next(8); v != nil {
+		return int64(binary.BigEndian.Uint64(v))
+	}
+
+	return 0
+}
+
+func (r *payloadReader) bytes() []byte {
+	return append([]byte(nil), r.next(r.uint32())...)
+}
+
+// count reads a list length, each element taking at least minSize bytes.
+func (r *payloadReader) count(minSize int) int {
+	n := r.uint32()
+	if r.err == nil && n > len(r.data)/minSize {
+		r.err = fmt.Errorf("%w: %d items do not fit in the payload", ErrProtocol, n)
+
+		return 0
+	}
+
+	return n
+}
+
+func (r *payloadReader) finish() error {
+	if r.err == nil && len(r.data) > 0 {
+		r.err = fmt.Errorf("%w: %d trailing bytes", ErrProtocol, len(r.data))
+	}
+
+	return r.err
+}
+
+// versionMsg opens the handshake. Genesis is empty for a node without a
+// chain yet.
+type versionMsg struct {
+	Version int
+	Height  int64
+	Genesis []byte
+}
+
+func (v versionMsg) encode() message {
+	var w payloadWriter
+	w.uint32(v.Version)
+	w.int64(v.Height)
+	w.bytes(v.Genesis)
+
+	return message{cmdVersion, w.buf}
+}
+
+func decodeVersion(payload []byte) (versionMsg, error) {
+	r := payloadReader{data: payload}
+	v := versionMsg{Version: r.uint32(), Height: r.int64(), Genesis: r.bytes()}
+
+	return v, r.finish()
+}
+
+// encodeHashes encodes a list of hashes, used by inv, getdata and
+// getblocks. Inventories start with the kind of their items.
+func encodeHashes(cmd command, kind invKind, hashes [][]byte) message {
+	var w payloadWriter
+	if cmd != cmdGetBlocks {
+		w.uint8(uint8(kind))
+	}
+	w.uint32(len(hashes))
+	for _, h := range hashes {
+		w.bytes(h)
+	}
+
+	return message{cmd, w.buf}
+}
+
+func decodeHashes(m message) (invKind, [][]byte, error) {
+	r := payloadReader{data: m.payload}
+	var kind invKind
+	if m.cmd != cmdGetBlocks {
+		kind = invKind(r.uint8())
+		if r.err == nil && kind != invBlock && kind != invTx {
+			return 0, nil, fmt.Errorf("%w: unknown inventory kind %d", ErrProtocol, kind)
+		}
+	}
+	hashes := make([][]byte, r.count(4))
+	for i := range hashes {
+		hashes[i] = r.bytes()
+	}
+
+	return kind, hashes, r.finish()
+}
@@ -0,0 +1,58 @@
+package p2p
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMessage_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	hashes := [][]byte{{1, 2, 3}, {4, 5}}
+
+	for _, m := range []message{
+		versionMsg{Version: ProtocolVersion, Height: 7, Genesis: []byte{9}}.encode(),
+		encodeHashes(cmdInv, invTx, hashes),
+		encodeHashes(cmdGetBlocks, 0, hashes),
+	} {
+		if err := writeMessage(&buf, m); err != nil {
+			t.Fatalf("writeMessage() error = %v", err)
+		}
+	}
+
+	m, err := readMessage(&buf)
+	if err != nil {
+		t.Fatalf("readMessage() error = %v", err)
+	}
+	v, err := decodeVersion(m.payload)
+	if err != nil || v.Height != 7 || !bytes.Equal(v.Genesis, []byte{9}) {
+		t.Errorf("decodeVersion() = %+v, %v", v, err)
+	}
+	for _, want := range []struct {
+		cmd  command
+		kind invKind
+	}{{cmdInv, invTx}, {cmdGetBlocks, 0}} {
+		m, err = readMessage(&buf)
+		if err != nil || m.cmd != want.cmd {
+			t.Fatalf("readMessage() = %v, %v, want %v", m.cmd, err, want.cmd)
+		}
+		kind, got, err := decodeHashes(m)
+		if err != nil || kind != want.kind || len(got) != 2 || !bytes.Equal(got[1], hashes[1]) {
+			t.Errorf("decodeHashes(%v) = %v, %x, %v", m.cmd, kind, got, err)
+		}
+	}
+}
+
+func TestMessage_Invalid(t *testing.T) {
+	if _, err := readMessage(bytes.NewReader([]byte("XXXX\x01\x00\x00\x00\x00"))); !errors.Is(err, ErrProtocol) {
+		t.Errorf("readMessage() with a bad magic error = %v, want %v", err, ErrProtocol)
+	}
+	if _, err := readMessage(bytes.NewReader([]byte("BCJC\x01\xff\xff\xff\xff"))); !errors.Is(err, ErrProtocol) {
+		t.Errorf("readMessage() of an oversized payload error = %v, want %v", err, ErrProtocol)
+	}
+	// A count larger than the payload can hold.
+	m := message{cmdInv, []byte{uint8(invBlock), 0xff, 0xff, 0xff, 0xff}}
+	if _, _, err := decodeHashes(m); !errors.Is(err, ErrProtocol) {
+		t.Errorf("decodeHashes() with a bogus count error = %v, want %v", err, ErrProtocol)
+	}
+}
@@ -0,0 +1,327 @@
+// Package p2p connects blockchain nodes. Peers exchange a version handshake,
+// announce blocks and transactions with inventories, fetch them with
+// getdata and catch up with getblocks.
+package p2p
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+const (
+	defaultRedialInterval = 5 * time.Second
+	dialTimeout           = 5 * time.Second
+)
+
+// maxBlocksPerInv is the number of block hashes sent in reply to getblocks.
+// A node receiving a full inventory asks for more once it has the last block.
+var maxBlocksPerInv = 500
+
+// Config sets where a node listens and which peers it dials.
+type Config struct {
+	// Listen is the TCP address to accept peers on, empty for none.
+	Listen string
+	// Peers are dialed on start and redialed when the connection drops.
+	Peers []string
+	// RedialInterval is the wait between connection attempts to a peer.
+	RedialInterval time.Duration
+}
+
+// Node relays the blocks and transactions of a chain to its peers and adds
+// the ones they announce.
+type Node struct {
+	bc     *blockchainlogic.Blockchain
+	cfg    Config
+	l      logger.Interface
+	ln     net.Listener
+	mu     sync.Mutex
+	peers  map[*peer]struct{}
+	closed chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewNode returns a node for bc. It does nothing until Start.
+func NewNode(bc *blockchainlogic.Blockchain, cfg Config, l logger.Interface) *Node {
+	if cfg.RedialInterval <= 0 {
+		cfg.RedialInterval = defaultRedialInterval
+	}
+
+	return &Node{
+		bc:     bc,
+		cfg:    cfg,
+		l:      l,
+		peers:  make(map[*peer]struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// Start listens for peers and dials the configured ones.
+func (n *Node) Start() error {
+	if n.cfg.Listen != "" {
+		ln, err := net.Listen("tcp", n.cfg.Listen)
+		if err != nil {
+			return err
+		}
+		n.ln = ln
+		n.wg.Add(1)
+		go n.accept()
+	}
+	for _, addr := range n.cfg.Peers {
+		n.wg.Add(1)
+		go n.dial(addr)
+	}
+	n.bc.AddChainObserver(n)
+
+	return nil
+}
+
+// Addr returns the address the node listens on, or nil.
+func (n *Node) Addr() net.Addr {
+	if n.ln == nil {
+		return nil
+	}
+
+	return n.ln.Addr()
+}
+
+// Close disconnects every peer and stops listening.
+func (n *Node) Close() error {
+	select {
+	case <-n.closed:
+		return nil
+	default:
+	}
+	close(n.closed)
+
+	var err error
+	if n.ln != nil {
+		err = n.ln.Close()
+	}
+	n.mu.Lock()
+	for p := range n.peers {
+		p.close()
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+
+	return err
+}
+
+// PeerCount returns the number of peers that completed the handshake.
+func (n *Node) PeerCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	count := 0
+	for p := range n.peers {
+		if p.isReady() {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (n *Node) accept() {
+	defer n.wg.Done()
+
+	for {
+		conn, err := n.ln.Accept()
+		if err != nil {
+			select {
+			case <-n.closed:
+			default:
+				n.l.Error(fmt.Errorf("p2p - accept: %w", err))
+			}
+
+			return
+		}
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.serve(conn)
+		}()
+	}
+}
+
+// dial keeps a connection to addr open until the node is closed.
+func (n *Node) dial(addr string) {
+	defer n.wg.Done()
+
+	for {
+		conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+		if err != nil {
+			n.l.Debug("p2p - dial %s: %v", addr, err)
+		} else {
+			n.serve(conn)
+		}
+
+		select {
+		case <-n.closed:
+			return
+		case <-time.After(n.cfg.RedialInterval):
+		}
+	}
+}
+
+// serve runs the connection until it drops.
+func (n *Node) serve(conn net.Conn) {
+	p := newPeer(n, conn)
+
+	n.mu.Lock()
+	select {
+	case <-n.closed:
+		n.mu.Unlock()
+		conn.Close()
+
+		return
+	default:
+	}
+	n.peers[p] = struct{}{}
+	n.mu.Unlock()
+
+	err := p.run()
+	if err != nil && !errors.Is(err, errPeerClosed) {
+		n.l.Debug("p2p - peer %s: %v", p.addr, err)
+	}
+
+	n.mu.Lock()
+	delete(n.peers, p)
+	n.mu.Unlock()
+}
+
+// broadcast queues m for every peer that completed the handshake.
+func (n *Node) broadcast(m message) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for p := range n.peers {
+		if p.isReady() {
+			p.send(m)
+		}
+	}
+}
+
+// BlockAdded announces a new block to the peers.
+func (n *Node) BlockAdded(block *blockchainlogic.Block) {
+	hash, err := hex.DecodeString(block.Hash)
+	if err != nil {
+		return
+	}
+	n.broadcast(encodeHashes(cmdInv, invBlock, [][]byte{hash}))
+}
+
+// BlockDisconnected announces nothing: peers follow the reorganisation from
+// the blocks of the new branch.
+func (n *Node) BlockDisconnected(*blockchainlogic.Block) {}
+
+// AddressDerived announces nothing, addresses are not shared with peers.
+func (n *Node) AddressDerived(string, string) {}
+
+// TransactionAccepted announces a new pending transaction to the peers.
+func (n *Node) TransactionAccepted(tx *blockchainlogic.Transaction) {
+	n.broadcast(encodeHashes(cmdInv, invTx, [][]byte{tx.ID}))
+}
+
+// version describes the local chain for the handshake.
+func (n *Node) version() (versionMsg, error) {
+	v := versionMsg{Version: ProtocolVersion, Height: -1}
+
+	store := n.bc.Store()
+	height, err := store.Height()
+	if err != nil {
+		return versionMsg{}, err
+	}
+	if height >= 0 {
+		genesis, err := store.BlockAt(0)
+		if err != nil {
+			return versionMsg{}, err
+		}
+		v.Height = int64(height)
+		v.Genesis, err = hex.DecodeString(genesis.Hash)
+		if err != nil {
+			return versionMsg{}, err
+		}
+	}
+
+	return v, nil
+}
+
+// locator lists block hashes from the tip back to genesis, dense near the
+// tip, for a peer to find where the chains part.
+func (n *Node) locator() ([][]byte, error) {
+	store := n.bc.Store()
+	height, err := store.Height()
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes [][]byte
+	step := 1
+	for h := height; h >= 0; h -= step {
+		block, err := store.BlockAt(h)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := hex.DecodeString(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+		if len(hashes) >= 10 {
+			step *= 2
+		}
+		if h > 0 && h-step < 0 {
+			// Always end with genesis.
+			step = h
+		}
+	}
+
+	return hashes, nil
+}
+
+// blocksAfter returns the hashes of up to maxBlocksPerInv blocks following
+// the first locator hash found in the chain, or following genesis' parent
+// when none is.
+func (n *Node) blocksAfter(locator [][]byte) ([][]byte, error) {
+	store := n.bc.Store()
+	start := 0
+	for _, hash := range locator {
+		height, err := store.BlockHeight(hex.EncodeToString(hash))
+		if errors.Is(err, blockchainlogic.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		start = height + 1
+
+		break
+	}
+
+	tip, err := store.Height()
+	if err != nil {
+		return nil, err
+	}
+	var hashes [][]byte
+	for h := start; h <= tip && len(hashes) < maxBlocksPerInv; h++ {
+		block, err := store.BlockAt(h)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := hex.DecodeString(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
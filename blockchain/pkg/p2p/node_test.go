@@ -0,0 +1,181 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+	"github.com/damndelion/blockchain_justCode/pkg/logger"
+)
+
+// testChain opens a chain in memory. Without genesis it starts empty and
+// takes the genesis block of its peers.
+func testChain(t *testing.T, genesis bool) (*blockchainlogic.Blockchain, string) {
+	t.Helper()
+
+	keys := blockchainlogic.NewWallets()
+	address, err := blockchainlogic.CreateWallet(keys)
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	store := blockchainlogic.NewMemoryChainStore()
+	if genesis {
+		return blockchainlogic.CreateBlockchain(store, keys, address), address
+	}
+
+	return blockchainlogic.NewBlockchain(store, keys, address), address
+}
+
+func startNode(t *testing.T, bc *blockchainlogic.Blockchain, peers ...string) *Node {
+	t.Helper()
+
+	n := NewNode(bc, Config{Listen: "127.0.0.1:0", Peers: peers, RedialInterval: 20 * time.Millisecond}, logger.New("error"))
+	if err := n.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { n.Close() })
+
+	return n
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func mine(t *testing.T, bc *blockchainlogic.Blockchain, n int, txs ...*blockchainlogic.Transaction) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		if err := bc.MineBlock(context.Background(), txs); err != nil {
+			t.Fatalf("MineBlock() error = %v", err)
+		}
+		txs = nil
+	}
+}
+
+func height(t *testing.T, bc *blockchainlogic.Blockchain) int {
+	t.Helper()
+
+	h, err := bc.Height()
+	if err != nil {
+		t.Fatalf("Height() error = %v", err)
+	}
+
+	return h
+}
+
+func sameTip(t *testing.T, a, b *blockchainlogic.Blockchain) bool {
+	t.Helper()
+
+	tipA, errA := a.Store().Tip()
+	tipB, errB := b.Store().Tip()
+
+	return errA == nil && errB == nil && tipA.Hash == tipB.Hash
+}
+
+func TestNode_LateJoinerSyncs(t *testing.T) {
+	perInv := maxBlocksPerInv
+	maxBlocksPerInv = 2
+	t.Cleanup(func() { maxBlocksPerInv = perInv })
+
+	a, _ := testChain(t, true)
+	mine(t, a, 4)
+	nodeA := startNode(t, a)
+
+	b, _ := testChain(t, false)
+	startNode(t, b, nodeA.Addr().String())
+
+	waitFor(t, "the late joiner to sync", func() bool { return sameTip(t, a, b) })
+	if got := height(t, b); got != 4 {
+		t.Errorf("Height() = %d, want 4", got)
+	}
+	report, err := blockchainlogic.VerifyChain(b.Store())
+	if err != nil || !report.Valid() {
+		t.Errorf("VerifyChain() of the synced chain = %v, %v, want valid", report, err)
+	}
+}
+
+func TestNode_RelaysBlocksAndTransactions(t *testing.T) {
+	a, miner := testChain(t, true)
+	b, _ := testChain(t, false)
+	c, _ := testChain(t, false)
+
+	// a <- b <- c: c hears about a only through b.
+	nodeA := startNode(t, a)
+	nodeB := startNode(t, b, nodeA.Addr().String())
+	startNode(t, c, nodeB.Addr().String())
+	waitFor(t, "the genesis block to reach c", func() bool { return sameTip(t, a, c) })
+
+	mine(t, a, 1)
+	waitFor(t, "a mined block to reach c", func() bool { return sameTip(t, a, c) })
+
+	recipient := string(blockchainlogic.NewWallet().GetAddress())
+	txID, err := a.Send(miner, recipient, 10, 0)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	waitFor(t, "the transaction to reach c", func() bool {
+		_, ok := c.PendingTransaction(txID)
+
+		return ok
+	})
+
+	tx, _ := a.PendingTransaction(txID)
+	mine(t, a, 1, tx)
+	waitFor(t, "the confirming block to reach c", func() bool { return sameTip(t, a, c) })
+
+	info, err := c.TransactionInfo(txID)
+	if err != nil || info.Status != blockchainlogic.TxStatusConfirmed {
+		t.Errorf("TransactionInfo() on c = %+v, %v, want confirmed", info, err)
+	}
+	if balance, err := c.GetBalance(recipient); err != nil || balance != 10 {
+		t.Errorf("GetBalance() on c = %v, %v, want 10", balance, err)
+	}
+}
+
+func TestNode_RejectsOtherGenesis(t *testing.T) {
+	a, _ := testChain(t, true)
+	b, _ := testChain(t, true)
+	nodeA := startNode(t, a)
+	nodeB := startNode(t, b, nodeA.Addr().String())
+
+	time.Sleep(200 * time.Millisecond)
+	if nodeA.PeerCount() != 0 || nodeB.PeerCount() != 0 {
+		t.Errorf("PeerCount() = %d, %d, want no peers across genesis blocks", nodeA.PeerCount(), nodeB.PeerCount())
+	}
+	if got := height(t, b); got != 0 {
+		t.Errorf("Height() = %d, want 0", got)
+	}
+}
+
+func TestNode_ConvergesOnHeaviestBranch(t *testing.T) {
+	a, _ := testChain(t, true)
+	b, _ := testChain(t, false)
+	nodeA := startNode(t, a)
+	nodeB := startNode(t, b, nodeA.Addr().String())
+	waitFor(t, "the genesis block to reach b", func() bool { return sameTip(t, a, b) })
+
+	// The chains part while b is offline; b mines more blocks.
+	nodeB.Close()
+	mine(t, a, 1)
+	mine(t, b, 3)
+
+	startNode(t, b, nodeA.Addr().String())
+	waitFor(t, "a to reorganise onto the branch of b", func() bool { return sameTip(t, a, b) })
+	if got := height(t, a); got != 3 {
+		t.Errorf("Height() = %d, want 3", got)
+	}
+	tips, err := a.Store().ChainTips()
+	if err != nil || len(tips) != 2 {
+		t.Errorf("ChainTips() = %+v, %v, want both branches", tips, err)
+	}
+}
@@ -0,0 +1,347 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+const (
+	handshakeTimeout = 10 * time.Second
+	writeTimeout     = 30 * time.Second
+	// sendQueueSize bounds the messages waiting for a slow peer, which is
+	// dropped when it fills up.
+	sendQueueSize = 256
+)
+
+var errPeerClosed = errors.New("peer closed")
+
+type peer struct {
+	node *Node
+	conn net.Conn
+	addr string
+	out  chan message
+	done chan struct{}
+	once sync.Once
+
+	mu        sync.Mutex
+	gotVerack bool
+	version   *versionMsg
+	// syncHash is the last block of a full inventory; once it is added the
+	// peer is asked for the following blocks.
+	syncHash string
+}
+
+func newPeer(n *Node, conn net.Conn) *peer {
+	return &peer{
+		node: n,
+		conn: conn,
+		addr: conn.RemoteAddr().String(),
+		out:  make(chan message, sendQueueSize),
+		done: make(chan struct{}),
+	}
+}
+
+func (p *peer) close() {
+	p.once.Do(func() {
+		close(p.done)
+		p.conn.Close()
+	})
+}
+
+// send queues m without blocking. A peer that does not keep up is dropped.
+func (p *peer) send(m message) {
+	select {
+	case p.out <- m:
+	case <-p.done:
+	default:
+		p.node.l.Warn("p2p - peer %s: send queue is full, disconnecting", p.addr)
+		p.close()
+	}
+}
+
+func (p *peer) isReady() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.version != nil && p.gotVerack
+}
+
+// run performs the handshake and handles messages until the connection
+// drops.
+func (p *peer) run() error {
+	defer p.close()
+
+	go p.writeLoop()
+
+	version, err := p.node.version()
+	if err != nil {
+		return err
+	}
+	p.send(version.encode())
+
+	err = p.conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	if err != nil {
+		return err
+	}
+	for {
+		m, err := readMessage(p.conn)
+		if err != nil {
+			select {
+			case <-p.done:
+				return errPeerClosed
+			default:
+				return err
+			}
+		}
+		if err = p.handle(m); err != nil {
+			return fmt.Errorf("%s: %w", m.cmd, err)
+		}
+	}
+}
+
+func (p *peer) writeLoop() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case m := <-p.out:
+			err := p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err == nil {
+				err = writeMessage(p.conn, m)
+			}
+			if err != nil {
+				p.node.l.Debug("p2p - peer %s: write %s: %v", p.addr, m.cmd, err)
+				p.close()
+
+				return
+			}
+		}
+	}
+}
+
+func (p *peer) handle(m message) error {
+	p.mu.Lock()
+	handshaken := p.version != nil
+	p.mu.Unlock()
+	if m.cmd != cmdVersion && !handshaken {
+		return fmt.Errorf("%w: message before version", ErrProtocol)
+	}
+
+	switch m.cmd {
+	case cmdVersion:
+		return p.handleVersion(m)
+	case cmdVerack:
+		p.mu.Lock()
+		p.gotVerack = true
+		p.mu.Unlock()
+
+		return nil
+	case cmdInv:
+		return p.handleInv(m)
+	case cmdGetBlocks:
+		return p.handleGetBlocks(m)
+	case cmdGetData:
+		return p.handleGetData(m)
+	case cmdBlock:
+		return p.handleBlock(m)
+	case cmdTx:
+		return p.handleTx(m)
+	default:
+		// Newer peers may send messages this version does not know.
+		return nil
+	}
+}
+
+func (p *peer) handleVersion(m message) error {
+	v, err := decodeVersion(m.payload)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if p.version != nil {
+		p.mu.Unlock()
+
+		return fmt.Errorf("%w: duplicate version", ErrProtocol)
+	}
+	p.version = &v
+	p.mu.Unlock()
+
+	local, err := p.node.version()
+	if err != nil {
+		return err
+	}
+	if len(local.Genesis) > 0 && len(v.Genesis) > 0 && !bytes.Equal(local.Genesis, v.Genesis) {
+		return fmt.Errorf("peer has another genesis block %x", v.Genesis)
+	}
+	if err = p.conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	p.send(message{cmd: cmdVerack})
+
+	if v.Height > local.Height {
+		return p.requestBlocks()
+	}
+
+	return nil
+}
+
+// requestBlocks asks the peer for the blocks following the local tip.
+func (p *peer) requestBlocks() error {
+	locator, err := p.node.locator()
+	if err != nil {
+		return err
+	}
+	p.send(encodeHashes(cmdGetBlocks, 0, locator))
+
+	return nil
+}
+
+func (p *peer) handleInv(m message) error {
+	kind, hashes, err := decodeHashes(m)
+	if err != nil {
+		return err
+	}
+
+	var wanted [][]byte
+	for _, hash := range hashes {
+		known, err := p.node.has(kind, hash)
+		if err != nil {
+			return err
+		}
+		if !known {
+			wanted = append(wanted, hash)
+		}
+	}
+	if kind == invBlock && len(hashes) >= maxBlocksPerInv {
+		p.mu.Lock()
+		p.syncHash = hex.EncodeToString(hashes[len(hashes)-1])
+		p.mu.Unlock()
+	}
+	if len(wanted) > 0 {
+		p.send(encodeHashes(cmdGetData, kind, wanted))
+	}
+
+	return nil
+}
+
+func (p *peer) handleGetBlocks(m message) error {
+	_, locator, err := decodeHashes(m)
+	if err != nil {
+		return err
+	}
+	hashes, err := p.node.blocksAfter(locator)
+	if err != nil {
+		return err
+	}
+	if len(hashes) > 0 {
+		p.send(encodeHashes(cmdInv, invBlock, hashes))
+	}
+
+	return nil
+}
+
+func (p *peer) handleGetData(m message) error {
+	kind, hashes, err := decodeHashes(m)
+	if err != nil {
+		return err
+	}
+
+	store := p.node.bc.Store()
+	for _, hash := range hashes {
+		switch kind {
+		case invBlock:
+			block, err := store.Block(hex.EncodeToString(hash))
+			if errors.Is(err, blockchainlogic.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			p.send(message{cmdBlock, block.Serialize()})
+		case invTx:
+			tx, ok := p.node.bc.PendingTransaction(hex.EncodeToString(hash))
+			if !ok {
+				continue
+			}
+			p.send(message{cmdTx, tx.Serialize()})
+		}
+	}
+
+	return nil
+}
+
+func (p *peer) handleBlock(m message) error {
+	block, err := blockchainlogic.DeserializeBlock(m.payload)
+	if err != nil {
+		return err
+	}
+
+	err = p.node.bc.AddBlock(block)
+	switch {
+	case errors.Is(err, blockchainlogic.ErrBlockExists):
+		return nil
+	case errors.Is(err, blockchainlogic.ErrOrphanBlock):
+		// The peer is ahead by more than this block, maybe on another
+		// branch; the locator finds where the chains part.
+		return p.requestBlocks()
+	case errors.Is(err, blockchainlogic.ErrInvalidBlock):
+		return err
+	case err != nil:
+		p.node.l.Error(fmt.Errorf("p2p - add block %s: %w", block.Hash, err))
+
+		return nil
+	}
+
+	p.mu.Lock()
+	more := p.syncHash == block.Hash
+	if more {
+		p.syncHash = ""
+	}
+	p.mu.Unlock()
+	if more {
+		return p.requestBlocks()
+	}
+
+	return nil
+}
+
+func (p *peer) handleTx(m message) error {
+	tx, err := blockchainlogic.DeserializeTransaction(m.payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.node.bc.SubmitTransaction(tx)
+	if err != nil && !errors.Is(err, blockchainlogic.ErrTxExists) {
+		// Transactions may be valid for the peer and not here yet, or
+		// conflict with one already pending; neither is misbehavior.
+		p.node.l.Debug("p2p - peer %s: transaction %x rejected: %v", p.addr, tx.ID, err)
+	}
+
+	return nil
+}
+
+// has reports whether the block or transaction is already known.
+func (n *Node) has(kind invKind, hash []byte) (bool, error) {
+	if kind == invTx {
+		return n.bc.HasTransaction(hash)
+	}
+
+	return n.hasBlock(hex.EncodeToString(hash))
+}
+
+func (n *Node) hasBlock(hash string) (bool, error) {
+	_, err := n.bc.Store().Block(hash)
+	if errors.Is(err, blockchainlogic.ErrNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
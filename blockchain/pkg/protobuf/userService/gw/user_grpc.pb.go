@@ -27,6 +27,8 @@ type UserServiceClient interface {
 	GetUserWallet(ctx context.Context, in *GetUserWalletRequest, opts ...grpc.CallOption) (*UserWalletResponse, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
 	SetUserWallet(ctx context.Context, in *SetUserWalletRequest, opts ...grpc.CallOption) (*SetUserWalletResponse, error)
+	ListUserWallets(ctx context.Context, in *ListUserWalletsRequest, opts ...grpc.CallOption) (*ListUserWalletsResponse, error)
+	AddUserWallet(ctx context.Context, in *AddUserWalletRequest, opts ...grpc.CallOption) (*UserWallet, error)
 }
 
 type userServiceClient struct {
@@ -82,6 +84,24 @@ func (c *userServiceClient) SetUserWallet(ctx context.Context, in *SetUserWallet
 	return out, nil
 }
 
+func (c *userServiceClient) ListUserWallets(ctx context.Context, in *ListUserWalletsRequest, opts ...grpc.CallOption) (*ListUserWalletsResponse, error) {
+	out := new(ListUserWalletsResponse)
+	err := c.cc.Invoke(ctx, "/userservice.UserService/ListUserWallets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) AddUserWallet(ctx context.Context, in *AddUserWalletRequest, opts ...grpc.CallOption) (*UserWallet, error) {
+	out := new(UserWallet)
+	err := c.cc.Invoke(ctx, "/userservice.UserService/AddUserWallet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility
@@ -91,6 +111,8 @@ type UserServiceServer interface {
 	GetUserWallet(context.Context, *GetUserWalletRequest) (*UserWalletResponse, error)
 	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
 	SetUserWallet(context.Context, *SetUserWalletRequest) (*SetUserWalletResponse, error)
+	ListUserWallets(context.Context, *ListUserWalletsRequest) (*ListUserWalletsResponse, error)
+	AddUserWallet(context.Context, *AddUserWalletRequest) (*UserWallet, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -113,6 +135,12 @@ func (UnimplementedUserServiceServer) CreateUser(context.Context, *CreateUserReq
 func (UnimplementedUserServiceServer) SetUserWallet(context.Context, *SetUserWalletRequest) (*SetUserWalletResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetUserWallet not implemented")
 }
+func (UnimplementedUserServiceServer) ListUserWallets(context.Context, *ListUserWalletsRequest) (*ListUserWalletsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserWallets not implemented")
+}
+func (UnimplementedUserServiceServer) AddUserWallet(context.Context, *AddUserWalletRequest) (*UserWallet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddUserWallet not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 
 // UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -216,6 +244,42 @@ func _UserService_SetUserWallet_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_ListUserWallets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserWalletsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListUserWallets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/userservice.UserService/ListUserWallets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListUserWallets(ctx, req.(*ListUserWalletsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_AddUserWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddUserWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).AddUserWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/userservice.UserService/AddUserWallet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).AddUserWallet(ctx, req.(*AddUserWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -243,6 +307,14 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetUserWallet",
 			Handler:    _UserService_SetUserWallet_Handler,
 		},
+		{
+			MethodName: "ListUserWallets",
+			Handler:    _UserService_ListUserWallets_Handler,
+		},
+		{
+			MethodName: "AddUserWallet",
+			Handler:    _UserService_AddUserWallet_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user.proto",
@@ -0,0 +1,457 @@
+// Package stream pushes chain events to subscribers: the headers of new
+// blocks and of blocks leaving the main chain, the transactions of their
+// addresses and the confirmation counts of those transactions. Recent events are kept, so a subscriber reconnecting
+// with the ID of the last event it got misses none.
+package stream
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+// Event types.
+const (
+	TypeBlock         = "block"
+	TypeTransaction   = "transaction"
+	TypeConfirmations = "confirmations"
+	// TypeDisconnected carries the header of a block a reorganisation
+	// removed from the main chain. Its transactions are unconfirmed until
+	// the new branch holds them again.
+	TypeDisconnected = "disconnected"
+	// TypeResync tells a subscriber that events it asked for are no longer
+	// kept, so it has to fetch its state again.
+	TypeResync = "resync"
+)
+
+const (
+	defaultBufferSize    = 1024
+	defaultQueueSize     = 64
+	defaultConfirmations = 6
+)
+
+// ErrSlowSubscriber is the reason a subscription is dropped when its queue is
+// full.
+var ErrSlowSubscriber = errors.New("subscriber does not keep up with the events")
+
+// Config sets how many events the hub keeps and queues.
+type Config struct {
+	// BufferSize is the number of recent events kept for resuming.
+	BufferSize int
+	// QueueSize is the number of events queued for a subscriber. A
+	// subscriber with a full queue is dropped and has to resume.
+	QueueSize int
+	// Confirmations is the confirmation count up to which updates are sent
+	// for a transaction.
+	Confirmations int
+}
+
+// Event is what subscribers receive. Data is a BlockHeader, Transaction or
+// Confirmations according to Type, a BlockHeader for disconnected blocks.
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data any    `json:"data,omitempty"`
+}
+
+// BlockHeader describes a block added to or removed from the main chain.
+type BlockHeader struct {
+	Hash         string    `json:"hash"`
+	PrevHash     string    `json:"prev_hash"`
+	Height       int       `json:"height"`
+	Timestamp    time.Time `json:"timestamp"`
+	Difficulty   int       `json:"difficulty"`
+	MerkleRoot   string    `json:"merkle_root"`
+	Transactions int       `json:"transactions"`
+}
+
+// Transaction describes a transaction of the subscriber addresses, from their
+// point of view, when it enters the mempool and when it is mined.
+type Transaction struct {
+	TxID         string `json:"txid"`
+	Status       string `json:"status"`
+	Direction    string `json:"direction"`
+	Counterparty string `json:"counterparty,omitempty"`
+	Amount       string `json:"amount"`
+	Change       string `json:"change"`
+	BlockHash    string `json:"block_hash,omitempty"`
+	// Height is -1 and Timestamp unset for pending transactions.
+	Height        int        `json:"height"`
+	Timestamp     *time.Time `json:"timestamp,omitempty"`
+	Confirmations int        `json:"confirmations"`
+}
+
+// Confirmations updates the confirmation count of a mined transaction.
+type Confirmations struct {
+	TxID          string `json:"txid"`
+	BlockHash     string `json:"block_hash"`
+	Height        int    `json:"height"`
+	Confirmations int    `json:"confirmations"`
+}
+
+// event is a kept event, rendered for every subscriber it concerns.
+type event struct {
+	seq    uint64
+	kind   string
+	header *BlockHeader
+	info   *blockchainlogic.TxInfo
+	// addresses are the addresses the transaction spends from and pays to.
+	addresses []string
+}
+
+// tracked is a mined transaction whose confirmations are still sent.
+type tracked struct {
+	info      *blockchainlogic.TxInfo
+	addresses []string
+}
+
+// Hub turns the blocks and transactions accepted by a chain into events for
+// its subscribers.
+type Hub struct {
+	bc  *blockchainlogic.Blockchain
+	cfg Config
+	// epoch tells the events of this hub from those of an earlier run.
+	epoch   string
+	mu      sync.Mutex
+	seq     uint64
+	events  []*event
+	subs    map[*Subscription]struct{}
+	tracked map[string]*tracked
+}
+
+// NewHub returns a hub receiving the blocks and transactions of bc.
+func NewHub(bc *blockchainlogic.Blockchain, cfg Config) *Hub {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.Confirmations <= 0 {
+		cfg.Confirmations = defaultConfirmations
+	}
+
+	h := &Hub{
+		bc:      bc,
+		cfg:     cfg,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:    make(map[*Subscription]struct{}),
+		tracked: make(map[string]*tracked),
+	}
+	bc.AddChainObserver(h)
+
+	return h
+}
+
+// Subscription receives the events of the hub concerning its addresses.
+type Subscription struct {
+	hub *Hub
+	// wallet are the subscriber addresses and owned their set. Addresses
+	// derived for its HD wallets are added.
+	wallet []string
+	owned  map[string]bool
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// Subscribe returns a subscription to block events and to the transaction
+// events of addresses. With the ID of the last event received it also
+// returns the kept events that followed it, or a single resync event when
+// they are no longer kept.
+func (h *Hub) Subscribe(addresses []string, lastEventID string) (*Subscription, []Event) {
+	s := &Subscription{
+		hub:    h,
+		wallet: append([]string(nil), addresses...),
+		owned:  make(map[string]bool, len(addresses)),
+		events: make(chan Event, h.cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+	for _, address := range addresses {
+		s.owned[address] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	if lastEventID == "" {
+		return s, nil
+	}
+
+	seq, ok := h.resumeSeq(lastEventID)
+	if !ok {
+		return s, []Event{{ID: h.eventID(h.seq), Type: TypeResync}}
+	}
+	var replay []Event
+	for _, e := range h.events {
+		if e.seq <= seq || !s.wants(e) {
+			continue
+		}
+		replay = append(replay, s.render(h.eventID(e.seq), e))
+	}
+
+	return s, replay
+}
+
+// Events returns the events of the subscription.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription is closed or dropped.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the subscription was dropped, nil unless it was.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s, nil)
+}
+
+// BlockAdded sends the header of a block added to the main chain, the
+// transactions it holds and the new confirmation counts of the transactions
+// of the blocks before it. Side branch blocks are skipped.
+func (h *Hub) BlockAdded(block *blockchainlogic.Block) {
+	height, err := h.bc.Store().BlockHeight(block.Hash)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publish(&event{kind: TypeBlock, header: &BlockHeader{
+		Hash:         block.Hash,
+		PrevHash:     block.PrevHash,
+		Height:       height,
+		Timestamp:    block.Timestamp,
+		Difficulty:   block.Difficulty,
+		MerkleRoot:   hex.EncodeToString(block.MerkleRoot),
+		Transactions: len(block.Transactions),
+	}})
+
+	ids := make([]string, 0, len(h.tracked))
+	for id := range h.tracked {
+		ids = append(ids, id)
+	}
+	// Oldest first, as the transactions were sent.
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := h.tracked[ids[i]].info, h.tracked[ids[j]].info
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		t := h.tracked[id]
+		if t.info.Height >= height {
+			// Its block left the main chain in a reorganisation. It
+			// comes back with the block holding it on the new branch.
+			delete(h.tracked, id)
+
+			continue
+		}
+		info := *t.info
+		info.Confirmations = height - info.Height + 1
+		h.publish(&event{kind: TypeConfirmations, info: &info, addresses: t.addresses})
+		if info.Confirmations >= h.cfg.Confirmations {
+			delete(h.tracked, id)
+		}
+	}
+
+	for _, tx := range block.Transactions {
+		addresses := tx.Addresses()
+		if len(addresses) == 0 {
+			continue
+		}
+		info := &blockchainlogic.TxInfo{
+			Tx:            tx,
+			Status:        blockchainlogic.TxStatusConfirmed,
+			BlockHash:     block.Hash,
+			Height:        height,
+			Timestamp:     block.Timestamp,
+			Confirmations: 1,
+		}
+		h.publish(&event{kind: TypeTransaction, info: info, addresses: addresses})
+		if h.cfg.Confirmations > 1 {
+			h.tracked[hex.EncodeToString(tx.ID)] = &tracked{info: info, addresses: addresses}
+		}
+	}
+}
+
+// BlockDisconnected sends the header of a block a reorganisation removed from
+// the main chain. Its transactions are no longer tracked; those the new
+// branch holds are sent again with their new block.
+func (h *Hub) BlockDisconnected(block *blockchainlogic.Block) {
+	node, err := h.bc.Store().BlockNode(block.Hash)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publish(&event{kind: TypeDisconnected, header: &BlockHeader{
+		Hash:         block.Hash,
+		PrevHash:     block.PrevHash,
+		Height:       node.Height,
+		Timestamp:    block.Timestamp,
+		Difficulty:   block.Difficulty,
+		MerkleRoot:   hex.EncodeToString(block.MerkleRoot),
+		Transactions: len(block.Transactions),
+	}})
+	for id, t := range h.tracked {
+		if t.info.BlockHash == block.Hash {
+			delete(h.tracked, id)
+		}
+	}
+}
+
+// AddressDerived adds derived to the subscriptions holding address, so they
+// get the transactions of the new address of their wallet.
+func (h *Hub) AddressDerived(address, derived string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.owned[address] && !s.owned[derived] {
+			s.owned[derived] = true
+			s.wallet = append(s.wallet, derived)
+		}
+	}
+}
+
+// TransactionAccepted sends a transaction entering the mempool.
+func (h *Hub) TransactionAccepted(tx *blockchainlogic.Transaction) {
+	addresses := tx.Addresses()
+	if len(addresses) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publish(&event{
+		kind:      TypeTransaction,
+		info:      &blockchainlogic.TxInfo{Tx: tx, Status: blockchainlogic.TxStatusPending, Height: -1},
+		addresses: addresses,
+	})
+}
+
+// publish keeps e and queues it for the subscribers it concerns, dropping
+// those whose queue is full. It is called with mu held.
+func (h *Hub) publish(e *event) {
+	h.seq++
+	e.seq = h.seq
+	h.events = append(h.events, e)
+	if len(h.events) > h.cfg.BufferSize {
+		h.events[0] = nil
+		h.events = h.events[1:]
+	}
+
+	id := h.eventID(e.seq)
+	for s := range h.subs {
+		if !s.wants(e) {
+			continue
+		}
+		select {
+		case s.events <- s.render(id, e):
+		default:
+			h.drop(s, ErrSlowSubscriber)
+		}
+	}
+}
+
+// drop removes s from the subscribers. It is called with mu held.
+func (h *Hub) drop(s *Subscription, err error) {
+	s.once.Do(func() {
+		delete(h.subs, s)
+		s.err = err
+		close(s.done)
+	})
+}
+
+// eventID returns the ID of the event numbered seq.
+func (h *Hub) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", h.epoch, seq)
+}
+
+// resumeSeq returns the number of the event of id when the events after it
+// are all kept. It is called with mu held.
+func (h *Hub) resumeSeq(id string) (uint64, bool) {
+	i := strings.LastIndexByte(id, '-')
+	if i < 0 || id[:i] != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil || seq > h.seq {
+		return 0, false
+	}
+	if len(h.events) > 0 && seq+1 < h.events[0].seq {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+// wants reports whether e concerns the subscriber.
+func (s *Subscription) wants(e *event) bool {
+	if e.kind == TypeBlock || e.kind == TypeDisconnected {
+		return true
+	}
+	for _, address := range e.addresses {
+		if s.owned[address] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// render returns e as seen by the subscriber.
+func (s *Subscription) render(id string, e *event) Event {
+	switch e.kind {
+	case TypeBlock, TypeDisconnected:
+		return Event{ID: id, Type: e.kind, Data: e.header}
+	case TypeConfirmations:
+		return Event{ID: id, Type: e.kind, Data: &Confirmations{
+			TxID:          hex.EncodeToString(e.info.Tx.ID),
+			BlockHash:     e.info.BlockHash,
+			Height:        e.info.Height,
+			Confirmations: e.info.Confirmations,
+		}}
+	default:
+		entry := blockchainlogic.WalletActivity(s.wallet, e.info)
+		tx := &Transaction{
+			TxID:          entry.TxID,
+			Status:        string(e.info.Status),
+			Direction:     string(entry.Direction),
+			Counterparty:  entry.Counterparty,
+			Amount:        entry.Amount.String(),
+			Change:        entry.Change.String(),
+			BlockHash:     entry.BlockHash,
+			Height:        entry.Height,
+			Confirmations: entry.Confirmations,
+		}
+		if !entry.Timestamp.IsZero() {
+			tx.Timestamp = &entry.Timestamp
+		}
+
+		return Event{ID: id, Type: e.kind, Data: tx}
+	}
+}
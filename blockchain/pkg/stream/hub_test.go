@@ -0,0 +1,269 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	blockchainlogic "github.com/damndelion/blockchain_justCode/pkg/blockchain_logic"
+)
+
+func testHub(t *testing.T, cfg Config) (*blockchainlogic.Blockchain, *Hub) {
+	t.Helper()
+
+	if err := blockchainlogic.SetDifficultyPolicy(blockchainlogic.RegtestDifficultyPolicy); err != nil {
+		t.Fatalf("SetDifficultyPolicy() error = %v", err)
+	}
+	bc, err := blockchainlogic.OpenRegtestBlockchain(blockchainlogic.NewMemoryChainStore(), blockchainlogic.NewWallets(), blockchainlogic.DefaultRegtestMnemonic)
+	if err != nil {
+		t.Fatalf("OpenRegtestBlockchain() error = %v", err)
+	}
+
+	return bc, NewHub(bc, cfg)
+}
+
+// received returns the events queued for s.
+func received(s *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case e := <-s.Events():
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func types(events []Event) []string {
+	kinds := make([]string, len(events))
+	for i, e := range events {
+		kinds[i] = e.Type
+	}
+
+	return kinds
+}
+
+func equalTypes(events []Event, want ...string) bool {
+	got := types(events)
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestHub_Events(t *testing.T) {
+	ctx := context.Background()
+	bc, hub := testHub(t, Config{Confirmations: 3})
+	recipient := string(blockchainlogic.NewWallet().GetAddress())
+	sub, replay := hub.Subscribe([]string{recipient}, "")
+	defer sub.Close()
+	other, _ := hub.Subscribe([]string{string(blockchainlogic.NewWallet().GetAddress())}, "")
+	defer other.Close()
+	if replay != nil {
+		t.Fatalf("Subscribe() replay = %v, want none", replay)
+	}
+
+	txID, err := bc.Fund(ctx, recipient, 25*blockchainlogic.Coin())
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	if _, err = bc.GenerateBlocks(ctx, 3); err != nil {
+		t.Fatalf("GenerateBlocks() error = %v", err)
+	}
+
+	events := received(sub)
+	want := []string{
+		TypeTransaction, TypeBlock, TypeTransaction,
+		TypeBlock, TypeConfirmations,
+		TypeBlock, TypeConfirmations,
+		TypeBlock,
+	}
+	if !equalTypes(events, want...) {
+		t.Fatalf("events = %v, want %v", types(events), want)
+	}
+	pending, ok := events[0].Data.(*Transaction)
+	if !ok || pending.TxID != txID || pending.Status != "pending" || pending.Direction != "in" ||
+		pending.Amount != (25*blockchainlogic.Coin()).String() || pending.Height != -1 || pending.Timestamp != nil {
+		t.Errorf("pending event = %+v", events[0].Data)
+	}
+	header := events[1].Data.(*BlockHeader)
+	confirmed, ok := events[2].Data.(*Transaction)
+	if !ok || confirmed.TxID != txID || confirmed.Status != "confirmed" || confirmed.BlockHash != header.Hash ||
+		confirmed.Height != header.Height || confirmed.Confirmations != 1 {
+		t.Errorf("confirmed event = %+v, block %+v", events[2].Data, header)
+	}
+	for i, n := range map[int]int{4: 2, 6: 3} {
+		c, ok := events[i].Data.(*Confirmations)
+		if !ok || c.TxID != txID || c.Confirmations != n {
+			t.Errorf("event %d = %+v, want %d confirmations", i, events[i].Data, n)
+		}
+	}
+
+	// Transactions of other addresses are not sent.
+	if events := received(other); !equalTypes(events, TypeBlock, TypeBlock, TypeBlock, TypeBlock) {
+		t.Errorf("events of another address = %v, want blocks only", types(events))
+	}
+}
+
+func TestHub_Resume(t *testing.T) {
+	ctx := context.Background()
+	// Every block makes two events, for itself and its coinbase.
+	bc, hub := testHub(t, Config{BufferSize: 6, Confirmations: 1})
+	sub, _ := hub.Subscribe(nil, "")
+	defer sub.Close()
+	if _, err := bc.GenerateBlocks(ctx, 3); err != nil {
+		t.Fatalf("GenerateBlocks() error = %v", err)
+	}
+	events := received(sub)
+	if len(events) != 3 {
+		t.Fatalf("events = %v, want 3 blocks", types(events))
+	}
+
+	resumed, replay := hub.Subscribe(nil, events[0].ID)
+	defer resumed.Close()
+	if len(replay) != 2 || replay[0].ID != events[1].ID || replay[1].ID != events[2].ID {
+		t.Errorf("replay after %s = %v, want %s and %s", events[0].ID, replay, events[1].ID, events[2].ID)
+	}
+	if _, replay = hub.Subscribe(nil, events[2].ID); len(replay) != 0 {
+		t.Errorf("replay after the last event = %v, want none", replay)
+	}
+
+	// The first event falls out of the buffer.
+	if _, err := bc.GenerateBlocks(ctx, 2); err != nil {
+		t.Fatalf("GenerateBlocks() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{name: "Dropped events", id: events[0].ID},
+		{name: "Earlier run", id: "0-1"},
+		{name: "Future event", id: hub.eventID(100)},
+		{name: "Malformed", id: "last"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, replay := hub.Subscribe(nil, tt.id)
+			defer s.Close()
+			if len(replay) != 1 || replay[0].Type != TypeResync || replay[0].ID != hub.eventID(10) {
+				t.Errorf("Subscribe(%q) replay = %v, want a resync at %s", tt.id, replay, hub.eventID(10))
+			}
+		})
+	}
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	bc, hub := testHub(t, Config{QueueSize: 1})
+	slow, _ := hub.Subscribe(nil, "")
+	closed, _ := hub.Subscribe(nil, "")
+	closed.Close()
+
+	if _, err := bc.GenerateBlocks(context.Background(), 2); err != nil {
+		t.Fatalf("GenerateBlocks() error = %v", err)
+	}
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscriber is not dropped")
+	}
+	if err := slow.Err(); !errors.Is(err, ErrSlowSubscriber) {
+		t.Errorf("Err() = %v, want %v", err, ErrSlowSubscriber)
+	}
+	if err := closed.Err(); err != nil {
+		t.Errorf("Err() of a closed subscription = %v, want nil", err)
+	}
+	if events := received(closed); len(events) != 0 {
+		t.Errorf("closed subscription got %v", types(events))
+	}
+}
+
+func TestHub_Reorganize(t *testing.T) {
+	ctx := context.Background()
+	bc, hub := testHub(t, Config{Confirmations: 3})
+	// A node of the same regtest chain mines a longer branch.
+	peer, err := blockchainlogic.OpenRegtestBlockchain(blockchainlogic.NewMemoryChainStore(), blockchainlogic.NewWallets(), blockchainlogic.DefaultRegtestMnemonic)
+	if err != nil {
+		t.Fatalf("OpenRegtestBlockchain() error = %v", err)
+	}
+	recipient := string(blockchainlogic.NewWallet().GetAddress())
+	txID, err := bc.Fund(ctx, recipient, 25*blockchainlogic.Coin())
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	funded, err := bc.Store().Tip()
+	if err != nil {
+		t.Fatalf("Tip() error = %v", err)
+	}
+	sub, _ := hub.Subscribe([]string{recipient}, "")
+	defer sub.Close()
+
+	if _, err = peer.GenerateBlocks(ctx, 2); err != nil {
+		t.Fatalf("GenerateBlocks() error = %v", err)
+	}
+	for height := 1; height <= 2; height++ {
+		block, err := peer.Store().BlockAt(height)
+		if err != nil {
+			t.Fatalf("BlockAt() error = %v", err)
+		}
+		if err = bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock() error = %v", err)
+		}
+	}
+
+	// The funding block leaves the main chain and its transaction is
+	// pending again; the side branch block is not sent until it is main.
+	events := received(sub)
+	if !equalTypes(events, TypeDisconnected, TypeTransaction, TypeBlock, TypeBlock) {
+		t.Fatalf("events = %v, want the disconnected block, the pending transaction and the new branch", types(events))
+	}
+	if header := events[0].Data.(*BlockHeader); header.Hash != funded.Hash || header.Height != 1 {
+		t.Errorf("disconnected event = %+v, want block %s at height 1", header, funded.Hash)
+	}
+	if tx := events[1].Data.(*Transaction); tx.TxID != txID || tx.Status != "pending" {
+		t.Errorf("transaction event = %+v, want %s pending", tx, txID)
+	}
+
+	// The next block confirms the transaction again; its former block sends
+	// no confirmations.
+	if _, err = bc.GenerateBlocks(ctx, 1); err != nil {
+		t.Fatalf("GenerateBlocks() error = %v", err)
+	}
+	if events = received(sub); !equalTypes(events, TypeBlock, TypeTransaction) {
+		t.Errorf("events after mining = %v, want the block confirming the transaction again", types(events))
+	}
+}
+
+func TestHub_DerivedAddress(t *testing.T) {
+	ctx := context.Background()
+	bc, hub := testHub(t, Config{Confirmations: 1})
+	wallet, _, err := bc.CreateHDWallet()
+	if err != nil {
+		t.Fatalf("CreateHDWallet() error = %v", err)
+	}
+	sub, _ := hub.Subscribe([]string{wallet}, "")
+	defer sub.Close()
+
+	derived, err := bc.NextAddress(wallet, blockchainlogic.ChainReceive)
+	if err != nil {
+		t.Fatalf("NextAddress() error = %v", err)
+	}
+	txID, err := bc.Fund(ctx, derived, blockchainlogic.Coin())
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+
+	events := received(sub)
+	if !equalTypes(events, TypeTransaction, TypeBlock, TypeTransaction) {
+		t.Fatalf("events = %v, want the payment to the derived address", types(events))
+	}
+	if tx := events[0].Data.(*Transaction); tx.TxID != txID || tx.Direction != "in" || tx.Amount != blockchainlogic.Coin().String() {
+		t.Errorf("transaction event = %+v, want %s incoming", tx, txID)
+	}
+}